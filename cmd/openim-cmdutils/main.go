@@ -33,10 +33,10 @@ func main() {
 	getCmd.AddIndexFlag()
 	getCmd.AddBeginSeqFlag()
 	getCmd.AddLimitFlag()
-	// openIM get seq --userID=xxx
-	// openIM get seq --superGroupID=xxx
-	// openIM get msg --userID=xxx --beginSeq=100 --limit=10
-	// openIM get msg --superGroupID=xxx --beginSeq=100 --limit=10
+	// openIM get seq --userID=xxx -c ./config
+	// openIM get seq --superGroupID=xxx -c ./config
+	// openIM get msg --userID=xxx --beginSeq=100 --limit=10 -c ./config
+	// openIM get msg --superGroupID=xxx --beginSeq=100 --limit=10 -c ./config
 
 	fixCmd.AddCommand(seqCmd.FixSeqCmd())
 	fixCmd.AddSuperGroupIDFlag()
@@ -44,9 +44,9 @@ func main() {
 	fixCmd.AddConfigDirFlag()
 	fixCmd.AddIndexFlag()
 	fixCmd.AddFixAllFlag()
-	// openIM fix seq --userID=xxx
-	// openIM fix seq --superGroupID=xxx
-	// openIM fix seq --fixAll
+	// openIM fix seq --userID=xxx -c ./config
+	// openIM fix seq --superGroupID=xxx -c ./config
+	// openIM fix seq --fixAll -c ./config
 
 	clearCmd.AddCommand(msgCmd.ClearMsgCmd())
 	clearCmd.AddSuperGroupIDFlag()
@@ -54,11 +54,13 @@ func main() {
 	clearCmd.AddConfigDirFlag()
 	clearCmd.AddIndexFlag()
 	clearCmd.AddClearAllFlag()
+	clearCmd.AddYesFlag()
 	clearCmd.AddBeginSeqFlag()
 	clearCmd.AddLimitFlag()
-	// openIM clear msg --userID=xxx --beginSeq=100 --limit=10
-	// openIM clear msg --superGroupID=xxx --beginSeq=100 --limit=10
-	// openIM clear msg --clearAll
+	// openIM clear msg --userID=xxx --beginSeq=100 --limit=10 -c ./config
+	// openIM clear msg --superGroupID=xxx --beginSeq=100 --limit=10 -c ./config
+	// openIM clear msg --superGroupID=xxx --clearAll -c ./config
+	// openIM clear msg --clearAll --yes -c ./config
 	msgUtilsCmd.AddCommand(&getCmd.Command, &fixCmd.Command, &clearCmd.Command)
 	if err := msgUtilsCmd.Execute(); err != nil {
 		program.ExitWithError(err)
@@ -3,20 +3,20 @@ package api
 import (
 	"encoding/json"
 	"reflect"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/openimsdk/open-im-server/v3/pkg/apistruct"
 	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
-	"github.com/openimsdk/open-im-server/v3/pkg/common/discovery/etcd"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/configsource"
 	"github.com/openimsdk/open-im-server/v3/version"
 	"github.com/openimsdk/tools/apiresp"
 	"github.com/openimsdk/tools/errs"
 	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/mcontext"
+	"github.com/openimsdk/tools/utils/datautil"
 	"github.com/openimsdk/tools/utils/runtimeenv"
-	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 const (
@@ -27,17 +27,17 @@ const (
 type ConfigManager struct {
 	imAdminUserID []string
 	config        *config.AllConfig
-	client        *clientv3.Client
+	source        configsource.Source
 
 	configPath string
 	runtimeEnv string
 }
 
-func NewConfigManager(IMAdminUserID []string, cfg *config.AllConfig, client *clientv3.Client, configPath string, runtimeEnv string) *ConfigManager {
+func NewConfigManager(IMAdminUserID []string, cfg *config.AllConfig, source configsource.Source, configPath string, runtimeEnv string) *ConfigManager {
 	cm := &ConfigManager{
 		imAdminUserID: IMAdminUserID,
 		config:        cfg,
-		client:        client,
+		source:        source,
 		configPath:    configPath,
 		runtimeEnv:    runtimeEnv,
 	}
@@ -62,7 +62,7 @@ func (cm *ConfigManager) GetConfig(c *gin.Context) {
 		apiresp.GinError(c, errs.ErrArgs.WithDetail("config name not found").Wrap())
 		return
 	}
-	b, err := json.Marshal(conf)
+	b, err := json.Marshal(config.Redact(conf))
 	if err != nil {
 		apiresp.GinError(c, err)
 		return
@@ -80,10 +80,6 @@ func (cm *ConfigManager) GetConfigList(c *gin.Context) {
 }
 
 func (cm *ConfigManager) SetConfig(c *gin.Context) {
-	if cm.config.Discovery.Enable != config.ETCD {
-		apiresp.GinError(c, errs.New("only etcd support set config").Wrap())
-		return
-	}
 	var req apistruct.SetConfigReq
 	if err := c.BindJSON(&req); err != nil {
 		apiresp.GinError(c, errs.ErrArgs.WithDetail(err.Error()).Wrap())
@@ -152,32 +148,40 @@ func compareAndSave[T any](c *gin.Context, old any, req *apistruct.SetConfigReq,
 	if err != nil {
 		return errs.ErrArgs.WithDetail(err.Error()).Wrap()
 	}
-	eq := reflect.DeepEqual(old, conf)
-	if eq {
+	// the secrets returned masked by GetConfig keep their values
+	config.RestoreSecrets(conf, old)
+	diff := config.Diff(old, conf)
+	if len(diff) == 0 {
 		return nil
 	}
 	data, err := json.Marshal(conf)
 	if err != nil {
 		return errs.ErrArgs.WithDetail(err.Error()).Wrap()
 	}
-	_, err = cm.client.Put(c, etcd.BuildKey(req.ConfigName), string(data))
-	if err != nil {
-		return errs.WrapMsg(err, "save to etcd failed")
+	record := &configsource.Record{
+		Action:  configsource.ActionSet,
+		UserID:  mcontext.GetOpUserID(c),
+		Changes: []configsource.Change{{ConfigName: req.ConfigName, Diff: diff, Data: string(data), Value: conf}},
+	}
+	if err := cm.source.Save(c, record); err != nil {
+		return errs.WrapMsg(err, "save config failed")
 	}
 	return nil
 }
 
 func (cm *ConfigManager) ResetConfig(c *gin.Context) {
+	opUserID := mcontext.GetOpUserID(c)
 	go func() {
-		if err := cm.resetConfig(c, true); err != nil {
+		if err := cm.resetConfig(c, opUserID, true, false); err != nil {
 			log.ZError(c, "reset config err", err)
 		}
 	}()
 	apiresp.GinSuccess(c, nil)
 }
 
-func (cm *ConfigManager) resetConfig(c *gin.Context, checkChange bool, ops ...clientv3.Op) error {
-	txn := cm.client.Txn(c)
+// resetConfig writes the configs of the config files to the source, enable switches the services to the source
+// along.
+func (cm *ConfigManager) resetConfig(c *gin.Context, opUserID string, checkChange bool, enable bool) error {
 	type initConf struct {
 		old any
 		new any
@@ -226,22 +230,124 @@ func (cm *ConfigManager) resetConfig(c *gin.Context, checkChange bool, ops ...cl
 		}
 	}
 
+	changes := make([]configsource.Change, 0, len(changedKeys))
 	for _, k := range changedKeys {
 		data, err := json.Marshal(configMap[k].new)
 		if err != nil {
 			log.ZError(c, "marshal config failed", err)
 			continue
 		}
-		ops = append(ops, clientv3.OpPut(etcd.BuildKey(k), string(data)))
+		changes = append(changes, configsource.Change{
+			ConfigName: k,
+			Diff:       config.Diff(configMap[k].old, configMap[k].new),
+			Data:       string(data),
+			Value:      configMap[k].new,
+		})
+	}
+	record := &configsource.Record{Action: configsource.ActionReset, UserID: opUserID, Changes: changes}
+	if enable {
+		return cm.source.SetEnabled(c, true, record)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return cm.source.Save(c, record)
+}
+
+func (cm *ConfigManager) GetConfigHistory(c *gin.Context) {
+	var req apistruct.GetConfigHistoryReq
+	if err := c.BindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrArgs.WithDetail(err.Error()).Wrap())
+		return
+	}
+	total, records, err := cm.source.GetRecords(c, req.ConfigName, req.Offset, req.Count)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, &apistruct.GetConfigHistoryResp{Total: total, Records: datautil.Slice(records, convertConfigRecord)})
+}
+
+// convertConfigRecord leaves out the data of the changes, it holds the secrets in plain.
+func convertConfigRecord(record *configsource.Record) *apistruct.ConfigRecord {
+	res := &apistruct.ConfigRecord{
+		Version:         record.Version,
+		Action:          record.Action,
+		UserID:          record.UserID,
+		RollbackVersion: record.RollbackVersion,
+		CreateTime:      record.CreateTime,
+		Changes:         make([]*apistruct.ConfigChange, 0, len(record.Changes)),
+	}
+	for _, change := range record.Changes {
+		res.Changes = append(res.Changes, &apistruct.ConfigChange{ConfigName: change.ConfigName, Diff: change.Diff})
+	}
+	return res
+}
+
+// RollbackConfig restores the configs to their state at a version: every config changed after the version is
+// written back as it was in the newest version up to it holding the config, so the changes of the version and of
+// the versions before it are kept. The configs changed after the version and held by no version up to it are left
+// as they are. The rollback is recorded as a new version.
+func (cm *ConfigManager) RollbackConfig(c *gin.Context) {
+	var req apistruct.RollbackConfigReq
+	if err := c.BindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrArgs.WithDetail(err.Error()).Wrap())
+		return
+	}
+	record, err := cm.source.GetRecord(c, req.Version)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	if record == nil {
+		apiresp.GinError(c, errs.ErrRecordNotFound.WrapMsg("config version not found", "version", req.Version))
+		return
+	}
+	_, records, err := cm.source.GetRecords(c, "", 0, 0)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
 	}
-	if len(ops) > 0 {
-		txn.Then(ops...)
-		_, err := txn.Commit()
+	versionChanges, missing := configsource.VersionChanges(records, req.Version)
+	if len(missing) > 0 {
+		log.ZWarn(c, "configs of no version up to the rollback are kept", nil, "version", req.Version, "configNames", missing)
+	}
+	changes := make([]configsource.Change, 0, len(versionChanges))
+	for _, change := range versionChanges {
+		old := cm.config.Name2Config(change.ConfigName)
+		if old == nil {
+			log.ZWarn(c, "config of the version no longer exists", nil, "version", req.Version, "configName", change.ConfigName)
+			continue
+		}
+		conf := reflect.New(reflect.TypeOf(old)).Interface()
+		if err := json.Unmarshal([]byte(change.Data), conf); err != nil {
+			apiresp.GinError(c, errs.WrapMsg(err, "invalid config data", "version", req.Version, "configName", change.ConfigName))
+			return
+		}
+		// the records of the file and kubernetes sources mask the secrets, they keep their current values
+		config.RestoreSecrets(conf, old)
+		data, err := json.Marshal(conf)
 		if err != nil {
-			return errs.WrapMsg(err, "commit etcd txn failed")
+			apiresp.GinError(c, errs.Wrap(err))
+			return
 		}
+		changes = append(changes, configsource.Change{ConfigName: change.ConfigName, Diff: config.Diff(old, conf), Data: string(data), Value: conf})
 	}
-	return nil
+	if len(changes) == 0 {
+		apiresp.GinSuccess(c, nil)
+		return
+	}
+	rollback := &configsource.Record{
+		Action:          configsource.ActionRollback,
+		UserID:          mcontext.GetOpUserID(c),
+		RollbackVersion: req.Version,
+		Changes:         changes,
+	}
+	if err := cm.source.Save(c, rollback); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, nil)
 }
 
 func (cm *ConfigManager) Restart(c *gin.Context) {
@@ -251,45 +357,33 @@ func (cm *ConfigManager) Restart(c *gin.Context) {
 
 func (cm *ConfigManager) restart(c *gin.Context) {
 	time.Sleep(waitHttp) // wait for Restart http call return
-	t := time.Now().Unix()
-	_, err := cm.client.Put(c, etcd.BuildKey(etcd.RestartKey), strconv.Itoa(int(t)))
-	if err != nil {
-		log.ZError(c, "restart etcd put key failed", err)
+	if err := cm.source.Restart(c); err != nil {
+		log.ZError(c, "restart failed", err)
 	}
 }
 
 func (cm *ConfigManager) SetEnableConfigManager(c *gin.Context) {
-	if cm.config.Discovery.Enable != config.ETCD {
-		apiresp.GinError(c, errs.New("only etcd support config manager").Wrap())
-		return
-	}
 	var req apistruct.SetEnableConfigManagerReq
 	if err := c.BindJSON(&req); err != nil {
 		apiresp.GinError(c, errs.ErrArgs.WithDetail(err.Error()).Wrap())
 		return
 	}
-	var enableStr string
-	if req.Enable {
-		enableStr = etcd.Enable
-	} else {
-		enableStr = etcd.Disable
-	}
-	resp, err := cm.client.Get(c, etcd.BuildKey(etcd.EnableConfigCenterKey))
+	opUserID := mcontext.GetOpUserID(c)
+	enabled, err := cm.source.Enabled(c)
 	if err != nil {
 		apiresp.GinError(c, errs.WrapMsg(err, "getEnableConfigManager failed"))
 		return
 	}
-	if !(resp.Count > 0 && string(resp.Kvs[0].Value) == etcd.Enable) && req.Enable {
+	if !enabled && req.Enable {
 		go func() {
 			time.Sleep(waitHttp) // wait for Restart http call return
-			err := cm.resetConfig(c, false, clientv3.OpPut(etcd.BuildKey(etcd.EnableConfigCenterKey), enableStr))
+			err := cm.resetConfig(c, opUserID, false, true)
 			if err != nil {
 				log.ZError(c, "resetConfig failed", err)
 			}
 		}()
 	} else {
-		_, err = cm.client.Put(c, etcd.BuildKey(etcd.EnableConfigCenterKey), enableStr)
-		if err != nil {
+		if err := cm.source.SetEnabled(c, req.Enable, nil); err != nil {
 			apiresp.GinError(c, errs.WrapMsg(err, "setEnableConfigManager failed"))
 			return
 		}
@@ -299,14 +393,10 @@ func (cm *ConfigManager) SetEnableConfigManager(c *gin.Context) {
 }
 
 func (cm *ConfigManager) GetEnableConfigManager(c *gin.Context) {
-	resp, err := cm.client.Get(c, etcd.BuildKey(etcd.EnableConfigCenterKey))
+	enable, err := cm.source.Enabled(c)
 	if err != nil {
 		apiresp.GinError(c, errs.WrapMsg(err, "getEnableConfigManager failed"))
 		return
 	}
-	var enable bool
-	if resp.Count > 0 && string(resp.Kvs[0].Value) == etcd.Enable {
-		enable = true
-	}
 	apiresp.GinSuccess(c, &apistruct.GetEnableConfigManagerResp{Enable: enable})
 }
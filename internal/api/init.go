@@ -27,9 +27,10 @@ import (
 	"time"
 
 	conf "github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/configsource"
 	kdisc "github.com/openimsdk/open-im-server/v3/pkg/common/discovery"
-	disetcd "github.com/openimsdk/open-im-server/v3/pkg/common/discovery/etcd"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/prommetrics"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
 	"github.com/openimsdk/tools/discovery/etcd"
 	"github.com/openimsdk/tools/errs"
 	"github.com/openimsdk/tools/log"
@@ -65,6 +66,14 @@ func Start(ctx context.Context, index int, config *Config) error {
 		return errs.WrapMsg(err, "failed to register discovery service")
 	}
 	client.AddOption(mw.GrpcClient(), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, "round_robin")))
+	shutdownTracing, err := tracing.Init(ctx, prommetrics.APIKeyName, &config.Share.Tracing)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+	if config.Share.Tracing.Enable {
+		client.AddOption(tracing.GrpcDialOption())
+	}
 
 	var (
 		netDone        = make(chan struct{}, 1)
@@ -144,9 +153,8 @@ func Start(ctx context.Context, index int, config *Config) error {
 		}
 	}()
 
-	if config.Discovery.Enable == conf.ETCD {
-		cm := disetcd.NewConfigManager(client.(*etcd.SvcDiscoveryRegistryImpl).GetClient(), config.GetConfigNames())
-		cm.Watch(ctx)
+	if err := configsource.Watch(ctx, &config.Discovery, config.RuntimeEnv, config.ConfigPath, client, config.GetConfigNames()); err != nil {
+		log.ZWarn(ctx, "watch config failed", err)
 	}
 
 	sigs := make(chan os.Signal, 1)
@@ -161,7 +169,7 @@ func Start(ctx context.Context, index int, config *Config) error {
 		}
 		return nil
 	}
-	disetcd.RegisterShutDown(shutdown)
+	configsource.RegisterShutDown(shutdown)
 	select {
 	case <-sigs:
 		program.SIGTERMExit()
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/s3/local"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+)
+
+// LocalObjectApi serves the presigned urls of the local object storage engine.
+type LocalObjectApi struct {
+	local *local.Local
+}
+
+func NewLocalObjectApi(local *local.Local) *LocalObjectApi {
+	return &LocalObjectApi{local: local}
+}
+
+func (o *LocalObjectApi) objectName(c *gin.Context) (string, bool) {
+	name := c.Param("name")
+	if len(name) > 0 && name[0] == '/' {
+		name = name[1:]
+	}
+	if name == "" {
+		c.String(http.StatusBadRequest, "name is empty")
+		return "", false
+	}
+	return name, true
+}
+
+func (o *LocalObjectApi) writeError(c *gin.Context, err error) {
+	switch {
+	case errs.ErrArgs.Is(err):
+		c.String(http.StatusBadRequest, err.Error())
+	case errs.ErrNoPermission.Is(err):
+		c.String(http.StatusForbidden, err.Error())
+	case o.local.IsNotFound(err):
+		c.String(http.StatusNotFound, "object not found")
+	default:
+		log.ZError(c, "local object failed", err, "path", c.Request.URL.Path)
+		c.String(http.StatusInternalServerError, err.Error())
+	}
+}
+
+// PutObject handles the presigned put urls and the part urls of multipart uploads.
+func (o *LocalObjectApi) PutObject(c *gin.Context) {
+	name, ok := o.objectName(c)
+	if !ok {
+		return
+	}
+	query := c.Request.URL.Query()
+	if err := o.local.Verify(name, local.OpPut, query); err != nil {
+		o.writeError(c, err)
+		return
+	}
+	var (
+		meta *local.ObjectMeta
+		err  error
+	)
+	if uploadID := query.Get(local.QueryUploadID); uploadID != "" {
+		partNumber, _ := strconv.Atoi(query.Get(local.QueryPartNumber))
+		meta, err = o.local.UploadPart(c, uploadID, name, partNumber, c.Request.Body)
+	} else {
+		meta, err = o.local.PutObject(c, name, c.GetHeader("Content-Type"), c.Request.Body)
+	}
+	if err != nil {
+		o.writeError(c, err)
+		return
+	}
+	c.Header("ETag", strconv.Quote(meta.ETag))
+	c.Status(http.StatusOK)
+}
+
+// PostObject handles the form uploads, the signed fields must precede the file field.
+func (o *LocalObjectApi) PostObject(c *gin.Context) {
+	name, ok := o.objectName(c)
+	if !ok {
+		return
+	}
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	form := make(map[string][]string)
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			c.String(http.StatusBadRequest, "form has no file")
+			return
+		}
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		if part.FormName() != local.FormFile {
+			value, err := io.ReadAll(io.LimitReader(part, 1024))
+			if err != nil {
+				c.String(http.StatusBadRequest, err.Error())
+				return
+			}
+			form[part.FormName()] = []string{string(value)}
+			continue
+		}
+		if err := o.local.Verify(name, local.OpForm, form); err != nil {
+			o.writeError(c, err)
+			return
+		}
+		o.putFormFile(c, name, form, part)
+		return
+	}
+}
+
+func (o *LocalObjectApi) putFormFile(c *gin.Context, name string, form map[string][]string, file io.Reader) {
+	var size int64 = -1
+	if values := form[local.QuerySize]; len(values) > 0 {
+		size, _ = strconv.ParseInt(values[0], 10, 64)
+		file = io.LimitReader(file, size+1)
+	}
+	var contentType string
+	if values := form[local.QueryContentType]; len(values) > 0 {
+		contentType = values[0]
+	}
+	meta, err := o.local.PutObject(c, name, contentType, file)
+	if err != nil {
+		o.writeError(c, err)
+		return
+	}
+	if size >= 0 && meta.Size > size {
+		if err := o.local.DeleteObject(c, name); err != nil {
+			log.ZError(c, "delete oversize local object failed", err, "name", name)
+		}
+		c.String(http.StatusBadRequest, "file size exceeds the limit")
+		return
+	}
+	c.Header("ETag", strconv.Quote(meta.ETag))
+	c.Status(http.StatusOK)
+}
+
+// GetObject handles the signed download urls returned by AccessURL.
+func (o *LocalObjectApi) GetObject(c *gin.Context) {
+	name, ok := o.objectName(c)
+	if !ok {
+		return
+	}
+	query := c.Request.URL.Query()
+	if err := o.local.Verify(name, local.OpGet, query); err != nil {
+		o.writeError(c, err)
+		return
+	}
+	file, meta, err := o.local.OpenObject(c, name)
+	if err != nil {
+		o.writeError(c, err)
+		return
+	}
+	defer file.Close()
+	contentType := query.Get(local.QueryContentType)
+	if contentType == "" {
+		contentType = meta.ContentType
+	}
+	if contentType != "" {
+		c.Header("Content-Type", contentType)
+	}
+	if filename := query.Get(local.QueryFilename); filename != "" {
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+	c.Header("ETag", strconv.Quote(meta.ETag))
+	http.ServeContent(c.Writer, c.Request, "", meta.LastModified, file)
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/openimsdk/open-im-server/v3/pkg/apistruct"
 	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/protocol/msgext"
 	"github.com/openimsdk/open-im-server/v3/pkg/rpcli"
 	"github.com/openimsdk/protocol/constant"
 	"github.com/openimsdk/protocol/msg"
@@ -38,13 +39,14 @@ import (
 
 type MessageApi struct {
 	Client        msg.MsgClient
+	extClient     msgext.MsgExtClient
 	userClient    *rpcli.UserClient
 	imAdminUserID []string
 	validate      *validator.Validate
 }
 
-func NewMessageApi(client msg.MsgClient, userClient *rpcli.UserClient, imAdminUserID []string) MessageApi {
-	return MessageApi{Client: client, userClient: userClient, imAdminUserID: imAdminUserID, validate: validator.New()}
+func NewMessageApi(client msg.MsgClient, extClient msgext.MsgExtClient, userClient *rpcli.UserClient, imAdminUserID []string) MessageApi {
+	return MessageApi{Client: client, extClient: extClient, userClient: userClient, imAdminUserID: imAdminUserID, validate: validator.New()}
 }
 
 func (*MessageApi) SetOptions(options map[string]bool, value bool) {
@@ -328,19 +330,11 @@ func (m *MessageApi) BatchSendMsg(c *gin.Context) {
 
 	var recvIDs []string
 	if req.IsSendAll {
-		var pageNumber int32 = 1
-		const showNumber = 500
-		for {
-			recvIDsPart, err := m.userClient.GetAllUserIDs(c, pageNumber, showNumber)
-			if err != nil {
-				apiresp.GinError(c, err)
-				return
-			}
-			recvIDs = append(recvIDs, recvIDsPart...)
-			if len(recvIDsPart) < showNumber {
-				break
-			}
-			pageNumber++
+		var err error
+		recvIDs, err = m.getAllUserIDs(c)
+		if err != nil {
+			apiresp.GinError(c, err)
+			return
 		}
 	} else {
 		recvIDs = req.RecvIDs
@@ -368,6 +362,74 @@ func (m *MessageApi) BatchSendMsg(c *gin.Context) {
 	apiresp.GinSuccess(c, resp)
 }
 
+func (m *MessageApi) getAllUserIDs(c *gin.Context) ([]string, error) {
+	var (
+		userIDs    []string
+		pageNumber int32 = 1
+	)
+	const showNumber = 500
+	for {
+		userIDsPart, err := m.userClient.GetAllUserIDs(c, pageNumber, showNumber)
+		if err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userIDsPart...)
+		if len(userIDsPart) < showNumber {
+			return userIDs, nil
+		}
+		pageNumber++
+	}
+}
+
+// ScheduleMsg schedules a message like SendMessage, or like BatchSendMsg when it has several receivers. The users
+// can schedule their own messages to a single receiver, the receivers of IsSendAll are the users at schedule time.
+func (m *MessageApi) ScheduleMsg(c *gin.Context) {
+	var req apistruct.ScheduleMsgReq
+	if err := c.BindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrArgs.WithDetail(err.Error()).Wrap())
+		return
+	}
+	isAdmin := authverify.IsAppManagerUid(c, m.imAdminUserID)
+	if !isAdmin && (req.IsSendAll || len(req.RecvIDs) > 0) {
+		apiresp.GinError(c, errs.ErrNoPermission.WrapMsg("only app manager can schedule a message to several receivers"))
+		return
+	}
+	sendMsgReq, err := m.getSendMsgReq(c, req.SendMsg)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	sendMsgReq.MsgData.RecvID = req.RecvID
+	if !isAdmin {
+		sendMsgReq.MsgData.MsgFrom = constant.UserMsgType
+	}
+	recvIDs := req.RecvIDs
+	if req.IsSendAll {
+		if recvIDs, err = m.getAllUserIDs(c); err != nil {
+			apiresp.GinError(c, err)
+			return
+		}
+	}
+	resp, err := m.extClient.ScheduleMsg(c, &msgext.ScheduleMsgReq{MsgData: sendMsgReq.MsgData, RecvIDs: recvIDs, SendAt: req.SendAt})
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, resp)
+}
+
+func (m *MessageApi) GetScheduledMsgs(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.GetScheduledMsgs, m.extClient)
+}
+
+func (m *MessageApi) CancelScheduledMsg(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.CancelScheduledMsg, m.extClient)
+}
+
+func (m *MessageApi) UpdateScheduledMsg(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.UpdateScheduledMsg, m.extClient)
+}
+
 func (m *MessageApi) CheckMsgIsSendSuccess(c *gin.Context) {
 	a2r.Call(c, msg.MsgClient.GetSendMsgStatus, m.Client)
 }
@@ -384,8 +446,36 @@ func (m *MessageApi) GetActiveGroup(c *gin.Context) {
 	a2r.Call(c, msg.MsgClient.GetActiveGroup, m.Client)
 }
 
+// SearchMsg searches the messages in the search index when the request uses a field only supported by it,
+// such as keyword, otherwise the messages are searched in mongo.
 func (m *MessageApi) SearchMsg(c *gin.Context) {
-	a2r.Call(c, msg.MsgClient.SearchMessage, m.Client)
+	req, err := a2r.ParseRequestNotCheck[msgext.SearchMsgReq](c)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	if req.UseIndex() {
+		resp, err := m.extClient.SearchMsg(c, req)
+		if err != nil {
+			apiresp.GinError(c, err)
+			return
+		}
+		apiresp.GinSuccess(c, resp)
+		return
+	}
+	resp, err := m.Client.SearchMessage(c, &msg.SearchMessageReq{
+		SendID:      req.SendID,
+		RecvID:      req.RecvID,
+		ContentType: req.ContentType,
+		SendTime:    req.SendTime,
+		SessionType: req.SessionType,
+		Pagination:  req.Pagination,
+	})
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, resp)
 }
 
 func (m *MessageApi) GetServerTime(c *gin.Context) {
@@ -399,3 +489,51 @@ func (m *MessageApi) GetStreamMsg(c *gin.Context) {
 func (m *MessageApi) AppendStreamMsg(c *gin.Context) {
 	a2r.Call(c, msg.MsgClient.GetServerTime, m.Client)
 }
+
+func (m *MessageApi) SetSensitiveWordList(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.SetSensitiveWordList, m.extClient)
+}
+
+func (m *MessageApi) DeleteSensitiveWordLists(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.DeleteSensitiveWordLists, m.extClient)
+}
+
+func (m *MessageApi) GetSensitiveWordLists(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.GetSensitiveWordLists, m.extClient)
+}
+
+func (m *MessageApi) BatchRevokeMsg(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.BatchRevokeMsg, m.extClient)
+}
+
+func (m *MessageApi) RevokeUserGroupMsgs(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.RevokeUserGroupMsgs, m.extClient)
+}
+
+func (m *MessageApi) EditMsg(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.EditMsg, m.extClient)
+}
+
+func (m *MessageApi) AddReaction(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.AddReaction, m.extClient)
+}
+
+func (m *MessageApi) DeleteReaction(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.DeleteReaction, m.extClient)
+}
+
+func (m *MessageApi) GetReactions(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.GetReactions, m.extClient)
+}
+
+func (m *MessageApi) GetThreadReplies(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.GetThreadReplies, m.extClient)
+}
+
+func (m *MessageApi) SetGroupReadReceipt(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.SetGroupReadReceipt, m.extClient)
+}
+
+func (m *MessageApi) GetMsgReadReceipts(c *gin.Context) {
+	a2r.Call(c, msgext.MsgExtClient.GetMsgReadReceipts, m.extClient)
+}
@@ -10,9 +10,14 @@ import (
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"github.com/openimsdk/open-im-server/v3/internal/api/jssdk"
-	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/configsource"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/prommetrics"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/s3/local"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
+	"github.com/openimsdk/open-im-server/v3/pkg/protocol/msgext"
+	"github.com/openimsdk/open-im-server/v3/pkg/ratelimit"
 	"github.com/openimsdk/open-im-server/v3/pkg/rpcli"
 	pbAuth "github.com/openimsdk/protocol/auth"
 	"github.com/openimsdk/protocol/constant"
@@ -24,10 +29,8 @@ import (
 	"github.com/openimsdk/protocol/user"
 	"github.com/openimsdk/tools/apiresp"
 	"github.com/openimsdk/tools/discovery"
-	"github.com/openimsdk/tools/discovery/etcd"
 	"github.com/openimsdk/tools/log"
 	"github.com/openimsdk/tools/mw"
-	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 const (
@@ -95,8 +98,23 @@ func newGinRouter(ctx context.Context, client discovery.SvcDiscoveryRegistry, cf
 	case BestSpeed:
 		r.Use(gzip.Gzip(gzip.BestSpeed))
 	}
+	if cfg.Share.Tracing.Enable {
+		r.ContextWithFallback = true
+		r.Use(tracing.GinMiddleware(prommetrics.APIKeyName))
+	}
 	r.Use(prommetricsGin(), gin.RecoveryWithWriter(gin.DefaultErrorWriter, mw.GinPanicErr), mw.CorsHandler(),
 		mw.GinParseOperationID(), GinParseToken(rpcli.NewAuthClient(authConn)))
+	if cfg.API.RateLimit.Enable {
+		rdb, err := tracing.NewRedisClient(ctx, cfg.Redis.Build(), &cfg.Share.Tracing)
+		if err != nil {
+			return nil, err
+		}
+		policy, err := ratelimit.New(&cfg.API.RateLimit, rdb)
+		if err != nil {
+			return nil, err
+		}
+		r.Use(GinRateLimit(policy, cfg.Share.IMAdminUserID))
+	}
 
 	u := NewUserApi(user.NewUserClient(userConn), client, cfg.Discovery.RpcService)
 	{
@@ -222,8 +240,19 @@ func newGinRouter(ctx context.Context, client discovery.SvcDiscoveryRegistry, cf
 		objectGroup.POST("/complete_form_data", t.CompleteFormData)
 		objectGroup.GET("/*name", t.ObjectRedirect)
 	}
+	if cfg.Third.Object.Enable == local.Engine {
+		lo, err := local.New(&cfg.Third.Object.Local, cfg.Share.Secret)
+		if err != nil {
+			return nil, err
+		}
+		o := NewLocalObjectApi(lo)
+		localObjectGroup := r.Group(local.Prefix)
+		localObjectGroup.PUT("/*name", o.PutObject)
+		localObjectGroup.POST("/*name", o.PostObject)
+		localObjectGroup.GET("/*name", o.GetObject)
+	}
 	// Message
-	m := NewMessageApi(msg.NewMsgClient(msgConn), rpcli.NewUserClient(userConn), cfg.Share.IMAdminUserID)
+	m := NewMessageApi(msg.NewMsgClient(msgConn), msgext.NewMsgExtClient(msgConn), rpcli.NewUserClient(userConn), cfg.Share.IMAdminUserID)
 	{
 		msgGroup := r.Group("/msg")
 		msgGroup.POST("/newest_seq", m.GetSeq)
@@ -232,10 +261,19 @@ func newGinRouter(ctx context.Context, client discovery.SvcDiscoveryRegistry, cf
 		msgGroup.POST("/send_business_notification", m.SendBusinessNotification)
 		msgGroup.POST("/pull_msg_by_seq", m.PullMsgBySeqs)
 		msgGroup.POST("/revoke_msg", m.RevokeMsg)
+		msgGroup.POST("/batch_revoke_msg", m.BatchRevokeMsg)
+		msgGroup.POST("/revoke_user_group_msgs", m.RevokeUserGroupMsgs)
+		msgGroup.POST("/edit_msg", m.EditMsg)
+		msgGroup.POST("/add_reaction", m.AddReaction)
+		msgGroup.POST("/delete_reaction", m.DeleteReaction)
+		msgGroup.POST("/get_reactions", m.GetReactions)
+		msgGroup.POST("/get_thread_replies", m.GetThreadReplies)
 		msgGroup.POST("/mark_msgs_as_read", m.MarkMsgsAsRead)
 		msgGroup.POST("/mark_conversation_as_read", m.MarkConversationAsRead)
 		msgGroup.POST("/get_conversations_has_read_and_max_seq", m.GetConversationsHasReadAndMaxSeq)
 		msgGroup.POST("/set_conversation_has_read_seq", m.SetConversationHasReadSeq)
+		msgGroup.POST("/set_group_read_receipt", m.SetGroupReadReceipt)
+		msgGroup.POST("/get_msg_read_receipts", m.GetMsgReadReceipts)
 
 		msgGroup.POST("/clear_conversation_msg", m.ClearConversationsMsg)
 		msgGroup.POST("/user_clear_all_msg", m.UserClearAllMsg)
@@ -244,10 +282,18 @@ func newGinRouter(ctx context.Context, client discovery.SvcDiscoveryRegistry, cf
 		msgGroup.POST("/delete_msg_physical", m.DeleteMsgPhysical)
 
 		msgGroup.POST("/batch_send_msg", m.BatchSendMsg)
+		msgGroup.POST("/schedule_msg", m.ScheduleMsg)
+		msgGroup.POST("/get_scheduled_msgs", m.GetScheduledMsgs)
+		msgGroup.POST("/cancel_scheduled_msg", m.CancelScheduledMsg)
+		msgGroup.POST("/update_scheduled_msg", m.UpdateScheduledMsg)
 		msgGroup.POST("/check_msg_is_send_success", m.CheckMsgIsSendSuccess)
 		msgGroup.POST("/get_server_time", m.GetServerTime)
 		msgGroup.POST("/get_stream_msg", m.GetStreamMsg)
 		msgGroup.POST("/append_stream_msg", m.AppendStreamMsg)
+
+		msgGroup.POST("/set_sensitive_word_list", m.SetSensitiveWordList)
+		msgGroup.POST("/delete_sensitive_word_lists", m.DeleteSensitiveWordLists)
+		msgGroup.POST("/get_sensitive_word_lists", m.GetSensitiveWordLists)
 	}
 	// Conversation
 	{
@@ -297,11 +343,11 @@ func newGinRouter(ctx context.Context, client discovery.SvcDiscoveryRegistry, cf
 		proDiscoveryGroup.GET("/msg_transfer", pd.MessageTransfer)
 	}
 
-	var etcdClient *clientv3.Client
-	if cfg.Discovery.Enable == config.ETCD {
-		etcdClient = client.(*etcd.SvcDiscoveryRegistryImpl).GetClient()
+	source, err := configsource.New(&cfg.Discovery, cfg.RuntimeEnv, cfg.ConfigPath, client)
+	if err != nil {
+		return nil, err
 	}
-	cm := NewConfigManager(cfg.Share.IMAdminUserID, cfg.AllConfig, etcdClient, cfg.ConfigPath, cfg.RuntimeEnv)
+	cm := NewConfigManager(cfg.Share.IMAdminUserID, cfg.AllConfig, source, cfg.ConfigPath, cfg.RuntimeEnv)
 	{
 
 		configGroup := r.Group("/config", cm.CheckAdmin)
@@ -311,6 +357,8 @@ func newGinRouter(ctx context.Context, client discovery.SvcDiscoveryRegistry, cf
 		configGroup.POST("/reset_config", cm.ResetConfig)
 		configGroup.POST("/set_enable_config_manager", cm.SetEnableConfigManager)
 		configGroup.POST("/get_enable_config_manager", cm.GetEnableConfigManager)
+		configGroup.POST("/get_config_history", cm.GetConfigHistory)
+		configGroup.POST("/rollback_config", cm.RollbackConfig)
 	}
 	{
 		r.POST("/restart", cm.CheckAdmin, cm.Restart)
@@ -349,8 +397,31 @@ func GinParseToken(authClient *rpcli.AuthClient) gin.HandlerFunc {
 	}
 }
 
+// GinRateLimit rejects the requests exceeding the rate limit, the app managers are not limited.
+func GinRateLimit(policy *ratelimit.Policy, imAdminUserID []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authverify.IsAppManagerUid(c, imAdminUserID) {
+			c.Next()
+			return
+		}
+		subject := ratelimit.Subject{
+			UserID:     c.GetString(constant.OpUserID),
+			PlatformID: constant.PlatformNameToID(c.GetString(constant.OpUserPlatform)),
+			IP:         policy.ClientIP(c.Request),
+		}
+		if err := policy.Check(c, c.Request.URL.Path, subject); err != nil {
+			log.ZWarn(c, "api rate limited", err, "path", c.Request.URL.Path, "ip", subject.IP)
+			apiresp.GinError(c, err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // Whitelist api not parse token
 var Whitelist = []string{
 	"/auth/get_admin_token",
 	"/auth/parse_token",
+	local.Prefix + "/",
 }
@@ -24,6 +24,7 @@ import (
 
 	"google.golang.org/protobuf/proto"
 
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
 	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
 	"github.com/openimsdk/protocol/constant"
 	"github.com/openimsdk/protocol/sdkws"
@@ -32,6 +33,7 @@ import (
 	"github.com/openimsdk/tools/log"
 	"github.com/openimsdk/tools/mcontext"
 	"github.com/openimsdk/tools/utils/stringutil"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -71,6 +73,9 @@ type Client struct {
 	IsBackground   bool   `json:"isBackground"`
 	SDKType        string `json:"sdkType"`
 	Encoder        Encoder
+	compressor     *FrameCompressor // Nil when the connection is not compressed.
+	session        *resumeSession   // Nil when the session resume is disabled.
+	resumed        *resumeSession   // The dropped session taken over by the connection until it is registered.
 	ctx            *UserConnContext
 	longConnServer LongConnServer
 	closed         atomic.Bool
@@ -87,7 +92,8 @@ func (c *Client) ResetClient(ctx *UserConnContext, conn LongConn, longConnServer
 	c.w = new(sync.Mutex)
 	c.conn = conn
 	c.PlatformID = stringutil.StringToInt(ctx.GetPlatformID())
-	c.IsCompress = ctx.GetCompression()
+	c.compressor = longConnServer.GetFrameCompressor(ctx.GetCompression())
+	c.IsCompress = c.compressor != nil
 	c.IsBackground = ctx.GetBackground()
 	c.UserID = ctx.GetUserID()
 	c.ctx = ctx
@@ -102,12 +108,10 @@ func (c *Client) ResetClient(ctx *UserConnContext, conn LongConn, longConnServer
 	if c.subUserIDs != nil {
 		clear(c.subUserIDs)
 	}
-	if c.SDKType == GoSDK {
-		c.Encoder = NewGobEncoder()
-	} else {
-		c.Encoder = NewJsonEncoder()
-	}
+	c.Encoder = newEncoder(ctx.GetEncoding(), c.SDKType)
 	c.subUserIDs = make(map[string]struct{})
+	c.session = nil
+	c.resumed = nil
 }
 
 func (c *Client) pingHandler(appData string) error {
@@ -190,7 +194,7 @@ func (c *Client) readMessage() {
 func (c *Client) handleMessage(message []byte) error {
 	if c.IsCompress {
 		var err error
-		message, err = c.longConnServer.DecompressWithPool(message)
+		message, err = c.compressor.DecompressFrame(message)
 		if err != nil {
 			return errs.Wrap(err)
 		}
@@ -218,10 +222,17 @@ func (c *Client) handleMessage(message []byte) error {
 
 	log.ZDebug(ctx, "gateway req message", "req", binaryReq.String())
 
+	if err := c.longConnServer.CheckRateLimit(ctx, c, binaryReq); err != nil {
+		log.ZWarn(ctx, "gateway req rate limited", err, "reqIdentifier", binaryReq.ReqIdentifier)
+		return c.replyMessage(ctx, binaryReq, err, nil)
+	}
+
 	var (
 		resp       []byte
 		messageErr error
 	)
+	ctx, span := tracing.Start(ctx, "msggateway.request", attribute.Int("reqIdentifier", int(binaryReq.ReqIdentifier)))
+	defer func() { tracing.End(span, messageErr) }()
 
 	switch binaryReq.ReqIdentifier {
 	case WSGetNewestSeq:
@@ -298,12 +309,16 @@ func (c *Client) replyMessage(ctx context.Context, binaryReq *Req, err error, re
 	log.ZDebug(ctx, "wireBinaryMsg end", "time cost", time.Since(t))
 
 	if binaryReq.ReqIdentifier == WsLogoutMsg {
+		c.session.close()
 		return errs.New("user logout", "operationID", binaryReq.OperationID).Wrap()
 	}
 	return nil
 }
 
 func (c *Client) PushMessage(ctx context.Context, msgData *sdkws.MsgData) error {
+	if c.session != nil && c.session.hold(msgData) {
+		return ErrSessionDetached
+	}
 	var msg sdkws.PushMessages
 	conversationID := msgprocessor.GetConversationIDByMsg(msgData)
 	m := map[string]*sdkws.PullMsgs{conversationID: {Msgs: []*sdkws.MsgData{msgData}}}
@@ -362,7 +377,7 @@ func (c *Client) writeBinaryMsg(resp Resp) error {
 	}
 
 	if c.IsCompress {
-		resultBuf, compressErr := c.longConnServer.CompressWithPool(encodedBuf)
+		resultBuf, compressErr := c.compressor.CompressFrame(encodedBuf)
 		if compressErr != nil {
 			return compressErr
 		}
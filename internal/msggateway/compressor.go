@@ -16,18 +16,55 @@ package msggateway
 
 import (
 	"bytes"
+	"compress/flate"
 	"compress/gzip"
 	"io"
 	"sync"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/openimsdk/tools/errs"
 )
 
 var (
 	gzipWriterPool = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
 	gzipReaderPool = sync.Pool{New: func() any { return new(gzip.Reader) }}
+
+	// The zstd encoders and decoders are single threaded so that EncodeAll and DecodeAll run on the caller goroutine
+	// and the pooled ones don't hold background goroutines.
+	zstdEncoderPool = sync.Pool{New: func() any {
+		encoder, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return encoder
+	}}
+	zstdDecoderPool = sync.Pool{New: func() any {
+		decoder, _ := newZstdDecoder()
+		return decoder
+	}}
+
+	flateWriterPool = sync.Pool{New: func() any {
+		writer, _ := flate.NewWriter(nil, flate.DefaultCompression)
+		return writer
+	}}
+	flateReaderPool = sync.Pool{New: func() any { return flate.NewReader(nil) }}
 )
 
+// The zstd and deflate frames of the clients are decompressed to at most maxMessageSize bytes, the read limit of
+// the uncompressed frames, so that a small frame cannot expand to an unbounded message.
+func newZstdDecoder() (*zstd.Decoder, error) {
+	return zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(maxMessageSize))
+}
+
+// readLimited reads reader up to maxMessageSize bytes, and fails when there are more.
+func readLimited(reader io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(reader, maxMessageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxMessageSize {
+		return nil, errs.New("decompressed data exceeds the max message size", "max", maxMessageSize)
+	}
+	return data, nil
+}
+
 type Compressor interface {
 	Compress(rawData []byte) ([]byte, error)
 	CompressWithPool(rawData []byte) ([]byte, error)
@@ -111,3 +148,167 @@ func (g *GzipCompressor) DecompressWithPool(compressedData []byte) ([]byte, erro
 	}
 	return decompressedData, nil
 }
+
+type ZstdCompressor struct{}
+
+func NewZstdCompressor() *ZstdCompressor {
+	return &ZstdCompressor{}
+}
+
+func (z *ZstdCompressor) Compress(rawData []byte) ([]byte, error) {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, errs.WrapMsg(err, "ZstdCompressor.Compress: creating zstd encoder failed")
+	}
+	defer encoder.Close()
+	return encoder.EncodeAll(rawData, nil), nil
+}
+
+func (z *ZstdCompressor) CompressWithPool(rawData []byte) ([]byte, error) {
+	encoder := zstdEncoderPool.Get().(*zstd.Encoder)
+	defer zstdEncoderPool.Put(encoder)
+	return encoder.EncodeAll(rawData, nil), nil
+}
+
+func (z *ZstdCompressor) DeCompress(compressedData []byte) ([]byte, error) {
+	decoder, err := newZstdDecoder()
+	if err != nil {
+		return nil, errs.WrapMsg(err, "ZstdCompressor.DeCompress: creating zstd decoder failed")
+	}
+	defer decoder.Close()
+	decompressedData, err := decoder.DecodeAll(compressedData, nil)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "ZstdCompressor.DeCompress: decoding failed")
+	}
+	return decompressedData, nil
+}
+
+func (z *ZstdCompressor) DecompressWithPool(compressedData []byte) ([]byte, error) {
+	decoder := zstdDecoderPool.Get().(*zstd.Decoder)
+	defer zstdDecoderPool.Put(decoder)
+	decompressedData, err := decoder.DecodeAll(compressedData, nil)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "ZstdCompressor.DecompressWithPool: decoding failed")
+	}
+	return decompressedData, nil
+}
+
+// DeflateCompressor compresses to raw deflate blocks without the zlib or gzip framing.
+type DeflateCompressor struct{}
+
+func NewDeflateCompressor() *DeflateCompressor {
+	return &DeflateCompressor{}
+}
+
+func (d *DeflateCompressor) Compress(rawData []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer, err := flate.NewWriter(&buffer, flate.DefaultCompression)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "DeflateCompressor.Compress: creating flate writer failed")
+	}
+	if _, err := writer.Write(rawData); err != nil {
+		return nil, errs.WrapMsg(err, "DeflateCompressor.Compress: writing to flate writer failed")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errs.WrapMsg(err, "DeflateCompressor.Compress: closing flate writer failed")
+	}
+	return buffer.Bytes(), nil
+}
+
+func (d *DeflateCompressor) CompressWithPool(rawData []byte) ([]byte, error) {
+	writer := flateWriterPool.Get().(*flate.Writer)
+	defer flateWriterPool.Put(writer)
+
+	var buffer bytes.Buffer
+	writer.Reset(&buffer)
+	if _, err := writer.Write(rawData); err != nil {
+		return nil, errs.WrapMsg(err, "DeflateCompressor.CompressWithPool: writing to flate writer failed")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errs.WrapMsg(err, "DeflateCompressor.CompressWithPool: closing flate writer failed")
+	}
+	return buffer.Bytes(), nil
+}
+
+func (d *DeflateCompressor) DeCompress(compressedData []byte) ([]byte, error) {
+	reader := flate.NewReader(bytes.NewReader(compressedData))
+	defer reader.Close()
+	decompressedData, err := readLimited(reader)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "DeflateCompressor.DeCompress: reading from flate reader failed")
+	}
+	return decompressedData, nil
+}
+
+func (d *DeflateCompressor) DecompressWithPool(compressedData []byte) ([]byte, error) {
+	reader := flateReaderPool.Get().(io.ReadCloser)
+	defer flateReaderPool.Put(reader)
+
+	if err := reader.(flate.Resetter).Reset(bytes.NewReader(compressedData), nil); err != nil {
+		return nil, errs.WrapMsg(err, "DeflateCompressor.DecompressWithPool: resetting flate reader failed")
+	}
+	decompressedData, err := readLimited(reader)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "DeflateCompressor.DecompressWithPool: reading from pooled flate reader failed")
+	}
+	return decompressedData, nil
+}
+
+// The flag starting the frames of the flagged compression protocols.
+const (
+	frameRaw        byte = 0
+	frameCompressed byte = 1
+)
+
+// FrameCompressor compresses the frames of a connection with the protocol negotiated at handshake.
+//
+// The zstd and deflate frames start with a flag byte telling whether the rest of the frame is compressed, so that
+// the frames shorter than minSize, or which compression would make longer, are sent as they are. The gzip frames
+// carry no flag and are always compressed, as the sdks negotiating gzip expect.
+type FrameCompressor struct {
+	Compressor
+	flagged bool
+	minSize int
+}
+
+// newFrameCompressors returns the frame compressors of the supported protocols by protocol name.
+func newFrameCompressors(minSize int) map[string]*FrameCompressor {
+	return map[string]*FrameCompressor{
+		GzipCompressionProtocol:    {Compressor: NewGzipCompressor()},
+		ZstdCompressionProtocol:    {Compressor: NewZstdCompressor(), flagged: true, minSize: minSize},
+		DeflateCompressionProtocol: {Compressor: NewDeflateCompressor(), flagged: true, minSize: minSize},
+	}
+}
+
+func (f *FrameCompressor) CompressFrame(data []byte) ([]byte, error) {
+	if !f.flagged {
+		return f.CompressWithPool(data)
+	}
+	if len(data) >= f.minSize {
+		compressed, err := f.CompressWithPool(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(compressed) < len(data) {
+			return append([]byte{frameCompressed}, compressed...), nil
+		}
+	}
+	return append([]byte{frameRaw}, data...), nil
+}
+
+func (f *FrameCompressor) DecompressFrame(frame []byte) ([]byte, error) {
+	if !f.flagged {
+		return f.DecompressWithPool(frame)
+	}
+	if len(frame) == 0 {
+		return nil, errs.New("FrameCompressor.DecompressFrame: empty frame")
+	}
+	switch frame[0] {
+	case frameRaw:
+		return frame[1:], nil
+	case frameCompressed:
+		return f.DecompressWithPool(frame[1:])
+	default:
+		return nil, errs.New("FrameCompressor.DecompressFrame: unknown frame flag", "flag", frame[0])
+	}
+}
@@ -15,6 +15,7 @@
 package msggateway
 
 import (
+	"bytes"
 	"crypto/rand"
 	"github.com/stretchr/testify/assert"
 	"sync"
@@ -137,3 +138,79 @@ func TestName(t *testing.T) {
 	t.Log(unsafe.Sizeof(Client{}))
 
 }
+
+func TestCompressorsRoundTrip(t *testing.T) {
+	compressors := map[string]Compressor{
+		ZstdCompressionProtocol:    NewZstdCompressor(),
+		DeflateCompressionProtocol: NewDeflateCompressor(),
+	}
+	src := bytes.Repeat([]byte("openim "), 100)
+	for name, compressor := range compressors {
+		dest, err := compressor.Compress(src)
+		assert.Nil(t, err, name)
+		res, err := compressor.DecompressWithPool(dest)
+		assert.Nil(t, err, name)
+		assert.EqualValues(t, src, res, name)
+
+		dest, err = compressor.CompressWithPool(src)
+		assert.Nil(t, err, name)
+		res, err = compressor.DeCompress(dest)
+		assert.Nil(t, err, name)
+		assert.EqualValues(t, src, res, name)
+	}
+}
+
+func TestDecompressLimit(t *testing.T) {
+	compressors := map[string]Compressor{
+		ZstdCompressionProtocol:    NewZstdCompressor(),
+		DeflateCompressionProtocol: NewDeflateCompressor(),
+	}
+	for name, compressor := range compressors {
+		dest, err := compressor.Compress(make([]byte, maxMessageSize))
+		assert.Nil(t, err, name)
+		res, err := compressor.DecompressWithPool(dest)
+		assert.Nil(t, err, name)
+		assert.Len(t, res, maxMessageSize, name)
+
+		dest, err = compressor.Compress(make([]byte, maxMessageSize+1))
+		assert.Nil(t, err, name)
+		_, err = compressor.DecompressWithPool(dest)
+		assert.NotNil(t, err, name)
+		_, err = compressor.DeCompress(dest)
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestFrameCompressor(t *testing.T) {
+	compressors := newFrameCompressors(64)
+	small := []byte("small frame")
+	large := bytes.Repeat([]byte("large frame "), 20)
+	for _, protocol := range []string{ZstdCompressionProtocol, DeflateCompressionProtocol} {
+		compressor := compressors[protocol]
+
+		frame, err := compressor.CompressFrame(small)
+		assert.Nil(t, err, protocol)
+		assert.Equal(t, frameRaw, frame[0], protocol)
+		res, err := compressor.DecompressFrame(frame)
+		assert.Nil(t, err, protocol)
+		assert.EqualValues(t, small, res, protocol)
+
+		frame, err = compressor.CompressFrame(large)
+		assert.Nil(t, err, protocol)
+		assert.Equal(t, frameCompressed, frame[0], protocol)
+		assert.Less(t, len(frame), len(large), protocol)
+		res, err = compressor.DecompressFrame(frame)
+		assert.Nil(t, err, protocol)
+		assert.EqualValues(t, large, res, protocol)
+
+		_, err = compressor.DecompressFrame([]byte{2})
+		assert.NotNil(t, err, protocol)
+	}
+
+	// The gzip frames are compressed whatever their size, without a flag.
+	frame, err := compressors[GzipCompressionProtocol].CompressFrame(small)
+	assert.Nil(t, err)
+	res, err := NewGzipCompressor().DecompressWithPool(frame)
+	assert.Nil(t, err)
+	assert.EqualValues(t, small, res)
+}
@@ -17,17 +17,25 @@ package msggateway
 import "time"
 
 const (
-	WsUserID                = "sendID"
-	CommonUserID            = "userID"
-	PlatformID              = "platformID"
-	ConnID                  = "connID"
-	Token                   = "token"
-	OperationID             = "operationID"
-	Compression             = "compression"
-	GzipCompressionProtocol = "gzip"
-	BackgroundStatus        = "isBackground"
-	SendResponse            = "isMsgResp"
-	SDKType                 = "sdkType"
+	WsUserID         = "sendID"
+	CommonUserID     = "userID"
+	PlatformID       = "platformID"
+	ConnID           = "connID"
+	Token            = "token"
+	OperationID      = "operationID"
+	Compression      = "compression"
+	BackgroundStatus = "isBackground"
+	SendResponse     = "isMsgResp"
+	SDKType          = "sdkType"
+	Encoding         = "encoding"
+	ResumeID         = "resumeID"
+)
+
+// The compression protocols negotiated at handshake, see FrameCompressor.
+const (
+	GzipCompressionProtocol    = "gzip"
+	ZstdCompressionProtocol    = "zstd"
+	DeflateCompressionProtocol = "deflate"
 )
 
 const (
@@ -35,8 +43,17 @@ const (
 	JsSDK = "js"
 )
 
+// The encodings of the Req and Resp negotiated at handshake, see newEncoder.
+const (
+	GobEncoding       = "gob"
+	JsonEncoding      = "json"
+	ProtobufEncoding  = "protobuf"
+	ProtoJsonEncoding = "protojson"
+)
+
 const (
 	WebSocket = iota + 1
+	SSE
 )
 
 const (
@@ -53,6 +70,7 @@ const (
 	WsLogoutMsg           = 2003
 	WsSetBackgroundStatus = 2004
 	WsSubUserOnlineStatus = 2005
+	WsSessionResume       = 2006
 	WSDataError           = 3001
 )
 
@@ -140,17 +140,25 @@ func (c *UserConnContext) GetToken() string {
 	return c.Req.URL.Query().Get(Token)
 }
 
-func (c *UserConnContext) GetCompression() bool {
-	compression, exists := c.Query(Compression)
-	if exists && compression == GzipCompressionProtocol {
+// GetCompression returns the compression protocol of the connection, an empty string when the connection is not
+// compressed or the protocol is not supported.
+func (c *UserConnContext) GetCompression() string {
+	if compression, _ := c.Query(Compression); isCompressionProtocol(compression) {
+		return compression
+	}
+	if compression, _ := c.GetHeader(Compression); isCompressionProtocol(compression) {
+		return compression
+	}
+	return ""
+}
+
+func isCompressionProtocol(compression string) bool {
+	switch compression {
+	case GzipCompressionProtocol, ZstdCompressionProtocol, DeflateCompressionProtocol:
 		return true
-	} else {
-		compression, exists := c.GetHeader(Compression)
-		if exists && compression == GzipCompressionProtocol {
-			return true
-		}
+	default:
+		return false
 	}
-	return false
 }
 
 func (c *UserConnContext) GetSDKType() string {
@@ -161,6 +169,19 @@ func (c *UserConnContext) GetSDKType() string {
 	return sdkType
 }
 
+func (c *UserConnContext) GetEncoding() string {
+	return c.Req.URL.Query().Get(Encoding)
+}
+
+// GetResumeID returns the resume id of the session the connection takes over, empty for a new session.
+func (c *UserConnContext) GetResumeID() string {
+	if resumeID, ok := c.Query(ResumeID); ok {
+		return resumeID
+	}
+	resumeID, _ := c.GetHeader(ResumeID)
+	return resumeID
+}
+
 func (c *UserConnContext) ShouldSendResp() bool {
 	errResp, exists := c.Query(SendResponse)
 	if exists {
@@ -207,5 +228,10 @@ func (c *UserConnContext) ParseEssentialArgs() error {
 	default:
 		return servererrs.ErrConnArgsErr.WrapMsg("sdkType is not go or js")
 	}
+	switch encoding, _ := c.Query(Encoding); encoding {
+	case "", GobEncoding, JsonEncoding, ProtobufEncoding, ProtoJsonEncoding:
+	default:
+		return servererrs.ErrConnArgsErr.WrapMsg("encoding is not gob, json, protobuf or protojson")
+	}
 	return nil
 }
@@ -0,0 +1,81 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msggateway
+
+import (
+	"github.com/openimsdk/protocol/msg"
+	"github.com/openimsdk/protocol/push"
+	"github.com/openimsdk/protocol/sdkws"
+	"google.golang.org/protobuf/proto"
+)
+
+// dataType is the message in the Data of the requests and responses of a req identifier.
+type dataType struct {
+	req  func() proto.Message
+	resp func() proto.Message
+}
+
+var dataTypes = map[int32]dataType{
+	WSGetNewestSeq: {
+		req:  func() proto.Message { return &sdkws.GetMaxSeqReq{} },
+		resp: func() proto.Message { return &sdkws.GetMaxSeqResp{} },
+	},
+	WSPullMsgBySeqList: {
+		req:  func() proto.Message { return &sdkws.PullMessageBySeqsReq{} },
+		resp: func() proto.Message { return &sdkws.PullMessageBySeqsResp{} },
+	},
+	WSSendMsg: {
+		req:  func() proto.Message { return &sdkws.MsgData{} },
+		resp: func() proto.Message { return &msg.SendMsgResp{} },
+	},
+	WSSendSignalMsg: {
+		req:  func() proto.Message { return &sdkws.MsgData{} },
+		resp: func() proto.Message { return &msg.SendMsgResp{} },
+	},
+	WSPullMsg: {
+		req:  func() proto.Message { return &msg.GetSeqMessageReq{} },
+		resp: func() proto.Message { return &msg.GetSeqMessageResp{} },
+	},
+	WSGetConvMaxReadSeq: {
+		req:  func() proto.Message { return &msg.GetConversationsHasReadAndMaxSeqReq{} },
+		resp: func() proto.Message { return &msg.GetConversationsHasReadAndMaxSeqResp{} },
+	},
+	WsPullConvLastMessage: {
+		req:  func() proto.Message { return &msg.GetLastMessageReq{} },
+		resp: func() proto.Message { return &msg.GetLastMessageResp{} },
+	},
+	WSPushMsg: {
+		resp: func() proto.Message { return &sdkws.PushMessages{} },
+	},
+	WsLogoutMsg: {
+		req:  func() proto.Message { return &push.DelUserPushTokenReq{} },
+		resp: func() proto.Message { return &push.DelUserPushTokenResp{} },
+	},
+	WsSetBackgroundStatus: {
+		req: func() proto.Message { return &sdkws.SetAppBackgroundStatusReq{} },
+	},
+	WsSubUserOnlineStatus: {
+		req:  func() proto.Message { return &sdkws.SubUserOnlineStatus{} },
+		resp: func() proto.Message { return &sdkws.SubUserOnlineStatusTips{} },
+	},
+}
+
+func reqDataType(reqIdentifier int32) func() proto.Message {
+	return dataTypes[reqIdentifier].req
+}
+
+func respDataType(reqIdentifier int32) func() proto.Message {
+	return dataTypes[reqIdentifier].resp
+}
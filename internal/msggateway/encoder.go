@@ -18,8 +18,12 @@ import (
 	"bytes"
 	"encoding/gob"
 	"encoding/json"
+	"fmt"
 
 	"github.com/openimsdk/tools/errs"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
 )
 
 type Encoder interface {
@@ -72,3 +76,340 @@ func (g JsonEncoder) Decode(encodeData []byte, decodeData any) error {
 	}
 	return nil
 }
+
+// ProtobufEncoder encodes the Req and Resp as protobuf messages prefixed with their length as a varint, the
+// messages are
+//
+//	message Req {
+//	  int32 reqIdentifier = 1;
+//	  string token = 2;
+//	  string sendID = 3;
+//	  string operationID = 4;
+//	  string msgIncr = 5;
+//	  bytes data = 6;
+//	}
+//
+//	message Resp {
+//	  int32 reqIdentifier = 1;
+//	  string msgIncr = 2;
+//	  string operationID = 3;
+//	  int32 errCode = 4;
+//	  string errMsg = 5;
+//	  bytes data = 6;
+//	}
+type ProtobufEncoder struct{}
+
+func NewProtobufEncoder() Encoder {
+	return ProtobufEncoder{}
+}
+
+func (p ProtobufEncoder) Encode(data any) ([]byte, error) {
+	var b []byte
+	switch v := data.(type) {
+	case Resp:
+		b = appendResp(nil, &v)
+	case *Resp:
+		b = appendResp(nil, v)
+	case Req:
+		b = appendReq(nil, &v)
+	case *Req:
+		b = appendReq(nil, v)
+	default:
+		return nil, errs.New("ProtobufEncoder.Encode unsupported type", "type", fmt.Sprintf("%T", data)).Wrap()
+	}
+	return append(protowire.AppendVarint(make([]byte, 0, len(b)+protowire.SizeVarint(uint64(len(b)))), uint64(len(b))), b...), nil
+}
+
+func (p ProtobufEncoder) Decode(encodeData []byte, decodeData any) error {
+	size, n := protowire.ConsumeVarint(encodeData)
+	if n < 0 || size != uint64(len(encodeData)-n) {
+		return errs.New("ProtobufEncoder.Decode invalid length prefix", "length", len(encodeData)).Wrap()
+	}
+	b := encodeData[n:]
+	var err error
+	switch v := decodeData.(type) {
+	case *Req:
+		*v = Req{}
+		err = consumeFields(b, func(num protowire.Number, value []byte, varint uint64) {
+			switch num {
+			case 1:
+				v.ReqIdentifier = int32(varint)
+			case 2:
+				v.Token = string(value)
+			case 3:
+				v.SendID = string(value)
+			case 4:
+				v.OperationID = string(value)
+			case 5:
+				v.MsgIncr = string(value)
+			case 6:
+				v.Data = append([]byte(nil), value...)
+			}
+		})
+	case *Resp:
+		*v = Resp{}
+		err = consumeFields(b, func(num protowire.Number, value []byte, varint uint64) {
+			switch num {
+			case 1:
+				v.ReqIdentifier = int32(varint)
+			case 2:
+				v.MsgIncr = string(value)
+			case 3:
+				v.OperationID = string(value)
+			case 4:
+				v.ErrCode = int(int32(varint))
+			case 5:
+				v.ErrMsg = string(value)
+			case 6:
+				v.Data = append([]byte(nil), value...)
+			}
+		})
+	default:
+		return errs.New("ProtobufEncoder.Decode unsupported type", "type", fmt.Sprintf("%T", decodeData)).Wrap()
+	}
+	if err != nil {
+		return errs.WrapMsg(err, "ProtobufEncoder.Decode failed", "action", "decode")
+	}
+	return nil
+}
+
+func appendReq(b []byte, req *Req) []byte {
+	b = appendVarintField(b, 1, uint64(req.ReqIdentifier))
+	b = appendBytesField(b, 2, []byte(req.Token))
+	b = appendBytesField(b, 3, []byte(req.SendID))
+	b = appendBytesField(b, 4, []byte(req.OperationID))
+	b = appendBytesField(b, 5, []byte(req.MsgIncr))
+	return appendBytesField(b, 6, req.Data)
+}
+
+func appendResp(b []byte, resp *Resp) []byte {
+	b = appendVarintField(b, 1, uint64(resp.ReqIdentifier))
+	b = appendBytesField(b, 2, []byte(resp.MsgIncr))
+	b = appendBytesField(b, 3, []byte(resp.OperationID))
+	b = appendVarintField(b, 4, uint64(int32(resp.ErrCode)))
+	b = appendBytesField(b, 5, []byte(resp.ErrMsg))
+	return appendBytesField(b, 6, resp.Data)
+}
+
+// appendVarintField appends a varint field, the zero values are omitted like proto3 does.
+func appendVarintField(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendBytesField(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+// consumeFields calls fn with the value of every varint and bytes field of b, the other fields are skipped.
+func consumeFields(b []byte, fn func(num protowire.Number, value []byte, varint uint64)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			fn(num, nil, v)
+			b = b[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			fn(num, v, 0)
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+		}
+	}
+	return nil
+}
+
+// ProtoJsonEncoder encodes the Req and Resp as JSON like JsonEncoder, but Data is the protobuf JSON of the message
+// of the req identifier instead of the base64 of its protobuf, see dataTypes.
+type ProtoJsonEncoder struct{}
+
+func NewProtoJsonEncoder() Encoder {
+	return ProtoJsonEncoder{}
+}
+
+type protoJsonReq struct {
+	ReqIdentifier int32           `json:"reqIdentifier"`
+	Token         string          `json:"token"`
+	SendID        string          `json:"sendID"`
+	OperationID   string          `json:"operationID"`
+	MsgIncr       string          `json:"msgIncr"`
+	Data          json.RawMessage `json:"data,omitempty"`
+}
+
+type protoJsonResp struct {
+	ReqIdentifier int32           `json:"reqIdentifier"`
+	MsgIncr       string          `json:"msgIncr"`
+	OperationID   string          `json:"operationID"`
+	ErrCode       int             `json:"errCode"`
+	ErrMsg        string          `json:"errMsg"`
+	Data          json.RawMessage `json:"data,omitempty"`
+}
+
+func (p ProtoJsonEncoder) Encode(data any) ([]byte, error) {
+	var resp *Resp
+	switch v := data.(type) {
+	case Resp:
+		resp = &v
+	case *Resp:
+		resp = v
+	case Req:
+		return p.encodeReq(&v)
+	case *Req:
+		return p.encodeReq(v)
+	default:
+		return nil, errs.New("ProtoJsonEncoder.Encode unsupported type", "type", fmt.Sprintf("%T", data)).Wrap()
+	}
+	raw, err := protoToJson(resp.Data, respDataType(resp.ReqIdentifier))
+	if err != nil {
+		return nil, errs.WrapMsg(err, "ProtoJsonEncoder.Encode failed", "reqIdentifier", resp.ReqIdentifier)
+	}
+	b, err := json.Marshal(&protoJsonResp{
+		ReqIdentifier: resp.ReqIdentifier,
+		MsgIncr:       resp.MsgIncr,
+		OperationID:   resp.OperationID,
+		ErrCode:       resp.ErrCode,
+		ErrMsg:        resp.ErrMsg,
+		Data:          raw,
+	})
+	if err != nil {
+		return nil, errs.WrapMsg(err, "ProtoJsonEncoder.Encode failed", "action", "encode")
+	}
+	return b, nil
+}
+
+func (p ProtoJsonEncoder) encodeReq(req *Req) ([]byte, error) {
+	raw, err := protoToJson(req.Data, reqDataType(req.ReqIdentifier))
+	if err != nil {
+		return nil, errs.WrapMsg(err, "ProtoJsonEncoder.Encode failed", "reqIdentifier", req.ReqIdentifier)
+	}
+	b, err := json.Marshal(&protoJsonReq{
+		ReqIdentifier: req.ReqIdentifier,
+		Token:         req.Token,
+		SendID:        req.SendID,
+		OperationID:   req.OperationID,
+		MsgIncr:       req.MsgIncr,
+		Data:          raw,
+	})
+	if err != nil {
+		return nil, errs.WrapMsg(err, "ProtoJsonEncoder.Encode failed", "action", "encode")
+	}
+	return b, nil
+}
+
+func (p ProtoJsonEncoder) Decode(encodeData []byte, decodeData any) error {
+	switch v := decodeData.(type) {
+	case *Req:
+		var req protoJsonReq
+		if err := json.Unmarshal(encodeData, &req); err != nil {
+			return errs.WrapMsg(err, "ProtoJsonEncoder.Decode failed", "action", "decode")
+		}
+		data, err := jsonToProto(req.Data, reqDataType(req.ReqIdentifier))
+		if err != nil {
+			return errs.WrapMsg(err, "ProtoJsonEncoder.Decode failed", "reqIdentifier", req.ReqIdentifier)
+		}
+		*v = Req{
+			ReqIdentifier: req.ReqIdentifier,
+			Token:         req.Token,
+			SendID:        req.SendID,
+			OperationID:   req.OperationID,
+			MsgIncr:       req.MsgIncr,
+			Data:          data,
+		}
+	case *Resp:
+		var resp protoJsonResp
+		if err := json.Unmarshal(encodeData, &resp); err != nil {
+			return errs.WrapMsg(err, "ProtoJsonEncoder.Decode failed", "action", "decode")
+		}
+		data, err := jsonToProto(resp.Data, respDataType(resp.ReqIdentifier))
+		if err != nil {
+			return errs.WrapMsg(err, "ProtoJsonEncoder.Decode failed", "reqIdentifier", resp.ReqIdentifier)
+		}
+		*v = Resp{
+			ReqIdentifier: resp.ReqIdentifier,
+			MsgIncr:       resp.MsgIncr,
+			OperationID:   resp.OperationID,
+			ErrCode:       resp.ErrCode,
+			ErrMsg:        resp.ErrMsg,
+			Data:          data,
+		}
+	default:
+		return errs.New("ProtoJsonEncoder.Decode unsupported type", "type", fmt.Sprintf("%T", decodeData)).Wrap()
+	}
+	return nil
+}
+
+// protoToJson converts the protobuf data of a message of the type to its JSON, the data of the unknown types is
+// kept as a base64 string like JsonEncoder does.
+func protoToJson(data []byte, newMsg func() proto.Message) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if newMsg == nil {
+		return json.Marshal(data)
+	}
+	msg := newMsg()
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return protojson.Marshal(msg)
+}
+
+func jsonToProto(raw json.RawMessage, newMsg func() proto.Message) ([]byte, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	if newMsg == nil {
+		var data []byte
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	msg := newMsg()
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(raw, msg); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(msg)
+}
+
+// newEncoder returns the encoder of the encoding of the handshake, the encoding defaults to gob for the go sdk
+// and to json for the others.
+func newEncoder(encoding string, sdkType string) Encoder {
+	switch encoding {
+	case GobEncoding:
+		return NewGobEncoder()
+	case JsonEncoding:
+		return NewJsonEncoder()
+	case ProtobufEncoding:
+		return NewProtobufEncoder()
+	case ProtoJsonEncoding:
+		return NewProtoJsonEncoder()
+	}
+	if sdkType == GoSDK {
+		return NewGobEncoder()
+	}
+	return NewJsonEncoder()
+}
@@ -0,0 +1,101 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msggateway
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/openimsdk/protocol/sdkws"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestProtobufEncoder(t *testing.T) {
+	encoder := NewProtobufEncoder()
+	req := Req{ReqIdentifier: WSSendMsg, Token: "token", SendID: "u1", OperationID: "op", MsgIncr: "1", Data: []byte{1, 2, 3}}
+	b, err := encoder.Encode(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decodedReq Req
+	if err := encoder.Decode(b, &decodedReq); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(req, decodedReq) {
+		t.Errorf("got %+v want %+v", decodedReq, req)
+	}
+	resp := Resp{ReqIdentifier: WSSendMsg, MsgIncr: "1", OperationID: "op", ErrCode: 1005, ErrMsg: "too many requests"}
+	if b, err = encoder.Encode(resp); err != nil {
+		t.Fatal(err)
+	}
+	var decodedResp Resp
+	if err := encoder.Decode(b, &decodedResp); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resp, decodedResp) {
+		t.Errorf("got %+v want %+v", decodedResp, resp)
+	}
+	if err := encoder.Decode(b[:len(b)-1], &decodedResp); err == nil {
+		t.Error("a truncated message should fail")
+	}
+}
+
+func TestProtoJsonEncoder(t *testing.T) {
+	encoder := NewProtoJsonEncoder()
+	msgData := &sdkws.MsgData{SendID: "u1", RecvID: "u2", ContentType: 101, Content: []byte(`{"content":"hi"}`)}
+	data, err := proto.Marshal(msgData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := encoder.Encode(&Req{ReqIdentifier: WSSendMsg, SendID: "u1", OperationID: "op", MsgIncr: "1", Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frame map[string]json.RawMessage
+	if err := json.Unmarshal(b, &frame); err != nil {
+		t.Fatal(err)
+	}
+	var embedded map[string]any
+	if err := json.Unmarshal(frame["data"], &embedded); err != nil {
+		t.Fatalf("data is not embedded JSON: %s", frame["data"])
+	}
+	if embedded["recvID"] != "u2" {
+		t.Errorf("got data %s", frame["data"])
+	}
+	var req Req
+	if err := encoder.Decode(b, &req); err != nil {
+		t.Fatal(err)
+	}
+	var decoded sdkws.MsgData
+	if err := proto.Unmarshal(req.Data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(msgData, &decoded) {
+		t.Errorf("got %v want %v", &decoded, msgData)
+	}
+	// The data of the req identifiers without a message type stays a base64 string.
+	b, err = encoder.Encode(Resp{ReqIdentifier: WSDataError, Data: []byte("raw")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp Resp
+	if err := encoder.Decode(b, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Data) != "raw" {
+		t.Errorf("got %q", resp.Data)
+	}
+}
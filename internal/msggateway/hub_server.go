@@ -16,6 +16,7 @@ package msggateway
 
 import (
 	"context"
+	"errors"
 	"sync/atomic"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/rpcli"
@@ -52,7 +53,7 @@ func (s *Server) InitServer(ctx context.Context, config *Config, disCov discover
 }
 
 func (s *Server) Start(ctx context.Context, index int, conf *Config) error {
-	return startrpc.Start(ctx, &conf.Discovery, &conf.MsgGateway.Prometheus, conf.MsgGateway.ListenIP,
+	return startrpc.Start(ctx, &conf.Discovery, conf.ConfigPath, &conf.MsgGateway.Prometheus, &conf.Share.Tracing, conf.MsgGateway.ListenIP,
 		conf.MsgGateway.RPC.RegisterIP,
 		conf.MsgGateway.RPC.AutoSetPorts, conf.MsgGateway.RPC.Ports, index,
 		conf.Discovery.RpcService.MessageGateway,
@@ -156,7 +157,11 @@ func (s *Server) pushToUser(ctx context.Context, userID string, msgData *sdkws.M
 		if !client.IsBackground ||
 			(client.IsBackground && client.PlatformID != constant.IOSPlatformID) {
 			err := client.PushMessage(ctx, msgData)
-			if err != nil {
+			if errors.Is(err, ErrSessionDetached) {
+				// Kept for the resume, the client may not come back so it is not an online push.
+				log.ZDebug(ctx, "online push msg held", "userID", userID, "platformID", client.PlatformID)
+				userPlatform.ResultCode = int64(servererrs.ErrPushMsgErr.Code())
+			} else if err != nil {
 				log.ZWarn(ctx, "online push msg failed", err, "userID", userID, "platformID", client.PlatformID)
 				userPlatform.ResultCode = int64(servererrs.ErrPushMsgErr.Code())
 			} else {
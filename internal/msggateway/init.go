@@ -19,8 +19,10 @@ import (
 	"time"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/redis"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
+	"github.com/openimsdk/open-im-server/v3/pkg/ratelimit"
 	"github.com/openimsdk/open-im-server/v3/pkg/rpccache"
-	"github.com/openimsdk/tools/db/redisutil"
 	"github.com/openimsdk/tools/utils/datautil"
 	"github.com/openimsdk/tools/utils/runtimeenv"
 
@@ -35,6 +37,7 @@ type Config struct {
 	Discovery      config.Discovery
 
 	RuntimeEnv string
+	ConfigPath string
 }
 
 // Start run ws server.
@@ -49,16 +52,19 @@ func Start(ctx context.Context, index int, conf *Config) error {
 		return err
 	}
 
-	rdb, err := redisutil.NewRedisClient(ctx, conf.RedisConfig.Build())
+	rdb, err := tracing.NewRedisClient(ctx, conf.RedisConfig.Build(), &conf.Share.Tracing)
 	if err != nil {
 		return err
 	}
 	longServer := NewWsServer(
 		conf,
+		rdb,
 		WithPort(wsPort),
 		WithMaxConnNum(int64(conf.MsgGateway.LongConnSvr.WebsocketMaxConnNum)),
 		WithHandshakeTimeout(time.Duration(conf.MsgGateway.LongConnSvr.WebsocketTimeout)*time.Second),
 		WithMessageMaxMsgLength(conf.MsgGateway.LongConnSvr.WebsocketMaxMsgLen),
+		WithCompressionMinSize(conf.MsgGateway.LongConnSvr.CompressionMinSize),
+		WithSSE(conf.MsgGateway.LongConnSvr.EnableSSE),
 	)
 
 	hubServer := NewServer(longServer, conf, func(srv *Server) error {
@@ -67,7 +73,14 @@ func Start(ctx context.Context, index int, conf *Config) error {
 		return err
 	})
 
+	longServer.userGateway = redis.NewUserGateway(rdb)
+	longServer.rateLimit, err = ratelimit.New(&conf.MsgGateway.RateLimit, rdb)
+	if err != nil {
+		return err
+	}
+
 	go longServer.ChangeOnlineStatus(4)
+	go longServer.RenewUserGateway()
 
 	netDone := make(chan error)
 	go func() {
@@ -29,6 +29,10 @@ type (
 		messageMaxMsgLength int
 		// Websocket write buffer, default: 4096, 4kb.
 		writeBufferSize int
+		// Frames shorter than this are sent uncompressed by the flagged compression protocols.
+		compressionMinSize int
+		// Serve the SSE transport besides the websocket.
+		enableSSE bool
 	}
 )
 
@@ -61,3 +65,15 @@ func WithWriteBufferSize(size int) Option {
 		opt.writeBufferSize = size
 	}
 }
+
+func WithCompressionMinSize(size int) Option {
+	return func(opt *configs) {
+		opt.compressionMinSize = size
+	}
+}
+
+func WithSSE(enable bool) Option {
+	return func(opt *configs) {
+		opt.enableSSE = enable
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msggateway
+
+import (
+	"crypto/subtle"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/errs"
+)
+
+// ErrSessionDetached is returned when a message is pushed to the client of a dropped session, the message is kept
+// to be replayed if the session is resumed.
+var ErrSessionDetached = errs.New("session detached")
+
+// SessionResp is the data of the WsSessionResume message sent to a client once it is registered.
+type SessionResp struct {
+	// ResumeID is given back by the client when it reconnects to take over the session.
+	ResumeID string `json:"resumeID"`
+	// Resumed tells whether the connection took over a dropped session.
+	Resumed bool `json:"resumed"`
+	// Complete is false when messages pushed to the dropped session could not be replayed, the client should sync
+	// the seqs as after a new login.
+	Complete bool `json:"complete"`
+}
+
+// resumeSession is the session of a client. When the connection drops the client is detached, it stays online
+// and the messages pushed to it are buffered until the session is resumed by a new connection or expires.
+type resumeSession struct {
+	id         string
+	client     *Client
+	bufferSize int
+
+	lock     sync.Mutex
+	closed   bool
+	detached bool
+	buffer   []*sdkws.MsgData
+	overflow bool
+
+	// The fields below are guarded by the lock of the store.
+	taken bool
+	timer *time.Timer
+}
+
+// close makes the session not resumable, it is called when the user logs out.
+func (r *resumeSession) close() {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.closed = true
+}
+
+// hold buffers msgData if the client is detached, it reports whether the message was buffered.
+func (r *resumeSession) hold(msgData *sdkws.MsgData) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.detached {
+		return false
+	}
+	if len(r.buffer) >= r.bufferSize {
+		r.overflow = true
+		if len(r.buffer) == 0 {
+			return true
+		}
+		r.buffer = append(r.buffer[:0], r.buffer[1:]...)
+	}
+	r.buffer = append(r.buffer, msgData)
+	return true
+}
+
+// release stops buffering and returns the buffered messages, complete is false when messages were dropped.
+func (r *resumeSession) release() (msgs []*sdkws.MsgData, complete bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.detached = false
+	msgs, r.buffer = r.buffer, nil
+	return msgs, !r.overflow
+}
+
+// sessionStore keeps the detached sessions of a gateway by resume id.
+type sessionStore struct {
+	grace      time.Duration
+	bufferSize int
+
+	lock     sync.Mutex
+	sessions map[string]*resumeSession
+}
+
+// newSessionStore returns nil when the session resume is disabled.
+func newSessionStore(conf *config.SessionResume) *sessionStore {
+	if !conf.Enable || conf.GracePeriod <= 0 {
+		return nil
+	}
+	return &sessionStore{
+		grace:      time.Duration(conf.GracePeriod) * time.Second,
+		bufferSize: conf.BufferSize,
+		sessions:   make(map[string]*resumeSession),
+	}
+}
+
+// open returns a new session of client, nil when the store is nil.
+func (s *sessionStore) open(client *Client) *resumeSession {
+	if s == nil {
+		return nil
+	}
+	return &resumeSession{id: uuid.NewString(), client: client, bufferSize: s.bufferSize}
+}
+
+// detach detaches the client of the session, expire is called when the session is not resumed within the grace
+// period. It reports false when the session is closed or already detached.
+func (s *sessionStore) detach(session *resumeSession, expire func()) bool {
+	session.lock.Lock()
+	if session.closed || session.detached {
+		session.lock.Unlock()
+		return false
+	}
+	session.detached = true
+	session.lock.Unlock()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sessions[session.id] = session
+	session.timer = time.AfterFunc(s.grace, func() {
+		if s.expire(session) {
+			expire()
+		}
+	})
+	return true
+}
+
+// expire removes the session unless it was taken, the client of a taken session is released by the resume.
+func (s *sessionStore) expire(session *resumeSession) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if session.taken {
+		return false
+	}
+	if s.sessions[session.id] == session {
+		delete(s.sessions, session.id)
+	}
+	return true
+}
+
+// take takes the detached session of resumeID over for the user, platform and token of the connection. It returns
+// nil when there is no such session or it belongs to another connection.
+func (s *sessionStore) take(resumeID string, userID string, platformID int, token string) *resumeSession {
+	if s == nil || resumeID == "" {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	session, ok := s.sessions[resumeID]
+	if !ok {
+		return nil
+	}
+	client := session.client
+	if client.UserID != userID || client.PlatformID != platformID ||
+		subtle.ConstantTimeCompare([]byte(client.token), []byte(token)) != 1 {
+		return nil
+	}
+	delete(s.sessions, resumeID)
+	session.taken = true
+	session.timer.Stop()
+	return session
+}
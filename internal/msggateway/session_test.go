@@ -0,0 +1,70 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msggateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionStore(t *testing.T) {
+	assert.Nil(t, newSessionStore(&config.SessionResume{Enable: false, GracePeriod: 60}))
+
+	store := newSessionStore(&config.SessionResume{Enable: true, GracePeriod: 60, BufferSize: 2})
+	client := &Client{UserID: "u1", PlatformID: 1, token: "token"}
+	session := store.open(client)
+	assert.False(t, session.hold(&sdkws.MsgData{Seq: 1}))
+
+	assert.True(t, store.detach(session, func() { t.Error("taken session expired") }))
+	assert.False(t, store.detach(session, nil))
+	for seq := int64(1); seq <= 3; seq++ {
+		assert.True(t, session.hold(&sdkws.MsgData{Seq: seq}))
+	}
+
+	assert.Nil(t, store.take(session.id, "u2", 1, "token"))
+	assert.Nil(t, store.take(session.id, "u1", 2, "token"))
+	assert.Nil(t, store.take(session.id, "u1", 1, "other"))
+	assert.Equal(t, session, store.take(session.id, "u1", 1, "token"))
+	assert.Nil(t, store.take(session.id, "u1", 1, "token"))
+
+	msgs, complete := session.release()
+	assert.False(t, complete)
+	assert.Len(t, msgs, 2)
+	assert.Equal(t, int64(2), msgs[0].Seq)
+	assert.False(t, session.hold(&sdkws.MsgData{Seq: 4}))
+}
+
+func TestSessionStoreExpire(t *testing.T) {
+	store := newSessionStore(&config.SessionResume{Enable: true, GracePeriod: 1})
+	store.grace = time.Millisecond * 10
+
+	closed := store.open(&Client{})
+	closed.close()
+	assert.False(t, store.detach(closed, nil))
+
+	session := store.open(&Client{UserID: "u1"})
+	expired := make(chan struct{})
+	assert.True(t, store.detach(session, func() { close(expired) }))
+	select {
+	case <-expired:
+	case <-time.After(time.Second):
+		t.Fatal("session not expired")
+	}
+	assert.Nil(t, store.take(session.id, "u1", 0, ""))
+}
@@ -0,0 +1,391 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msggateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+)
+
+// The paths of the SSE transport, for the clients behind the proxies which don't let the websockets through.
+//
+// A client opens the event stream with a GET of SSEPath with the same arguments as the websocket handshake. The
+// first event is an open event carrying the stream id as {"streamID": "..."}, then every frame the server writes
+// is an event: binary events carry the base64 of a binary frame, text events a text frame, and ping, pong and
+// close events the control frames. The client sends its frames with a POST of SSESendPath?streamID=&token=&type=
+// whose body is the frame, type is binary (the default) or text. The frames are the ones of the websocket, with the
+// same encoding and compression, and the client keeps the stream alive with the text ping of the websocket.
+//
+// The streams are local to the gateway serving them, the posts of a stream must be routed to that gateway.
+const (
+	SSEPath     = "/sse"
+	SSESendPath = "/sse/send"
+
+	StreamID  = "streamID"
+	FrameType = "type"
+)
+
+type sseFrame struct {
+	messageType int
+	data        []byte
+}
+
+// SSEConn is a LongConn of which the server writes the frames to an event stream and reads the frames posted by
+// the client.
+type SSEConn struct {
+	id           string
+	token        string
+	protocolType int
+	rc           *http.ResponseController
+	w            http.ResponseWriter
+
+	writeLock sync.Mutex
+	closed    bool
+	done      chan struct{}
+
+	frames chan sseFrame
+
+	lock         sync.Mutex
+	readDeadline time.Time
+	readLimit    int64
+	pingHandler  PingPongHandler
+	pongHandler  PingPongHandler
+}
+
+func newSSEConn(w http.ResponseWriter, token string) *SSEConn {
+	return &SSEConn{
+		id:           uuid.NewString(),
+		token:        token,
+		protocolType: SSE,
+		rc:           http.NewResponseController(w),
+		w:            w,
+		done:         make(chan struct{}),
+		frames:       make(chan sseFrame),
+	}
+}
+
+// Done is closed when the connection is closed.
+func (d *SSEConn) Done() <-chan struct{} {
+	return d.done
+}
+
+func (d *SSEConn) Close() error {
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
+	if !d.closed {
+		d.closed = true
+		close(d.done)
+	}
+	return nil
+}
+
+func (d *SSEConn) WriteMessage(messageType int, message []byte) error {
+	var event string
+	switch messageType {
+	case MessageBinary:
+		event, message = "binary", []byte(base64.StdEncoding.EncodeToString(message))
+	case MessageText:
+		event = "text"
+	case PingMessage:
+		event = "ping"
+	case PongMessage:
+		event = "pong"
+	case CloseMessage:
+		event = "close"
+	default:
+		return errs.New("SSEConn.WriteMessage: unknown message type", "messageType", messageType)
+	}
+	return d.writeEvent(event, message)
+}
+
+// writeEvent writes an event, a data line for every line of data.
+func (d *SSEConn) writeEvent(event string, data []byte) error {
+	var buf bytes.Buffer
+	buf.WriteString("event: ")
+	buf.WriteString(event)
+	buf.WriteByte('\n')
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		buf.WriteString("data: ")
+		buf.Write(bytes.TrimSuffix(line, []byte{'\r'}))
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
+	if d.closed {
+		return ErrConnClosed
+	}
+	if _, err := d.w.Write(buf.Bytes()); err != nil {
+		return errs.WrapMsg(err, "SSEConn.WriteMessage failed")
+	}
+	if err := d.rc.Flush(); err != nil {
+		return errs.WrapMsg(err, "SSEConn.WriteMessage flush failed")
+	}
+	return nil
+}
+
+// ReadMessage returns the next frame posted by the client, the ping and pong frames are passed to their handlers.
+func (d *SSEConn) ReadMessage() (int, []byte, error) {
+	for {
+		frame, err := d.nextFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		d.lock.Lock()
+		pingHandler, pongHandler := d.pingHandler, d.pongHandler
+		d.lock.Unlock()
+		switch {
+		case frame.messageType == PingMessage && pingHandler != nil:
+			if err := pingHandler(string(frame.data)); err != nil {
+				return 0, nil, err
+			}
+		case frame.messageType == PongMessage && pongHandler != nil:
+			if err := pongHandler(string(frame.data)); err != nil {
+				return 0, nil, err
+			}
+		default:
+			return frame.messageType, frame.data, nil
+		}
+	}
+}
+
+// nextFrame waits for the next posted frame until the read deadline, the connection is closed when it passes.
+func (d *SSEConn) nextFrame() (sseFrame, error) {
+	d.lock.Lock()
+	deadline := d.readDeadline
+	d.lock.Unlock()
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case frame := <-d.frames:
+		return frame, nil
+	case <-timeout:
+		_ = d.Close()
+		return sseFrame{}, errs.New("SSEConn.ReadMessage: read timeout")
+	case <-d.done:
+		return sseFrame{}, ErrConnClosed
+	}
+}
+
+// post passes a frame posted by the client to the reader.
+func (d *SSEConn) post(ctx context.Context, frame sseFrame) error {
+	select {
+	case d.frames <- frame:
+		return nil
+	case <-d.done:
+		return ErrConnClosed
+	case <-ctx.Done():
+		return errs.Wrap(ctx.Err())
+	}
+}
+
+func (d *SSEConn) SetReadDeadline(timeout time.Duration) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.readDeadline = time.Now().Add(timeout)
+	return nil
+}
+
+func (d *SSEConn) SetWriteDeadline(timeout time.Duration) error {
+	if timeout <= 0 {
+		return errs.New("timeout must be greater than 0")
+	}
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
+	if d.closed {
+		return ErrConnClosed
+	}
+	if err := d.rc.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return errs.WrapMsg(err, "SSEConn.SetWriteDeadline failed")
+	}
+	return nil
+}
+
+func (d *SSEConn) Dial(urlStr string, _ http.Header) (*http.Response, error) {
+	return nil, errs.New("SSEConn.Dial is not supported", "url", urlStr)
+}
+
+func (d *SSEConn) IsNil() bool {
+	return d.w == nil
+}
+
+func (d *SSEConn) SetConnNil() {
+	d.w = nil
+}
+
+func (d *SSEConn) SetReadLimit(limit int64) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.readLimit = limit
+}
+
+func (d *SSEConn) getReadLimit() int64 {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.readLimit
+}
+
+func (d *SSEConn) SetPongHandler(handler PingPongHandler) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.pongHandler = handler
+}
+
+func (d *SSEConn) SetPingHandler(handler PingPongHandler) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.pingHandler = handler
+}
+
+// GenerateLongConn starts the event stream and writes the open event.
+func (d *SSEConn) GenerateLongConn(w http.ResponseWriter, _ *http.Request) error {
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	// Tells nginx not to buffer the stream.
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	data, err := json.Marshal(map[string]string{StreamID: d.id})
+	if err != nil {
+		return errs.WrapMsg(err, "json marshal failed")
+	}
+	return d.writeEvent("open", data)
+}
+
+// allowCORS lets the web clients of any origin use the SSE transport, as the websocket upgrader does.
+func allowCORS(w http.ResponseWriter, r *http.Request) bool {
+	header := w.Header()
+	header.Set("Access-Control-Allow-Origin", "*")
+	if r.Method != http.MethodOptions {
+		return false
+	}
+	header.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	header.Set("Access-Control-Allow-Headers", "*")
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// sseHandler serves the event stream of a SSE connection until the connection is closed.
+func (ws *WsServer) sseHandler(w http.ResponseWriter, r *http.Request) {
+	if allowCORS(w, r) {
+		return
+	}
+	connContext := newContext(w, r)
+	if r.Method != http.MethodGet {
+		httpError(connContext, errs.ErrArgs.WrapMsg("sse stream must be opened by GET"))
+		return
+	}
+	if ws.onlineUserConnNum.Load() >= ws.wsMaxConnNum {
+		httpError(connContext, servererrs.ErrConnOverMaxNumLimit.WrapMsg("over max conn num limit"))
+		return
+	}
+	if err := connContext.ParseEssentialArgs(); err != nil {
+		httpError(connContext, err)
+		return
+	}
+	resumed, err := ws.authenticate(connContext)
+	if err != nil {
+		httpError(connContext, err)
+		return
+	}
+
+	conn := newSSEConn(w, connContext.GetToken())
+	log.ZDebug(connContext, "new sse conn", "token", connContext.GetToken(), "streamID", conn.id)
+	if err := conn.GenerateLongConn(w, r); err != nil {
+		log.ZWarn(connContext, "sse stream fails", err)
+		ws.releaseResumed(resumed)
+		return
+	}
+	ws.sseConns.Store(conn.id, conn)
+	defer ws.sseConns.Delete(conn.id)
+	ws.registerConn(connContext, conn, resumed)
+
+	// The client is closed by its reader once the stream is closed, the writes are rejected after Close so that
+	// nothing is written once the handler returned.
+	select {
+	case <-conn.Done():
+	case <-r.Context().Done():
+		_ = conn.Close()
+	}
+}
+
+// sseSendHandler passes the frame posted by the client of a SSE connection to the connection.
+func (ws *WsServer) sseSendHandler(w http.ResponseWriter, r *http.Request) {
+	if allowCORS(w, r) {
+		return
+	}
+	connContext := newContext(w, r)
+	if r.Method != http.MethodPost {
+		httpError(connContext, errs.ErrArgs.WrapMsg("sse frames must be sent by POST"))
+		return
+	}
+	query := r.URL.Query()
+	value, ok := ws.sseConns.Load(query.Get(StreamID))
+	if !ok {
+		httpError(connContext, errs.ErrArgs.WrapMsg("sse stream not found", "streamID", query.Get(StreamID)))
+		return
+	}
+	conn := value.(*SSEConn)
+	if subtle.ConstantTimeCompare([]byte(conn.token), []byte(query.Get(Token))) != 1 {
+		httpError(connContext, servererrs.ErrTokenInvalid.WrapMsg("token does not match the stream"))
+		return
+	}
+	frame := sseFrame{messageType: MessageBinary}
+	switch query.Get(FrameType) {
+	case "", "binary":
+	case "text":
+		frame.messageType = MessageText
+	default:
+		httpError(connContext, errs.ErrArgs.WrapMsg("invalid frame type", "type", query.Get(FrameType)))
+		return
+	}
+	var body io.Reader = r.Body
+	limit := conn.getReadLimit()
+	if limit > 0 {
+		body = io.LimitReader(r.Body, limit+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		httpError(connContext, errs.WrapMsg(err, "read sse frame failed"))
+		return
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		httpError(connContext, errs.ErrArgs.WrapMsg("sse frame is too large", "limit", limit))
+		return
+	}
+	frame.data = data
+	if err := conn.post(r.Context(), frame); err != nil {
+		httpError(connContext, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
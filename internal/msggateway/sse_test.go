@@ -0,0 +1,74 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msggateway
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSEConnWrite(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	conn := newSSEConn(recorder, "token")
+	assert.Nil(t, conn.GenerateLongConn(recorder, nil))
+	assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+
+	frame := []byte{0, 1, 2, 0xff}
+	assert.Nil(t, conn.WriteMessage(MessageBinary, frame))
+	assert.Nil(t, conn.WriteMessage(MessageText, []byte("a\nb")))
+	assert.Nil(t, conn.Close())
+	assert.Equal(t, ErrConnClosed, conn.WriteMessage(MessageText, []byte("closed")))
+
+	events := strings.Split(strings.TrimSuffix(recorder.Body.String(), "\n\n"), "\n\n")
+	assert.Len(t, events, 3)
+	assert.Equal(t, `event: open`+"\n"+`data: {"streamID":"`+conn.id+`"}`, events[0])
+	assert.Equal(t, "event: binary\ndata: "+base64.StdEncoding.EncodeToString(frame), events[1])
+	assert.Equal(t, "event: text\ndata: a\ndata: b", events[2])
+}
+
+func TestSSEConnRead(t *testing.T) {
+	conn := newSSEConn(httptest.NewRecorder(), "token")
+	var pings []string
+	conn.SetPingHandler(func(appData string) error {
+		pings = append(pings, appData)
+		return nil
+	})
+	go func() {
+		ctx := context.Background()
+		_ = conn.post(ctx, sseFrame{messageType: PingMessage, data: []byte("ping")})
+		_ = conn.post(ctx, sseFrame{messageType: MessageBinary, data: []byte("req")})
+	}()
+	messageType, data, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, MessageBinary, messageType)
+	assert.Equal(t, []byte("req"), data)
+	assert.Equal(t, []string{"ping"}, pings)
+
+	assert.Nil(t, conn.SetReadDeadline(time.Millisecond*10))
+	_, _, err = conn.ReadMessage()
+	assert.NotNil(t, err)
+	select {
+	case <-conn.Done():
+	default:
+		t.Error("conn not closed after the read deadline")
+	}
+	assert.Equal(t, ErrConnClosed, conn.post(context.Background(), sseFrame{}))
+}
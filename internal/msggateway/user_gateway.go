@@ -0,0 +1,107 @@
+package msggateway
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/cachekey"
+	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/mcontext"
+)
+
+const userGatewayBatchSize = 1000
+
+var userGatewayOperationID atomic.Int64
+
+func newUserGatewayContext() context.Context {
+	operationID := fmt.Sprintf("ug_%d_%d", os.Getpid(), userGatewayOperationID.Add(1))
+	return mcontext.SetOperationID(context.Background(), operationID)
+}
+
+// selfNode is the target other services use to reach this node, it is empty until the rpc server is registered.
+func (ws *WsServer) selfNode() string {
+	disCov := ws.getDiscoveryRegistry()
+	if disCov == nil || ws.userGateway == nil {
+		return ""
+	}
+	return disCov.GetSelfConnTarget()
+}
+
+func (ws *WsServer) setUserGateway(ctx context.Context, userID string) {
+	node := ws.selfNode()
+	if node == "" {
+		return
+	}
+	if err := ws.userGateway.SetUserGateway(ctx, node, userID); err != nil {
+		log.ZWarn(ctx, "set user gateway failed", err, "userID", userID, "node", node)
+	}
+}
+
+// delUserGateway drops the record of a user whose last connection to this node is closed. It runs apart from the
+// connection, a reconnect of the user may set the record again meanwhile, so the record is only dropped while the
+// user has no connection here and is set back when one shows up during the delete.
+func (ws *WsServer) delUserGateway(userID string) {
+	node := ws.selfNode()
+	if node == "" {
+		return
+	}
+	if ws.isUserConnected(userID) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(newUserGatewayContext(), time.Second*5)
+	defer cancel()
+	if err := ws.userGateway.DelUserGateway(ctx, node, userID); err != nil {
+		log.ZWarn(ctx, "del user gateway failed", err, "userID", userID, "node", node)
+	}
+	if ws.isUserConnected(userID) {
+		ws.setUserGateway(ctx, userID)
+	}
+}
+
+func (ws *WsServer) isUserConnected(userID string) bool {
+	_, ok := ws.clients.GetAll(userID)
+	return ok
+}
+
+// RenewUserGateway periodically renews the records of all users connected to this node and then marks the node
+// as renewed. While every node is freshly renewed a user without a record is offline, otherwise the pushes fall
+// back to all nodes.
+func (ws *WsServer) RenewUserGateway() {
+	for {
+		interval := cachekey.UserGatewayExpire / 3
+		if !ws.renewUserGateway() {
+			// the node is not registered yet or the renewal failed, the node is not marked as renewed
+			interval = time.Second * 5
+		}
+		time.Sleep(interval)
+	}
+}
+
+func (ws *WsServer) renewUserGateway() bool {
+	node := ws.selfNode()
+	if node == "" {
+		return false
+	}
+	userIDs := ws.clients.GetAllUserIDs()
+	for i := 0; i < len(userIDs); i += userGatewayBatchSize {
+		batch := userIDs[i:min(i+userGatewayBatchSize, len(userIDs))]
+		ctx, cancel := context.WithTimeout(newUserGatewayContext(), time.Second*10)
+		err := ws.userGateway.SetUserGateway(ctx, node, batch...)
+		cancel()
+		if err != nil {
+			log.ZWarn(ctx, "renew user gateway failed", err, "node", node, "num", len(batch))
+			return false
+		}
+	}
+	ctx, cancel := context.WithTimeout(newUserGatewayContext(), time.Second*5)
+	defer cancel()
+	if err := ws.userGateway.SetNodeRenewed(ctx, node); err != nil {
+		log.ZWarn(ctx, "set user gateway node renewed failed", err, "node", node)
+		return false
+	}
+	log.ZDebug(ctx, "renew user gateway", "node", node, "num", len(userIDs))
+	return true
+}
@@ -10,9 +10,11 @@ type UserMap interface {
 	GetAll(userID string) ([]*Client, bool)
 	Get(userID string, platformID int) ([]*Client, bool, bool)
 	Set(userID string, v *Client)
+	Replace(userID string, old, client *Client) bool
 	DeleteClients(userID string, clients []*Client) (isDeleteUser bool)
 	UserState() <-chan UserState
 	GetAllUserStatus(deadline time.Time, nowtime time.Time) []UserState
+	GetAllUserIDs() []string
 	RecvSubChange(userID string, platformIDs []int32) bool
 }
 
@@ -131,6 +133,24 @@ func (u *userMap) Set(userID string, client *Client) {
 	u.push(client.UserID, result, nil)
 }
 
+// Replace replaces the client old of the user with client without changing the online state of the user, it
+// reports false when old is not found.
+func (u *userMap) Replace(userID string, old, client *Client) bool {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	result, ok := u.data[userID]
+	if !ok {
+		return false
+	}
+	for i, c := range result.Clients {
+		if c == old {
+			result.Clients[i] = client
+			return true
+		}
+	}
+	return false
+}
+
 func (u *userMap) DeleteClients(userID string, clients []*Client) (isDeleteUser bool) {
 	if len(clients) == 0 {
 		return false
@@ -180,6 +200,12 @@ func (u *userMap) GetAllUserStatus(deadline time.Time, nowtime time.Time) (resul
 	return result
 }
 
+func (u *userMap) GetAllUserIDs() []string {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+	return datautil.Keys(u.data)
+}
+
 func (u *userMap) UserState() <-chan UserState {
 	return u.ch
 }
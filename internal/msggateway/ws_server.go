@@ -2,17 +2,22 @@ package msggateway
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/rpcli"
 
-	"github.com/openimsdk/open-im-server/v3/pkg/common/discovery/etcd"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/configsource"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/webhook"
+	"github.com/openimsdk/open-im-server/v3/pkg/ratelimit"
 	"github.com/openimsdk/open-im-server/v3/pkg/rpccache"
 	pbAuth "github.com/openimsdk/protocol/auth"
 	"github.com/openimsdk/tools/mcontext"
@@ -22,10 +27,13 @@ import (
 	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
 	"github.com/openimsdk/protocol/constant"
 	"github.com/openimsdk/protocol/msggateway"
+	"github.com/openimsdk/protocol/sdkws"
 	"github.com/openimsdk/tools/discovery"
 	"github.com/openimsdk/tools/errs"
 	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/utils/datautil"
 	"github.com/openimsdk/tools/utils/stringutil"
+	"github.com/redis/go-redis/v9"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -40,6 +48,9 @@ type LongConnServer interface {
 	UnRegister(c *Client)
 	SetKickHandlerInfo(i *kickHandler)
 	SubUserOnlineStatus(ctx context.Context, client *Client, data *Req) ([]byte, error)
+	CheckRateLimit(ctx context.Context, client *Client, req *Req) error
+	// GetFrameCompressor returns the frame compressor of a compression protocol, nil for an empty protocol.
+	GetFrameCompressor(protocol string) *FrameCompressor
 	Compressor
 	MessageHandler
 }
@@ -53,13 +64,20 @@ type WsServer struct {
 	kickHandlerChan   chan *kickHandler
 	clients           UserMap
 	online            *rpccache.OnlineCache
+	userGateway       cache.UserGatewayCache
 	subscription      *Subscription
+	rateLimit         *ratelimit.Policy // Nil when the rate limit is disabled.
 	clientPool        sync.Pool
 	onlineUserNum     atomic.Int64
 	onlineUserConnNum atomic.Int64
 	handshakeTimeout  time.Duration
 	writeBufferSize   int
+	compressors       map[string]*FrameCompressor
+	sessions          *sessionStore // Nil when the session resume is disabled.
+	enableSSE         bool
+	sseConns          sync.Map // The SSE connections by stream id.
 	validate          *validator.Validate
+	disCovLock        sync.RWMutex // Guards disCov, set after the connections are already served.
 	disCov            discovery.SvcDiscoveryRegistry
 	Compressor
 	//Encoder
@@ -95,10 +113,18 @@ func (ws *WsServer) SetDiscoveryRegistry(ctx context.Context, disCov discovery.S
 	ws.userClient = rpcli.NewUserClient(userConn)
 	ws.authClient = rpcli.NewAuthClient(authConn)
 	ws.MessageHandler = NewGrpcHandler(ws.validate, rpcli.NewMsgClient(msgConn), rpcli.NewPushMsgServiceClient(pushConn))
+	ws.disCovLock.Lock()
 	ws.disCov = disCov
+	ws.disCovLock.Unlock()
 	return nil
 }
 
+func (ws *WsServer) getDiscoveryRegistry() discovery.SvcDiscoveryRegistry {
+	ws.disCovLock.RLock()
+	defer ws.disCovLock.RUnlock()
+	return ws.disCov
+}
+
 //func (ws *WsServer) SetUserOnlineStatus(ctx context.Context, client *Client, status int32) {
 //	err := ws.userClient.SetUserStatus(ctx, client.UserID, status, client.PlatformID)
 //	if err != nil {
@@ -120,6 +146,16 @@ func (ws *WsServer) Validate(_ any) error {
 	return nil
 }
 
+// CheckRateLimit checks the rate limit of the req identifier of the request for the user, platform and ip of the
+// connection.
+func (ws *WsServer) CheckRateLimit(ctx context.Context, client *Client, req *Req) error {
+	if ws.rateLimit == nil {
+		return nil
+	}
+	subject := ratelimit.Subject{UserID: client.UserID, PlatformID: client.PlatformID, IP: ws.rateLimit.ClientIP(client.ctx.Req)}
+	return ws.rateLimit.Check(ctx, strconv.Itoa(int(req.ReqIdentifier)), subject)
+}
+
 func (ws *WsServer) GetUserAllCons(userID string) ([]*Client, bool) {
 	return ws.clients.GetAll(userID)
 }
@@ -128,7 +164,7 @@ func (ws *WsServer) GetUserPlatformCons(userID string, platform int) ([]*Client,
 	return ws.clients.Get(userID, platform)
 }
 
-func NewWsServer(msgGatewayConfig *Config, opts ...Option) *WsServer {
+func NewWsServer(msgGatewayConfig *Config, rdb redis.UniversalClient, opts ...Option) *WsServer {
 	var config configs
 	for _, o := range opts {
 		o(&config)
@@ -142,6 +178,9 @@ func NewWsServer(msgGatewayConfig *Config, opts ...Option) *WsServer {
 		wsMaxConnNum:     config.maxConnNum,
 		writeBufferSize:  config.writeBufferSize,
 		handshakeTimeout: config.handshakeTimeout,
+		compressors:      newFrameCompressors(config.compressionMinSize),
+		sessions:         newSessionStore(&msgGatewayConfig.MsgGateway.Resume),
+		enableSSE:        config.enableSSE,
 		clientPool: sync.Pool{
 			New: func() any {
 				return new(Client)
@@ -154,10 +193,14 @@ func NewWsServer(msgGatewayConfig *Config, opts ...Option) *WsServer {
 		clients:         newUserMap(),
 		subscription:    newSubscription(),
 		Compressor:      NewGzipCompressor(),
-		webhookClient:   webhook.NewWebhookClient(msgGatewayConfig.WebhooksConfig.URL),
+		webhookClient:   webhook.NewWebhookClient(&msgGatewayConfig.WebhooksConfig, rdb),
 	}
 }
 
+func (ws *WsServer) GetFrameCompressor(protocol string) *FrameCompressor {
+	return ws.compressors[protocol]
+}
+
 func (ws *WsServer) Run(done chan error) error {
 	var (
 		client       *Client
@@ -184,6 +227,10 @@ func (ws *WsServer) Run(done chan error) error {
 	netDone := make(chan struct{}, 1)
 	go func() {
 		http.HandleFunc("/", ws.wsHandler)
+		if ws.enableSSE {
+			http.HandleFunc(SSEPath, ws.sseHandler)
+			http.HandleFunc(SSESendPath, ws.sseSendHandler)
+		}
 		err := server.ListenAndServe()
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			netErr = errs.WrapMsg(err, "ws start err", server.Addr)
@@ -199,7 +246,7 @@ func (ws *WsServer) Run(done chan error) error {
 		close(shutdownDone)
 		return nil
 	}
-	etcd.RegisterShutDown(shutDown)
+	configsource.RegisterShutDown(shutDown)
 	defer cancel()
 	var err error
 	select {
@@ -219,7 +266,11 @@ func (ws *WsServer) Run(done chan error) error {
 var concurrentRequest = 3
 
 func (ws *WsServer) sendUserOnlineInfoToOtherNode(ctx context.Context, client *Client) error {
-	conns, err := ws.disCov.GetConns(ctx, ws.msgGatewayConfig.Discovery.RpcService.MessageGateway)
+	disCov := ws.getDiscoveryRegistry()
+	if disCov == nil {
+		return nil
+	}
+	conns, err := disCov.GetConns(ctx, ws.msgGatewayConfig.Discovery.RpcService.MessageGateway)
 	if err != nil {
 		return err
 	}
@@ -230,7 +281,7 @@ func (ws *WsServer) sendUserOnlineInfoToOtherNode(ctx context.Context, client *C
 	for _, v := range conns {
 		v := v
 		log.ZDebug(ctx, " sendUserOnlineInfoToOtherNode conn ", "target", v.Target())
-		if v.Target() == ws.disCov.GetSelfConnTarget() {
+		if v.Target() == disCov.GetSelfConnTarget() {
 			log.ZDebug(ctx, "Filter out this node", "node", v.Target())
 			continue
 		}
@@ -257,6 +308,12 @@ func (ws *WsServer) SetKickHandlerInfo(i *kickHandler) {
 }
 
 func (ws *WsServer) registerClient(client *Client) {
+	if resumed := client.resumed; resumed != nil {
+		client.resumed = nil
+		if ws.resumeClient(client, resumed) {
+			return
+		}
+	}
 	var (
 		userOK     bool
 		clientOK   bool
@@ -295,6 +352,14 @@ func (ws *WsServer) registerClient(client *Client) {
 		}()
 	}
 
+	if !userOK {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ws.setUserGateway(client.ctx, client.UserID)
+		}()
+	}
+
 	//wg.Add(1)
 	//go func() {
 	//	defer wg.Done()
@@ -302,10 +367,67 @@ func (ws *WsServer) registerClient(client *Client) {
 	//}()
 
 	wg.Wait()
+	ws.startSession(client, nil)
 
 	log.ZDebug(client.ctx, "user online", "online user Num", ws.onlineUserNum.Load(), "online user conn Num", ws.onlineUserConnNum.Load())
 }
 
+// resumeClient replaces the detached client of the resumed session with client, the user stays online and the
+// other gateways are not notified. It reports false when the detached client was kicked meanwhile.
+func (ws *WsServer) resumeClient(client *Client, resumed *resumeSession) bool {
+	old := resumed.client
+	if !ws.clients.Replace(client.UserID, old, client) {
+		ws.unregisterClient(old)
+		return false
+	}
+	old.subLock.Lock()
+	subUserIDs := datautil.Keys(old.subUserIDs)
+	old.subLock.Unlock()
+	ws.subscription.DelClient(old)
+	ws.subscription.Sub(client, subUserIDs, nil)
+	ws.clientPool.Put(old)
+	ws.startSession(client, resumed)
+	log.ZDebug(client.ctx, "user session resumed", "userID", client.UserID, "platformID", client.PlatformID)
+	return true
+}
+
+// startSession sends the session of client to it, and replays the messages pushed to the resumed session.
+func (ws *WsServer) startSession(client *Client, resumed *resumeSession) {
+	if client.session == nil {
+		return
+	}
+	resp := SessionResp{ResumeID: client.session.id, Complete: true}
+	var msgs []*sdkws.MsgData
+	if resumed != nil {
+		resp.Resumed = true
+		msgs, resp.Complete = resumed.release()
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		log.ZError(client.ctx, "marshal session resp failed", err)
+		return
+	}
+	go func() {
+		if err := client.writeBinaryMsg(Resp{ReqIdentifier: WsSessionResume, Data: data}); err != nil {
+			log.ZWarn(client.ctx, "send session failed", err)
+			return
+		}
+		for _, msgData := range msgs {
+			if err := client.PushMessage(client.ctx, msgData); err != nil {
+				log.ZWarn(client.ctx, "replay session push failed", err, "replayed", len(msgs))
+				return
+			}
+		}
+	}()
+}
+
+// isRegistered reports whether client is still a connection of its user, the kicked clients are removed before
+// they are closed.
+func (ws *WsServer) isRegistered(client *Client) bool {
+	clients, _, _ := ws.clients.Get(client.UserID, client.PlatformID)
+	return slices.Contains(clients, client)
+}
+
 func getRemoteAdders(client []*Client) string {
 	var ret string
 	for i, c := range client {
@@ -392,11 +514,15 @@ func (ws *WsServer) multiTerminalLoginChecker(clientOK bool, oldClients []*Clien
 }
 
 func (ws *WsServer) unregisterClient(client *Client) {
+	if ws.detachClient(client) {
+		return
+	}
 	defer ws.clientPool.Put(client)
 	isDeleteUser := ws.clients.DeleteClients(client.UserID, []*Client{client})
 	if isDeleteUser {
 		ws.onlineUserNum.Add(-1)
 		prommetrics.OnlineUserGauge.Dec()
+		go ws.delUserGateway(client.UserID)
 	}
 	ws.onlineUserConnNum.Add(-1)
 	ws.subscription.DelClient(client)
@@ -407,6 +533,20 @@ func (ws *WsServer) unregisterClient(client *Client) {
 	)
 }
 
+// detachClient keeps the session of a dropped client for the grace period instead of unregistering the client,
+// the client stays online until the session is resumed or expires. The sessions of the clients that were kicked,
+// logged out or closed the connection are not kept.
+func (ws *WsServer) detachClient(client *Client) bool {
+	if ws.sessions == nil || client.session == nil || client.closedErr == ErrClientClosed || !ws.isRegistered(client) {
+		return false
+	}
+	if !ws.sessions.detach(client.session, func() { ws.UnRegister(client) }) {
+		return false
+	}
+	log.ZDebug(client.ctx, "user session detached", "close reason", client.closedErr, "resumeID", client.session.id)
+	return true
+}
+
 // validateRespWithRequest checks if the response matches the expected userID and platformID.
 func (ws *WsServer) validateRespWithRequest(ctx *UserConnContext, resp *pbAuth.ParseTokenResp) error {
 	userID := ctx.GetUserID()
@@ -440,8 +580,7 @@ func (ws *WsServer) wsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Call the authentication client to parse the Token obtained from the context
-	resp, err := ws.authClient.ParseToken(connContext, connContext.GetToken())
+	resumed, err := ws.authenticate(connContext)
 	if err != nil {
 		// If there's an error parsing the Token, decide whether to send the error message via WebSocket based on the context flag
 		shouldSendError := connContext.ShouldSendResp()
@@ -458,20 +597,13 @@ func (ws *WsServer) wsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate the authentication response matches the request (e.g., user ID and platform ID)
-	err = ws.validateRespWithRequest(connContext, resp)
-	if err != nil {
-		// If validation fails, return an error via HTTP and stop processing
-		httpError(connContext, err)
-		return
-	}
-
 	log.ZDebug(connContext, "new conn", "token", connContext.GetToken())
 	// Create a WebSocket long connection object
 	wsLongConn := newGWebSocket(WebSocket, ws.handshakeTimeout, ws.writeBufferSize)
 	if err := wsLongConn.GenerateLongConn(w, r); err != nil {
 		//If the creation of the long connection fails, the error is handled internally during the handshake process.
 		log.ZWarn(connContext, "long connection fails", err)
+		ws.releaseResumed(resumed)
 		return
 	} else {
 		// Check if a normal response should be sent via WebSocket
@@ -480,14 +612,54 @@ func (ws *WsServer) wsHandler(w http.ResponseWriter, r *http.Request) {
 			// Attempt to send a success message through WebSocket
 			if err := wsLongConn.RespondWithSuccess(); err != nil {
 				// If the success message is successfully sent, end further processing
+				ws.releaseResumed(resumed)
 				return
 			}
 		}
 	}
 
-	// Retrieve a client object from the client pool, reset its state, and associate it with the current WebSocket long connection
+	ws.registerConn(connContext, wsLongConn, resumed)
+}
+
+// authenticate checks the token of a new connection, unless the connection takes over the dropped session it gives.
+func (ws *WsServer) authenticate(connContext *UserConnContext) (*resumeSession, error) {
+	// Take over the dropped session given by the client, the token was checked when the session was created
+	resumed := ws.sessions.take(connContext.GetResumeID(), connContext.GetUserID(),
+		stringutil.StringToInt(connContext.GetPlatformID()), connContext.GetToken())
+	if resumed != nil {
+		if ws.isRegistered(resumed.client) {
+			return resumed, nil
+		}
+		// The detached client was kicked, release it and check the token again
+		ws.releaseResumed(resumed)
+	}
+
+	// Call the authentication client to parse the Token obtained from the context
+	resp, err := ws.authClient.ParseToken(connContext, connContext.GetToken())
+	if err != nil {
+		return nil, err
+	}
+	// Validate the authentication response matches the request (e.g., user ID and platform ID)
+	if err := ws.validateRespWithRequest(connContext, resp); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// releaseResumed unregisters the detached client of a session taken by a connection which failed.
+func (ws *WsServer) releaseResumed(resumed *resumeSession) {
+	if resumed != nil {
+		ws.UnRegister(resumed.client)
+	}
+}
+
+// registerConn registers the client of a new long connection and starts reading its messages.
+func (ws *WsServer) registerConn(connContext *UserConnContext, conn LongConn, resumed *resumeSession) {
+	// Retrieve a client object from the client pool, reset its state, and associate it with the current long connection
 	client := ws.clientPool.Get().(*Client)
-	client.ResetClient(connContext, wsLongConn, ws)
+	client.ResetClient(connContext, conn, ws)
+	client.session = ws.sessions.open(client)
+	client.resumed = resumed
 
 	// Register the client with the server and start message processing
 	ws.registerChan <- client
@@ -25,7 +25,7 @@ import (
 	"strconv"
 	"syscall"
 
-	disetcd "github.com/openimsdk/open-im-server/v3/pkg/common/discovery/etcd"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/configsource"
 	"github.com/openimsdk/tools/discovery"
 	"github.com/openimsdk/tools/discovery/etcd"
 	"github.com/openimsdk/tools/utils/jsonutil"
@@ -34,14 +34,14 @@ import (
 	"github.com/openimsdk/open-im-server/v3/pkg/common/prommetrics"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/redis"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database/mgo"
-	"github.com/openimsdk/tools/db/mongoutil"
-	"github.com/openimsdk/tools/db/redisutil"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
 	"github.com/openimsdk/tools/utils/datautil"
 	"github.com/openimsdk/tools/utils/runtimeenv"
 
 	conf "github.com/openimsdk/open-im-server/v3/pkg/common/config"
 	discRegister "github.com/openimsdk/open-im-server/v3/pkg/common/discovery"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/controller"
+	"github.com/openimsdk/open-im-server/v3/pkg/mq"
 	"github.com/openimsdk/tools/errs"
 	"github.com/openimsdk/tools/log"
 	"github.com/openimsdk/tools/mw"
@@ -71,6 +71,8 @@ type Config struct {
 	Share          conf.Share
 	WebhooksConfig conf.Webhooks
 	Discovery      conf.Discovery
+
+	ConfigPath string
 }
 
 func Start(ctx context.Context, index int, config *Config) error {
@@ -79,11 +81,11 @@ func Start(ctx context.Context, index int, config *Config) error {
 	log.CInfo(ctx, "MSG-TRANSFER server is initializing", "runTimeEnv", runTimeEnv, "prometheusPorts",
 		config.MsgTransfer.Prometheus.Ports, "index", index)
 
-	mgocli, err := mongoutil.NewMongoDB(ctx, config.MongodbConfig.Build())
+	mgocli, err := tracing.NewMongoDB(ctx, config.MongodbConfig.Build(), &config.Share.Tracing)
 	if err != nil {
 		return err
 	}
-	rdb, err := redisutil.NewRedisClient(ctx, config.RedisConfig.Build())
+	rdb, err := tracing.NewRedisClient(ctx, config.RedisConfig.Build(), &config.Share.Tracing)
 	if err != nil {
 		return err
 	}
@@ -93,19 +95,27 @@ func Start(ctx context.Context, index int, config *Config) error {
 	}
 	client.AddOption(mw.GrpcClient(), grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, "round_robin")))
+	shutdownTracing, err := tracing.Init(ctx, prommetrics.MessageTransferKeyName, &config.Share.Tracing)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+	if config.Share.Tracing.Enable {
+		client.AddOption(tracing.GrpcDialOption())
+	}
 
-	if config.Discovery.Enable == conf.ETCD {
-		cm := disetcd.NewConfigManager(client.(*etcd.SvcDiscoveryRegistryImpl).GetClient(), []string{
-			config.MsgTransfer.GetConfigFileName(),
-			config.RedisConfig.GetConfigFileName(),
-			config.MongodbConfig.GetConfigFileName(),
-			config.KafkaConfig.GetConfigFileName(),
-			config.Share.GetConfigFileName(),
-			config.WebhooksConfig.GetConfigFileName(),
-			config.Discovery.GetConfigFileName(),
-			conf.LogConfigFileName,
-		})
-		cm.Watch(ctx)
+	err = configsource.Watch(ctx, &config.Discovery, runTimeEnv, config.ConfigPath, client, []string{
+		config.MsgTransfer.GetConfigFileName(),
+		config.RedisConfig.GetConfigFileName(),
+		config.MongodbConfig.GetConfigFileName(),
+		config.KafkaConfig.GetConfigFileName(),
+		config.Share.GetConfigFileName(),
+		config.WebhooksConfig.GetConfigFileName(),
+		config.Discovery.GetConfigFileName(),
+		conf.LogConfigFileName,
+	})
+	if err != nil {
+		log.ZWarn(ctx, "watch config failed", err)
 	}
 
 	msgDocModel, err := mgo.NewMsgMongo(mgocli.GetDB())
@@ -123,15 +133,34 @@ func Start(ctx context.Context, index int, config *Config) error {
 		return err
 	}
 	seqUserCache := redis.NewSeqUserCacheRedis(rdb, seqUser)
-	msgTransferDatabase, err := controller.NewMsgTransferDatabase(msgDocModel, msgModel, seqUserCache, seqConversationCache, &config.KafkaConfig)
+	mqBuilder, err := mq.NewBuilder(&config.KafkaConfig, rdb)
 	if err != nil {
 		return err
 	}
-	historyCH, err := NewOnlineHistoryRedisConsumerHandler(ctx, client, config, msgTransferDatabase)
+	producerToMongo, err := mqBuilder.GetTopicProducer(ctx, config.KafkaConfig.ToMongoTopic)
 	if err != nil {
 		return err
 	}
-	historyMongoCH, err := NewOnlineHistoryMongoConsumerHandler(&config.KafkaConfig, msgTransferDatabase)
+	producerToPush, err := mqBuilder.GetTopicProducer(ctx, config.KafkaConfig.ToPushTopic)
+	if err != nil {
+		return err
+	}
+	msgTransferDatabase := controller.NewMsgTransferDatabase(msgDocModel, msgModel, seqUserCache, seqConversationCache, producerToMongo, producerToPush)
+	historyCH, err := NewOnlineHistoryRedisConsumerHandler(ctx, client, config, mqBuilder, msgTransferDatabase)
+	if err != nil {
+		return err
+	}
+	msgThread, err := mgo.NewMsgThreadMongo(mgocli.GetDB())
+	if err != nil {
+		return err
+	}
+	msgThreadDatabase := controller.NewMsgThreadDatabase(msgThread, msgDocModel, msgModel)
+	msgReadReceipt, err := mgo.NewMsgReadReceiptMongo(mgocli.GetDB())
+	if err != nil {
+		return err
+	}
+	msgReadReceiptDatabase := controller.NewMsgReadReceiptDatabase(msgReadReceipt, redis.NewMsgReadReceiptCacheRedis(rdb, msgReadReceipt, redis.GetRocksCacheOptions()))
+	historyMongoCH, err := NewOnlineHistoryMongoConsumerHandler(ctx, mqBuilder, &config.KafkaConfig, msgTransferDatabase, msgThreadDatabase, msgReadReceiptDatabase)
 	if err != nil {
 		return err
 	}
@@ -152,8 +181,8 @@ func (m *MsgTransfer) Start(index int, config *Config, client discovery.SvcDisco
 		netErr  error
 	)
 
-	go m.historyCH.historyConsumerGroup.RegisterHandleAndConsumer(m.ctx, m.historyCH)
-	go m.historyMongoCH.historyConsumerGroup.RegisterHandleAndConsumer(m.ctx, m.historyMongoCH)
+	go subscribe(m.ctx, m.historyCH.historyConsumer, m.historyCH.handleMessage)
+	go subscribe(m.ctx, m.historyMongoCH.historyConsumer, m.historyMongoCH.handleMessage)
 	go m.historyCH.HandleUserHasReadSeqMessages(m.ctx)
 	err := m.historyCH.redisMessageBatches.Start()
 	if err != nil {
@@ -227,20 +256,26 @@ func (m *MsgTransfer) Start(index int, config *Config, client discovery.SvcDisco
 	select {
 	case <-sigs:
 		program.SIGTERMExit()
-		// graceful close kafka client.
+		// graceful close mq client.
 		m.cancel()
 		m.historyCH.redisMessageBatches.Close()
 		m.historyCH.Close()
-		m.historyCH.historyConsumerGroup.Close()
-		m.historyMongoCH.historyConsumerGroup.Close()
+		m.historyCH.historyConsumer.Close()
+		m.historyMongoCH.historyConsumer.Close()
 		return nil
 	case <-netDone:
 		m.cancel()
 		m.historyCH.redisMessageBatches.Close()
 		m.historyCH.Close()
-		m.historyCH.historyConsumerGroup.Close()
-		m.historyMongoCH.historyConsumerGroup.Close()
+		m.historyCH.historyConsumer.Close()
+		m.historyMongoCH.historyConsumer.Close()
 		close(netDone)
 		return netErr
 	}
 }
+
+func subscribe(ctx context.Context, consumer mq.Consumer, handle mq.Handler) {
+	if err := consumer.Subscribe(ctx, handle); err != nil {
+		log.ZError(ctx, "mq subscribe failed", err)
+	}
+}
@@ -17,36 +17,43 @@ package msgtransfer
 import (
 	"context"
 
-	"github.com/IBM/sarama"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/prommetrics"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/controller"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
+	"github.com/openimsdk/open-im-server/v3/pkg/mq"
 	pbmsg "github.com/openimsdk/protocol/msg"
 	"github.com/openimsdk/tools/log"
-	"github.com/openimsdk/tools/mq/kafka"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/protobuf/proto"
 )
 
 type OnlineHistoryMongoConsumerHandler struct {
-	historyConsumerGroup *kafka.MConsumerGroup
-	msgTransferDatabase  controller.MsgTransferDatabase
+	historyConsumer        mq.Consumer
+	msgTransferDatabase    controller.MsgTransferDatabase
+	msgThreadDatabase      controller.MsgThreadDatabase
+	msgReadReceiptDatabase controller.MsgReadReceiptDatabase
 }
 
-func NewOnlineHistoryMongoConsumerHandler(kafkaConf *config.Kafka, database controller.MsgTransferDatabase) (*OnlineHistoryMongoConsumerHandler, error) {
-	historyConsumerGroup, err := kafka.NewMConsumerGroup(kafkaConf.Build(), kafkaConf.ToMongoGroupID, []string{kafkaConf.ToMongoTopic}, true)
+// NewOnlineHistoryMongoConsumerHandler creates the consumer storing messages in mongo, the thread replies of the
+// stored messages are recorded and the read receipts of the group messages marked at send time are created.
+func NewOnlineHistoryMongoConsumerHandler(ctx context.Context, builder mq.Builder, kafkaConf *config.Kafka, database controller.MsgTransferDatabase, msgThreadDatabase controller.MsgThreadDatabase, msgReadReceiptDatabase controller.MsgReadReceiptDatabase) (*OnlineHistoryMongoConsumerHandler, error) {
+	historyConsumer, err := builder.GetTopicConsumer(ctx, kafkaConf.ToMongoTopic, kafkaConf.ToMongoGroupID)
 	if err != nil {
 		return nil, err
 	}
 
 	mc := &OnlineHistoryMongoConsumerHandler{
-		historyConsumerGroup: historyConsumerGroup,
-		msgTransferDatabase:  database,
+		historyConsumer:        historyConsumer,
+		msgTransferDatabase:    database,
+		msgThreadDatabase:      msgThreadDatabase,
+		msgReadReceiptDatabase: msgReadReceiptDatabase,
 	}
 	return mc, nil
 }
 
-func (mc *OnlineHistoryMongoConsumerHandler) handleChatWs2Mongo(ctx context.Context, cMsg *sarama.ConsumerMessage, key string, session sarama.ConsumerGroupSession) {
-	msg := cMsg.Value
+func (mc *OnlineHistoryMongoConsumerHandler) handleChatWs2Mongo(ctx context.Context, cMsg *mq.Message, key string) {
+	msg := cMsg.Value()
 	msgFromMQ := pbmsg.MsgDataToMongoByMQ{}
 	err := proto.Unmarshal(msg, &msgFromMQ)
 	if err != nil {
@@ -54,7 +61,7 @@ func (mc *OnlineHistoryMongoConsumerHandler) handleChatWs2Mongo(ctx context.Cont
 		return
 	}
 	if len(msgFromMQ.MsgData) == 0 {
-		log.ZError(ctx, "msgFromMQ.MsgData is empty", nil, "cMsg", cMsg)
+		log.ZError(ctx, "msgFromMQ.MsgData is empty", nil, "key", key)
 		return
 	}
 	log.ZDebug(ctx, "mongo consumer recv msg", "msgs", msgFromMQ.String())
@@ -70,30 +77,24 @@ func (mc *OnlineHistoryMongoConsumerHandler) handleChatWs2Mongo(ctx context.Cont
 			msgFromMQ.ConversationID,
 		)
 		prommetrics.MsgInsertMongoFailedCounter.Inc()
-	} else {
-		prommetrics.MsgInsertMongoSuccessCounter.Inc()
+		return
+	}
+	prommetrics.MsgInsertMongoSuccessCounter.Inc()
+	if err := mc.msgThreadDatabase.AddReplies(ctx, msgFromMQ.ConversationID, msgFromMQ.MsgData); err != nil {
+		log.ZError(ctx, "add thread replies failed", err, "conversationID", msgFromMQ.ConversationID)
 	}
-	var seqs []int64
-	for _, msg := range msgFromMQ.MsgData {
-		seqs = append(seqs, msg.Seq)
+	if err := mc.msgReadReceiptDatabase.CreateReceipts(ctx, msgFromMQ.ConversationID, msgFromMQ.MsgData); err != nil {
+		log.ZError(ctx, "create read receipts failed", err, "conversationID", msgFromMQ.ConversationID)
 	}
 }
 
-func (*OnlineHistoryMongoConsumerHandler) Setup(_ sarama.ConsumerGroupSession) error { return nil }
-
-func (*OnlineHistoryMongoConsumerHandler) Cleanup(_ sarama.ConsumerGroupSession) error { return nil }
-
-func (mc *OnlineHistoryMongoConsumerHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error { // an instance in the consumer group
-	log.ZDebug(context.Background(), "online new session msg come", "highWaterMarkOffset",
-		claim.HighWaterMarkOffset(), "topic", claim.Topic(), "partition", claim.Partition())
-	for msg := range claim.Messages() {
-		ctx := mc.historyConsumerGroup.GetContextFromMsg(msg)
-		if len(msg.Value) != 0 {
-			mc.handleChatWs2Mongo(ctx, msg, string(msg.Key), sess)
-		} else {
-			log.ZError(ctx, "mongo msg get from kafka but is nil", nil, "conversationID", msg.Key)
-		}
-		sess.MarkMessage(msg, "")
+func (mc *OnlineHistoryMongoConsumerHandler) handleMessage(msg *mq.Message) {
+	ctx, span := tracing.StartConsumer(msg.Context(), "msgtransfer.ToMongo", attribute.String("conversationID", msg.Key()))
+	defer span.End()
+	if len(msg.Value()) != 0 {
+		mc.handleChatWs2Mongo(ctx, msg, msg.Key())
+	} else {
+		log.ZError(ctx, "mongo msg get from mq but is nil", nil, "conversationID", msg.Key())
 	}
-	return nil
+	msg.Ack()
 }
@@ -3,46 +3,47 @@ package push
 import (
 	"context"
 
-	"github.com/IBM/sarama"
 	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
 	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/options"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/prommetrics"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
+	"github.com/openimsdk/open-im-server/v3/pkg/mq"
 	"github.com/openimsdk/protocol/constant"
 	pbpush "github.com/openimsdk/protocol/push"
 	"github.com/openimsdk/protocol/sdkws"
 	"github.com/openimsdk/tools/errs"
 	"github.com/openimsdk/tools/log"
-	"github.com/openimsdk/tools/mq/kafka"
 	"github.com/openimsdk/tools/utils/jsonutil"
 	"google.golang.org/protobuf/proto"
 )
 
 type OfflinePushConsumerHandler struct {
-	OfflinePushConsumerGroup *kafka.MConsumerGroup
-	offlinePusher            offlinepush.OfflinePusher
+	OfflinePushConsumer mq.Consumer
+	offlinePusher       offlinepush.OfflinePusher
 }
 
-func NewOfflinePushConsumerHandler(config *Config, offlinePusher offlinepush.OfflinePusher) (*OfflinePushConsumerHandler, error) {
+func NewOfflinePushConsumerHandler(ctx context.Context, config *Config, offlinePusher offlinepush.OfflinePusher, builder mq.Builder) (*OfflinePushConsumerHandler, error) {
 	var offlinePushConsumerHandler OfflinePushConsumerHandler
 	var err error
 	offlinePushConsumerHandler.offlinePusher = offlinePusher
-	offlinePushConsumerHandler.OfflinePushConsumerGroup, err = kafka.NewMConsumerGroup(config.KafkaConfig.Build(), config.KafkaConfig.ToOfflineGroupID,
-		[]string{config.KafkaConfig.ToOfflinePushTopic}, true)
+	offlinePushConsumerHandler.OfflinePushConsumer, err = builder.GetTopicConsumer(ctx, config.KafkaConfig.ToOfflinePushTopic, config.KafkaConfig.ToOfflineGroupID)
 	if err != nil {
 		return nil, err
 	}
 	return &offlinePushConsumerHandler, nil
 }
 
-func (*OfflinePushConsumerHandler) Setup(sarama.ConsumerGroupSession) error   { return nil }
-func (*OfflinePushConsumerHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
-func (o *OfflinePushConsumerHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for msg := range claim.Messages() {
-		ctx := o.OfflinePushConsumerGroup.GetContextFromMsg(msg)
-		o.handleMsg2OfflinePush(ctx, msg.Value)
-		sess.MarkMessage(msg, "")
+// Consume consumes the offline push topic until ctx is done.
+func (o *OfflinePushConsumerHandler) Consume(ctx context.Context) {
+	err := o.OfflinePushConsumer.Subscribe(ctx, func(msg *mq.Message) {
+		ctx, span := tracing.StartConsumer(msg.Context(), "push.ToOfflinePush")
+		o.handleMsg2OfflinePush(ctx, msg.Value())
+		span.End()
+		msg.Ack()
+	})
+	if err != nil {
+		log.ZError(ctx, "offline push consumer subscribe failed", err)
 	}
-	return nil
 }
 
 func (o *OfflinePushConsumerHandler) handleMsg2OfflinePush(ctx context.Context, msg []byte) {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/openimsdk/protocol/msggateway"
 	"github.com/openimsdk/protocol/sdkws"
@@ -15,6 +16,8 @@ import (
 	"google.golang.org/grpc"
 
 	conf "github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/cachekey"
 )
 
 type OnlinePusher interface {
@@ -41,14 +44,14 @@ func (u emptyOnlinePusher) GetOnlinePushFailedUserIDs(ctx context.Context, msg *
 	return nil
 }
 
-func NewOnlinePusher(disCov discovery.SvcDiscoveryRegistry, config *Config) OnlinePusher {
+func NewOnlinePusher(disCov discovery.SvcDiscoveryRegistry, config *Config, userGateway cache.UserGatewayCache) OnlinePusher {
 
 	if config.runTimeEnv == conf.KUBERNETES {
 		return NewDefaultAllNode(disCov, config)
 	}
 	switch config.Discovery.Enable {
-	case conf.ETCD:
-		return NewDefaultAllNode(disCov, config)
+	case conf.ETCD, conf.DIRECT:
+		return NewUserGatewayNode(disCov, config, userGateway)
 	default:
 		log.ZError(context.Background(), "NewOnlinePusher is error", errs.Wrap(errors.New("unsupported discovery type")), "type", config.Discovery.Enable)
 		return nil
@@ -138,6 +141,127 @@ func (d *DefaultAllNode) GetOnlinePushFailedUserIDs(_ context.Context, msg *sdkw
 	return datautil.SliceSub(*pushToUserIDs, onlineSuccessUserIDs)
 }
 
+// UserGatewayNode only pushes to the gateway nodes recorded for the users by the gateways. While every discovered
+// node has freshly renewed the records of its users, a user without a record is offline and is not pushed online.
+// Once a node falls behind, the users without a record are pushed to all nodes.
+type UserGatewayNode struct {
+	disCov      discovery.SvcDiscoveryRegistry
+	config      *Config
+	userGateway cache.UserGatewayCache
+	allNode     *DefaultAllNode
+}
+
+func NewUserGatewayNode(disCov discovery.SvcDiscoveryRegistry, config *Config, userGateway cache.UserGatewayCache) *UserGatewayNode {
+	return &UserGatewayNode{
+		disCov:      disCov,
+		config:      config,
+		userGateway: userGateway,
+		allNode:     NewDefaultAllNode(disCov, config),
+	}
+}
+
+func (u *UserGatewayNode) GetConnsAndOnlinePush(ctx context.Context, msg *sdkws.MsgData,
+	pushToUserIDs []string) (wsResults []*msggateway.SingleMsgToUserResults, err error) {
+	userNodes, err := u.userGateway.GetUserGateway(ctx, pushToUserIDs)
+	if err != nil {
+		log.ZWarn(ctx, "get user gateway failed, push to all nodes", err)
+		return u.allNode.GetConnsAndOnlinePush(ctx, msg, pushToUserIDs)
+	}
+	conns, err := u.disCov.GetConns(ctx, u.config.Discovery.RpcService.MessageGateway)
+	if err != nil {
+		return nil, err
+	}
+	nodeConns := make(map[string]*grpc.ClientConn, len(conns))
+	for _, conn := range conns {
+		nodeConns[conn.Target()] = conn
+	}
+	fresh := u.nodesFresh(ctx, nodeConns)
+	var (
+		nodeUserIDs     = make(map[*grpc.ClientConn][]string)
+		fallbackUserIDs []string
+	)
+	for _, userID := range pushToUserIDs {
+		var userConns []*grpc.ClientConn
+		for _, node := range userNodes[userID] {
+			if conn, ok := nodeConns[node]; ok {
+				userConns = append(userConns, conn)
+			}
+		}
+		if len(userConns) == 0 {
+			if !fresh {
+				fallbackUserIDs = append(fallbackUserIDs, userID)
+			}
+			continue
+		}
+		for _, conn := range userConns {
+			nodeUserIDs[conn] = append(nodeUserIDs[conn], userID)
+		}
+	}
+	log.ZDebug(ctx, "user gateway push", "nodeNum", len(nodeUserIDs), "connNum", len(conns), "fresh", fresh, "fallbackUserIDs", fallbackUserIDs)
+
+	var (
+		mu         sync.Mutex
+		wg         = errgroup.Group{}
+		maxWorkers = u.config.RpcConfig.MaxConcurrentWorkers
+	)
+	if maxWorkers < 3 {
+		maxWorkers = 3
+	}
+	wg.SetLimit(maxWorkers)
+	if len(fallbackUserIDs) > 0 {
+		wg.Go(func() error {
+			results, _ := u.allNode.GetConnsAndOnlinePush(ctx, msg, fallbackUserIDs)
+			mu.Lock()
+			wsResults = append(wsResults, results...)
+			mu.Unlock()
+			return nil
+		})
+	}
+	for conn, userIDs := range nodeUserIDs {
+		conn, userIDs := conn, userIDs
+		wg.Go(func() error {
+			input := &msggateway.OnlineBatchPushOneMsgReq{MsgData: msg, PushToUserIDs: userIDs}
+			reply, err := msggateway.NewMsgGatewayClient(conn).SuperGroupOnlineBatchPushOneMsg(ctx, input)
+			if err != nil {
+				log.ZError(ctx, "SuperGroupOnlineBatchPushOneMsg ", err, "node", conn.Target(), "req:", input.String())
+				return nil
+			}
+			log.ZDebug(ctx, "push result", "reply", reply)
+			if reply != nil && reply.SinglePushResult != nil {
+				mu.Lock()
+				wsResults = append(wsResults, reply.SinglePushResult...)
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	_ = wg.Wait()
+	return wsResults, nil
+}
+
+// nodesFresh reports whether every discovered node has renewed the records of its users recently enough for the
+// records to be complete.
+func (u *UserGatewayNode) nodesFresh(ctx context.Context, nodeConns map[string]*grpc.ClientConn) bool {
+	renewed, err := u.userGateway.GetNodeRenewed(ctx)
+	if err != nil {
+		log.ZWarn(ctx, "get user gateway node renewed failed", err)
+		return false
+	}
+	deadline := time.Now().Add(-cachekey.UserGatewayNodeFresh)
+	for node := range nodeConns {
+		if t, ok := renewed[node]; !ok || t.Before(deadline) {
+			log.ZDebug(ctx, "user gateway node is not fresh", "node", node, "renewed", t)
+			return false
+		}
+	}
+	return true
+}
+
+func (u *UserGatewayNode) GetOnlinePushFailedUserIDs(ctx context.Context, msg *sdkws.MsgData,
+	wsResults []*msggateway.SingleMsgToUserResults, pushToUserIDs *[]string) []string {
+	return u.allNode.GetOnlinePushFailedUserIDs(ctx, msg, wsResults, pushToUserIDs)
+}
+
 type K8sStaticConsistentHash struct {
 	disCov discovery.SvcDiscoveryRegistry
 	config *Config
@@ -0,0 +1,38 @@
+package push
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/cachekey"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+type fakeUserGateway struct {
+	cache.UserGatewayCache
+	renewed map[string]time.Time
+}
+
+func (f *fakeUserGateway) GetNodeRenewed(context.Context) (map[string]time.Time, error) {
+	return f.renewed, nil
+}
+
+func TestNodesFresh(t *testing.T) {
+	now := time.Now()
+	userGateway := &fakeUserGateway{renewed: map[string]time.Time{
+		"10.0.0.1:10140": now,
+		"10.0.0.2:10140": now.Add(-cachekey.UserGatewayNodeFresh / 2),
+		"10.0.0.3:10140": now.Add(-cachekey.UserGatewayNodeFresh * 2),
+	}}
+	u := &UserGatewayNode{userGateway: userGateway}
+	ctx := context.Background()
+
+	assert.True(t, u.nodesFresh(ctx, map[string]*grpc.ClientConn{"10.0.0.1:10140": nil, "10.0.0.2:10140": nil}))
+	// a node behind on its renewals
+	assert.False(t, u.nodesFresh(ctx, map[string]*grpc.ClientConn{"10.0.0.1:10140": nil, "10.0.0.3:10140": nil}))
+	// a node that never renewed
+	assert.False(t, u.nodesFresh(ctx, map[string]*grpc.ClientConn{"10.0.0.1:10140": nil, "10.0.0.4:10140": nil}))
+}
@@ -7,8 +7,9 @@ import (
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/redis"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/controller"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
+	"github.com/openimsdk/open-im-server/v3/pkg/mq"
 	pbpush "github.com/openimsdk/protocol/push"
-	"github.com/openimsdk/tools/db/redisutil"
 	"github.com/openimsdk/tools/discovery"
 	"github.com/openimsdk/tools/utils/runtimeenv"
 	"google.golang.org/grpc"
@@ -48,7 +49,7 @@ func (p pushServer) DelUserPushToken(ctx context.Context,
 func Start(ctx context.Context, config *Config, client discovery.SvcDiscoveryRegistry, server *grpc.Server) error {
 	config.runTimeEnv = runtimeenv.PrintRuntimeEnvironment()
 
-	rdb, err := redisutil.NewRedisClient(ctx, config.RedisConfig.Build())
+	rdb, err := tracing.NewRedisClient(ctx, config.RedisConfig.Build(), &config.Share.Tracing)
 	if err != nil {
 		return err
 	}
@@ -58,14 +59,22 @@ func Start(ctx context.Context, config *Config, client discovery.SvcDiscoveryReg
 		return err
 	}
 
-	database := controller.NewPushDatabase(cacheModel, &config.KafkaConfig)
+	mqBuilder, err := mq.NewBuilder(&config.KafkaConfig, rdb)
+	if err != nil {
+		return err
+	}
+	producerToOfflinePush, err := mqBuilder.GetTopicProducer(ctx, config.KafkaConfig.ToOfflinePushTopic)
+	if err != nil {
+		return err
+	}
+	database := controller.NewPushDatabase(cacheModel, producerToOfflinePush)
 
-	consumer, err := NewConsumerHandler(ctx, config, database, offlinePusher, rdb, client)
+	consumer, err := NewConsumerHandler(ctx, config, database, offlinePusher, rdb, mqBuilder, client)
 	if err != nil {
 		return err
 	}
 
-	offlinePushConsumer, err := NewOfflinePushConsumerHandler(config, offlinePusher)
+	offlinePushConsumer, err := NewOfflinePushConsumerHandler(ctx, config, offlinePusher, mqBuilder)
 	if err != nil {
 		return err
 	}
@@ -78,9 +87,9 @@ func Start(ctx context.Context, config *Config, client discovery.SvcDiscoveryReg
 		offlinePushCh: offlinePushConsumer,
 	})
 
-	go consumer.pushConsumerGroup.RegisterHandleAndConsumer(ctx, consumer)
+	go consumer.Consume(ctx)
 
-	go offlinePushConsumer.OfflinePushConsumerGroup.RegisterHandleAndConsumer(ctx, offlinePushConsumer)
+	go offlinePushConsumer.Consume(ctx)
 
 	return nil
 }
@@ -33,17 +33,17 @@ import (
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/controller"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database/mgo"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/webhook"
 	"github.com/openimsdk/open-im-server/v3/pkg/localcache"
 	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
 	"github.com/openimsdk/open-im-server/v3/pkg/notification/grouphash"
+	"github.com/openimsdk/open-im-server/v3/pkg/sensitive"
 	"github.com/openimsdk/protocol/constant"
 	pbconv "github.com/openimsdk/protocol/conversation"
 	pbgroup "github.com/openimsdk/protocol/group"
 	"github.com/openimsdk/protocol/sdkws"
 	"github.com/openimsdk/protocol/wrapperspb"
-	"github.com/openimsdk/tools/db/mongoutil"
-	"github.com/openimsdk/tools/db/redisutil"
 	"github.com/openimsdk/tools/discovery"
 	"github.com/openimsdk/tools/errs"
 	"github.com/openimsdk/tools/log"
@@ -63,6 +63,7 @@ type groupServer struct {
 	userClient         *rpcli.UserClient
 	msgClient          *rpcli.MsgClient
 	conversationClient *rpcli.ConversationClient
+	sensitiveFilter    *sensitive.Filter // Nil when the sensitive word filter is disabled.
 }
 
 type Config struct {
@@ -77,11 +78,11 @@ type Config struct {
 }
 
 func Start(ctx context.Context, config *Config, client discovery.SvcDiscoveryRegistry, server *grpc.Server) error {
-	mgocli, err := mongoutil.NewMongoDB(ctx, config.MongodbConfig.Build())
+	mgocli, err := tracing.NewMongoDB(ctx, config.MongodbConfig.Build(), &config.Share.Tracing)
 	if err != nil {
 		return err
 	}
-	rdb, err := redisutil.NewRedisClient(ctx, config.RedisConfig.Build())
+	rdb, err := tracing.NewRedisClient(ctx, config.RedisConfig.Build(), &config.Share.Tracing)
 	if err != nil {
 		return err
 	}
@@ -97,6 +98,16 @@ func Start(ctx context.Context, config *Config, client discovery.SvcDiscoveryReg
 	if err != nil {
 		return err
 	}
+	sensitiveWordDB, err := mgo.NewSensitiveWordMongo(mgocli.GetDB())
+	if err != nil {
+		return err
+	}
+	sensitiveFilter, err := sensitive.Start(ctx, &config.Share.SensitiveWord, rdb, func(ctx context.Context) ([]*model.SensitiveWordList, error) {
+		return sensitiveWordDB.Find(ctx, nil)
+	})
+	if err != nil {
+		return err
+	}
 
 	//userRpcClient := rpcclient.NewUserRpcClient(client, config.Share.RpcRegisterName.User, config.Share.IMAdminUserID)
 	//msgRpcClient := rpcclient.NewMessageRpcClient(client, config.Share.RpcRegisterName.Msg)
@@ -116,10 +127,11 @@ func Start(ctx context.Context, config *Config, client discovery.SvcDiscoveryReg
 	}
 	gs := groupServer{
 		config:             config,
-		webhookClient:      webhook.NewWebhookClient(config.WebhooksConfig.URL),
+		webhookClient:      webhook.NewWebhookClient(&config.WebhooksConfig, rdb),
 		userClient:         rpcli.NewUserClient(userConn),
 		msgClient:          rpcli.NewMsgClient(msgConn),
 		conversationClient: rpcli.NewConversationClient(conversationConn),
+		sensitiveFilter:    sensitiveFilter,
 	}
 	gs.db = controller.NewGroupDatabase(rdb, &config.LocalCacheConfig, groupDB, groupMemberDB, groupRequestDB, mgocli.GetTx(), grouphash.NewGroupHashFromGroupServer(&gs))
 	gs.notification = NewNotificationSender(gs.db, config, gs.userClient, gs.msgClient, gs.conversationClient)
@@ -971,6 +983,13 @@ func (g *groupServer) SetGroupInfo(ctx context.Context, req *pbgroup.SetGroupInf
 	if err := g.webhookBeforeSetGroupInfo(ctx, &g.config.WebhooksConfig.BeforeSetGroupInfo, req); err != nil && err != servererrs.ErrCallbackContinue {
 		return nil, err
 	}
+	var err error
+	if req.GroupInfoForSet.GroupName, err = g.checkSensitiveWords(ctx, req.GroupInfoForSet.GroupID, callbackstruct.SensitiveWordSceneGroupName, req.GroupInfoForSet.GroupName); err != nil {
+		return nil, err
+	}
+	if req.GroupInfoForSet.Notification, err = g.checkSensitiveWords(ctx, req.GroupInfoForSet.GroupID, callbackstruct.SensitiveWordSceneGroupNotification, req.GroupInfoForSet.Notification); err != nil {
+		return nil, err
+	}
 
 	group, err := g.db.TakeGroup(ctx, req.GroupInfoForSet.GroupID)
 	if err != nil {
@@ -1067,6 +1086,17 @@ func (g *groupServer) SetGroupInfoEx(ctx context.Context, req *pbgroup.SetGroupI
 	if err := g.webhookBeforeSetGroupInfoEx(ctx, &g.config.WebhooksConfig.BeforeSetGroupInfoEx, req); err != nil && err != servererrs.ErrCallbackContinue {
 		return nil, err
 	}
+	var err error
+	if req.GroupName != nil {
+		if req.GroupName.Value, err = g.checkSensitiveWords(ctx, req.GroupID, callbackstruct.SensitiveWordSceneGroupName, req.GroupName.Value); err != nil {
+			return nil, err
+		}
+	}
+	if req.Notification != nil {
+		if req.Notification.Value, err = g.checkSensitiveWords(ctx, req.GroupID, callbackstruct.SensitiveWordSceneGroupNotification, req.Notification.Value); err != nil {
+			return nil, err
+		}
+	}
 
 	group, err := g.db.TakeGroup(ctx, req.GroupID)
 	if err != nil {
@@ -0,0 +1,50 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package group
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/callbackstruct"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/tools/mcontext"
+)
+
+// checkSensitiveWords checks a text of the group info with the sensitive word filter and returns the text
+// with the words of the replace lists replaced.
+func (g *groupServer) checkSensitiveWords(ctx context.Context, groupID string, scene string, text string) (string, error) {
+	if g.sensitiveFilter == nil || text == "" {
+		return text, nil
+	}
+	res := g.sensitiveFilter.Check(text)
+	if res.Reject {
+		return "", servererrs.ErrSensitiveWord.WrapMsg("group info contains sensitive words", "scene", scene, "words", res.Words)
+	}
+	if res.Flag {
+		cbReq := &callbackstruct.CallbackAfterSensitiveWordReq{
+			CallbackCommand: callbackstruct.CallbackAfterSensitiveWordCommand,
+			OperationID:     mcontext.GetOperationID(ctx),
+			Scene:           scene,
+			UserID:          mcontext.GetOpUserID(ctx),
+			GroupID:         groupID,
+			SessionType:     constant.ReadGroupChatType,
+			Text:            text,
+			Words:           res.Words,
+		}
+		g.webhookClient.AsyncPost(ctx, cbReq.GetCallbackCommand(), cbReq, &callbackstruct.CallbackAfterSensitiveWordResp{}, &g.config.WebhooksConfig.AfterSensitiveWord)
+	}
+	return res.Text, nil
+}
@@ -171,6 +171,11 @@ func (m *msgServer) MarkConversationAsRead(ctx context.Context, req *msg.MarkCon
 	} else if conversation.ConversationType == constant.ReadGroupChatType ||
 		conversation.ConversationType == constant.NotificationChatType {
 		if req.HasReadSeq > hasReadSeq {
+			if conversation.ConversationType == constant.ReadGroupChatType {
+				if err := m.markGroupReadReceipts(ctx, req.ConversationID, conversation.GroupID, req.UserID, hasReadSeq, req.HasReadSeq); err != nil {
+					log.ZWarn(ctx, "mark group read receipts failed", err, "conversationID", req.ConversationID, "userID", req.UserID)
+				}
+			}
 			err = m.MsgDatabase.SetHasReadSeq(ctx, req.UserID, req.ConversationID, req.HasReadSeq)
 			if err != nil {
 				return nil, err
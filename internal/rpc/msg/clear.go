@@ -46,6 +46,7 @@ func (m *msgServer) DestructMsgs(ctx context.Context, req *msg.DestructMsgsReq)
 		if err := m.MsgDatabase.SetMinSeq(ctx, conversationID, minSeq); err != nil {
 			return nil, err
 		}
+		m.clearSearchIndex(ctx, "", map[string]int64{conversationID: minSeq})
 		log.ZDebug(ctx, "DestructMsgs delete doc set min seq", "index", i, "docID", doc.DocID, "conversationID", conversationID, "setMinSeq", minSeq)
 	}
 	return &msg.DestructMsgsResp{Count: int32(len(docs))}, nil
@@ -74,6 +74,7 @@ func (m *msgServer) DeleteMsgs(ctx context.Context, req *msg.DeleteMsgsReq) (*ms
 		if err := m.MsgDatabase.DeleteMsgsPhysicalBySeqs(ctx, req.ConversationID, req.Seqs); err != nil {
 			return nil, err
 		}
+		m.deleteSearchIndex(ctx, req.ConversationID, req.Seqs)
 		conv, err := m.conversationClient.GetConversationsByConversationID(ctx, req.ConversationID)
 		if err != nil {
 			return nil, err
@@ -85,6 +86,7 @@ func (m *msgServer) DeleteMsgs(ctx context.Context, req *msg.DeleteMsgsReq) (*ms
 		if err := m.MsgDatabase.DeleteUserMsgsBySeqs(ctx, req.UserID, req.ConversationID, req.Seqs); err != nil {
 			return nil, err
 		}
+		m.hideSearchIndex(ctx, req.UserID, req.ConversationID, req.Seqs)
 		if isSyncSelf {
 			tips := &sdkws.DeleteMsgsTips{UserID: req.UserID, ConversationID: req.ConversationID, Seqs: req.Seqs}
 			m.notificationSender.NotificationWithSessionType(ctx, req.UserID, req.UserID, constant.DeleteMsgsNotification, constant.SingleChatType, tips)
@@ -98,6 +100,7 @@ func (m *msgServer) DeleteMsgPhysicalBySeq(ctx context.Context, req *msg.DeleteM
 	if err != nil {
 		return nil, err
 	}
+	m.deleteSearchIndex(ctx, req.ConversationID, req.Seqs)
 	return &msg.DeleteMsgPhysicalBySeqResp{}, nil
 }
 
@@ -134,6 +137,7 @@ func (m *msgServer) clearConversation(ctx context.Context, conversationIDs []str
 		if err := m.MsgDatabase.SetUserConversationsMinSeqs(ctx, userID, setSeqs); err != nil {
 			return err
 		}
+		m.clearSearchIndex(ctx, userID, setSeqs)
 		ownerUserIDs := []string{userID}
 		for conversationID, seq := range setSeqs {
 			if err := m.conversationClient.SetConversationMinSeq(ctx, conversationID, ownerUserIDs, seq); err != nil {
@@ -146,9 +150,11 @@ func (m *msgServer) clearConversation(ctx context.Context, conversationIDs []str
 			m.notificationSender.NotificationWithSessionType(ctx, userID, userID, constant.ClearConversationNotification, constant.SingleChatType, tips)
 		}
 	} else {
-		if err := m.MsgDatabase.SetMinSeqs(ctx, m.getMinSeqs(maxSeqs)); err != nil {
+		minSeqs := m.getMinSeqs(maxSeqs)
+		if err := m.MsgDatabase.SetMinSeqs(ctx, minSeqs); err != nil {
 			return err
 		}
+		m.clearSearchIndex(ctx, "", minSeqs)
 		for _, conversation := range existConversations {
 			tips := &sdkws.ClearConversationTips{UserID: userID, ConversationIDs: []string{conversation.ConversationID}}
 			m.notificationSender.NotificationWithSessionType(ctx, userID, m.conversationAndGetRecvID(conversation, userID), constant.ClearConversationNotification, conversation.ConversationType, tips)
@@ -0,0 +1,133 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+	"github.com/openimsdk/open-im-server/v3/pkg/protocol/msgext"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/msg"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/utils/datautil"
+	"google.golang.org/protobuf/proto"
+)
+
+// EditMsg replaces the content of a message by its sender, the sender and the new content are verified and go
+// through the interceptors and the modify webhook like a sent message, the previous content is kept in the edit
+// history.
+func (m *msgServer) EditMsg(ctx context.Context, req *msgext.EditMsgReq) (*msgext.EditMsgResp, error) {
+	if err := authverify.CheckAccessV3(ctx, req.UserID, m.config.Share.IMAdminUserID); err != nil {
+		return nil, err
+	}
+	_, _, msgs, err := m.MsgDatabase.GetMsgBySeqs(ctx, req.UserID, req.ConversationID, []int64{req.Seq})
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 || msgs[0] == nil || msgs[0].Status == constant.MsgDeleted {
+		return nil, errs.ErrRecordNotFound.WrapMsg("msg not found")
+	}
+	now := time.Now()
+	if err := checkEditPolicy(&m.config.RpcConfig.Edit, msgs[0], req.UserID, now); err != nil {
+		return nil, err
+	}
+	if err := m.verifyEdit(ctx, msgs[0]); err != nil {
+		return nil, err
+	}
+	sendReq := &msg.SendMsgReq{MsgData: proto.Clone(msgs[0]).(*sdkws.MsgData)}
+	sendReq.MsgData.Content = []byte(req.Content)
+	if err := m.interceptMsg(ctx, sendReq); err != nil {
+		return nil, err
+	}
+	if err := m.webhookBeforeMsgModify(ctx, &m.config.WebhooksConfig.BeforeMsgModify, sendReq); err != nil {
+		return nil, err
+	}
+	// only the content of the message is edited, the other fields changed by the webhook are ignored
+	content := string(sendReq.MsgData.Content)
+	if err := m.MsgDatabase.EditMsg(ctx, req.ConversationID, req.Seq, content, now.UnixMilli()); err != nil {
+		return nil, err
+	}
+	edited := proto.Clone(msgs[0]).(*sdkws.MsgData)
+	edited.Content = []byte(content)
+	m.indexSearchMsgs(ctx, req.ConversationID, []*sdkws.MsgData{edited})
+	tips := &msgext.MsgEditTips{
+		EditorUserID:   req.UserID,
+		ConversationID: req.ConversationID,
+		Seq:            req.Seq,
+		ClientMsgID:    msgs[0].ClientMsgID,
+		SessionType:    msgs[0].SessionType,
+		ContentType:    msgs[0].ContentType,
+		Content:        content,
+		EditTime:       now.UnixMilli(),
+	}
+	recvID := msgs[0].RecvID
+	if msgs[0].SessionType == constant.ReadGroupChatType {
+		recvID = msgs[0].GroupID
+	}
+	m.notificationSender.NotificationDetailWithSessionType(ctx, req.UserID, recvID, msgprocessor.MsgEditNotification, msgs[0].SessionType, tips)
+	return &msgext.EditMsgResp{EditTime: now.UnixMilli()}, nil
+}
+
+// verifyEdit checks that the sender of the message can still send messages to its conversation.
+func (m *msgServer) verifyEdit(ctx context.Context, msgData *sdkws.MsgData) error {
+	if datautil.Contain(msgData.SendID, m.config.Share.IMAdminUserID...) {
+		return nil
+	}
+	switch msgData.SessionType {
+	case constant.SingleChatType:
+		return m.verifyPeer(ctx, msgData.SendID, msgData.RecvID)
+	case constant.ReadGroupChatType:
+		groupInfo, err := m.GroupLocalCache.GetGroupInfo(ctx, msgData.GroupID)
+		if err != nil {
+			return err
+		}
+		if groupInfo.Status == constant.GroupStatusDismissed {
+			return servererrs.ErrDismissedAlready.Wrap()
+		}
+		return m.verifyGroupMember(ctx, groupInfo, msgData.SendID)
+	default:
+		return nil
+	}
+}
+
+// checkEditPolicy checks that userID is the sender of the message and the edit window has not passed.
+func checkEditPolicy(policy *config.MsgEdit, msgData *sdkws.MsgData, userID string, now time.Time) error {
+	if policy.Disable {
+		return errs.ErrNoPermission.WrapMsg("editing messages is disabled")
+	}
+	if msgData.ContentType == constant.MsgRevokeNotification {
+		return servererrs.ErrMsgAlreadyRevoke.WrapMsg("msg already revoke")
+	}
+	if isServerNotification(msgData) {
+		return errs.ErrArgs.WrapMsg("notifications can not be edited", "contentType", msgData.ContentType)
+	}
+	if msgData.SendID != userID {
+		return errs.ErrNoPermission.WrapMsg("only the sender can edit the msg")
+	}
+	if policy.Window <= 0 {
+		return nil
+	}
+	deadline := time.UnixMilli(msgData.SendTime).Add(time.Duration(policy.Window) * time.Second)
+	if now.After(deadline) {
+		return servererrs.ErrMsgEditExpired.WrapMsg("edit window has passed", "sendTime", msgData.SendTime, "window", policy.Window)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/errs"
+)
+
+func TestCheckEditPolicy(t *testing.T) {
+	now := time.Now()
+	sent := now.Add(-time.Minute).UnixMilli()
+	tests := []struct {
+		name    string
+		policy  config.MsgEdit
+		msgData *sdkws.MsgData
+		userID  string
+		code    int
+	}{
+		{name: "sender", msgData: &sdkws.MsgData{SendID: "u1", ContentType: constant.Text, SendTime: sent}, userID: "u1"},
+		{name: "in window", policy: config.MsgEdit{Window: 120}, msgData: &sdkws.MsgData{SendID: "u1", ContentType: constant.Text, SendTime: sent}, userID: "u1"},
+		{name: "expired", policy: config.MsgEdit{Window: 30}, msgData: &sdkws.MsgData{SendID: "u1", ContentType: constant.Text, SendTime: sent}, userID: "u1", code: servererrs.MsgEditExpired},
+		{name: "not sender", msgData: &sdkws.MsgData{SendID: "u1", ContentType: constant.Text, SendTime: sent}, userID: "u2", code: errs.NoPermissionError},
+		{name: "disabled", policy: config.MsgEdit{Disable: true}, msgData: &sdkws.MsgData{SendID: "u1", ContentType: constant.Text, SendTime: sent}, userID: "u1", code: errs.NoPermissionError},
+		{name: "revoked", msgData: &sdkws.MsgData{SendID: "u1", ContentType: constant.MsgRevokeNotification, SendTime: sent}, userID: "u1", code: servererrs.MsgAlreadyRevoke},
+		{name: "notification", msgData: &sdkws.MsgData{SendID: "u1", ContentType: constant.OANotification, SendTime: sent}, userID: "u1", code: errs.ArgsError},
+	}
+	for _, test := range tests {
+		err := checkEditPolicy(&test.policy, test.msgData, test.userID, now)
+		if test.code == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", test.name, err)
+			}
+			continue
+		}
+		codeErr, ok := errs.Unwrap(err).(errs.CodeError)
+		if !ok || codeErr.Code() != test.code {
+			t.Errorf("%s: got %v want code %d", test.name, err, test.code)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package msg
 
 import (
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/webhook"
 	pbchat "github.com/openimsdk/protocol/msg"
 	"github.com/openimsdk/tools/utils/datautil"
 	"strconv"
@@ -12,8 +13,11 @@ const (
 	separator = "-"
 )
 
-func filterAfterMsg(msg *pbchat.SendMsgReq, after *config.AfterConfig) bool {
-	return filterMsg(msg, after.AttentionIds, after.AllowedTypes, after.DeniedTypes)
+// filterAfterMsg applies the filters of every after callback target to msg.
+func filterAfterMsg(msg *pbchat.SendMsgReq) webhook.AfterFilter {
+	return func(target *config.AfterSubscriber) bool {
+		return filterMsg(msg, target.AttentionIds, target.AllowedTypes, target.DeniedTypes)
+	}
 }
 
 func filterBeforeMsg(msg *pbchat.SendMsgReq, before *config.BeforeConfig) bool {
@@ -0,0 +1,44 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/protocol/constant"
+	pbchat "github.com/openimsdk/protocol/msg"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterAfterMsg(t *testing.T) {
+	msg := &pbchat.SendMsgReq{MsgData: &sdkws.MsgData{SendID: "u1", RecvID: "u2", ContentType: constant.Text}}
+	filter := filterAfterMsg(msg)
+
+	assert.True(t, filter(&config.AfterSubscriber{URL: "http://127.0.0.1:10007/all"}))
+
+	// attentionIds match the sender or the receiver
+	assert.True(t, filter(&config.AfterSubscriber{AttentionIds: []string{"u2"}}))
+	assert.False(t, filter(&config.AfterSubscriber{AttentionIds: []string{"u3"}}))
+
+	// single types and intervals
+	assert.True(t, filter(&config.AfterSubscriber{AllowedTypes: []string{"101"}}))
+	assert.True(t, filter(&config.AfterSubscriber{AllowedTypes: []string{"100-110"}}))
+	assert.False(t, filter(&config.AfterSubscriber{AllowedTypes: []string{"102-110"}}))
+	assert.False(t, filter(&config.AfterSubscriber{DeniedTypes: []string{"101"}}))
+	assert.True(t, filter(&config.AfterSubscriber{DeniedTypes: []string{"102-110"}}))
+	assert.False(t, filter(&config.AfterSubscriber{AllowedTypes: []string{"100-110"}, DeniedTypes: []string{"101"}}))
+}
@@ -0,0 +1,108 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/msg"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/utils/datautil"
+)
+
+var registeredInterceptors MessageInterceptorChain
+
+// RegisterMessageInterceptor registers the interceptors run after the built-in ones before a message is verified.
+// It must be called before the msg server starts, usually in the init of a package imported by the main package.
+//
+// An interceptor returns a non nil MsgData to replace the message, or an error to reject it. Errors without a
+// code are returned as servererrs.ErrMsgRejected.
+func RegisterMessageInterceptor(interceptorFunc ...MessageInterceptorFunc) {
+	registeredInterceptors = append(registeredInterceptors, interceptorFunc...)
+}
+
+// builtinInterceptors returns the built-in interceptors enabled in the config.
+func builtinInterceptors(conf *Config) MessageInterceptorChain {
+	var chain MessageInterceptorChain
+	interceptor := conf.RpcConfig.Interceptor
+	if interceptor.MaxContentLength > 0 {
+		chain = append(chain, MaxContentLengthInterceptor)
+	}
+	allowed := interceptor.AllowedContentTypes
+	if len(allowed.Single) > 0 || len(allowed.Group) > 0 || len(allowed.Notification) > 0 {
+		chain = append(chain, ContentTypeInterceptor)
+	}
+	return chain
+}
+
+// interceptMsg runs the interceptor chain on the message.
+func (m *msgServer) interceptMsg(ctx context.Context, req *msg.SendMsgReq) error {
+	for _, handler := range m.Handlers {
+		msgData, err := handler(ctx, m.config, req)
+		if err != nil {
+			if _, ok := errs.Unwrap(err).(errs.CodeError); ok {
+				return err
+			}
+			return servererrs.ErrMsgRejected.WrapMsg(err.Error())
+		}
+		if msgData != nil {
+			req.MsgData = msgData
+		}
+	}
+	return nil
+}
+
+// isServerNotification reports whether the message is a notification generated by the server, the built-in
+// interceptors only check the messages of the users.
+func isServerNotification(msgData *sdkws.MsgData) bool {
+	return msgData.ContentType >= constant.NotificationBegin && msgData.ContentType <= constant.NotificationEnd
+}
+
+// MaxContentLengthInterceptor rejects the messages whose content exceeds maxContentLength bytes.
+func MaxContentLengthInterceptor(_ context.Context, globalConfig *Config, req *msg.SendMsgReq) (*sdkws.MsgData, error) {
+	maxLength := globalConfig.RpcConfig.Interceptor.MaxContentLength
+	if maxLength <= 0 || isServerNotification(req.MsgData) {
+		return nil, nil
+	}
+	if len(req.MsgData.Content) > maxLength {
+		return nil, servererrs.ErrMsgContentTooLong.WrapMsg("message content is too long", "length", len(req.MsgData.Content), "max", maxLength)
+	}
+	return nil, nil
+}
+
+// ContentTypeInterceptor rejects the content types missing from the allow-list of the session type,
+// an empty allow-list allows all of them.
+func ContentTypeInterceptor(_ context.Context, globalConfig *Config, req *msg.SendMsgReq) (*sdkws.MsgData, error) {
+	if isServerNotification(req.MsgData) {
+		return nil, nil
+	}
+	allowed := globalConfig.RpcConfig.Interceptor.AllowedContentTypes
+	var contentTypes []int32
+	switch req.MsgData.SessionType {
+	case constant.SingleChatType:
+		contentTypes = allowed.Single
+	case constant.ReadGroupChatType, constant.WriteGroupChatType:
+		contentTypes = allowed.Group
+	case constant.NotificationChatType:
+		contentTypes = allowed.Notification
+	}
+	if len(contentTypes) == 0 || datautil.Contain(req.MsgData.ContentType, contentTypes...) {
+		return nil, nil
+	}
+	return nil, servererrs.ErrMsgContentTypeDenied.WrapMsg("content type is not allowed", "sessionType", req.MsgData.SessionType, "contentType", req.MsgData.ContentType)
+}
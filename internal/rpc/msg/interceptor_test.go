@@ -0,0 +1,59 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/msg"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestInterceptMsg(t *testing.T) {
+	conf := &Config{}
+	conf.RpcConfig.Interceptor.MaxContentLength = 5
+	conf.RpcConfig.Interceptor.AllowedContentTypes.Group = []int32{constant.Text}
+	m := &msgServer{config: conf}
+	m.addInterceptorHandler(builtinInterceptors(conf)...)
+	ctx := context.Background()
+
+	newReq := func(sessionType, contentType int32, content string) *msg.SendMsgReq {
+		return &msg.SendMsgReq{MsgData: &sdkws.MsgData{SessionType: sessionType, ContentType: contentType, Content: []byte(content)}}
+	}
+	assert.NoError(t, m.interceptMsg(ctx, newReq(constant.ReadGroupChatType, constant.Text, "12345")))
+	assert.True(t, servererrs.ErrMsgContentTooLong.Is(m.interceptMsg(ctx, newReq(constant.ReadGroupChatType, constant.Text, "123456"))))
+	assert.True(t, servererrs.ErrMsgContentTypeDenied.Is(m.interceptMsg(ctx, newReq(constant.ReadGroupChatType, constant.Picture, "{}"))))
+	assert.NoError(t, m.interceptMsg(ctx, newReq(constant.SingleChatType, constant.Picture, "{}")))
+	assert.NoError(t, m.interceptMsg(ctx, newReq(constant.ReadGroupChatType, constant.GroupCreatedNotification, "a long notification")))
+
+	m.addInterceptorHandler(func(ctx context.Context, globalConfig *Config, req *msg.SendMsgReq) (*sdkws.MsgData, error) {
+		if string(req.MsgData.Content) == "bad" {
+			return nil, errors.New("bad word")
+		}
+		rewritten := proto.Clone(req.MsgData).(*sdkws.MsgData)
+		rewritten.Ex = "intercepted"
+		return rewritten, nil
+	})
+	req := newReq(constant.SingleChatType, constant.Text, "ok")
+	assert.NoError(t, m.interceptMsg(ctx, req))
+	assert.Equal(t, "intercepted", req.MsgData.Ex)
+	assert.True(t, servererrs.ErrMsgRejected.Is(m.interceptMsg(ctx, newReq(constant.SingleChatType, constant.Text, "bad"))))
+}
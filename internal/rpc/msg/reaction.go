@@ -0,0 +1,173 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+	"github.com/openimsdk/open-im-server/v3/pkg/protocol/msgext"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/utils/datautil"
+)
+
+func (m *msgServer) AddReaction(ctx context.Context, req *msgext.AddReactionReq) (*msgext.AddReactionResp, error) {
+	if err := msgprocessor.CheckReaction(req.Reaction); err != nil {
+		return nil, err
+	}
+	msgData, err := m.verifyReaction(ctx, req.UserID, req.ConversationID, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	added, err := m.MsgDatabase.AddReaction(ctx, req.ConversationID, req.Seq, req.Reaction, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if added {
+		m.reactionNotification(ctx, req.UserID, req.ConversationID, msgData, req.Reaction, true)
+	}
+	return &msgext.AddReactionResp{}, nil
+}
+
+func (m *msgServer) DeleteReaction(ctx context.Context, req *msgext.DeleteReactionReq) (*msgext.DeleteReactionResp, error) {
+	if err := msgprocessor.CheckReaction(req.Reaction); err != nil {
+		return nil, err
+	}
+	msgData, err := m.verifyReaction(ctx, req.UserID, req.ConversationID, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := m.MsgDatabase.DeleteReaction(ctx, req.ConversationID, req.Seq, req.Reaction, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if deleted {
+		m.reactionNotification(ctx, req.UserID, req.ConversationID, msgData, req.Reaction, false)
+	}
+	return &msgext.DeleteReactionResp{}, nil
+}
+
+func (m *msgServer) GetReactions(ctx context.Context, req *msgext.GetReactionsReq) (*msgext.GetReactionsResp, error) {
+	if err := authverify.CheckAccessV3(ctx, req.UserID, m.config.Share.IMAdminUserID); err != nil {
+		return nil, err
+	}
+	if err := m.verifyConversationAccess(ctx, req.UserID, req.ConversationID); err != nil {
+		return nil, err
+	}
+	if _, err := m.getReactionMsg(ctx, req.UserID, req.ConversationID, req.Seq); err != nil {
+		return nil, err
+	}
+	reactions, err := m.MsgDatabase.GetReactions(ctx, req.ConversationID, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	resp := &msgext.GetReactionsResp{Reactions: make([]*msgext.MsgReaction, 0, len(reactions))}
+	for reaction, userIDs := range reactions {
+		resp.Reactions = append(resp.Reactions, &msgext.MsgReaction{Reaction: reaction, UserIDs: userIDs})
+	}
+	sort.Slice(resp.Reactions, func(i, j int) bool {
+		return resp.Reactions[i].Reaction < resp.Reactions[j].Reaction
+	})
+	return resp, nil
+}
+
+// verifyReaction checks that the user can react to the message like sending a message to its conversation:
+// the peer of a single chat has not blocked the user, and the user is a member of the group who is not muted.
+func (m *msgServer) verifyReaction(ctx context.Context, userID string, conversationID string, seq int64) (*sdkws.MsgData, error) {
+	if err := authverify.CheckAccessV3(ctx, userID, m.config.Share.IMAdminUserID); err != nil {
+		return nil, err
+	}
+	msgData, err := m.getReactionMsg(ctx, userID, conversationID, seq)
+	if err != nil {
+		return nil, err
+	}
+	isAdmin := datautil.Contain(userID, m.config.Share.IMAdminUserID...)
+	switch msgData.SessionType {
+	case constant.SingleChatType:
+		if userID != msgData.SendID && userID != msgData.RecvID {
+			return nil, errs.ErrNoPermission.WrapMsg("user is not in the conversation")
+		}
+		if isAdmin {
+			return msgData, nil
+		}
+		return msgData, m.verifyPeer(ctx, userID, reactionPeer(userID, msgData))
+	case constant.ReadGroupChatType:
+		groupInfo, err := m.GroupLocalCache.GetGroupInfo(ctx, msgData.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		if groupInfo.Status == constant.GroupStatusDismissed {
+			return nil, servererrs.ErrDismissedAlready.Wrap()
+		}
+		if isAdmin {
+			return msgData, nil
+		}
+		return msgData, m.verifyGroupMember(ctx, groupInfo, userID)
+	default:
+		return nil, errs.ErrArgs.WrapMsg("reactions are not supported in the session type", "sessionType", msgData.SessionType)
+	}
+}
+
+// getReactionMsg returns the message if it is visible to the user and can have reactions.
+func (m *msgServer) getReactionMsg(ctx context.Context, userID string, conversationID string, seq int64) (*sdkws.MsgData, error) {
+	_, _, msgs, err := m.MsgDatabase.GetMsgBySeqs(ctx, userID, conversationID, []int64{seq})
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 || msgs[0] == nil || msgs[0].Status == constant.MsgDeleted || msgs[0].Status == constant.MsgStatusHasDeleted {
+		return nil, errs.ErrRecordNotFound.WrapMsg("msg not found")
+	}
+	if msgs[0].ContentType == constant.MsgRevokeNotification {
+		return nil, servererrs.ErrMsgAlreadyRevoke.WrapMsg("msg already revoke")
+	}
+	if isServerNotification(msgs[0]) {
+		return nil, errs.ErrArgs.WrapMsg("notifications have no reactions", "contentType", msgs[0].ContentType)
+	}
+	return msgs[0], nil
+}
+
+// reactionNotification notifies the conversation of the message, the notification neither counts as unread nor
+// updates the conversation.
+func (m *msgServer) reactionNotification(ctx context.Context, userID string, conversationID string, msgData *sdkws.MsgData, reaction string, added bool) {
+	tips := &msgext.MsgReactionTips{
+		UserID:         userID,
+		ConversationID: conversationID,
+		Seq:            msgData.Seq,
+		ClientMsgID:    msgData.ClientMsgID,
+		SessionType:    msgData.SessionType,
+		Reaction:       reaction,
+		Added:          added,
+		Time:           time.Now().UnixMilli(),
+	}
+	recvID := msgData.GroupID
+	if msgData.SessionType == constant.SingleChatType {
+		recvID = reactionPeer(userID, msgData)
+	}
+	m.notificationSender.NotificationDetailWithSessionType(ctx, userID, recvID, msgprocessor.MsgReactionNotification, msgData.SessionType, tips)
+}
+
+// reactionPeer returns the other user of the single chat of the message.
+func reactionPeer(userID string, msgData *sdkws.MsgData) string {
+	if userID == msgData.SendID {
+		return msgData.RecvID
+	}
+	return msgData.SendID
+}
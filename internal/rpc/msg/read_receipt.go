@@ -0,0 +1,225 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/controller"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+	"github.com/openimsdk/open-im-server/v3/pkg/protocol/msgext"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/utils/datautil"
+)
+
+// SetGroupReadReceipt turns the read receipts of a group on or off, the messages sent before keep their state.
+func (m *msgServer) SetGroupReadReceipt(ctx context.Context, req *msgext.SetGroupReadReceiptReq) (*msgext.SetGroupReadReceiptResp, error) {
+	if m.msgReadReceiptDatabase == nil {
+		return nil, errs.ErrNoPermission.WrapMsg("read receipts are disabled")
+	}
+	if err := authverify.CheckAccessV3(ctx, req.UserID, m.config.Share.IMAdminUserID); err != nil {
+		return nil, err
+	}
+	if !datautil.Contain(req.UserID, m.config.Share.IMAdminUserID...) {
+		member, err := m.GroupLocalCache.GetGroupMember(ctx, req.GroupID, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if member.RoleLevel != constant.GroupOwner && member.RoleLevel != constant.GroupAdmin {
+			return nil, errs.ErrNoPermission.WrapMsg("only the owner and the admins can set the read receipts of the group")
+		}
+	} else if _, err := m.GroupLocalCache.GetGroupInfo(ctx, req.GroupID); err != nil {
+		return nil, err
+	}
+	if err := m.msgReadReceiptDatabase.SetGroupReadReceipt(ctx, req.GroupID, req.UserID, req.Enable); err != nil {
+		return nil, err
+	}
+	return &msgext.SetGroupReadReceiptResp{}, nil
+}
+
+// GetMsgReadReceipts returns the read and unread members of the messages, only the sender of the messages and the
+// app managers can get them.
+func (m *msgServer) GetMsgReadReceipts(ctx context.Context, req *msgext.GetMsgReadReceiptsReq) (*msgext.GetMsgReadReceiptsResp, error) {
+	if m.msgReadReceiptDatabase == nil {
+		return nil, errs.ErrNoPermission.WrapMsg("read receipts are disabled")
+	}
+	if err := authverify.CheckAccessV3(ctx, req.UserID, m.config.Share.IMAdminUserID); err != nil {
+		return nil, err
+	}
+	if !msgprocessor.IsGroupConversationID(req.ConversationID) {
+		return nil, errs.ErrArgs.WrapMsg("read receipts are only supported in groups", "conversationID", req.ConversationID)
+	}
+	receipts, err := m.findReadReceipts(ctx, req.UserID, req.ConversationID, datautil.Distinct(req.Seqs))
+	if err != nil {
+		return nil, err
+	}
+	resp := &msgext.GetMsgReadReceiptsResp{Receipts: make([]*msgext.MsgReadReceipt, 0, len(receipts))}
+	if len(receipts) == 0 {
+		return resp, nil
+	}
+	if !datautil.Contain(req.UserID, m.config.Share.IMAdminUserID...) {
+		for _, receipt := range receipts {
+			if receipt.SendID != req.UserID {
+				return nil, errs.ErrNoPermission.WrapMsg("only the sender can get the read receipts", "seq", receipt.Seq)
+			}
+		}
+	}
+	memberIDs, err := m.GroupLocalCache.GetGroupMemberIDs(ctx, receipts[0].GroupID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := m.GroupLocalCache.GetGroupMembers(ctx, receipts[0].GroupID, memberIDs)
+	if err != nil {
+		return nil, err
+	}
+	hasReadSeqs := make(map[string]int64, len(members))
+	for _, member := range members {
+		hasReadSeq, err := m.MsgDatabase.GetHasReadSeq(ctx, member.UserID, req.ConversationID)
+		if err != nil {
+			return nil, err
+		}
+		hasReadSeqs[member.UserID] = hasReadSeq
+	}
+	sort.Slice(receipts, func(i, j int) bool { return receipts[i].Seq < receipts[j].Seq })
+	for _, receipt := range receipts {
+		resp.Receipts = append(resp.Receipts, convertReadReceipt(receipt, members, hasReadSeqs))
+	}
+	return resp, nil
+}
+
+// findReadReceipts returns the receipts of the seqs, the receipts of the messages not stored yet or whose receipts
+// failed to be created are built from the messages.
+func (m *msgServer) findReadReceipts(ctx context.Context, userID string, conversationID string, seqs []int64) ([]*model.MsgReadReceipt, error) {
+	receipts, err := m.msgReadReceiptDatabase.FindReceipts(ctx, conversationID, seqs)
+	if err != nil {
+		return nil, err
+	}
+	found := datautil.SliceSetAny(receipts, func(receipt *model.MsgReadReceipt) int64 { return receipt.Seq })
+	missing := datautil.Filter(seqs, func(seq int64) (int64, bool) {
+		_, ok := found[seq]
+		return seq, !ok
+	})
+	if len(missing) == 0 {
+		return receipts, nil
+	}
+	_, _, msgs, err := m.MsgDatabase.GetMsgBySeqs(ctx, userID, conversationID, missing)
+	if err != nil {
+		return nil, err
+	}
+	return append(receipts, controller.NewMsgReadReceipts(conversationID, msgs)...), nil
+}
+
+// convertReadReceipt counts the current members who joined before the message was sent as the ones who should
+// read it, the readers who left the group are still listed. A member whose read seq reached the message read it
+// even when the receipt missed the read, as the message was read before its receipt was created, its read time
+// is then unknown and left 0.
+func convertReadReceipt(receipt *model.MsgReadReceipt, members []*sdkws.GroupMemberFullInfo, hasReadSeqs map[string]int64) *msgext.MsgReadReceipt {
+	res := &msgext.MsgReadReceipt{
+		Seq:           receipt.Seq,
+		ReadUsers:     make([]*msgext.MsgReadUser, 0, len(receipt.ReadUsers)),
+		UnreadUserIDs: []string{},
+	}
+	read := make(map[string]struct{}, len(receipt.ReadUsers))
+	for _, reader := range receipt.ReadUsers {
+		read[reader.UserID] = struct{}{}
+		res.ReadUsers = append(res.ReadUsers, &msgext.MsgReadUser{UserID: reader.UserID, ReadTime: reader.ReadTime})
+	}
+	for _, member := range members {
+		if member.UserID == receipt.SendID || member.JoinTime > receipt.SendTime {
+			continue
+		}
+		if _, ok := read[member.UserID]; ok {
+			continue
+		}
+		if hasReadSeqs[member.UserID] >= receipt.Seq {
+			res.ReadUsers = append(res.ReadUsers, &msgext.MsgReadUser{UserID: member.UserID})
+			continue
+		}
+		res.UnreadUserIDs = append(res.UnreadUserIDs, member.UserID)
+	}
+	res.ReadCount = int32(len(res.ReadUsers))
+	res.UnreadCount = int32(len(res.UnreadUserIDs))
+	return res
+}
+
+// setReadReceiptOption marks the message to have a read receipt when the group has the read receipts on and is
+// small enough, the option set by the client is ignored.
+func (m *msgServer) setReadReceiptOption(ctx context.Context, msgData *sdkws.MsgData) error {
+	delete(msgData.Options, msgprocessor.IsReadReceipt)
+	conf := m.config.RpcConfig.ReadReceipt
+	if m.msgReadReceiptDatabase == nil || isServerNotification(msgData) {
+		return nil
+	}
+	groupInfo, err := m.GroupLocalCache.GetGroupInfo(ctx, msgData.GroupID)
+	if err != nil {
+		return err
+	}
+	if conf.MaxMemberCount > 0 && int32(groupInfo.MemberCount) > conf.MaxMemberCount {
+		return nil
+	}
+	enabled, err := m.msgReadReceiptDatabase.IsGroupReadReceiptEnabled(ctx, msgData.GroupID)
+	if err != nil || !enabled {
+		return err
+	}
+	if msgData.Options == nil {
+		msgData.Options = make(map[string]bool)
+	}
+	msgData.Options[msgprocessor.IsReadReceipt] = true
+	return nil
+}
+
+// markGroupReadReceipts records the messages of (fromSeq, toSeq] as read by the user and notifies their senders.
+func (m *msgServer) markGroupReadReceipts(ctx context.Context, conversationID string, groupID string, userID string, fromSeq int64, toSeq int64) error {
+	if m.msgReadReceiptDatabase == nil || groupID == "" {
+		return nil
+	}
+	enabled, err := m.msgReadReceiptDatabase.IsGroupReadReceiptEnabled(ctx, groupID)
+	if err != nil || !enabled {
+		return err
+	}
+	member, err := m.GroupLocalCache.GetGroupMember(ctx, groupID, userID)
+	if err != nil {
+		return err
+	}
+	receipts, err := m.msgReadReceiptDatabase.MarkRead(ctx, conversationID, userID, fromSeq, toSeq, member.JoinTime)
+	if err != nil {
+		return err
+	}
+	senderSeqs := make(map[string][]int64)
+	for _, receipt := range receipts {
+		if receipt.SendID != userID {
+			senderSeqs[receipt.SendID] = append(senderSeqs[receipt.SendID], receipt.Seq)
+		}
+	}
+	readTime := time.Now().UnixMilli()
+	for sendID, seqs := range senderSeqs {
+		sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+		tips := &msgext.MsgReadReceiptTips{
+			UserID:         userID,
+			ConversationID: conversationID,
+			GroupID:        groupID,
+			Seqs:           seqs,
+			ReadTime:       readTime,
+		}
+		m.notificationSender.NotificationDetailWithSessionType(ctx, userID, sendID, msgprocessor.MsgReadReceiptNotification, constant.SingleChatType, tips)
+	}
+	return nil
+}
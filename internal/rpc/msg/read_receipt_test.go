@@ -0,0 +1,58 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/protocol/sdkws"
+)
+
+func TestConvertReadReceipt(t *testing.T) {
+	receipt := &model.MsgReadReceipt{
+		Seq:      10,
+		SendID:   "sender",
+		SendTime: 1000,
+		ReadUsers: []*model.MsgReadMember{
+			{UserID: "u1", ReadTime: 1100},
+			{UserID: "left", ReadTime: 1200},
+		},
+	}
+	members := []*sdkws.GroupMemberFullInfo{
+		{UserID: "sender", JoinTime: 100},
+		{UserID: "u1", JoinTime: 100},
+		{UserID: "u2", JoinTime: 1000},
+		{UserID: "u3", JoinTime: 100},
+		{UserID: "late", JoinTime: 1001},
+		{UserID: "u4", JoinTime: 100},
+	}
+	// u4 read the message before its receipt was created
+	hasReadSeqs := map[string]int64{"u1": 10, "u3": 9, "u4": 12}
+	res := convertReadReceipt(receipt, members, hasReadSeqs)
+	if res.Seq != 10 || res.ReadCount != 3 || res.UnreadCount != 2 {
+		t.Fatalf("got seq %d read %d unread %d", res.Seq, res.ReadCount, res.UnreadCount)
+	}
+	if want := []string{"u2", "u3"}; !reflect.DeepEqual(res.UnreadUserIDs, want) {
+		t.Errorf("unread got %v want %v", res.UnreadUserIDs, want)
+	}
+	if res.ReadUsers[1].UserID != "left" || res.ReadUsers[1].ReadTime != 1200 {
+		t.Errorf("read users got %+v", res.ReadUsers[1])
+	}
+	if res.ReadUsers[2].UserID != "u4" || res.ReadUsers[2].ReadTime != 0 {
+		t.Errorf("read users got %+v", res.ReadUsers[2])
+	}
+}
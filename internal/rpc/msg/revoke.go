@@ -17,10 +17,14 @@ package msg
 import (
 	"context"
 	"encoding/json"
-	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
 	"time"
 
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+	"github.com/openimsdk/open-im-server/v3/pkg/protocol/msgext"
+
 	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
 	"github.com/openimsdk/protocol/constant"
 	"github.com/openimsdk/protocol/msg"
@@ -55,54 +59,145 @@ func (m *msgServer) RevokeMsg(ctx context.Context, req *msg.RevokeMsgReq) (*msg.
 	if len(msgs) == 0 || msgs[0] == nil {
 		return nil, errs.ErrRecordNotFound.WrapMsg("msg not found")
 	}
-	if msgs[0].ContentType == constant.MsgRevokeNotification {
-		return nil, servererrs.ErrMsgAlreadyRevoke.WrapMsg("msg already revoke")
-	}
-
 	data, _ := json.Marshal(msgs[0])
 	log.ZDebug(ctx, "GetMsgBySeqs", "conversationID", req.ConversationID, "seq", req.Seq, "msg", string(data))
+	if err := m.revokeMsg(ctx, user, req.ConversationID, msgs[0]); err != nil {
+		return nil, err
+	}
+	m.webhookAfterRevokeMsg(ctx, &m.config.WebhooksConfig.AfterRevokeMsg, req)
+	return &msg.RevokeMsgResp{}, nil
+}
+
+// BatchRevokeMsg revokes the messages of the seqs one by one, the messages which fail to be revoked are
+// returned in the failures instead of failing the request.
+func (m *msgServer) BatchRevokeMsg(ctx context.Context, req *msgext.BatchRevokeMsgReq) (*msgext.BatchRevokeMsgResp, error) {
+	if maxBatchSize := m.config.RpcConfig.Revoke.MaxBatchSize; maxBatchSize > 0 && len(req.Seqs) > maxBatchSize {
+		return nil, errs.ErrArgs.WrapMsg("too many seqs", "num", len(req.Seqs), "max", maxBatchSize)
+	}
+	if err := authverify.CheckAccessV3(ctx, req.UserID, m.config.Share.IMAdminUserID); err != nil {
+		return nil, err
+	}
+	user, err := m.UserLocalCache.GetUserInfo(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return m.revokeMsgs(ctx, user, req.ConversationID, datautil.Distinct(req.Seqs))
+}
+
+// RevokeUserGroupMsgs revokes the messages of a user in a group which are not revoked yet, it is used by the app
+// managers and the group owners and admins to clean up after a member. A request revokes at most
+// revoke.maxBatchSize messages, the response tells where to continue when older messages remain.
+func (m *msgServer) RevokeUserGroupMsgs(ctx context.Context, req *msgext.RevokeUserGroupMsgsReq) (*msgext.RevokeUserGroupMsgsResp, error) {
+	opUserID := mcontext.GetOpUserID(ctx)
+	if !authverify.IsAppManagerUid(ctx, m.config.Share.IMAdminUserID) {
+		member, err := m.GroupLocalCache.GetGroupMember(ctx, req.GroupID, opUserID)
+		if err != nil {
+			return nil, err
+		}
+		if member.RoleLevel != constant.GroupOwner && member.RoleLevel != constant.GroupAdmin {
+			return nil, errs.ErrNoPermission.WrapMsg("no group owner or admin")
+		}
+	}
+	user, err := m.UserLocalCache.GetUserInfo(ctx, opUserID)
+	if err != nil {
+		return nil, err
+	}
+	conversationID := msgprocessor.GetConversationIDBySessionType(constant.ReadGroupChatType, req.GroupID)
+	limit := m.config.RpcConfig.Revoke.MaxBatchSize
+	if limit > 0 {
+		// one more seq tells whether older messages remain
+		limit++
+	}
+	seqs, err := m.MsgDatabase.FindSendSeqs(ctx, conversationID, req.UserID, req.BeforeSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+	var nextSeq int64
+	if limit > 0 && len(seqs) == limit {
+		seqs = seqs[:limit-1]
+		nextSeq = seqs[len(seqs)-1]
+	}
+	resp, err := m.revokeMsgs(ctx, user, conversationID, seqs)
+	if err != nil {
+		return nil, err
+	}
+	return &msgext.RevokeUserGroupMsgsResp{ConversationID: conversationID, RevokedSeqs: resp.RevokedSeqs, Failures: resp.Failures, NextSeq: nextSeq}, nil
+}
+
+func (m *msgServer) revokeMsgs(ctx context.Context, user *sdkws.UserInfo, conversationID string, seqs []int64) (*msgext.BatchRevokeMsgResp, error) {
+	resp := &msgext.BatchRevokeMsgResp{RevokedSeqs: []int64{}, Failures: []*msgext.RevokeMsgFailure{}}
+	if len(seqs) == 0 {
+		return resp, nil
+	}
+	_, _, msgs, err := m.MsgDatabase.GetMsgBySeqs(ctx, user.UserID, conversationID, seqs)
+	if err != nil {
+		return nil, err
+	}
+	seqMsgs := make(map[int64]*sdkws.MsgData, len(msgs))
+	for _, msgData := range msgs {
+		if msgData != nil {
+			seqMsgs[msgData.Seq] = msgData
+		}
+	}
+	for _, seq := range seqs {
+		msgData, ok := seqMsgs[seq]
+		if !ok {
+			resp.Failures = append(resp.Failures, msgext.NewRevokeMsgFailure(seq, errs.ErrRecordNotFound.WrapMsg("msg not found")))
+			continue
+		}
+		if err := m.revokeMsg(ctx, user, conversationID, msgData); err != nil {
+			log.ZDebug(ctx, "revoke msg failed", "conversationID", conversationID, "seq", seq, "err", err)
+			resp.Failures = append(resp.Failures, msgext.NewRevokeMsgFailure(seq, err))
+			continue
+		}
+		resp.RevokedSeqs = append(resp.RevokedSeqs, seq)
+		m.webhookAfterRevokeMsg(ctx, &m.config.WebhooksConfig.AfterRevokeMsg, &msg.RevokeMsgReq{ConversationID: conversationID, Seq: seq, UserID: user.UserID})
+	}
+	return resp, nil
+}
+
+// revokeMsg checks the permission and the revoke policy of user on the message, revokes it and notifies the
+// conversation.
+func (m *msgServer) revokeMsg(ctx context.Context, user *sdkws.UserInfo, conversationID string, msgData *sdkws.MsgData) error {
+	if msgData.ContentType == constant.MsgRevokeNotification {
+		return servererrs.ErrMsgAlreadyRevoke.WrapMsg("msg already revoke")
+	}
 	var role int32
 	if !authverify.IsAppManagerUid(ctx, m.config.Share.IMAdminUserID) {
-		sessionType := msgs[0].SessionType
+		sessionType := msgData.SessionType
 		switch sessionType {
 		case constant.SingleChatType:
-			if err := authverify.CheckAccessV3(ctx, msgs[0].SendID, m.config.Share.IMAdminUserID); err != nil {
-				return nil, err
+			if err := authverify.CheckAccessV3(ctx, msgData.SendID, m.config.Share.IMAdminUserID); err != nil {
+				return err
 			}
 			role = user.AppMangerLevel
 		case constant.ReadGroupChatType:
-			members, err := m.GroupLocalCache.GetGroupMemberInfoMap(ctx, msgs[0].GroupID, datautil.Distinct([]string{req.UserID, msgs[0].SendID}))
+			members, err := m.GroupLocalCache.GetGroupMemberInfoMap(ctx, msgData.GroupID, datautil.Distinct([]string{user.UserID, msgData.SendID}))
 			if err != nil {
-				return nil, err
+				return err
 			}
-			if req.UserID != msgs[0].SendID {
-				switch members[req.UserID].RoleLevel {
-				case constant.GroupOwner:
-				case constant.GroupAdmin:
-					if members[msgs[0].SendID].RoleLevel != constant.GroupOrdinaryUsers {
-						return nil, errs.ErrNoPermission.WrapMsg("no permission")
-					}
-				default:
-					return nil, errs.ErrNoPermission.WrapMsg("no permission")
-				}
-			}
-			if member := members[req.UserID]; member != nil {
-				role = member.RoleLevel
+			role, err = checkGroupRevoker(members, user.UserID, msgData.SendID)
+			if err != nil {
+				return err
 			}
 		default:
-			return nil, errs.ErrInternalServer.WrapMsg("msg sessionType not supported", "sessionType", sessionType)
+			return errs.ErrInternalServer.WrapMsg("msg sessionType not supported", "sessionType", sessionType)
+		}
+		if err := checkRevokePolicy(&m.config.RpcConfig.Revoke, msgData, role, time.Now()); err != nil {
+			return err
 		}
 	}
 	now := time.Now().UnixMilli()
-	err = m.MsgDatabase.RevokeMsg(ctx, req.ConversationID, req.Seq, &model.RevokeModel{
+	err := m.MsgDatabase.RevokeMsg(ctx, conversationID, msgData.Seq, &model.RevokeModel{
 		Role:     role,
-		UserID:   req.UserID,
+		UserID:   user.UserID,
 		Nickname: user.Nickname,
 		Time:     now,
 	})
 	if err != nil {
-		return nil, err
+		return err
 	}
+	m.deleteSearchIndex(ctx, conversationID, []int64{msgData.Seq})
 	revokerUserID := mcontext.GetOpUserID(ctx)
 	var flag bool
 
@@ -111,20 +206,79 @@ func (m *msgServer) RevokeMsg(ctx context.Context, req *msg.RevokeMsgReq) (*msg.
 	}
 	tips := sdkws.RevokeMsgTips{
 		RevokerUserID:  revokerUserID,
-		ClientMsgID:    msgs[0].ClientMsgID,
+		ClientMsgID:    msgData.ClientMsgID,
 		RevokeTime:     now,
-		Seq:            req.Seq,
-		SesstionType:   msgs[0].SessionType,
-		ConversationID: req.ConversationID,
+		Seq:            msgData.Seq,
+		SesstionType:   msgData.SessionType,
+		ConversationID: conversationID,
 		IsAdminRevoke:  flag,
 	}
 	var recvID string
-	if msgs[0].SessionType == constant.ReadGroupChatType {
-		recvID = msgs[0].GroupID
+	if msgData.SessionType == constant.ReadGroupChatType {
+		recvID = msgData.GroupID
 	} else {
-		recvID = msgs[0].RecvID
+		recvID = msgData.RecvID
 	}
-	m.notificationSender.NotificationWithSessionType(ctx, req.UserID, recvID, constant.MsgRevokeNotification, msgs[0].SessionType, &tips)
-	m.webhookAfterRevokeMsg(ctx, &m.config.WebhooksConfig.AfterRevokeMsg, req)
-	return &msg.RevokeMsgResp{}, nil
+	m.notificationSender.NotificationWithSessionType(ctx, user.UserID, recvID, constant.MsgRevokeNotification, msgData.SessionType, &tips)
+	return nil
+}
+
+// checkGroupRevoker checks that the revoker can revoke a message of the sender in a group and returns the role of
+// the revoker, members are the group members among them. The owners revoke every message, the admins the messages
+// of the ordinary users, a sender who left the group counts as an ordinary user.
+func checkGroupRevoker(members map[string]*sdkws.GroupMemberFullInfo, revokerID string, senderID string) (int32, error) {
+	revoker, ok := members[revokerID]
+	if !ok || revoker == nil {
+		if revokerID == senderID {
+			return 0, nil
+		}
+		return 0, errs.ErrNoPermission.WrapMsg("not a group member", "userID", revokerID)
+	}
+	if revokerID == senderID {
+		return revoker.RoleLevel, nil
+	}
+	switch revoker.RoleLevel {
+	case constant.GroupOwner:
+	case constant.GroupAdmin:
+		if sender := members[senderID]; sender != nil && sender.RoleLevel != constant.GroupOrdinaryUsers {
+			return 0, errs.ErrNoPermission.WrapMsg("no permission")
+		}
+	default:
+		return 0, errs.ErrNoPermission.WrapMsg("no permission")
+	}
+	return revoker.RoleLevel, nil
+}
+
+// checkRevokePolicy checks the revoke policy for a user who is not an app manager, role is the group role of
+// the user in group chats. The group owners and admins are limited by the window of their role, the other
+// senders by the sender window.
+func checkRevokePolicy(policy *config.MsgRevoke, msgData *sdkws.MsgData, role int32, now time.Time) error {
+	var window int
+	switch msgData.SessionType {
+	case constant.SingleChatType:
+		if policy.DisableSingle {
+			return errs.ErrNoPermission.WrapMsg("revoking single chat messages is disabled")
+		}
+		window = policy.SenderWindow
+	case constant.ReadGroupChatType:
+		if policy.DisableGroup {
+			return errs.ErrNoPermission.WrapMsg("revoking group messages is disabled")
+		}
+		switch role {
+		case constant.GroupOwner:
+			window = policy.GroupOwnerWindow
+		case constant.GroupAdmin:
+			window = policy.GroupAdminWindow
+		default:
+			window = policy.SenderWindow
+		}
+	}
+	if window <= 0 {
+		return nil
+	}
+	deadline := time.UnixMilli(msgData.SendTime).Add(time.Duration(window) * time.Second)
+	if now.After(deadline) {
+		return servererrs.ErrMsgRevokeExpired.WrapMsg("revoke window has passed", "sendTime", msgData.SendTime, "window", window)
+	}
+	return nil
 }
@@ -0,0 +1,98 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/errs"
+)
+
+func TestCheckRevokePolicy(t *testing.T) {
+	now := time.Now()
+	sent := now.Add(-time.Minute * 5).UnixMilli()
+	policy := &config.MsgRevoke{SenderWindow: 120, GroupAdminWindow: 600, GroupOwnerWindow: 0}
+	tests := []struct {
+		name        string
+		policy      *config.MsgRevoke
+		sessionType int32
+		role        int32
+		code        int
+	}{
+		{name: "single expired", policy: policy, sessionType: constant.SingleChatType, code: servererrs.MsgRevokeExpired},
+		{name: "group member expired", policy: policy, sessionType: constant.ReadGroupChatType, role: constant.GroupOrdinaryUsers, code: servererrs.MsgRevokeExpired},
+		{name: "group admin in window", policy: policy, sessionType: constant.ReadGroupChatType, role: constant.GroupAdmin},
+		{name: "group owner no limit", policy: policy, sessionType: constant.ReadGroupChatType, role: constant.GroupOwner},
+		{name: "no window", policy: &config.MsgRevoke{}, sessionType: constant.SingleChatType},
+		{name: "single disabled", policy: &config.MsgRevoke{DisableSingle: true}, sessionType: constant.SingleChatType, code: errs.NoPermissionError},
+		{name: "group disabled", policy: &config.MsgRevoke{DisableGroup: true}, sessionType: constant.ReadGroupChatType, role: constant.GroupOwner, code: errs.NoPermissionError},
+	}
+	for _, test := range tests {
+		msgData := &sdkws.MsgData{SessionType: test.sessionType, SendTime: sent}
+		err := checkRevokePolicy(test.policy, msgData, test.role, now)
+		if test.code == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", test.name, err)
+			}
+			continue
+		}
+		codeErr, ok := errs.Unwrap(err).(errs.CodeError)
+		if !ok || codeErr.Code() != test.code {
+			t.Errorf("%s: got %v want code %d", test.name, err, test.code)
+		}
+	}
+}
+
+func TestCheckGroupRevoker(t *testing.T) {
+	members := map[string]*sdkws.GroupMemberFullInfo{
+		"owner":  {UserID: "owner", RoleLevel: constant.GroupOwner},
+		"admin":  {UserID: "admin", RoleLevel: constant.GroupAdmin},
+		"admin2": {UserID: "admin2", RoleLevel: constant.GroupAdmin},
+		"member": {UserID: "member", RoleLevel: constant.GroupOrdinaryUsers},
+	}
+	tests := []struct {
+		name     string
+		revoker  string
+		sender   string
+		role     int32
+		noAccess bool
+	}{
+		{name: "owner revokes admin", revoker: "owner", sender: "admin", role: constant.GroupOwner},
+		{name: "admin revokes member", revoker: "admin", sender: "member", role: constant.GroupAdmin},
+		{name: "admin revokes admin", revoker: "admin", sender: "admin2", noAccess: true},
+		{name: "admin revokes left member", revoker: "admin", sender: "left", role: constant.GroupAdmin},
+		{name: "member revokes member", revoker: "member", sender: "admin", noAccess: true},
+		{name: "member revokes own", revoker: "member", sender: "member", role: constant.GroupOrdinaryUsers},
+		{name: "left revoker", revoker: "left", sender: "member", noAccess: true},
+		{name: "left sender revokes own", revoker: "left", sender: "left"},
+	}
+	for _, test := range tests {
+		role, err := checkGroupRevoker(members, test.revoker, test.sender)
+		if test.noAccess {
+			if !errs.ErrNoPermission.Is(err) {
+				t.Errorf("%s: got %v want no permission", test.name, err)
+			}
+			continue
+		}
+		if err != nil || role != test.role {
+			t.Errorf("%s: got role %d error %v want role %d", test.name, role, err, test.role)
+		}
+	}
+}
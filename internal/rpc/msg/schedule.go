@@ -0,0 +1,401 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/open-im-server/v3/pkg/protocol/msgext"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/msg"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/mcontext"
+	"github.com/openimsdk/tools/utils/datautil"
+	"github.com/openimsdk/tools/utils/idutil"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultScheduleInterval  = time.Second
+	defaultScheduleBatchSize = 100
+	// scheduleLockTTL bounds how long a crashed dispatcher blocks the others, the messages are claimed one by one
+	// so a dispatch outliving the lock does not send a message twice.
+	scheduleLockTTL = time.Minute
+	// scheduleSendChunk is the number of receivers sent between two renewals of the lock and of the update time.
+	scheduleSendChunk = 100
+)
+
+// ScheduleMsg stores a message sent at its send time by the dispatcher through SendMsg, the message goes through
+// the interceptors now and is verified again when it is sent.
+func (m *msgServer) ScheduleMsg(ctx context.Context, req *msgext.ScheduleMsgReq) (*msgext.ScheduleMsgResp, error) {
+	if err := req.Check(); err != nil {
+		return nil, err
+	}
+	if err := authverify.CheckAccessV3(ctx, req.MsgData.SendID, m.config.Share.IMAdminUserID); err != nil {
+		return nil, err
+	}
+	isAdmin := authverify.IsAppManagerUid(ctx, m.config.Share.IMAdminUserID)
+	if len(req.RecvIDs) > 0 && !isAdmin {
+		return nil, errs.ErrNoPermission.WrapMsg("only app managers can schedule a msg to several receivers")
+	}
+	switch req.MsgData.SessionType {
+	case constant.SingleChatType, constant.NotificationChatType, constant.ReadGroupChatType:
+	default:
+		return nil, errs.ErrArgs.WrapMsg("unknown sessionType", "sessionType", req.MsgData.SessionType)
+	}
+	now := time.Now()
+	policy := &m.config.RpcConfig.Schedule
+	if err := checkSchedulePolicy(policy, req.SendAt, now); err != nil {
+		return nil, err
+	}
+	ownerUserID := mcontext.GetOpUserID(ctx)
+	if !isAdmin && policy.MaxPending > 0 {
+		count, err := m.scheduledMsgDatabase.CountPendingScheduledMsgs(ctx, ownerUserID)
+		if err != nil {
+			return nil, err
+		}
+		if count >= int64(policy.MaxPending) {
+			return nil, errs.ErrArgs.WrapMsg("too many pending scheduled msgs", "count", count, "max", policy.MaxPending)
+		}
+	}
+	msgData := proto.Clone(req.MsgData).(*sdkws.MsgData)
+	msgData.ServerMsgID = ""
+	msgData.Seq = 0
+	msgData.SendTime = 0
+	if msgData.ClientMsgID == "" {
+		msgData.ClientMsgID = idutil.GetMsgIDByMD5(msgData.SendID)
+	}
+	data, err := m.interceptScheduledMsg(ctx, msgData)
+	if err != nil {
+		return nil, err
+	}
+	scheduledMsg := &model.ScheduledMsg{
+		ScheduleID:  idutil.GetMsgIDByMD5(ownerUserID),
+		OwnerUserID: ownerUserID,
+		MsgData:     data,
+		RecvIDs:     datautil.Distinct(req.RecvIDs),
+		SendAt:      time.UnixMilli(req.SendAt),
+		Status:      model.ScheduledMsgPending,
+		CreateTime:  now,
+		UpdateTime:  now,
+	}
+	if err := m.scheduledMsgDatabase.CreateScheduledMsg(ctx, scheduledMsg); err != nil {
+		return nil, err
+	}
+	return &msgext.ScheduleMsgResp{ScheduleID: scheduledMsg.ScheduleID}, nil
+}
+
+func (m *msgServer) GetScheduledMsgs(ctx context.Context, req *msgext.GetScheduledMsgsReq) (*msgext.GetScheduledMsgsResp, error) {
+	if req.OwnerUserID == "" {
+		if err := authverify.CheckAdmin(ctx, m.config.Share.IMAdminUserID); err != nil {
+			return nil, err
+		}
+	} else if err := authverify.CheckAccessV3(ctx, req.OwnerUserID, m.config.Share.IMAdminUserID); err != nil {
+		return nil, err
+	}
+	total, scheduledMsgs, err := m.scheduledMsgDatabase.FindScheduledMsgs(ctx, req.OwnerUserID, req.Statuses, req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+	resp := &msgext.GetScheduledMsgsResp{Total: total, Msgs: make([]*msgext.ScheduledMsg, 0, len(scheduledMsgs))}
+	for _, scheduledMsg := range scheduledMsgs {
+		msgData, err := unmarshalScheduledMsg(scheduledMsg)
+		if err != nil {
+			return nil, err
+		}
+		resp.Msgs = append(resp.Msgs, &msgext.ScheduledMsg{
+			ScheduleID:  scheduledMsg.ScheduleID,
+			OwnerUserID: scheduledMsg.OwnerUserID,
+			MsgData:     msgData,
+			RecvIDs:     scheduledMsg.RecvIDs,
+			SendAt:      scheduledMsg.SendAt.UnixMilli(),
+			Status:      scheduledMsg.Status,
+			FailedIDs:   scheduledMsg.FailedIDs,
+			ErrMsg:      scheduledMsg.ErrMsg,
+			CreateTime:  scheduledMsg.CreateTime.UnixMilli(),
+			UpdateTime:  scheduledMsg.UpdateTime.UnixMilli(),
+			SentTime:    unixMilli(scheduledMsg.SentTime),
+		})
+	}
+	return resp, nil
+}
+
+func (m *msgServer) CancelScheduledMsg(ctx context.Context, req *msgext.CancelScheduledMsgReq) (*msgext.CancelScheduledMsgResp, error) {
+	if _, err := m.takeOwnScheduledMsg(ctx, req.ScheduleID); err != nil {
+		return nil, err
+	}
+	update := map[string]any{"status": model.ScheduledMsgCanceled, "update_time": time.Now()}
+	if err := m.updatePendingScheduledMsg(ctx, req.ScheduleID, update); err != nil {
+		return nil, err
+	}
+	return &msgext.CancelScheduledMsgResp{}, nil
+}
+
+// UpdateScheduledMsg changes the send time or the content of a pending message, the new content goes through the
+// interceptors like a scheduled message.
+func (m *msgServer) UpdateScheduledMsg(ctx context.Context, req *msgext.UpdateScheduledMsgReq) (*msgext.UpdateScheduledMsgResp, error) {
+	scheduledMsg, err := m.takeOwnScheduledMsg(ctx, req.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	update := map[string]any{"update_time": now}
+	if req.SendAt > 0 {
+		if err := checkSchedulePolicy(&m.config.RpcConfig.Schedule, req.SendAt, now); err != nil {
+			return nil, err
+		}
+		update["send_at"] = time.UnixMilli(req.SendAt)
+	}
+	if req.Content != "" {
+		msgData, err := unmarshalScheduledMsg(scheduledMsg)
+		if err != nil {
+			return nil, err
+		}
+		msgData.Content = []byte(req.Content)
+		data, err := m.interceptScheduledMsg(ctx, msgData)
+		if err != nil {
+			return nil, err
+		}
+		update["msg_data"] = data
+	}
+	if err := m.updatePendingScheduledMsg(ctx, req.ScheduleID, update); err != nil {
+		return nil, err
+	}
+	return &msgext.UpdateScheduledMsgResp{}, nil
+}
+
+// checkSchedulePolicy checks the send time of a message scheduled or updated at now.
+func checkSchedulePolicy(policy *config.MsgSchedule, sendAt int64, now time.Time) error {
+	if policy.Disable {
+		return errs.ErrNoPermission.WrapMsg("scheduling messages is disabled")
+	}
+	at := time.UnixMilli(sendAt)
+	if !at.After(now) {
+		return errs.ErrArgs.WrapMsg("sendAt must be in the future", "sendAt", sendAt)
+	}
+	if policy.MaxDelay > 0 && at.After(now.Add(time.Duration(policy.MaxDelay)*time.Second)) {
+		return errs.ErrArgs.WrapMsg("sendAt is too far in the future", "sendAt", sendAt, "maxDelay", policy.MaxDelay)
+	}
+	return nil
+}
+
+// takeOwnScheduledMsg returns a scheduled message of the operator, the app managers can access all of them.
+func (m *msgServer) takeOwnScheduledMsg(ctx context.Context, scheduleID string) (*model.ScheduledMsg, error) {
+	scheduledMsg, err := m.scheduledMsgDatabase.TakeScheduledMsg(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authverify.CheckAccessV3(ctx, scheduledMsg.OwnerUserID, m.config.Share.IMAdminUserID); err != nil {
+		return nil, err
+	}
+	return scheduledMsg, nil
+}
+
+func (m *msgServer) updatePendingScheduledMsg(ctx context.Context, scheduleID string, update map[string]any) error {
+	ok, err := m.scheduledMsgDatabase.UpdateScheduledMsg(ctx, scheduleID, model.ScheduledMsgPending, update)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return servererrs.ErrScheduledMsgNotPending.WrapMsg("scheduled msg is not pending", "scheduleID", scheduleID)
+	}
+	return nil
+}
+
+// interceptScheduledMsg runs the interceptors on the message and returns the protobuf of the intercepted message.
+func (m *msgServer) interceptScheduledMsg(ctx context.Context, msgData *sdkws.MsgData) ([]byte, error) {
+	req := &msg.SendMsgReq{MsgData: msgData}
+	if err := m.interceptMsg(ctx, req); err != nil {
+		return nil, err
+	}
+	data, err := proto.Marshal(req.MsgData)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "marshal scheduled msg failed")
+	}
+	return data, nil
+}
+
+func unmarshalScheduledMsg(scheduledMsg *model.ScheduledMsg) (*sdkws.MsgData, error) {
+	var msgData sdkws.MsgData
+	if err := proto.Unmarshal(scheduledMsg.MsgData, &msgData); err != nil {
+		return nil, errs.WrapMsg(err, "unmarshal scheduled msg failed", "scheduleID", scheduledMsg.ScheduleID)
+	}
+	return &msgData, nil
+}
+
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// dispatchScheduledMsgs sends the due messages every interval, the instance holding the dispatch lock scans them.
+func (m *msgServer) dispatchScheduledMsgs(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.ZPanic(ctx, "scheduled msg dispatch panic", errs.ErrPanic(r))
+		}
+	}()
+	interval := time.Duration(m.config.RpcConfig.Schedule.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultScheduleInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.dispatchDueScheduledMsgs(ctx)
+		}
+	}
+}
+
+func (m *msgServer) dispatchDueScheduledMsgs(ctx context.Context) {
+	token := idutil.OperationIDGenerator()
+	ok, err := m.scheduledMsgDatabase.LockDispatch(ctx, token, scheduleLockTTL)
+	if err != nil {
+		log.ZError(ctx, "lock scheduled msg dispatch failed", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	defer func() {
+		if err := m.scheduledMsgDatabase.UnlockDispatch(ctx, token); err != nil {
+			log.ZWarn(ctx, "unlock scheduled msg dispatch failed", err)
+		}
+	}()
+	batchSize := m.config.RpcConfig.Schedule.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultScheduleBatchSize
+	}
+	m.failStaleScheduledMsgs(ctx)
+	scheduledMsgs, err := m.scheduledMsgDatabase.FindDueScheduledMsgs(ctx, time.Now(), batchSize)
+	if err != nil {
+		log.ZError(ctx, "find due scheduled msgs failed", err)
+		return
+	}
+	for _, scheduledMsg := range scheduledMsgs {
+		m.sendScheduledMsg(ctx, token, scheduledMsg)
+	}
+}
+
+// failStaleScheduledMsgs fails the messages left sending by a dispatcher that stopped for longer than the lock.
+// They are not sent again since some of their receivers may already have got them.
+func (m *msgServer) failStaleScheduledMsgs(ctx context.Context) {
+	now := time.Now()
+	update := map[string]any{"status": model.ScheduledMsgFailed, "err_msg": "dispatch interrupted", "update_time": now}
+	count, err := m.scheduledMsgDatabase.UpdateStaleScheduledMsgs(ctx, model.ScheduledMsgSending, now.Add(-scheduleLockTTL), update)
+	if err != nil {
+		log.ZError(ctx, "fail stale scheduled msgs failed", err)
+		return
+	}
+	if count > 0 {
+		log.ZWarn(ctx, "failed stale scheduled msgs", nil, "count", count)
+	}
+}
+
+// sendScheduledMsg claims a pending message and sends it as its owner. The message is sent when it reaches at
+// least one of its receivers, the receivers it failed to reach are recorded. The lock and the update time are
+// renewed every chunk of receivers so that a long send is not failed as stale.
+func (m *msgServer) sendScheduledMsg(ctx context.Context, token string, scheduledMsg *model.ScheduledMsg) {
+	update := map[string]any{"status": model.ScheduledMsgSending, "update_time": time.Now()}
+	ok, err := m.scheduledMsgDatabase.UpdateScheduledMsg(ctx, scheduledMsg.ScheduleID, model.ScheduledMsgPending, update)
+	if err != nil {
+		log.ZError(ctx, "claim scheduled msg failed", err, "scheduleID", scheduledMsg.ScheduleID)
+		return
+	}
+	if !ok {
+		return
+	}
+	ctx = mcontext.SetOpUserID(mcontext.NewCtx(idutil.OperationIDGenerator()), scheduledMsg.OwnerUserID)
+	var (
+		failedIDs []string
+		lastErr   error
+		total     = 1
+	)
+	msgData, err := unmarshalScheduledMsg(scheduledMsg)
+	if err != nil {
+		lastErr = err
+	} else if len(scheduledMsg.RecvIDs) == 0 {
+		if _, err := m.SendMsg(ctx, &msg.SendMsgReq{MsgData: msgData}); err != nil {
+			lastErr = err
+			failedIDs = append(failedIDs, datautil.If(msgData.GroupID != "", msgData.GroupID, msgData.RecvID))
+		}
+	} else {
+		total = len(scheduledMsg.RecvIDs)
+		for i, recvID := range scheduledMsg.RecvIDs {
+			if i > 0 && i%scheduleSendChunk == 0 && !m.renewScheduledMsg(ctx, token, scheduledMsg.ScheduleID) {
+				return
+			}
+			data := proto.Clone(msgData).(*sdkws.MsgData)
+			data.RecvID = recvID
+			data.ClientMsgID = idutil.GetMsgIDByMD5(data.SendID)
+			if _, err := m.SendMsg(ctx, &msg.SendMsgReq{MsgData: data}); err != nil {
+				lastErr = err
+				failedIDs = append(failedIDs, recvID)
+			}
+		}
+	}
+	now := time.Now()
+	update = map[string]any{"status": model.ScheduledMsgSent, "sent_time": now, "update_time": now}
+	if lastErr != nil {
+		log.ZWarn(ctx, "send scheduled msg failed", lastErr, "scheduleID", scheduledMsg.ScheduleID, "failedIDs", len(failedIDs))
+		if len(failedIDs) == 0 || len(failedIDs) == total {
+			update["status"] = model.ScheduledMsgFailed
+		}
+		update["failed_ids"] = failedIDs
+		update["err_msg"] = lastErr.Error()
+	}
+	ok, err = m.scheduledMsgDatabase.UpdateScheduledMsg(ctx, scheduledMsg.ScheduleID, model.ScheduledMsgSending, update)
+	if err != nil {
+		log.ZError(ctx, "update scheduled msg failed", err, "scheduleID", scheduledMsg.ScheduleID)
+		return
+	}
+	if !ok {
+		log.ZWarn(ctx, "scheduled msg is no longer sending, its result is dropped", nil, "scheduleID", scheduledMsg.ScheduleID,
+			"status", update["status"], "failedIDs", len(failedIDs))
+	}
+}
+
+// renewScheduledMsg renews the dispatch lock and the update time of the message being sent, it reports whether
+// the message is still sending by this dispatcher.
+func (m *msgServer) renewScheduledMsg(ctx context.Context, token string, scheduleID string) bool {
+	ok, err := m.scheduledMsgDatabase.RenewDispatch(ctx, token, scheduleLockTTL)
+	if err != nil {
+		log.ZWarn(ctx, "renew scheduled msg dispatch failed", err, "scheduleID", scheduleID)
+	} else if !ok {
+		log.ZWarn(ctx, "scheduled msg dispatch lock lost", nil, "scheduleID", scheduleID)
+	}
+	ok, err = m.scheduledMsgDatabase.UpdateScheduledMsg(ctx, scheduleID, model.ScheduledMsgSending, map[string]any{"update_time": time.Now()})
+	if err != nil {
+		log.ZWarn(ctx, "renew scheduled msg update time failed", err, "scheduleID", scheduleID)
+		return true
+	}
+	if !ok {
+		log.ZWarn(ctx, "scheduled msg is no longer sending, the remaining receivers are skipped", nil, "scheduleID", scheduleID)
+	}
+	return ok
+}
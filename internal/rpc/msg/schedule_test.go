@@ -0,0 +1,53 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/tools/errs"
+)
+
+func TestCheckSchedulePolicy(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		policy config.MsgSchedule
+		sendAt time.Time
+		code   int
+	}{
+		{name: "future", sendAt: now.Add(time.Hour)},
+		{name: "in max delay", policy: config.MsgSchedule{MaxDelay: 7200}, sendAt: now.Add(time.Hour)},
+		{name: "beyond max delay", policy: config.MsgSchedule{MaxDelay: 60}, sendAt: now.Add(time.Hour), code: errs.ArgsError},
+		{name: "now", sendAt: now, code: errs.ArgsError},
+		{name: "past", sendAt: now.Add(-time.Minute), code: errs.ArgsError},
+		{name: "disabled", policy: config.MsgSchedule{Disable: true}, sendAt: now.Add(time.Hour), code: errs.NoPermissionError},
+	}
+	for _, test := range tests {
+		err := checkSchedulePolicy(&test.policy, test.sendAt.UnixMilli(), now)
+		if test.code == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", test.name, err)
+			}
+			continue
+		}
+		codeErr, ok := errs.Unwrap(err).(errs.CodeError)
+		if !ok || codeErr.Code() != test.code {
+			t.Errorf("%s: got %v want code %d", test.name, err, test.code)
+		}
+	}
+}
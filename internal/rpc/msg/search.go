@@ -0,0 +1,203 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
+	"github.com/openimsdk/open-im-server/v3/pkg/mq"
+	"github.com/openimsdk/open-im-server/v3/pkg/protocol/msgext"
+	"github.com/openimsdk/protocol/msg"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/mcontext"
+	"github.com/openimsdk/tools/utils/datautil"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/protobuf/proto"
+)
+
+// SearchMsg searches the messages in the search index, the app managers search all conversations
+// and the other users only the conversations they own.
+func (m *msgServer) SearchMsg(ctx context.Context, req *msgext.SearchMsgReq) (*msgext.SearchMsgResp, error) {
+	if m.msgSearchDatabase == nil {
+		return nil, errs.ErrArgs.WrapMsg("message search index is disabled")
+	}
+	query, err := m.msgSearchQuery(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if query == nil {
+		return &msgext.SearchMsgResp{}, nil
+	}
+	total, hits, err := m.msgSearchDatabase.SearchMsgs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	conversationSeqs := make(map[string][]int64)
+	for _, hit := range hits {
+		conversationSeqs[hit.ConversationID] = append(conversationSeqs[hit.ConversationID], hit.Seq)
+	}
+	msgs := make(map[string]map[int64]*msg.SearchedMsgData, len(conversationSeqs))
+	for conversationID, seqs := range conversationSeqs {
+		searchedMsgs, err := m.MsgDatabase.GetSearchedMsgs(ctx, conversationID, seqs)
+		if err != nil {
+			return nil, err
+		}
+		msgs[conversationID] = datautil.SliceToMap(searchedMsgs, func(e *msg.SearchedMsgData) int64 {
+			return e.MsgData.Seq
+		})
+	}
+	chatLogs := make([]*msg.SearchedMsgData, 0, len(hits))
+	for _, hit := range hits {
+		searchedMsg, ok := msgs[hit.ConversationID][hit.Seq]
+		if !ok {
+			// the message is indexed before it is persisted, or its deletion is not yet removed from the index,
+			// the revoke, delete and clear paths prune the index so the hit is only skipped here
+			continue
+		}
+		if searchedMsg.IsRevoked {
+			total--
+			continue
+		}
+		chatLogs = append(chatLogs, searchedMsg)
+	}
+	searchChatLogs, err := m.searchChatLogs(ctx, chatLogs)
+	if err != nil {
+		return nil, err
+	}
+	return &msgext.SearchMsgResp{ChatLogs: searchChatLogs, ChatLogsNum: int32(total)}, nil
+}
+
+// msgSearchQuery returns the query of the request, it returns nil when the user owns no conversation to search.
+func (m *msgServer) msgSearchQuery(ctx context.Context, req *msgext.SearchMsgReq) (*model.MsgSearchQuery, error) {
+	query := &model.MsgSearchQuery{
+		Keyword:      req.Keyword,
+		SendID:       req.SendID,
+		RecvID:       req.RecvID,
+		SessionType:  req.SessionType,
+		ContentTypes: req.ContentTypes,
+		StartTime:    req.StartTime,
+		EndTime:      req.EndTime,
+		Offset:       int((req.Pagination.GetPageNumber() - 1) * req.Pagination.GetShowNumber()),
+		Limit:        int(req.Pagination.GetShowNumber()),
+	}
+	if req.ContentType != 0 {
+		query.ContentTypes = append(query.ContentTypes, req.ContentType)
+	}
+	if req.SendTime != "" && req.StartTime == 0 && req.EndTime == 0 {
+		sendTime, err := time.Parse(time.DateOnly, req.SendTime)
+		if err != nil {
+			return nil, errs.ErrArgs.WrapMsg("invalid sendTime", "req", req.SendTime, "format", time.DateOnly, "cause", err.Error())
+		}
+		query.StartTime = sendTime.UnixMilli()
+		query.EndTime = sendTime.Add(time.Hour * 24).UnixMilli()
+	}
+	if req.ConversationID != "" {
+		query.ConversationIDs = []string{req.ConversationID}
+	}
+	if authverify.IsAppManagerUid(ctx, m.config.Share.IMAdminUserID) {
+		return query, nil
+	}
+	query.UserID = mcontext.GetOpUserID(ctx)
+	conversationIDs, err := m.ConversationLocalCache.GetConversationIDs(ctx, query.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ConversationID == "" {
+		if len(conversationIDs) == 0 {
+			return nil, nil
+		}
+		query.ConversationIDs = conversationIDs
+	} else if !datautil.Contain(req.ConversationID, conversationIDs...) {
+		return nil, servererrs.ErrNoPermission.WrapMsg("not the owner of the conversation", "conversationID", req.ConversationID)
+	}
+	return query, nil
+}
+
+// consumeSearchIndex indexes the messages of the mongo topic until ctx is done, the index is secondary to the
+// stored messages so its errors are only logged.
+func (m *msgServer) consumeSearchIndex(ctx context.Context, consumer mq.Consumer) {
+	err := consumer.Subscribe(ctx, func(message *mq.Message) {
+		defer message.Ack()
+		ctx, span := tracing.StartConsumer(message.Context(), "msg.SearchIndex", attribute.String("conversationID", message.Key()))
+		defer span.End()
+		var msgFromMQ msg.MsgDataToMongoByMQ
+		if err := proto.Unmarshal(message.Value(), &msgFromMQ); err != nil {
+			log.ZError(ctx, "unmarshall failed", err, "key", message.Key(), "len", len(message.Value()))
+			return
+		}
+		if err := m.msgSearchDatabase.IndexMsgs(ctx, msgFromMQ.ConversationID, msgFromMQ.MsgData); err != nil {
+			log.ZError(ctx, "index msgs failed", err, "conversationID", msgFromMQ.ConversationID)
+		}
+	})
+	if err != nil {
+		log.ZError(ctx, "search index consumer subscribe failed", err)
+	}
+}
+
+// indexSearchMsgs replaces the edited messages in the search index.
+func (m *msgServer) indexSearchMsgs(ctx context.Context, conversationID string, msgs []*sdkws.MsgData) {
+	if m.msgSearchDatabase == nil {
+		return
+	}
+	if err := m.msgSearchDatabase.IndexMsgs(ctx, conversationID, msgs); err != nil {
+		log.ZWarn(ctx, "index msgs failed", err, "conversationID", conversationID)
+	}
+}
+
+// deleteSearchIndex removes the deleted or revoked messages from the search index.
+func (m *msgServer) deleteSearchIndex(ctx context.Context, conversationID string, seqs []int64) {
+	if m.msgSearchDatabase == nil {
+		return
+	}
+	if err := m.msgSearchDatabase.DeleteMsgs(ctx, conversationID, seqs); err != nil {
+		log.ZWarn(ctx, "delete msgs from search index failed", err, "conversationID", conversationID, "seqs", seqs)
+	}
+}
+
+// clearSearchIndex removes the messages before the min seq of the cleared conversations from the search index,
+// they are only hidden from the searches of userID when it is not empty.
+func (m *msgServer) clearSearchIndex(ctx context.Context, userID string, minSeqs map[string]int64) {
+	if m.msgSearchDatabase == nil {
+		return
+	}
+	for conversationID, minSeq := range minSeqs {
+		var err error
+		if userID == "" {
+			err = m.msgSearchDatabase.DeleteMsgsBefore(ctx, conversationID, minSeq)
+		} else {
+			err = m.msgSearchDatabase.HideMsgsBefore(ctx, userID, conversationID, minSeq)
+		}
+		if err != nil {
+			log.ZWarn(ctx, "clear search index failed", err, "userID", userID, "conversationID", conversationID, "minSeq", minSeq)
+		}
+	}
+}
+
+// hideSearchIndex hides the messages deleted by userID for themselves from their searches.
+func (m *msgServer) hideSearchIndex(ctx context.Context, userID string, conversationID string, seqs []int64) {
+	if m.msgSearchDatabase == nil {
+		return
+	}
+	if err := m.msgSearchDatabase.HideMsgs(ctx, userID, conversationID, seqs); err != nil {
+		log.ZWarn(ctx, "hide msgs in search index failed", err, "userID", userID, "conversationID", conversationID, "seqs", seqs)
+	}
+}
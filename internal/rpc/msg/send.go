@@ -34,6 +34,9 @@ import (
 func (m *msgServer) SendMsg(ctx context.Context, req *pbmsg.SendMsgReq) (*pbmsg.SendMsgResp, error) {
 	if req.MsgData != nil {
 		m.encapsulateMsgData(req.MsgData)
+		if err := m.interceptMsg(ctx, req); err != nil {
+			return nil, err
+		}
 		if req.MsgData.ContentType == constant.Stream {
 			if err := m.handlerStreamMsg(ctx, req.MsgData); err != nil {
 				return nil, err
@@ -65,6 +68,9 @@ func (m *msgServer) sendMsgGroupChat(ctx context.Context, req *pbmsg.SendMsgReq)
 	if err := m.webhookBeforeMsgModify(ctx, &m.config.WebhooksConfig.BeforeMsgModify, req); err != nil {
 		return nil, err
 	}
+	if err := m.setReadReceiptOption(ctx, req.MsgData); err != nil {
+		return nil, err
+	}
 	err = m.MsgDatabase.MsgToMQ(ctx, conversationutil.GenConversationUniqueKeyForGroup(req.MsgData.GroupID), req.MsgData)
 	if err != nil {
 		return nil, err
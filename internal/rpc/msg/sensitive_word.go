@@ -0,0 +1,152 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	cbapi "github.com/openimsdk/open-im-server/v3/pkg/callbackstruct"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/open-im-server/v3/pkg/protocol/msgext"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/msg"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mcontext"
+	"github.com/openimsdk/tools/utils/datautil"
+	"google.golang.org/protobuf/proto"
+)
+
+// sensitiveTextFields are the fields of the contents holding the text typed by the users.
+var sensitiveTextFields = map[int32]string{
+	constant.Text:   "content",
+	constant.AtText: "text",
+	constant.Quote:  "text",
+}
+
+// sensitiveWordInterceptor checks the text messages with the sensitive word filter, it rejects the messages
+// containing the words of the reject lists, replaces the words of the replace lists and reports the messages
+// containing the words of the flag lists to the afterSensitiveWord webhook.
+func (m *msgServer) sensitiveWordInterceptor(ctx context.Context, _ *Config, req *msg.SendMsgReq) (*sdkws.MsgData, error) {
+	field, ok := sensitiveTextFields[req.MsgData.ContentType]
+	if !ok || isServerNotification(req.MsgData) {
+		return nil, nil
+	}
+	content := make(map[string]any)
+	decoder := json.NewDecoder(bytes.NewReader(req.MsgData.Content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&content); err != nil {
+		return nil, nil
+	}
+	text, _ := content[field].(string)
+	if text == "" {
+		return nil, nil
+	}
+	res := m.sensitiveFilter.Check(text)
+	if res.Reject {
+		return nil, servererrs.ErrSensitiveWord.WrapMsg("message contains sensitive words", "words", res.Words)
+	}
+	if res.Flag {
+		m.webhookAfterSensitiveWord(ctx, req.MsgData, text, res.Words)
+	}
+	if !res.Replaced() {
+		return nil, nil
+	}
+	content[field] = res.Text
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "marshal content failed")
+	}
+	msgData := proto.Clone(req.MsgData).(*sdkws.MsgData)
+	msgData.Content = data
+	return msgData, nil
+}
+
+func (m *msgServer) webhookAfterSensitiveWord(ctx context.Context, msgData *sdkws.MsgData, text string, words []string) {
+	cbReq := &cbapi.CallbackAfterSensitiveWordReq{
+		CallbackCommand: cbapi.CallbackAfterSensitiveWordCommand,
+		OperationID:     mcontext.GetOperationID(ctx),
+		Scene:           cbapi.SensitiveWordSceneMsg,
+		UserID:          msgData.SendID,
+		RecvID:          msgData.RecvID,
+		GroupID:         msgData.GroupID,
+		SessionType:     msgData.SessionType,
+		ContentType:     msgData.ContentType,
+		ClientMsgID:     msgData.ClientMsgID,
+		ServerMsgID:     msgData.ServerMsgID,
+		Text:            text,
+		Words:           words,
+	}
+	m.webhookClient.AsyncPost(ctx, cbReq.GetCallbackCommand(), cbReq, &cbapi.CallbackAfterSensitiveWordResp{}, &m.config.WebhooksConfig.AfterSensitiveWord)
+}
+
+func (m *msgServer) SetSensitiveWordList(ctx context.Context, req *msgext.SetSensitiveWordListReq) (*msgext.SetSensitiveWordListResp, error) {
+	if err := authverify.CheckAdmin(ctx, m.config.Share.IMAdminUserID); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	list := &model.SensitiveWordList{
+		ListID:     req.List.ListID,
+		Name:       req.List.Name,
+		Action:     req.List.Action,
+		Words:      datautil.Distinct(req.List.Words),
+		CreateTime: now,
+		UpdateTime: now,
+	}
+	if list.Words == nil {
+		list.Words = []string{}
+	}
+	if err := m.sensitiveWordDatabase.SetWordList(ctx, list); err != nil {
+		return nil, err
+	}
+	return &msgext.SetSensitiveWordListResp{}, nil
+}
+
+func (m *msgServer) DeleteSensitiveWordLists(ctx context.Context, req *msgext.DeleteSensitiveWordListsReq) (*msgext.DeleteSensitiveWordListsResp, error) {
+	if err := authverify.CheckAdmin(ctx, m.config.Share.IMAdminUserID); err != nil {
+		return nil, err
+	}
+	if err := m.sensitiveWordDatabase.DeleteWordLists(ctx, req.ListIDs); err != nil {
+		return nil, err
+	}
+	return &msgext.DeleteSensitiveWordListsResp{}, nil
+}
+
+func (m *msgServer) GetSensitiveWordLists(ctx context.Context, req *msgext.GetSensitiveWordListsReq) (*msgext.GetSensitiveWordListsResp, error) {
+	if err := authverify.CheckAdmin(ctx, m.config.Share.IMAdminUserID); err != nil {
+		return nil, err
+	}
+	lists, err := m.sensitiveWordDatabase.FindWordLists(ctx, req.ListIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &msgext.GetSensitiveWordListsResp{
+		Lists: datautil.Slice(lists, func(list *model.SensitiveWordList) *msgext.SensitiveWordList {
+			return &msgext.SensitiveWordList{
+				ListID:     list.ListID,
+				Name:       list.Name,
+				Action:     list.Action,
+				Words:      list.Words,
+				CreateTime: list.CreateTime.UnixMilli(),
+				UpdateTime: list.UpdateTime.UnixMilli(),
+			}
+		}),
+	}, nil
+}
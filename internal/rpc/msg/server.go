@@ -21,14 +21,17 @@ import (
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/redis"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database/mgo"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/webhook"
 	"github.com/openimsdk/protocol/sdkws"
-	"github.com/openimsdk/tools/db/mongoutil"
-	"github.com/openimsdk/tools/db/redisutil"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/controller"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
+	"github.com/openimsdk/open-im-server/v3/pkg/mq"
 	"github.com/openimsdk/open-im-server/v3/pkg/notification"
+	"github.com/openimsdk/open-im-server/v3/pkg/protocol/msgext"
 	"github.com/openimsdk/open-im-server/v3/pkg/rpccache"
+	"github.com/openimsdk/open-im-server/v3/pkg/sensitive"
 	"github.com/openimsdk/protocol/constant"
 	"github.com/openimsdk/protocol/conversation"
 	"github.com/openimsdk/protocol/msg"
@@ -36,6 +39,7 @@ import (
 	"google.golang.org/grpc"
 )
 
+// MessageInterceptorFunc intercepts a message before it is verified, see RegisterMessageInterceptor.
 type MessageInterceptorFunc func(ctx context.Context, globalConfig *Config, req *msg.SendMsgReq) (*sdkws.MsgData, error)
 
 // MessageInterceptorChain defines a chain of message interceptor functions.
@@ -56,9 +60,15 @@ type Config struct {
 // MsgServer encapsulates dependencies required for message handling.
 type msgServer struct {
 	msg.UnimplementedMsgServer
+	msgext.UnimplementedMsgExtServer
 	RegisterCenter         discovery.SvcDiscoveryRegistry // Service discovery registry for service registration.
 	MsgDatabase            controller.CommonMsgDatabase   // Interface for message database operations.
 	StreamMsgDatabase      controller.StreamMsgDatabase
+	msgSearchDatabase      controller.MsgSearchDatabase // Nil when the search index is disabled.
+	sensitiveWordDatabase  controller.SensitiveWordDatabase
+	msgThreadDatabase      controller.MsgThreadDatabase
+	scheduledMsgDatabase   controller.ScheduledMsgDatabase
+	sensitiveFilter        *sensitive.Filter                // Nil when the sensitive word filter is disabled.
 	UserLocalCache         *rpccache.UserLocalCache         // Local cache for user data.
 	FriendLocalCache       *rpccache.FriendLocalCache       // Local cache for friend data.
 	GroupLocalCache        *rpccache.GroupLocalCache        // Local cache for group data.
@@ -69,6 +79,7 @@ type msgServer struct {
 	config                 *Config                          // Global configuration settings.
 	webhookClient          *webhook.Client
 	conversationClient     *rpcli.ConversationClient
+	msgReadReceiptDatabase controller.MsgReadReceiptDatabase // Nil when read receipts are disabled.
 }
 
 func (m *msgServer) addInterceptorHandler(interceptorFunc ...MessageInterceptorFunc) {
@@ -77,11 +88,11 @@ func (m *msgServer) addInterceptorHandler(interceptorFunc ...MessageInterceptorF
 }
 
 func Start(ctx context.Context, config *Config, client discovery.SvcDiscoveryRegistry, server *grpc.Server) error {
-	mgocli, err := mongoutil.NewMongoDB(ctx, config.MongodbConfig.Build())
+	mgocli, err := tracing.NewMongoDB(ctx, config.MongodbConfig.Build(), &config.Share.Tracing)
 	if err != nil {
 		return err
 	}
-	rdb, err := redisutil.NewRedisClient(ctx, config.RedisConfig.Build())
+	rdb, err := tracing.NewRedisClient(ctx, config.RedisConfig.Build(), &config.Share.Tracing)
 	if err != nil {
 		return err
 	}
@@ -104,7 +115,43 @@ func Start(ctx context.Context, config *Config, client discovery.SvcDiscoveryReg
 		return err
 	}
 	seqUserCache := redis.NewSeqUserCacheRedis(rdb, seqUser)
-	msgDatabase, err := controller.NewCommonMsgDatabase(msgDocModel, msgModel, seqUserCache, seqConversationCache, &config.KafkaConfig)
+	mqBuilder, err := mq.NewBuilder(&config.KafkaConfig, rdb)
+	if err != nil {
+		return err
+	}
+	producerToRedis, err := mqBuilder.GetTopicProducer(ctx, config.KafkaConfig.ToRedisTopic)
+	if err != nil {
+		return err
+	}
+	msgDatabase := controller.NewCommonMsgDatabase(msgDocModel, msgModel, seqUserCache, seqConversationCache, producerToRedis)
+	msgSearchDatabase, err := controller.NewMsgSearchDatabase(&config.RpcConfig.SearchIndex, mgocli.GetDB())
+	if err != nil {
+		return err
+	}
+	msgThread, err := mgo.NewMsgThreadMongo(mgocli.GetDB())
+	if err != nil {
+		return err
+	}
+	scheduledMsg, err := mgo.NewScheduledMsgMongo(mgocli.GetDB())
+	if err != nil {
+		return err
+	}
+	var msgReadReceiptDatabase controller.MsgReadReceiptDatabase
+	if config.RpcConfig.ReadReceipt.Enable {
+		msgReadReceipt, err := mgo.NewMsgReadReceiptMongo(mgocli.GetDB())
+		if err != nil {
+			return err
+		}
+		msgReadReceiptDatabase = controller.NewMsgReadReceiptDatabase(msgReadReceipt, redis.NewMsgReadReceiptCacheRedis(rdb, msgReadReceipt, redis.GetRocksCacheOptions()))
+	}
+	sensitiveWord, err := mgo.NewSensitiveWordMongo(mgocli.GetDB())
+	if err != nil {
+		return err
+	}
+	sensitiveWordDatabase := controller.NewSensitiveWordDatabase(sensitiveWord, rdb)
+	sensitiveFilter, err := sensitive.Start(ctx, &config.Share.SensitiveWord, rdb, func(ctx context.Context) ([]*model.SensitiveWordList, error) {
+		return sensitiveWordDatabase.FindWordLists(ctx, nil)
+	})
 	if err != nil {
 		return err
 	}
@@ -128,20 +175,42 @@ func Start(ctx context.Context, config *Config, client discovery.SvcDiscoveryReg
 	s := &msgServer{
 		MsgDatabase:            msgDatabase,
 		StreamMsgDatabase:      controller.NewStreamMsgDatabase(streamMsg),
+		msgSearchDatabase:      msgSearchDatabase,
+		sensitiveWordDatabase:  sensitiveWordDatabase,
+		msgThreadDatabase:      controller.NewMsgThreadDatabase(msgThread, msgDocModel, msgModel),
+		scheduledMsgDatabase:   controller.NewScheduledMsgDatabase(scheduledMsg, rdb),
+		msgReadReceiptDatabase: msgReadReceiptDatabase,
+		sensitiveFilter:        sensitiveFilter,
 		RegisterCenter:         client,
 		UserLocalCache:         rpccache.NewUserLocalCache(rpcli.NewUserClient(userConn), &config.LocalCacheConfig, rdb),
 		GroupLocalCache:        rpccache.NewGroupLocalCache(rpcli.NewGroupClient(groupConn), &config.LocalCacheConfig, rdb),
 		ConversationLocalCache: rpccache.NewConversationLocalCache(conversationClient, &config.LocalCacheConfig, rdb),
 		FriendLocalCache:       rpccache.NewFriendLocalCache(rpcli.NewRelationClient(friendConn), &config.LocalCacheConfig, rdb),
 		config:                 config,
-		webhookClient:          webhook.NewWebhookClient(config.WebhooksConfig.URL),
+		webhookClient:          webhook.NewWebhookClient(&config.WebhooksConfig, rdb),
 		conversationClient:     conversationClient,
 	}
 
+	s.addInterceptorHandler(builtinInterceptors(config)...)
+	s.addInterceptorHandler(s.threadInterceptor)
+	s.addInterceptorHandler(registeredInterceptors...)
+	if s.sensitiveFilter != nil {
+		s.addInterceptorHandler(s.sensitiveWordInterceptor)
+	}
 	s.notificationSender = rpcclient.NewNotificationSender(&config.NotificationConfig, rpcclient.WithLocalSendMsg(s.SendMsg))
 	s.msgNotificationSender = NewMsgNotificationSender(config, rpcclient.WithLocalSendMsg(s.SendMsg))
 
+	go s.dispatchScheduledMsgs(context.Background())
+	if s.msgSearchDatabase != nil {
+		consumer, err := mqBuilder.GetTopicConsumer(ctx, config.KafkaConfig.ToMongoTopic, config.KafkaConfig.ToMsgSearchGroupID)
+		if err != nil {
+			return err
+		}
+		go s.consumeSearchIndex(context.Background(), consumer)
+	}
+
 	msg.RegisterMsgServer(server, s)
+	msgext.RegisterMsgExtServer(server, s)
 
 	return nil
 }
@@ -154,7 +154,15 @@ func (m *msgServer) SearchMessage(ctx context.Context, req *msg.SearchMessageReq
 	if total, chatLogs, err = m.MsgDatabase.SearchMessage(ctx, req); err != nil {
 		return nil, err
 	}
+	if resp.ChatLogs, err = m.searchChatLogs(ctx, chatLogs); err != nil {
+		return nil, err
+	}
+	resp.ChatLogsNum = int32(total)
+	return resp, nil
+}
 
+// searchChatLogs fills the searched messages with the nicknames of the users and the information of the groups.
+func (m *msgServer) searchChatLogs(ctx context.Context, chatLogs []*msg.SearchedMsgData) ([]*msg.SearchChatLog, error) {
 	var (
 		sendIDs  []string
 		recvIDs  []string
@@ -214,6 +222,7 @@ func (m *msgServer) SearchMessage(ctx context.Context, req *msg.SearchMessageReq
 	}
 
 	// Construct response with updated information
+	var searchChatLogs []*msg.SearchChatLog
 	for _, chatLog := range chatLogs {
 		pbchatLog := &msg.ChatLog{}
 		datautil.CopyStructFields(pbchatLog, chatLog.MsgData)
@@ -236,10 +245,9 @@ func (m *msgServer) SearchMessage(ctx context.Context, req *msg.SearchMessageReq
 		}
 		searchChatLog := &msg.SearchChatLog{ChatLog: pbchatLog, IsRevoked: chatLog.IsRevoked}
 
-		resp.ChatLogs = append(resp.ChatLogs, searchChatLog)
+		searchChatLogs = append(searchChatLogs, searchChatLog)
 	}
-	resp.ChatLogsNum = int32(total)
-	return resp, nil
+	return searchChatLogs, nil
 }
 
 func (m *msgServer) GetServerTime(ctx context.Context, _ *msg.GetServerTimeReq) (*msg.GetServerTimeResp, error) {
@@ -0,0 +1,103 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+	"github.com/openimsdk/open-im-server/v3/pkg/protocol/msgext"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/msg"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/errs"
+)
+
+// threadInterceptor checks the root message of a thread reply, the replies are group messages whose attached
+// info has the seq of a root message of the group, and a reply can not be a root.
+func (m *msgServer) threadInterceptor(ctx context.Context, _ *Config, req *msg.SendMsgReq) (*sdkws.MsgData, error) {
+	rootSeq := msgprocessor.GetThreadRootSeq(req.MsgData.AttachedInfo)
+	if rootSeq == 0 || isServerNotification(req.MsgData) {
+		return nil, nil
+	}
+	if rootSeq < 0 {
+		return nil, errs.ErrArgs.WrapMsg("threadRootSeq is invalid", "threadRootSeq", rootSeq)
+	}
+	if req.MsgData.SessionType != constant.ReadGroupChatType {
+		return nil, errs.ErrArgs.WrapMsg("thread replies are only supported in groups", "sessionType", req.MsgData.SessionType)
+	}
+	conversationID := msgprocessor.GetConversationIDByMsg(req.MsgData)
+	_, _, msgs, err := m.MsgDatabase.GetMsgBySeqs(ctx, req.MsgData.SendID, conversationID, []int64{rootSeq})
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 || msgs[0] == nil || msgs[0].Status == constant.MsgDeleted || msgs[0].Status == constant.MsgStatusHasDeleted {
+		return nil, errs.ErrRecordNotFound.WrapMsg("thread root msg not found", "threadRootSeq", rootSeq)
+	}
+	root := msgs[0]
+	if root.ContentType == constant.MsgRevokeNotification {
+		return nil, servererrs.ErrMsgAlreadyRevoke.WrapMsg("thread root msg already revoke")
+	}
+	if isServerNotification(root) {
+		return nil, errs.ErrArgs.WrapMsg("a notification can not be a thread root", "contentType", root.ContentType)
+	}
+	if msgprocessor.GetThreadRootSeq(root.AttachedInfo) != 0 {
+		return nil, errs.ErrArgs.WrapMsg("a thread reply can not be a thread root", "threadRootSeq", rootSeq)
+	}
+	return nil, nil
+}
+
+// GetThreadReplies pages the replies of a thread from the thread records of a conversation of the user, the messages
+// are read by seqs so that the conversation is not scanned.
+func (m *msgServer) GetThreadReplies(ctx context.Context, req *msgext.GetThreadRepliesReq) (*msgext.GetThreadRepliesResp, error) {
+	if err := req.Check(); err != nil {
+		return nil, err
+	}
+	if err := authverify.CheckAccessV3(ctx, req.UserID, m.config.Share.IMAdminUserID); err != nil {
+		return nil, err
+	}
+	if !msgprocessor.IsGroupConversationID(req.ConversationID) {
+		return nil, errs.ErrArgs.WrapMsg("threads are only supported in groups", "conversationID", req.ConversationID)
+	}
+	if err := m.verifyConversationAccess(ctx, req.UserID, req.ConversationID); err != nil {
+		return nil, err
+	}
+	seqs, err := m.msgThreadDatabase.FindReplySeqs(ctx, req.ConversationID, req.RootSeq, req.StartSeq, int(req.Count), req.Desc)
+	if err != nil {
+		return nil, err
+	}
+	resp := &msgext.GetThreadRepliesResp{Msgs: []*sdkws.MsgData{}, IsEnd: len(seqs) < int(req.Count)}
+	if len(seqs) == 0 {
+		return resp, nil
+	}
+	_, _, msgs, err := m.MsgDatabase.GetMsgBySeqs(ctx, req.UserID, req.ConversationID, seqs)
+	if err != nil {
+		return nil, err
+	}
+	seqMsgs := make(map[int64]*sdkws.MsgData, len(msgs))
+	for _, msgData := range msgs {
+		if msgData != nil {
+			seqMsgs[msgData.Seq] = msgData
+		}
+	}
+	for _, seq := range seqs {
+		if msgData, ok := seqMsgs[seq]; ok {
+			resp.Msgs = append(resp.Msgs, msgData)
+		}
+	}
+	return resp, nil
+}
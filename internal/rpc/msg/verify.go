@@ -62,24 +62,7 @@ func (m *msgServer) messageVerification(ctx context.Context, data *msg.SendMsgRe
 		if err := m.webhookBeforeSendSingleMsg(ctx, &m.config.WebhooksConfig.BeforeSendSingleMsg, data); err != nil {
 			return err
 		}
-		black, err := m.FriendLocalCache.IsBlack(ctx, data.MsgData.SendID, data.MsgData.RecvID)
-		if err != nil {
-			return err
-		}
-		if black {
-			return servererrs.ErrBlockedByPeer.Wrap()
-		}
-		if m.config.RpcConfig.FriendVerify {
-			friend, err := m.FriendLocalCache.IsFriend(ctx, data.MsgData.SendID, data.MsgData.RecvID)
-			if err != nil {
-				return err
-			}
-			if !friend {
-				return servererrs.ErrNotPeersFriend.Wrap()
-			}
-			return nil
-		}
-		return nil
+		return m.verifyPeer(ctx, data.MsgData.SendID, data.MsgData.RecvID)
 	case constant.ReadGroupChatType:
 		groupInfo, err := m.GroupLocalCache.GetGroupInfo(ctx, data.MsgData.GroupID)
 		if err != nil {
@@ -100,35 +83,77 @@ func (m *msgServer) messageVerification(ctx context.Context, data *msg.SendMsgRe
 			data.MsgData.ContentType >= constant.NotificationBegin {
 			return nil
 		}
-		memberIDs, err := m.GroupLocalCache.GetGroupMemberIDMap(ctx, data.MsgData.GroupID)
+		return m.verifyGroupMember(ctx, groupInfo, data.MsgData.SendID)
+	default:
+		return nil
+	}
+}
+
+// verifyPeer checks that sendID is not blocked by recvID and that they are friends when friend verification is on.
+func (m *msgServer) verifyPeer(ctx context.Context, sendID string, recvID string) error {
+	black, err := m.FriendLocalCache.IsBlack(ctx, sendID, recvID)
+	if err != nil {
+		return err
+	}
+	if black {
+		return servererrs.ErrBlockedByPeer.Wrap()
+	}
+	if m.config.RpcConfig.FriendVerify {
+		friend, err := m.FriendLocalCache.IsFriend(ctx, sendID, recvID)
 		if err != nil {
 			return err
 		}
-		if _, ok := memberIDs[data.MsgData.SendID]; !ok {
-			return servererrs.ErrNotInGroupYet.Wrap()
+		if !friend {
+			return servererrs.ErrNotPeersFriend.Wrap()
 		}
+		return nil
+	}
+	return nil
+}
 
-		groupMemberInfo, err := m.GroupLocalCache.GetGroupMember(ctx, data.MsgData.GroupID, data.MsgData.SendID)
-		if err != nil {
-			if errs.ErrRecordNotFound.Is(err) {
-				return servererrs.ErrNotInGroupYet.WrapMsg(err.Error())
-			}
-			return err
+// verifyConversationAccess checks that the conversation belongs to userID like pulling its messages does, the app
+// managers access every conversation.
+func (m *msgServer) verifyConversationAccess(ctx context.Context, userID string, conversationID string) error {
+	if datautil.Contain(userID, m.config.Share.IMAdminUserID...) {
+		return nil
+	}
+	if _, err := m.ConversationLocalCache.GetConversation(ctx, userID, conversationID); err != nil {
+		if errs.ErrRecordNotFound.Is(err) {
+			return errs.ErrNoPermission.WrapMsg("not in the conversation", "userID", userID, "conversationID", conversationID)
 		}
-		if groupMemberInfo.RoleLevel == constant.GroupOwner {
-			return nil
-		} else {
-			if groupMemberInfo.MuteEndTime >= time.Now().UnixMilli() {
-				return servererrs.ErrMutedInGroup.Wrap()
-			}
-			if groupInfo.Status == constant.GroupStatusMuted && groupMemberInfo.RoleLevel != constant.GroupAdmin {
-				return servererrs.ErrMutedGroup.Wrap()
-			}
+		return err
+	}
+	return nil
+}
+
+// verifyGroupMember checks that userID is a member of the group who is not muted.
+func (m *msgServer) verifyGroupMember(ctx context.Context, groupInfo *sdkws.GroupInfo, userID string) error {
+	memberIDs, err := m.GroupLocalCache.GetGroupMemberIDMap(ctx, groupInfo.GroupID)
+	if err != nil {
+		return err
+	}
+	if _, ok := memberIDs[userID]; !ok {
+		return servererrs.ErrNotInGroupYet.Wrap()
+	}
+
+	groupMemberInfo, err := m.GroupLocalCache.GetGroupMember(ctx, groupInfo.GroupID, userID)
+	if err != nil {
+		if errs.ErrRecordNotFound.Is(err) {
+			return servererrs.ErrNotInGroupYet.WrapMsg(err.Error())
 		}
+		return err
+	}
+	if groupMemberInfo.RoleLevel == constant.GroupOwner {
 		return nil
-	default:
-		return nil
+	} else {
+		if groupMemberInfo.MuteEndTime >= time.Now().UnixMilli() {
+			return servererrs.ErrMutedInGroup.Wrap()
+		}
+		if groupInfo.Status == constant.GroupStatusMuted && groupMemberInfo.RoleLevel != constant.GroupAdmin {
+			return servererrs.ErrMutedGroup.Wrap()
+		}
 	}
+	return nil
 }
 
 func (m *msgServer) encapsulateMsgData(msg *sdkws.MsgData) {
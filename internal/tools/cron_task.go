@@ -4,12 +4,11 @@ import (
 	"context"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/configsource"
 	kdisc "github.com/openimsdk/open-im-server/v3/pkg/common/discovery"
-	disetcd "github.com/openimsdk/open-im-server/v3/pkg/common/discovery/etcd"
 	pbconversation "github.com/openimsdk/protocol/conversation"
 	"github.com/openimsdk/protocol/msg"
 	"github.com/openimsdk/protocol/third"
-	"github.com/openimsdk/tools/discovery/etcd"
 
 	"github.com/openimsdk/tools/mcontext"
 	"github.com/openimsdk/tools/mw"
@@ -27,6 +26,7 @@ type CronTaskConfig struct {
 	Share     config.Share
 	Discovery config.Discovery
 
+	ConfigPath string
 	runTimeEnv string
 }
 
@@ -59,13 +59,13 @@ func Start(ctx context.Context, conf *CronTaskConfig) error {
 		return err
 	}
 
-	if conf.Discovery.Enable == config.ETCD {
-		cm := disetcd.NewConfigManager(client.(*etcd.SvcDiscoveryRegistryImpl).GetClient(), []string{
-			conf.CronTask.GetConfigFileName(),
-			conf.Share.GetConfigFileName(),
-			conf.Discovery.GetConfigFileName(),
-		})
-		cm.Watch(ctx)
+	err = configsource.Watch(ctx, &conf.Discovery, conf.runTimeEnv, conf.ConfigPath, client, []string{
+		conf.CronTask.GetConfigFileName(),
+		conf.Share.GetConfigFileName(),
+		conf.Discovery.GetConfigFileName(),
+	})
+	if err != nil {
+		log.ZWarn(ctx, "watch config failed", err)
 	}
 
 	srv := &cronServer{
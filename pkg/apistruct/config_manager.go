@@ -1,5 +1,7 @@
 package apistruct
 
+import "github.com/openimsdk/open-im-server/v3/pkg/common/config"
+
 type GetConfigReq struct {
 	ConfigName string `json:"configName"`
 }
@@ -22,3 +24,35 @@ type SetEnableConfigManagerReq struct {
 type GetEnableConfigManagerResp struct {
 	Enable bool `json:"enable"`
 }
+
+type GetConfigHistoryReq struct {
+	// ConfigName filters the records, all of them are returned when it is empty.
+	ConfigName string `json:"configName"`
+	Offset     int    `json:"offset"`
+	Count      int    `json:"count"`
+}
+
+type ConfigChange struct {
+	ConfigName string               `json:"configName"`
+	Diff       []config.FieldChange `json:"diff"`
+}
+
+type ConfigRecord struct {
+	Version         int64           `json:"version"`
+	Action          string          `json:"action"`
+	UserID          string          `json:"userID"`
+	RollbackVersion int64           `json:"rollbackVersion,omitempty"`
+	CreateTime      int64           `json:"createTime"`
+	Changes         []*ConfigChange `json:"changes"`
+}
+
+type GetConfigHistoryResp struct {
+	Total   int             `json:"total"`
+	Records []*ConfigRecord `json:"records"`
+}
+
+// RollbackConfigReq restores the configs to their state at Version, the configs changed after Version are written
+// back as they were in the newest version up to Version holding them.
+type RollbackConfigReq struct {
+	Version int64 `json:"version"`
+}
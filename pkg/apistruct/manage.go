@@ -102,6 +102,23 @@ type BatchSendMsgReq struct {
 	RecvIDs []string `json:"recvIDs" binding:"required"`
 }
 
+// ScheduleMsgReq defines the structure for scheduling a message sent later to one or multiple recipients.
+type ScheduleMsgReq struct {
+	SendMsg
+
+	// RecvID uniquely identifies the receiver of a one-on-one or notification chat message.
+	RecvID string `json:"recvID"`
+
+	// IsSendAll indicates whether the message should be sent to all users, only allowed to app managers.
+	IsSendAll bool `json:"isSendAll"`
+
+	// RecvIDs is a slice of receiver identifiers to whom the message will be sent, only allowed to app managers.
+	RecvIDs []string `json:"recvIDs"`
+
+	// SendAt is the timestamp in milliseconds at which the message will be sent.
+	SendAt int64 `json:"sendAt" binding:"required"`
+}
+
 // BatchSendMsgResp contains the results of a batch message send operation.
 type BatchSendMsgResp struct {
 	// Results is a slice of SingleReturnResult, representing the outcome of each message sent.
@@ -0,0 +1,43 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package callbackstruct
+
+// Scenes of CallbackAfterSensitiveWordReq.
+const (
+	SensitiveWordSceneMsg               = "msg"
+	SensitiveWordSceneGroupName         = "groupName"
+	SensitiveWordSceneGroupNotification = "groupNotification"
+)
+
+// CallbackAfterSensitiveWordReq reports a text containing the words of the flag lists, the text is not changed.
+type CallbackAfterSensitiveWordReq struct {
+	CallbackCommand `json:"callbackCommand"`
+	OperationID     string `json:"operationID"`
+	Scene           string `json:"scene"`
+	// UserID is the sender of the message or the user setting the group info.
+	UserID      string   `json:"userID"`
+	RecvID      string   `json:"recvID"`
+	GroupID     string   `json:"groupID"`
+	SessionType int32    `json:"sessionType"`
+	ContentType int32    `json:"contentType"`
+	ClientMsgID string   `json:"clientMsgID"`
+	ServerMsgID string   `json:"serverMsgID"`
+	Text        string   `json:"text"`
+	Words       []string `json:"words"`
+}
+
+type CallbackAfterSensitiveWordResp struct {
+	CommonCallbackResp
+}
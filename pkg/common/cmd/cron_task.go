@@ -42,6 +42,7 @@ func NewCronTaskCmd() *CronTaskCmd {
 	ret.RootCmd = NewRootCmd(program.GetProcessName(), WithConfigMap(ret.configMap))
 	ret.ctx = context.WithValue(context.Background(), "version", version.Version)
 	ret.Command.RunE = func(cmd *cobra.Command, args []string) error {
+		cronTaskConfig.ConfigPath = ret.configPath
 		return ret.runE()
 	}
 	return ret
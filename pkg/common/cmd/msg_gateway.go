@@ -45,6 +45,7 @@ func NewMsgGatewayCmd() *MsgGatewayCmd {
 	ret.RootCmd = NewRootCmd(program.GetProcessName(), WithConfigMap(ret.configMap))
 	ret.ctx = context.WithValue(context.Background(), "version", version.Version)
 	ret.Command.RunE = func(cmd *cobra.Command, args []string) error {
+		msgGatewayConfig.ConfigPath = ret.configPath
 		return ret.runE()
 	}
 	return ret
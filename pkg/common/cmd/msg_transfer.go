@@ -46,6 +46,7 @@ func NewMsgTransferCmd() *MsgTransferCmd {
 	ret.RootCmd = NewRootCmd(program.GetProcessName(), WithConfigMap(ret.configMap))
 	ret.ctx = context.WithValue(context.Background(), "version", version.Version)
 	ret.Command.RunE = func(cmd *cobra.Command, args []string) error {
+		msgTransferConfig.ConfigPath = ret.configPath
 		return ret.runE()
 	}
 	return ret
@@ -15,7 +15,15 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mcontext"
 	"github.com/spf13/cobra"
 )
 
@@ -35,6 +43,11 @@ func (m *MsgUtilsCmd) AddConfigDirFlag() {
 
 }
 
+func (m *MsgUtilsCmd) getConfigDirFlag(cmdLines *cobra.Command) string {
+	configDir, _ := cmdLines.Flags().GetString(config.FlagConf)
+	return configDir
+}
+
 func (m *MsgUtilsCmd) getUserIDFlag(cmdLines *cobra.Command) string {
 	userID, _ := cmdLines.Flags().GetString("userID")
 	return userID
@@ -44,19 +57,28 @@ func (m *MsgUtilsCmd) AddFixAllFlag() {
 	m.Command.PersistentFlags().BoolP("fixAll", "f", false, "openIM fix all seqs")
 }
 
-/* func (m *MsgUtilsCmd) getFixAllFlag(cmdLines *cobra.Command) bool {
+func (m *MsgUtilsCmd) getFixAllFlag(cmdLines *cobra.Command) bool {
 	fixAll, _ := cmdLines.Flags().GetBool("fixAll")
 	return fixAll
-} */
+}
 
 func (m *MsgUtilsCmd) AddClearAllFlag() {
 	m.Command.PersistentFlags().BoolP("clearAll", "", false, "openIM clear all seqs")
 }
 
-/* func (m *MsgUtilsCmd) getClearAllFlag(cmdLines *cobra.Command) bool {
+func (m *MsgUtilsCmd) getClearAllFlag(cmdLines *cobra.Command) bool {
 	clearAll, _ := cmdLines.Flags().GetBool("clearAll")
 	return clearAll
-} */
+}
+
+func (m *MsgUtilsCmd) AddYesFlag() {
+	m.Command.PersistentFlags().BoolP("yes", "y", false, "confirm clearing the messages of all conversations")
+}
+
+func (m *MsgUtilsCmd) getYesFlag(cmdLines *cobra.Command) bool {
+	yes, _ := cmdLines.Flags().GetBool("yes")
+	return yes
+}
 
 func (m *MsgUtilsCmd) AddSuperGroupIDFlag() {
 	m.Command.PersistentFlags().StringP("superGroupID", "g", "", "openIM superGroupID")
@@ -71,19 +93,19 @@ func (m *MsgUtilsCmd) AddBeginSeqFlag() {
 	m.Command.PersistentFlags().Int64P("beginSeq", "b", 0, "openIM beginSeq")
 }
 
-/* func (m *MsgUtilsCmd) getBeginSeqFlag(cmdLines *cobra.Command) int64 {
+func (m *MsgUtilsCmd) getBeginSeqFlag(cmdLines *cobra.Command) int64 {
 	beginSeq, _ := cmdLines.Flags().GetInt64("beginSeq")
 	return beginSeq
-} */
+}
 
 func (m *MsgUtilsCmd) AddLimitFlag() {
 	m.Command.PersistentFlags().Int64P("limit", "l", 0, "openIM limit")
 }
 
-/* func (m *MsgUtilsCmd) getLimitFlag(cmdLines *cobra.Command) int64 {
+func (m *MsgUtilsCmd) getLimitFlag(cmdLines *cobra.Command) int64 {
 	limit, _ := cmdLines.Flags().GetInt64("limit")
 	return limit
-} */
+}
 
 func (m *MsgUtilsCmd) Execute() error {
 	return m.Command.Execute()
@@ -140,15 +162,43 @@ func NewSeqCmd() *SeqCmd {
 	return seqCmd
 }
 
+// GetSeqCmd prints the seq range cached in redis and the seq range actually stored in mongo.
 func (s *SeqCmd) GetSeqCmd() *cobra.Command {
-	s.Command.Run = func(cmdLines *cobra.Command, args []string) {
-
-	}
-	return &s.Command
+	return s.newSubCmd("get the min and max seq of conversations", func(ctx context.Context, tool *msgTool, cmdLines *cobra.Command) error {
+		conversationIDs, err := tool.getConversationIDs(ctx, s.getUserIDFlag(cmdLines), s.getSuperGroupIDFlag(cmdLines), false)
+		if err != nil {
+			return err
+		}
+		for _, conversationID := range conversationIDs {
+			seq, err := tool.getSeq(ctx, conversationID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("conversationID: %s, cache minSeq: %d, cache maxSeq: %d, mongo minSeq: %d, mongo maxSeq: %d\n",
+				conversationID, seq.minSeqCache, seq.maxSeqCache, seq.minSeqMongo, seq.maxSeqMongo)
+		}
+		return nil
+	})
 }
 
+// FixSeqCmd raises the max seq of conversations whose allocated seq has fallen behind the messages stored in mongo.
 func (s *SeqCmd) FixSeqCmd() *cobra.Command {
-	return &s.Command
+	return s.newSubCmd("fix the max seq of conversations", func(ctx context.Context, tool *msgTool, cmdLines *cobra.Command) error {
+		conversationIDs, err := tool.getConversationIDs(ctx, s.getUserIDFlag(cmdLines), s.getSuperGroupIDFlag(cmdLines), s.getFixAllFlag(cmdLines))
+		if err != nil {
+			return err
+		}
+		for _, conversationID := range conversationIDs {
+			fixed, err := tool.fixSeq(ctx, conversationID)
+			if err != nil {
+				return err
+			}
+			if fixed != nil {
+				fmt.Printf("conversationID: %s, maxSeq fixed from %d to %d\n", conversationID, fixed.maxSeqCache, fixed.maxSeqMongo)
+			}
+		}
+		return nil
+	})
 }
 
 type MsgCmd struct {
@@ -162,10 +212,97 @@ func NewMsgCmd() *MsgCmd {
 	return msgCmd
 }
 
+// GetMsgCmd prints the messages in [beginSeq, beginSeq+limit) of conversations.
 func (m *MsgCmd) GetMsgCmd() *cobra.Command {
-	return &m.Command
+	return m.newSubCmd("get the messages of conversations", func(ctx context.Context, tool *msgTool, cmdLines *cobra.Command) error {
+		seqs, err := getSeqRange(m.getBeginSeqFlag(cmdLines), m.getLimitFlag(cmdLines))
+		if err != nil {
+			return err
+		}
+		userID := m.getUserIDFlag(cmdLines)
+		conversationIDs, err := tool.getConversationIDs(ctx, userID, m.getSuperGroupIDFlag(cmdLines), false)
+		if err != nil {
+			return err
+		}
+		for _, conversationID := range conversationIDs {
+			_, _, msgs, err := tool.msgDatabase.GetMsgBySeqs(ctx, userID, conversationID, seqs)
+			if err != nil {
+				return err
+			}
+			for _, msg := range msgs {
+				data, err := json.Marshal(msg)
+				if err != nil {
+					return errs.Wrap(err)
+				}
+				fmt.Printf("conversationID: %s, seq: %d, msg: %s\n", conversationID, msg.Seq, string(data))
+			}
+		}
+		return nil
+	})
 }
 
+// ClearMsgCmd physically deletes the messages in [beginSeq, beginSeq+limit) of conversations,
+// with clearAll all stored messages are deleted and the min seq is moved past the max seq.
+// Clearing all conversations, without userID or superGroupID, must be confirmed with yes.
 func (m *MsgCmd) ClearMsgCmd() *cobra.Command {
-	return &m.Command
+	return m.newSubCmd("clear the messages of conversations", func(ctx context.Context, tool *msgTool, cmdLines *cobra.Command) error {
+		clearAll := m.getClearAllFlag(cmdLines)
+		if clearAll && m.getUserIDFlag(cmdLines) == "" && m.getSuperGroupIDFlag(cmdLines) == "" && !m.getYesFlag(cmdLines) {
+			return errs.ErrArgs.WrapMsg("clearing the messages of all conversations needs --yes")
+		}
+		var seqs []int64
+		if !clearAll {
+			var err error
+			seqs, err = getSeqRange(m.getBeginSeqFlag(cmdLines), m.getLimitFlag(cmdLines))
+			if err != nil {
+				return err
+			}
+		}
+		conversationIDs, err := tool.getConversationIDs(ctx, m.getUserIDFlag(cmdLines), m.getSuperGroupIDFlag(cmdLines), clearAll)
+		if err != nil {
+			return err
+		}
+		for _, conversationID := range conversationIDs {
+			if clearAll {
+				err = tool.clearAllMsg(ctx, conversationID)
+			} else {
+				err = tool.msgDatabase.DeleteMsgsPhysicalBySeqs(ctx, conversationID, seqs)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Printf("conversationID: %s, msg cleared\n", conversationID)
+		}
+		return nil
+	})
+}
+
+func (m *MsgUtilsCmd) newSubCmd(short string, fn func(ctx context.Context, tool *msgTool, cmdLines *cobra.Command) error) *cobra.Command {
+	return &cobra.Command{
+		Use:          m.Command.Use,
+		Short:        short,
+		SilenceUsage: true,
+		RunE: func(cmdLines *cobra.Command, args []string) error {
+			ctx := mcontext.SetOperationID(context.Background(), "cmdutils"+strconv.FormatInt(time.Now().UnixMilli(), 10))
+			tool, err := newMsgTool(ctx, m.getConfigDirFlag(cmdLines))
+			if err != nil {
+				return err
+			}
+			return fn(ctx, tool, cmdLines)
+		},
+	}
+}
+
+func getSeqRange(beginSeq int64, limit int64) ([]int64, error) {
+	if beginSeq <= 0 {
+		return nil, errs.ErrArgs.WrapMsg("beginSeq must be greater than 0")
+	}
+	if limit <= 0 {
+		return nil, errs.ErrArgs.WrapMsg("limit must be greater than 0")
+	}
+	seqs := make([]int64, 0, limit)
+	for seq := beginSeq; seq < beginSeq+limit; seq++ {
+		seqs = append(seqs, seq)
+	}
+	return seqs, nil
 }
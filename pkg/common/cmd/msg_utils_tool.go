@@ -0,0 +1,166 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/redis"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/controller"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database/mgo"
+	"github.com/openimsdk/open-im-server/v3/pkg/mq"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/tools/db/mongoutil"
+	"github.com/openimsdk/tools/db/redisutil"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/utils/runtimeenv"
+)
+
+type msgToolConfig struct {
+	RedisConfig   config.Redis
+	MongodbConfig config.Mongo
+	KafkaConfig   config.Kafka
+}
+
+// msgTool holds the storage used by the get/fix/clear sub commands of openim-cmdutils.
+type msgTool struct {
+	msgDatabase          controller.CommonMsgDatabase
+	conversationDatabase database.Conversation
+}
+
+type conversationSeq struct {
+	minSeqMongo int64
+	maxSeqMongo int64
+	minSeqCache int64
+	maxSeqCache int64
+}
+
+func newMsgTool(ctx context.Context, configDir string) (*msgTool, error) {
+	var conf msgToolConfig
+	runtimeEnv := runtimeenv.PrintRuntimeEnvironment()
+	configMap := map[string]any{
+		config.RedisConfigFileName:   &conf.RedisConfig,
+		config.MongodbConfigFileName: &conf.MongodbConfig,
+		config.KafkaConfigFileName:   &conf.KafkaConfig,
+	}
+	for configFileName, configStruct := range configMap {
+		if err := config.Load(configDir, configFileName, config.EnvPrefixMap[configFileName], runtimeEnv, configStruct); err != nil {
+			return nil, err
+		}
+	}
+	mgocli, err := mongoutil.NewMongoDB(ctx, conf.MongodbConfig.Build())
+	if err != nil {
+		return nil, err
+	}
+	rdb, err := redisutil.NewRedisClient(ctx, conf.RedisConfig.Build())
+	if err != nil {
+		return nil, err
+	}
+	msgDocModel, err := mgo.NewMsgMongo(mgocli.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	seqConversation, err := mgo.NewSeqConversationMongo(mgocli.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	seqUser, err := mgo.NewSeqUserMongo(mgocli.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	conversationDB, err := mgo.NewConversationMongo(mgocli.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	mqBuilder, err := mq.NewBuilder(&conf.KafkaConfig, rdb)
+	if err != nil {
+		return nil, err
+	}
+	producerToRedis, err := mqBuilder.GetTopicProducer(ctx, conf.KafkaConfig.ToRedisTopic)
+	if err != nil {
+		return nil, err
+	}
+	msgDatabase := controller.NewCommonMsgDatabase(msgDocModel, redis.NewMsgCache(rdb, msgDocModel),
+		redis.NewSeqUserCacheRedis(rdb, seqUser), redis.NewSeqConversationCacheRedis(rdb, seqConversation), producerToRedis)
+	return &msgTool{
+		msgDatabase:          msgDatabase,
+		conversationDatabase: conversationDB,
+	}, nil
+}
+
+// getConversationIDs resolves the conversations selected by the command line flags,
+// superGroupID takes precedence over userID, and all conversations are returned only when all is set.
+func (t *msgTool) getConversationIDs(ctx context.Context, userID string, superGroupID string, all bool) ([]string, error) {
+	switch {
+	case superGroupID != "":
+		return []string{msgprocessor.GetConversationIDBySessionType(constant.ReadGroupChatType, superGroupID)}, nil
+	case userID != "":
+		return t.conversationDatabase.FindUserIDAllConversationID(ctx, userID)
+	case all:
+		return t.conversationDatabase.GetAllConversationIDs(ctx)
+	default:
+		return nil, errs.ErrArgs.WrapMsg("userID or superGroupID is required")
+	}
+}
+
+func (t *msgTool) getSeq(ctx context.Context, conversationID string) (*conversationSeq, error) {
+	var (
+		seq conversationSeq
+		err error
+	)
+	seq.minSeqMongo, seq.maxSeqMongo, seq.minSeqCache, seq.maxSeqCache, err = t.msgDatabase.GetConversationMinMaxSeqInMongoAndCache(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return &seq, nil
+}
+
+// fixSeq returns the seq before the fix, or nil if the conversation does not need to be fixed.
+func (t *msgTool) fixSeq(ctx context.Context, conversationID string) (*conversationSeq, error) {
+	seq, err := t.getSeq(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if seq.maxSeqCache >= seq.maxSeqMongo {
+		return nil, nil
+	}
+	log.ZInfo(ctx, "fix conversation max seq", "conversationID", conversationID, "maxSeqCache", seq.maxSeqCache, "maxSeqMongo", seq.maxSeqMongo)
+	if err := t.msgDatabase.SetMaxSeq(ctx, conversationID, seq.maxSeqMongo); err != nil {
+		return nil, err
+	}
+	return seq, nil
+}
+
+func (t *msgTool) clearAllMsg(ctx context.Context, conversationID string) error {
+	seq, err := t.getSeq(ctx, conversationID)
+	if err != nil {
+		return err
+	}
+	if seq.maxSeqMongo > 0 {
+		seqs := make([]int64, 0, seq.maxSeqMongo-seq.minSeqMongo+1)
+		for i := seq.minSeqMongo; i <= seq.maxSeqMongo; i++ {
+			seqs = append(seqs, i)
+		}
+		if err := t.msgDatabase.DeleteMsgsPhysicalBySeqs(ctx, conversationID, seqs); err != nil {
+			return err
+		}
+	}
+	maxSeq := max(seq.maxSeqCache, seq.maxSeqMongo)
+	return t.msgDatabase.SetMinSeq(ctx, conversationID, maxSeq+1)
+}
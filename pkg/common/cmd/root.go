@@ -2,19 +2,15 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
-	kdisc "github.com/openimsdk/open-im-server/v3/pkg/common/discovery"
-	disetcd "github.com/openimsdk/open-im-server/v3/pkg/common/discovery/etcd"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/configsource"
 	"github.com/openimsdk/open-im-server/v3/version"
-	"github.com/openimsdk/tools/discovery/etcd"
 	"github.com/openimsdk/tools/errs"
 	"github.com/openimsdk/tools/log"
 	"github.com/openimsdk/tools/utils/runtimeenv"
 	"github.com/spf13/cobra"
-	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 type RootCmd struct {
@@ -25,7 +21,7 @@ type RootCmd struct {
 	log            config.Log
 	index          int
 	configPath     string
-	etcdClient     *clientv3.Client
+	configSource   configsource.Source
 }
 
 func (r *RootCmd) ConfigPath() string {
@@ -80,7 +76,9 @@ func NewRootCmd(processName string, opts ...func(*CmdOpts)) *RootCmd {
 	return rootCmd
 }
 
-func (r *RootCmd) initEtcd() error {
+// initConfigSource opens the config source of the deployment, the services are started with the config files
+// when it can not be opened.
+func (r *RootCmd) initConfigSource() error {
 	configDirectory, _, err := r.getFlag(&r.Command)
 	if err != nil {
 		return err
@@ -92,29 +90,33 @@ func (r *RootCmd) initEtcd() error {
 	if err != nil {
 		return err
 	}
-	if disConfig.Enable == config.ETCD {
-		discov, _ := kdisc.NewDiscoveryRegister(&disConfig, env, nil)
-		r.etcdClient = discov.(*etcd.SvcDiscoveryRegistryImpl).GetClient()
+	source, err := configsource.New(&disConfig, env, configDirectory, nil)
+	if err != nil {
+		log.ZWarn(context.TODO(), "root cmd initConfigSource, open config source err", err)
+		return nil
 	}
+	r.configSource = source
 	return nil
 }
 
 func (r *RootCmd) persistentPreRun(cmd *cobra.Command, opts ...func(*CmdOpts)) error {
-	if err := r.initEtcd(); err != nil {
+	if err := r.initConfigSource(); err != nil {
 		return err
 	}
 	cmdOpts := r.applyOptions(opts...)
 	if err := r.initializeConfiguration(cmd, cmdOpts); err != nil {
 		return err
 	}
-	if err := r.updateConfigFromEtcd(cmdOpts); err != nil {
+	if err := r.updateConfigFromSource(cmdOpts); err != nil {
 		return err
 	}
 	if err := r.initializeLogger(cmdOpts); err != nil {
 		return errs.WrapMsg(err, "failed to initialize logger")
 	}
-	if err := r.etcdClient.Close(); err != nil {
-		return errs.WrapMsg(err, "failed to close etcd client")
+	if r.configSource != nil {
+		if err := r.configSource.Close(); err != nil {
+			return errs.WrapMsg(err, "failed to close config source")
+		}
 	}
 	return nil
 }
@@ -139,62 +141,28 @@ func (r *RootCmd) initializeConfiguration(cmd *cobra.Command, opts *CmdOpts) err
 	return config.Load(configDirectory, config.LogConfigFileName, config.EnvPrefixMap[config.LogConfigFileName], runtimeEnv, &r.log)
 }
 
-func (r *RootCmd) updateConfigFromEtcd(opts *CmdOpts) error {
-	if r.etcdClient == nil {
+// updateConfigFromSource replaces the configs loaded from the config files with the ones of the config source
+// when it is enabled.
+func (r *RootCmd) updateConfigFromSource(opts *CmdOpts) error {
+	if r.configSource == nil {
 		return nil
 	}
 	ctx := context.TODO()
 
-	res, err := r.etcdClient.Get(ctx, disetcd.BuildKey(disetcd.EnableConfigCenterKey))
+	enabled, err := r.configSource.Enabled(ctx)
 	if err != nil {
-		log.ZWarn(ctx, "root cmd updateConfigFromEtcd, etcd Get EnableConfigCenterKey err: %v", errs.Wrap(err))
+		log.ZWarn(ctx, "root cmd updateConfigFromSource, get enable config center err", err)
 		return nil
 	}
-	if res.Count == 0 {
-		return nil
-	} else {
-		if string(res.Kvs[0].Value) == disetcd.Disable {
-			return nil
-		} else if string(res.Kvs[0].Value) != disetcd.Enable {
-			return errs.New("unknown EnableConfigCenter value").Wrap()
-		}
-	}
-
-	update := func(configFileName string, configStruct any) error {
-		key := disetcd.BuildKey(configFileName)
-		etcdRes, err := r.etcdClient.Get(ctx, key)
-		if err != nil {
-			log.ZWarn(ctx, "root cmd updateConfigFromEtcd, etcd Get err: %v", errs.Wrap(err))
-			return nil
-		}
-		if etcdRes.Count == 0 {
-			data, err := json.Marshal(configStruct)
-			if err != nil {
-				return errs.ErrArgs.WithDetail(err.Error()).Wrap()
-			}
-			_, err = r.etcdClient.Put(ctx, disetcd.BuildKey(configFileName), string(data))
-			if err != nil {
-				log.ZWarn(ctx, "root cmd updateConfigFromEtcd, etcd Put err: %v", errs.Wrap(err))
-			}
-			return nil
-		}
-		err = json.Unmarshal(etcdRes.Kvs[0].Value, configStruct)
-		if err != nil {
-			return errs.WrapMsg(err, "failed to unmarshal config from etcd")
-		}
+	if !enabled {
 		return nil
 	}
 	for configFileName, configStruct := range opts.configMap {
-		if err := update(configFileName, configStruct); err != nil {
+		if err := r.configSource.Load(ctx, configFileName, configStruct); err != nil {
 			return err
 		}
 	}
-	if err := update(config.LogConfigFileName, &r.log); err != nil {
-		return err
-	}
-	// Load common log configuration file
-	return nil
-
+	return r.configSource.Load(ctx, config.LogConfigFileName, &r.log)
 }
 
 func (r *RootCmd) applyOptions(opts ...func(*CmdOpts)) *CmdOpts {
@@ -57,26 +57,26 @@ type Log struct {
 type Minio struct {
 	Bucket          string `mapstructure:"bucket"`
 	AccessKeyID     string `mapstructure:"accessKeyID"`
-	SecretAccessKey string `mapstructure:"secretAccessKey"`
-	SessionToken    string `mapstructure:"sessionToken"`
+	SecretAccessKey string `mapstructure:"secretAccessKey" secret:"true"`
+	SessionToken    string `mapstructure:"sessionToken" secret:"true"`
 	InternalAddress string `mapstructure:"internalAddress"`
 	ExternalAddress string `mapstructure:"externalAddress"`
 	PublicRead      bool   `mapstructure:"publicRead"`
 }
 
 type Mongo struct {
-	URI         string   `mapstructure:"uri"`
+	URI         string   `mapstructure:"uri" secret:"true"`
 	Address     []string `mapstructure:"address"`
 	Database    string   `mapstructure:"database"`
 	Username    string   `mapstructure:"username"`
-	Password    string   `mapstructure:"password"`
+	Password    string   `mapstructure:"password" secret:"true"`
 	AuthSource  string   `mapstructure:"authSource"`
 	MaxPoolSize int      `mapstructure:"maxPoolSize"`
 	MaxRetry    int      `mapstructure:"maxRetry"`
 }
 type Kafka struct {
 	Username           string   `mapstructure:"username"`
-	Password           string   `mapstructure:"password"`
+	Password           string   `mapstructure:"password" secret:"true"`
 	ProducerAck        string   `mapstructure:"producerAck"`
 	CompressType       string   `mapstructure:"compressType"`
 	Address            []string `mapstructure:"address"`
@@ -88,15 +88,27 @@ type Kafka struct {
 	ToMongoGroupID     string   `mapstructure:"toMongoGroupID"`
 	ToPushGroupID      string   `mapstructure:"toPushGroupID"`
 	ToOfflineGroupID   string   `mapstructure:"toOfflinePushGroupID"`
+	ToMsgSearchGroupID string   `mapstructure:"toMsgSearchGroupID"`
 
 	Tls TLSConfig `mapstructure:"tls"`
+
+	// Backend is the message queue carrying the topics: kafka, redis or memory, memory only works when all the
+	// components run in a single process.
+	Backend     string      `mapstructure:"backend"`
+	RedisStream RedisStream `mapstructure:"redisStream"`
+}
+
+// RedisStream configures the redis backend, every topic is split into Partitions streams.
+type RedisStream struct {
+	Partitions int   `mapstructure:"partitions"`
+	MaxLen     int64 `mapstructure:"maxLen"`
 }
 type TLSConfig struct {
 	EnableTLS          bool   `mapstructure:"enableTLS"`
 	CACrt              string `mapstructure:"caCrt"`
 	ClientCrt          string `mapstructure:"clientCrt"`
 	ClientKey          string `mapstructure:"clientKey"`
-	ClientKeyPwd       string `mapstructure:"clientKeyPwd"`
+	ClientKeyPwd       string `mapstructure:"clientKeyPwd" secret:"true"`
 	InsecureSkipVerify bool   `mapstructure:"insecureSkipVerify"`
 }
 
@@ -112,6 +124,27 @@ type API struct {
 		Ports        []int  `mapstructure:"ports"`
 		GrafanaURL   string `mapstructure:"grafanaURL"`
 	} `mapstructure:"prometheus"`
+	RateLimit RateLimit `mapstructure:"rateLimit"`
+}
+
+// RateLimit throttles the requests with token buckets shared by all the instances through redis.
+type RateLimit struct {
+	Enable bool            `mapstructure:"enable"`
+	Rules  []RateLimitRule `mapstructure:"rules"`
+	// TrustedProxies are the CIDRs of the proxies the forwarded headers of the client ip are read from.
+	TrustedProxies []string `mapstructure:"trustedProxies"`
+}
+
+// RateLimitRule is a bucket of Burst tokens refilled at Rate tokens per second, kept for every user, user platform
+// or ip sending the requests matched by the rule.
+type RateLimitRule struct {
+	Name string `mapstructure:"name"`
+	// Match are the api paths or the req identifiers of the gateway, an empty list matches all the requests.
+	Match []string `mapstructure:"match"`
+	// Key is user, platform or ip.
+	Key   string  `mapstructure:"key"`
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
 }
 
 type CronTask struct {
@@ -189,7 +222,19 @@ type MsgGateway struct {
 		WebsocketMaxConnNum int   `mapstructure:"websocketMaxConnNum"`
 		WebsocketMaxMsgLen  int   `mapstructure:"websocketMaxMsgLen"`
 		WebsocketTimeout    int   `mapstructure:"websocketTimeout"`
+		CompressionMinSize  int   `mapstructure:"compressionMinSize"`
+		EnableSSE           bool  `mapstructure:"enableSSE"`
 	} `mapstructure:"longConnSvr"`
+	RateLimit RateLimit     `mapstructure:"rateLimit"`
+	Resume    SessionResume `mapstructure:"resume"`
+}
+
+// SessionResume keeps the sessions of the dropped connections for GracePeriod seconds, with up to BufferSize
+// pushed messages, so that a client reconnecting in time takes over its session.
+type SessionResume struct {
+	Enable      bool `mapstructure:"enable"`
+	GracePeriod int  `mapstructure:"gracePeriod"`
+	BufferSize  int  `mapstructure:"bufferSize"`
 }
 
 type MsgTransfer struct {
@@ -200,6 +245,13 @@ type MsgTransfer struct {
 	} `mapstructure:"prometheus"`
 }
 
+type SearchIndex struct {
+	Enable string `mapstructure:"enable"`
+	Bleve  struct {
+		Dir string `mapstructure:"dir"`
+	} `mapstructure:"bleve"`
+}
+
 type Push struct {
 	RPC struct {
 		RegisterIP   string `mapstructure:"registerIP"`
@@ -212,7 +264,7 @@ type Push struct {
 	Enable               string     `mapstructure:"enable"`
 	GeTui                struct {
 		PushUrl      string `mapstructure:"pushUrl"`
-		MasterSecret string `mapstructure:"masterSecret"`
+		MasterSecret string `mapstructure:"masterSecret" secret:"true"`
 		AppKey       string `mapstructure:"appKey"`
 		Intent       string `mapstructure:"intent"`
 		ChannelID    string `mapstructure:"channelID"`
@@ -224,7 +276,7 @@ type Push struct {
 	} `mapstructure:"fcm"`
 	JPush struct {
 		AppKey       string `mapstructure:"appKey"`
-		MasterSecret string `mapstructure:"masterSecret"`
+		MasterSecret string `mapstructure:"masterSecret" secret:"true"`
 		PushURL      string `mapstructure:"pushURL"`
 		PushIntent   string `mapstructure:"pushIntent"`
 	} `mapstructure:"jpush"`
@@ -287,8 +339,60 @@ type Msg struct {
 		AutoSetPorts bool   `mapstructure:"autoSetPorts"`
 		Ports        []int  `mapstructure:"ports"`
 	} `mapstructure:"rpc"`
-	Prometheus   Prometheus `mapstructure:"prometheus"`
-	FriendVerify bool       `mapstructure:"friendVerify"`
+	Prometheus   Prometheus     `mapstructure:"prometheus"`
+	FriendVerify bool           `mapstructure:"friendVerify"`
+	SearchIndex  SearchIndex    `mapstructure:"searchIndex"`
+	Interceptor  MsgInterceptor `mapstructure:"interceptor"`
+	Revoke       MsgRevoke      `mapstructure:"revoke"`
+	Edit         MsgEdit        `mapstructure:"edit"`
+	Schedule     MsgSchedule    `mapstructure:"schedule"`
+	ReadReceipt  MsgReadReceipt `mapstructure:"readReceipt"`
+}
+
+// MsgReadReceipt records who read the messages of the groups opting in to the read receipts, the messages of a
+// group with more than MaxMemberCount members are not tracked.
+type MsgReadReceipt struct {
+	Enable         bool  `mapstructure:"enable"`
+	MaxMemberCount int32 `mapstructure:"maxMemberCount"`
+}
+
+// MsgSchedule is the policy of the scheduled messages, the durations are in seconds.
+type MsgSchedule struct {
+	Disable bool `mapstructure:"disable"`
+	// MaxDelay is the max time between scheduling a message and its send time, 0 means no limit.
+	MaxDelay int `mapstructure:"maxDelay"`
+	// MaxPending is the max number of pending messages of a user, the app managers are not limited, 0 means no limit.
+	MaxPending int `mapstructure:"maxPending"`
+	// Interval is the time between two scans of the due messages, BatchSize is the max number of messages sent by a scan.
+	Interval  int `mapstructure:"interval"`
+	BatchSize int `mapstructure:"batchSize"`
+}
+
+// MsgEdit is the edit policy of the senders, the windows are in seconds since the messages are sent, 0 means no limit.
+type MsgEdit struct {
+	Disable bool `mapstructure:"disable"`
+	Window  int  `mapstructure:"window"`
+}
+
+// MsgRevoke is the revoke policy of the users, the app managers can always revoke. The windows are in seconds
+// since the messages are sent, 0 means no limit.
+type MsgRevoke struct {
+	SenderWindow     int  `mapstructure:"senderWindow"`
+	GroupAdminWindow int  `mapstructure:"groupAdminWindow"`
+	GroupOwnerWindow int  `mapstructure:"groupOwnerWindow"`
+	DisableSingle    bool `mapstructure:"disableSingle"`
+	DisableGroup     bool `mapstructure:"disableGroup"`
+	// MaxBatchSize is the max number of messages revoked by a request of batch revoke or revoke user group msgs.
+	MaxBatchSize int `mapstructure:"maxBatchSize"`
+}
+
+type MsgInterceptor struct {
+	MaxContentLength    int `mapstructure:"maxContentLength"`
+	AllowedContentTypes struct {
+		Single       []int32 `mapstructure:"single"`
+		Group        []int32 `mapstructure:"group"`
+		Notification []int32 `mapstructure:"notification"`
+	} `mapstructure:"allowedContentTypes"`
 }
 
 type Third struct {
@@ -305,13 +409,19 @@ type Third struct {
 		Oss    Oss    `mapstructure:"oss"`
 		Kodo   Kodo   `mapstructure:"kodo"`
 		Aws    Aws    `mapstructure:"aws"`
+		Local  Local  `mapstructure:"local"`
 	} `mapstructure:"object"`
 }
+type Local struct {
+	Dir     string `mapstructure:"dir"`
+	BaseURL string `mapstructure:"baseURL"`
+	Secret  string `mapstructure:"secret" secret:"true"`
+}
 type Cos struct {
 	BucketURL    string `mapstructure:"bucketURL"`
 	SecretID     string `mapstructure:"secretID"`
-	SecretKey    string `mapstructure:"secretKey"`
-	SessionToken string `mapstructure:"sessionToken"`
+	SecretKey    string `mapstructure:"secretKey" secret:"true"`
+	SessionToken string `mapstructure:"sessionToken" secret:"true"`
 	PublicRead   bool   `mapstructure:"publicRead"`
 }
 type Oss struct {
@@ -319,8 +429,8 @@ type Oss struct {
 	Bucket          string `mapstructure:"bucket"`
 	BucketURL       string `mapstructure:"bucketURL"`
 	AccessKeyID     string `mapstructure:"accessKeyID"`
-	AccessKeySecret string `mapstructure:"accessKeySecret"`
-	SessionToken    string `mapstructure:"sessionToken"`
+	AccessKeySecret string `mapstructure:"accessKeySecret" secret:"true"`
+	SessionToken    string `mapstructure:"sessionToken" secret:"true"`
 	PublicRead      bool   `mapstructure:"publicRead"`
 }
 
@@ -329,8 +439,8 @@ type Kodo struct {
 	Bucket          string `mapstructure:"bucket"`
 	BucketURL       string `mapstructure:"bucketURL"`
 	AccessKeyID     string `mapstructure:"accessKeyID"`
-	AccessKeySecret string `mapstructure:"accessKeySecret"`
-	SessionToken    string `mapstructure:"sessionToken"`
+	AccessKeySecret string `mapstructure:"accessKeySecret" secret:"true"`
+	SessionToken    string `mapstructure:"sessionToken" secret:"true"`
 	PublicRead      bool   `mapstructure:"publicRead"`
 }
 
@@ -338,8 +448,8 @@ type Aws struct {
 	Region          string `mapstructure:"region"`
 	Bucket          string `mapstructure:"bucket"`
 	AccessKeyID     string `mapstructure:"accessKeyID"`
-	SecretAccessKey string `mapstructure:"secretAccessKey"`
-	SessionToken    string `mapstructure:"sessionToken"`
+	SecretAccessKey string `mapstructure:"secretAccessKey" secret:"true"`
+	SessionToken    string `mapstructure:"sessionToken" secret:"true"`
 	PublicRead      bool   `mapstructure:"publicRead"`
 }
 
@@ -356,7 +466,7 @@ type User struct {
 type Redis struct {
 	Address     []string `mapstructure:"address"`
 	Username    string   `mapstructure:"username"`
-	Password    string   `mapstructure:"password"`
+	Password    string   `mapstructure:"password" secret:"true"`
 	ClusterMode bool     `mapstructure:"clusterMode"`
 	DB          int      `mapstructure:"storage"`
 	MaxRetry    int      `mapstructure:"maxRetry"`
@@ -365,6 +475,7 @@ type Redis struct {
 
 type BeforeConfig struct {
 	Enable         bool     `mapstructure:"enable"`
+	URL            string   `mapstructure:"url"`
 	Timeout        int      `mapstructure:"timeout"`
 	FailedContinue bool     `mapstructure:"failedContinue"`
 	AllowedTypes   []string `mapstructure:"allowedTypes"`
@@ -372,17 +483,58 @@ type BeforeConfig struct {
 }
 
 type AfterConfig struct {
-	Enable       bool     `mapstructure:"enable"`
+	Enable       bool              `mapstructure:"enable"`
+	URL          string            `mapstructure:"url"`
+	Timeout      int               `mapstructure:"timeout"`
+	AttentionIds []string          `mapstructure:"attentionIds"`
+	AllowedTypes []string          `mapstructure:"allowedTypes"`
+	DeniedTypes  []string          `mapstructure:"deniedTypes"`
+	Subscribers  []AfterSubscriber `mapstructure:"subscribers"`
+}
+
+// AfterSubscriber receives an after callback in addition to the url of the event, with its own timeout and filters.
+type AfterSubscriber struct {
+	URL          string   `mapstructure:"url"`
 	Timeout      int      `mapstructure:"timeout"`
 	AttentionIds []string `mapstructure:"attentionIds"`
 	AllowedTypes []string `mapstructure:"allowedTypes"`
 	DeniedTypes  []string `mapstructure:"deniedTypes"`
 }
 
+// WebhookRetry is the exponential backoff of after callbacks, intervals are in seconds.
+type WebhookRetry struct {
+	MaxTimes        int `mapstructure:"maxTimes"`
+	InitialInterval int `mapstructure:"initialInterval"`
+	MaxInterval     int `mapstructure:"maxInterval"`
+}
+
+// WebhookQueue persists after callbacks in a redis stream so that they survive restarts.
+type WebhookQueue struct {
+	Enable bool   `mapstructure:"enable"`
+	Stream string `mapstructure:"stream"`
+	MaxLen int64  `mapstructure:"maxLen"`
+}
+
 type Share struct {
-	Secret        string     `mapstructure:"secret"`
-	IMAdminUserID []string   `mapstructure:"imAdminUserID"`
-	MultiLogin    MultiLogin `mapstructure:"multiLogin"`
+	Secret        string        `mapstructure:"secret" secret:"true"`
+	IMAdminUserID []string      `mapstructure:"imAdminUserID"`
+	MultiLogin    MultiLogin    `mapstructure:"multiLogin"`
+	SensitiveWord SensitiveWord `mapstructure:"sensitiveWord"`
+	Tracing       Tracing       `mapstructure:"tracing"`
+}
+
+type Tracing struct {
+	Enable      bool    `mapstructure:"enable"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	Sampler     string  `mapstructure:"sampler"`
+	SampleRatio float64 `mapstructure:"sampleRatio"`
+}
+
+type SensitiveWord struct {
+	Enable         bool   `mapstructure:"enable"`
+	Replacement    string `mapstructure:"replacement"`
+	ReloadInterval int    `mapstructure:"reloadInterval"`
 }
 
 type MultiLogin struct {
@@ -418,80 +570,125 @@ func (r *RpcService) GetServiceNames() []string {
 
 // FullConfig stores all configurations for before and after events
 type Webhooks struct {
-	URL                      string       `mapstructure:"url"`
-	BeforeSendSingleMsg      BeforeConfig `mapstructure:"beforeSendSingleMsg"`
-	BeforeUpdateUserInfoEx   BeforeConfig `mapstructure:"beforeUpdateUserInfoEx"`
-	AfterUpdateUserInfoEx    AfterConfig  `mapstructure:"afterUpdateUserInfoEx"`
-	AfterSendSingleMsg       AfterConfig  `mapstructure:"afterSendSingleMsg"`
-	BeforeSendGroupMsg       BeforeConfig `mapstructure:"beforeSendGroupMsg"`
-	BeforeMsgModify          BeforeConfig `mapstructure:"beforeMsgModify"`
-	AfterSendGroupMsg        AfterConfig  `mapstructure:"afterSendGroupMsg"`
-	AfterUserOnline          AfterConfig  `mapstructure:"afterUserOnline"`
-	AfterUserOffline         AfterConfig  `mapstructure:"afterUserOffline"`
-	AfterUserKickOff         AfterConfig  `mapstructure:"afterUserKickOff"`
-	BeforeOfflinePush        BeforeConfig `mapstructure:"beforeOfflinePush"`
-	BeforeOnlinePush         BeforeConfig `mapstructure:"beforeOnlinePush"`
-	BeforeGroupOnlinePush    BeforeConfig `mapstructure:"beforeGroupOnlinePush"`
-	BeforeAddFriend          BeforeConfig `mapstructure:"beforeAddFriend"`
-	BeforeUpdateUserInfo     BeforeConfig `mapstructure:"beforeUpdateUserInfo"`
-	AfterUpdateUserInfo      AfterConfig  `mapstructure:"afterUpdateUserInfo"`
-	BeforeCreateGroup        BeforeConfig `mapstructure:"beforeCreateGroup"`
-	AfterCreateGroup         AfterConfig  `mapstructure:"afterCreateGroup"`
-	BeforeMemberJoinGroup    BeforeConfig `mapstructure:"beforeMemberJoinGroup"`
-	BeforeSetGroupMemberInfo BeforeConfig `mapstructure:"beforeSetGroupMemberInfo"`
-	AfterSetGroupMemberInfo  AfterConfig  `mapstructure:"afterSetGroupMemberInfo"`
-	AfterQuitGroup           AfterConfig  `mapstructure:"afterQuitGroup"`
-	AfterKickGroupMember     AfterConfig  `mapstructure:"afterKickGroupMember"`
-	AfterDismissGroup        AfterConfig  `mapstructure:"afterDismissGroup"`
-	BeforeApplyJoinGroup     BeforeConfig `mapstructure:"beforeApplyJoinGroup"`
-	AfterGroupMsgRead        AfterConfig  `mapstructure:"afterGroupMsgRead"`
-	AfterSingleMsgRead       AfterConfig  `mapstructure:"afterSingleMsgRead"`
-	BeforeUserRegister       BeforeConfig `mapstructure:"beforeUserRegister"`
-	AfterUserRegister        AfterConfig  `mapstructure:"afterUserRegister"`
-	AfterTransferGroupOwner  AfterConfig  `mapstructure:"afterTransferGroupOwner"`
-	BeforeSetFriendRemark    BeforeConfig `mapstructure:"beforeSetFriendRemark"`
-	AfterSetFriendRemark     AfterConfig  `mapstructure:"afterSetFriendRemark"`
-	AfterGroupMsgRevoke      AfterConfig  `mapstructure:"afterGroupMsgRevoke"`
-	AfterJoinGroup           AfterConfig  `mapstructure:"afterJoinGroup"`
-	BeforeInviteUserToGroup  BeforeConfig `mapstructure:"beforeInviteUserToGroup"`
-	AfterSetGroupInfo        AfterConfig  `mapstructure:"afterSetGroupInfo"`
-	BeforeSetGroupInfo       BeforeConfig `mapstructure:"beforeSetGroupInfo"`
-	AfterSetGroupInfoEx      AfterConfig  `mapstructure:"afterSetGroupInfoEx"`
-	BeforeSetGroupInfoEx     BeforeConfig `mapstructure:"beforeSetGroupInfoEx"`
-	AfterRevokeMsg           AfterConfig  `mapstructure:"afterRevokeMsg"`
-	BeforeAddBlack           BeforeConfig `mapstructure:"beforeAddBlack"`
-	AfterAddFriend           AfterConfig  `mapstructure:"afterAddFriend"`
-	BeforeAddFriendAgree     BeforeConfig `mapstructure:"beforeAddFriendAgree"`
-	AfterAddFriendAgree      AfterConfig  `mapstructure:"afterAddFriendAgree"`
-	AfterDeleteFriend        AfterConfig  `mapstructure:"afterDeleteFriend"`
-	BeforeImportFriends      BeforeConfig `mapstructure:"beforeImportFriends"`
-	AfterImportFriends       AfterConfig  `mapstructure:"afterImportFriends"`
-	AfterRemoveBlack         AfterConfig  `mapstructure:"afterRemoveBlack"`
+	URL                      string            `mapstructure:"url"`
+	Secret                   string            `mapstructure:"secret" secret:"true"`
+	Retry                    WebhookRetry      `mapstructure:"retry"`
+	Queue                    WebhookQueue      `mapstructure:"queue"`
+	AfterSubscribers         []AfterSubscriber `mapstructure:"afterSubscribers"`
+	BeforeSendSingleMsg      BeforeConfig      `mapstructure:"beforeSendSingleMsg"`
+	BeforeUpdateUserInfoEx   BeforeConfig      `mapstructure:"beforeUpdateUserInfoEx"`
+	AfterUpdateUserInfoEx    AfterConfig       `mapstructure:"afterUpdateUserInfoEx"`
+	AfterSendSingleMsg       AfterConfig       `mapstructure:"afterSendSingleMsg"`
+	BeforeSendGroupMsg       BeforeConfig      `mapstructure:"beforeSendGroupMsg"`
+	BeforeMsgModify          BeforeConfig      `mapstructure:"beforeMsgModify"`
+	AfterSendGroupMsg        AfterConfig       `mapstructure:"afterSendGroupMsg"`
+	AfterUserOnline          AfterConfig       `mapstructure:"afterUserOnline"`
+	AfterUserOffline         AfterConfig       `mapstructure:"afterUserOffline"`
+	AfterUserKickOff         AfterConfig       `mapstructure:"afterUserKickOff"`
+	BeforeOfflinePush        BeforeConfig      `mapstructure:"beforeOfflinePush"`
+	BeforeOnlinePush         BeforeConfig      `mapstructure:"beforeOnlinePush"`
+	BeforeGroupOnlinePush    BeforeConfig      `mapstructure:"beforeGroupOnlinePush"`
+	BeforeAddFriend          BeforeConfig      `mapstructure:"beforeAddFriend"`
+	BeforeUpdateUserInfo     BeforeConfig      `mapstructure:"beforeUpdateUserInfo"`
+	AfterUpdateUserInfo      AfterConfig       `mapstructure:"afterUpdateUserInfo"`
+	BeforeCreateGroup        BeforeConfig      `mapstructure:"beforeCreateGroup"`
+	AfterCreateGroup         AfterConfig       `mapstructure:"afterCreateGroup"`
+	BeforeMemberJoinGroup    BeforeConfig      `mapstructure:"beforeMemberJoinGroup"`
+	BeforeSetGroupMemberInfo BeforeConfig      `mapstructure:"beforeSetGroupMemberInfo"`
+	AfterSetGroupMemberInfo  AfterConfig       `mapstructure:"afterSetGroupMemberInfo"`
+	AfterQuitGroup           AfterConfig       `mapstructure:"afterQuitGroup"`
+	AfterKickGroupMember     AfterConfig       `mapstructure:"afterKickGroupMember"`
+	AfterDismissGroup        AfterConfig       `mapstructure:"afterDismissGroup"`
+	BeforeApplyJoinGroup     BeforeConfig      `mapstructure:"beforeApplyJoinGroup"`
+	AfterGroupMsgRead        AfterConfig       `mapstructure:"afterGroupMsgRead"`
+	AfterSingleMsgRead       AfterConfig       `mapstructure:"afterSingleMsgRead"`
+	BeforeUserRegister       BeforeConfig      `mapstructure:"beforeUserRegister"`
+	AfterUserRegister        AfterConfig       `mapstructure:"afterUserRegister"`
+	AfterTransferGroupOwner  AfterConfig       `mapstructure:"afterTransferGroupOwner"`
+	BeforeSetFriendRemark    BeforeConfig      `mapstructure:"beforeSetFriendRemark"`
+	AfterSetFriendRemark     AfterConfig       `mapstructure:"afterSetFriendRemark"`
+	AfterGroupMsgRevoke      AfterConfig       `mapstructure:"afterGroupMsgRevoke"`
+	AfterJoinGroup           AfterConfig       `mapstructure:"afterJoinGroup"`
+	BeforeInviteUserToGroup  BeforeConfig      `mapstructure:"beforeInviteUserToGroup"`
+	AfterSetGroupInfo        AfterConfig       `mapstructure:"afterSetGroupInfo"`
+	BeforeSetGroupInfo       BeforeConfig      `mapstructure:"beforeSetGroupInfo"`
+	AfterSetGroupInfoEx      AfterConfig       `mapstructure:"afterSetGroupInfoEx"`
+	BeforeSetGroupInfoEx     BeforeConfig      `mapstructure:"beforeSetGroupInfoEx"`
+	AfterRevokeMsg           AfterConfig       `mapstructure:"afterRevokeMsg"`
+	BeforeAddBlack           BeforeConfig      `mapstructure:"beforeAddBlack"`
+	AfterAddFriend           AfterConfig       `mapstructure:"afterAddFriend"`
+	BeforeAddFriendAgree     BeforeConfig      `mapstructure:"beforeAddFriendAgree"`
+	AfterAddFriendAgree      AfterConfig       `mapstructure:"afterAddFriendAgree"`
+	AfterDeleteFriend        AfterConfig       `mapstructure:"afterDeleteFriend"`
+	BeforeImportFriends      BeforeConfig      `mapstructure:"beforeImportFriends"`
+	AfterImportFriends       AfterConfig       `mapstructure:"afterImportFriends"`
+	AfterRemoveBlack         AfterConfig       `mapstructure:"afterRemoveBlack"`
+	AfterSensitiveWord       AfterConfig       `mapstructure:"afterSensitiveWord"`
 }
 
 type ZooKeeper struct {
 	Schema   string   `mapstructure:"schema"`
 	Address  []string `mapstructure:"address"`
 	Username string   `mapstructure:"username"`
-	Password string   `mapstructure:"password"`
+	Password string   `mapstructure:"password" secret:"true"`
 }
 
 type Discovery struct {
 	Enable     string     `mapstructure:"enable"`
 	Etcd       Etcd       `mapstructure:"etcd"`
 	Kubernetes Kubernetes `mapstructure:"kubernetes"`
+	Direct     Direct     `mapstructure:"direct"`
 	RpcService RpcService `mapstructure:"rpcService"`
+
+	// ConfigSource is where the config manager writes the configs and the services watch them: etcd, file or
+	// kubernetes. It is chosen from the deployment when empty.
+	ConfigSource string `mapstructure:"configSource"`
 }
 
 type Kubernetes struct {
 	Namespace string `mapstructure:"namespace"`
+	// ConfigMap holds the config files mounted in the pods.
+	ConfigMap string `mapstructure:"configMap"`
+}
+
+// Direct lists the fixed host:port addresses of every rpc service, used when no registry is deployed.
+type Direct struct {
+	HealthCheckInterval int           `mapstructure:"healthCheckInterval"`
+	Addresses           DirectAddress `mapstructure:"addresses"`
+}
+
+type DirectAddress struct {
+	User           []string `mapstructure:"user"`
+	Friend         []string `mapstructure:"friend"`
+	Msg            []string `mapstructure:"msg"`
+	Push           []string `mapstructure:"push"`
+	MessageGateway []string `mapstructure:"messageGateway"`
+	Group          []string `mapstructure:"group"`
+	Auth           []string `mapstructure:"auth"`
+	Conversation   []string `mapstructure:"conversation"`
+	Third          []string `mapstructure:"third"`
+}
+
+// GetServiceAddresses maps the registered name of every rpc service to its direct addresses.
+func (d *Discovery) GetServiceAddresses() map[string][]string {
+	return map[string][]string{
+		d.RpcService.User:           d.Direct.Addresses.User,
+		d.RpcService.Friend:         d.Direct.Addresses.Friend,
+		d.RpcService.Msg:            d.Direct.Addresses.Msg,
+		d.RpcService.Push:           d.Direct.Addresses.Push,
+		d.RpcService.MessageGateway: d.Direct.Addresses.MessageGateway,
+		d.RpcService.Group:          d.Direct.Addresses.Group,
+		d.RpcService.Auth:           d.Direct.Addresses.Auth,
+		d.RpcService.Conversation:   d.Direct.Addresses.Conversation,
+		d.RpcService.Third:          d.Direct.Addresses.Third,
+	}
 }
 
 type Etcd struct {
 	RootDirectory string   `mapstructure:"rootDirectory"`
 	Address       []string `mapstructure:"address"`
 	Username      string   `mapstructure:"username"`
-	Password      string   `mapstructure:"password"`
+	Password      string   `mapstructure:"password" secret:"true"`
 }
 
 func (m *Mongo) Build() *mongoutil.Config {
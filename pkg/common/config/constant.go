@@ -21,6 +21,14 @@ const (
 	DeploymentType      = "DEPLOYMENT_TYPE"
 	KUBERNETES          = "kubernetes"
 	ETCD                = "etcd"
+	DIRECT              = "direct"
+)
+
+const (
+	// SearchIndexMongo keeps the message search index in mongo.
+	SearchIndexMongo = "mongo"
+	// SearchIndexBleve keeps the message search index in an embedded bleve index on the local disk.
+	SearchIndexBleve = "bleve"
 )
 
 const (
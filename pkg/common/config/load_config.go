@@ -1,6 +1,7 @@
 package config
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"strings"
@@ -24,20 +25,44 @@ func Load(configDirectory string, configFileName string, envPrefix string, runti
 }
 
 func loadConfig(path string, envPrefix string, config any) error {
-	v := viper.New()
+	v := newViper(envPrefix)
 	v.SetConfigFile(path)
-	v.SetEnvPrefix(envPrefix)
-	v.AutomaticEnv()
-	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	if err := v.ReadInConfig(); err != nil {
 		return errs.WrapMsg(err, "failed to read config file", "path", path, "envPrefix", envPrefix)
 	}
 
-	if err := v.Unmarshal(config, func(config *mapstructure.DecoderConfig) {
-		config.TagName = "mapstructure"
-	}); err != nil {
+	if err := unmarshal(v, config); err != nil {
 		return errs.WrapMsg(err, "failed to unmarshal config", "path", path, "envPrefix", envPrefix)
 	}
 	return nil
 }
+
+// LoadData loads the yaml content of a config file, the environment variables override it as in Load.
+func LoadData(data []byte, envPrefix string, config any) error {
+	v := newViper(envPrefix)
+	v.SetConfigType("yaml")
+
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return errs.WrapMsg(err, "failed to read config data", "envPrefix", envPrefix)
+	}
+
+	if err := unmarshal(v, config); err != nil {
+		return errs.WrapMsg(err, "failed to unmarshal config", "envPrefix", envPrefix)
+	}
+	return nil
+}
+
+func newViper(envPrefix string) *viper.Viper {
+	v := viper.New()
+	v.SetEnvPrefix(envPrefix)
+	v.AutomaticEnv()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	return v
+}
+
+func unmarshal(v *viper.Viper, config any) error {
+	return v.Unmarshal(config, func(config *mapstructure.DecoderConfig) {
+		config.TagName = "mapstructure"
+	})
+}
@@ -0,0 +1,158 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+
+	"github.com/openimsdk/tools/errs"
+	"gopkg.in/yaml.v3"
+)
+
+// MergeYAML returns the content of a config file with the values of conf written in, conf is a config struct or
+// a pointer to one. The keys of data keep their order and comments, and the unchanged values are left as they are.
+// The secret fields are only written when diff changes them, the secrets loaded from the environment or left
+// unchanged are not written to the file.
+func MergeYAML(data []byte, conf any, diff []FieldChange) ([]byte, error) {
+	v := reflect.Indirect(reflect.ValueOf(conf))
+	if v.Kind() != reflect.Struct {
+		return nil, errs.ErrArgs.WrapMsg("config is not a struct", "type", v.Type().String())
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errs.WrapMsg(err, "failed to parse config data")
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	m := &merger{changed: make(map[string]struct{}, len(diff))}
+	for _, change := range diff {
+		m.changed[change.Path] = struct{}{}
+	}
+	if err := m.mergeNode(doc.Content[0], "", v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, errs.WrapMsg(err, "failed to encode config data")
+	}
+	if err := enc.Close(); err != nil {
+		return nil, errs.WrapMsg(err, "failed to encode config data")
+	}
+	return buf.Bytes(), nil
+}
+
+type merger struct {
+	changed map[string]struct{}
+}
+
+// secretChanged reports whether the diff changes the secret field of path, or a slice holding it.
+func (m *merger) secretChanged(path string) bool {
+	for {
+		if _, ok := m.changed[path]; ok {
+			return true
+		}
+		i := strings.LastIndexByte(path, '.')
+		if i < 0 {
+			return false
+		}
+		path = path[:i]
+	}
+}
+
+func (m *merger) mergeNode(node *yaml.Node, path string, v reflect.Value) error {
+	switch {
+	case v.Kind() == reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			replaceNode(node, &yaml.Node{Kind: yaml.MappingNode})
+		}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldPath := joinPath(path, field)
+			if isSecret(field) && !m.secretChanged(fieldPath) {
+				continue
+			}
+			key := fieldKey(field)
+			value := mappingValue(node, key)
+			if value == nil {
+				// a missing key loads as the zero value
+				if v.Field(i).IsZero() {
+					continue
+				}
+				value = &yaml.Node{}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+			}
+			if err := m.mergeNode(value, fieldPath, v.Field(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct:
+		// the items are merged by index so that the comments of the existing ones are kept
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Style: node.Style}
+		for i := 0; i < v.Len(); i++ {
+			item := &yaml.Node{}
+			if node.Kind == yaml.SequenceNode && i < len(node.Content) {
+				item = node.Content[i]
+			}
+			if err := m.mergeNode(item, path, v.Index(i)); err != nil {
+				return err
+			}
+			seq.Content = append(seq.Content, item)
+		}
+		replaceNode(node, seq)
+		return nil
+	default:
+		if node.Kind != 0 {
+			current := reflect.New(v.Type())
+			if err := node.Decode(current.Interface()); err == nil && reflect.DeepEqual(current.Elem().Interface(), v.Interface()) {
+				return nil
+			}
+		}
+		var value yaml.Node
+		if err := value.Encode(v.Interface()); err != nil {
+			return errs.WrapMsg(err, "failed to encode config value")
+		}
+		// keep the flow style of the sequences and the quoting of the strings
+		if value.Kind == node.Kind && (value.Kind != yaml.ScalarNode || value.Tag == node.ShortTag()) {
+			value.Style = node.Style
+		}
+		replaceNode(node, &value)
+		return nil
+	}
+}
+
+// replaceNode replaces node with value and keeps the comments of node.
+func replaceNode(node *yaml.Node, value *yaml.Node) {
+	value.HeadComment, value.LineComment, value.FootComment = node.HeadComment, node.LineComment, node.FootComment
+	*node = *value
+}
+
+// mappingValue returns the value of key in the mapping node, the keys are case-insensitive as in Load.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.EqualFold(node.Content[i].Value, key) {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeYAML(t *testing.T) {
+	data := []byte(`# secret of the server
+secret: openIM123
+# admins
+imAdminUserID: [ imAdmin ]
+multiLogin:
+  policy: 1
+  maxNumOneEnd: 30
+`)
+	var share Share
+	assert.NoError(t, LoadData(data, "", &share))
+	old := share
+	share.MultiLogin.MaxNumOneEnd = 10
+	share.IMAdminUserID = append(share.IMAdminUserID, "imAdmin2")
+
+	merged, err := MergeYAML(data, &share, Diff(&old, &share))
+	assert.NoError(t, err)
+	text := string(merged)
+	assert.True(t, strings.Contains(text, "# secret of the server"))
+	assert.True(t, strings.Contains(text, "# admins"))
+	assert.True(t, strings.Index(text, "secret:") < strings.Index(text, "multiLogin:"))
+
+	var got Share
+	assert.NoError(t, LoadData(merged, "", &got))
+	assert.Equal(t, share, got)
+
+	// the config is written whole to an empty file
+	merged, err = MergeYAML(nil, &share, Diff(&Share{}, &share))
+	assert.NoError(t, err)
+	got = Share{}
+	assert.NoError(t, LoadData(merged, "", &got))
+	assert.Equal(t, share, got)
+
+	// a secret loaded from the environment is not written, a changed one is
+	old = share
+	share.Secret = "fromEnv"
+	merged, err = MergeYAML(data, &share, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(merged), "secret: openIM123"))
+	assert.False(t, strings.Contains(string(merged), "fromEnv"))
+	merged, err = MergeYAML(data, &share, Diff(&old, &share))
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(merged), "secret: fromEnv"))
+}
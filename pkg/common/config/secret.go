@@ -0,0 +1,186 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SecretMask replaces the secret fields of a config returned by the config manager, a masked value written back
+// keeps the secret unchanged.
+const SecretMask = "******"
+
+// isSecret reports whether the field is tagged secret:"true".
+func isSecret(field reflect.StructField) bool {
+	return field.Tag.Get("secret") == "true"
+}
+
+// Redact returns a copy of conf with its secret fields masked, conf is a config struct or a pointer to one.
+func Redact(conf any) any {
+	v := reflect.Indirect(reflect.ValueOf(conf))
+	if v.Kind() != reflect.Struct {
+		return conf
+	}
+	return redacted(v).Addr().Interface()
+}
+
+// redacted returns a copy of v with its secret fields masked.
+func redacted(v reflect.Value) reflect.Value {
+	dst := reflect.New(v.Type()).Elem()
+	dst.Set(v)
+	redact(dst)
+	return dst
+}
+
+func redact(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if isSecret(field) && field.Type.Kind() == reflect.String {
+				if v.Field(i).String() != "" {
+					v.Field(i).SetString(SecretMask)
+				}
+				continue
+			}
+			redact(v.Field(i))
+		}
+	case reflect.Slice:
+		if v.Len() == 0 || !hasSecret(v.Type().Elem()) {
+			return
+		}
+		// the elements are shared with the source, they are masked in a copy
+		elems := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(elems, v)
+		for i := 0; i < elems.Len(); i++ {
+			redact(elems.Index(i))
+		}
+		v.Set(elems)
+	}
+}
+
+func hasSecret(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); isSecret(field) || hasSecret(field.Type) {
+				return true
+			}
+		}
+	case reflect.Slice:
+		return hasSecret(t.Elem())
+	}
+	return false
+}
+
+// RestoreSecrets sets the secret fields of conf holding SecretMask to their values in old, conf is a pointer
+// to a config struct and old is the same struct or a pointer to it.
+func RestoreSecrets(conf any, old any) {
+	dst := reflect.Indirect(reflect.ValueOf(conf))
+	src := reflect.Indirect(reflect.ValueOf(old))
+	if dst.Kind() != reflect.Struct || dst.Type() != src.Type() || !dst.CanSet() {
+		return
+	}
+	restoreSecrets(dst, src)
+}
+
+func restoreSecrets(dst reflect.Value, src reflect.Value) {
+	switch dst.Kind() {
+	case reflect.Struct:
+		for i := 0; i < dst.NumField(); i++ {
+			field := dst.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if isSecret(field) && field.Type.Kind() == reflect.String {
+				if dst.Field(i).String() == SecretMask {
+					dst.Field(i).SetString(src.Field(i).String())
+				}
+				continue
+			}
+			restoreSecrets(dst.Field(i), src.Field(i))
+		}
+	case reflect.Slice:
+		// the elements are matched by index, the ones appended keep their values
+		for i := 0; i < dst.Len() && i < src.Len(); i++ {
+			restoreSecrets(dst.Index(i), src.Index(i))
+		}
+	}
+}
+
+// FieldChange is a field changed between two versions of a config, Path is the key of the field in the config
+// file. The values of the secret fields are masked.
+type FieldChange struct {
+	Path string `json:"path"`
+	Old  any    `json:"old"`
+	New  any    `json:"new"`
+}
+
+// Diff returns the fields changed from old to conf, both are the same config struct or pointers to it.
+func Diff(old any, conf any) []FieldChange {
+	src := reflect.Indirect(reflect.ValueOf(old))
+	dst := reflect.Indirect(reflect.ValueOf(conf))
+	if src.Type() != dst.Type() {
+		return []FieldChange{{Old: old, New: conf}}
+	}
+	var changes []FieldChange
+	diff(&changes, "", src, dst, false)
+	return changes
+}
+
+func diff(changes *[]FieldChange, path string, old reflect.Value, conf reflect.Value, secret bool) {
+	if old.Kind() == reflect.Struct {
+		for i := 0; i < old.NumField(); i++ {
+			field := old.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			diff(changes, joinPath(path, field), old.Field(i), conf.Field(i), isSecret(field))
+		}
+		return
+	}
+	if reflect.DeepEqual(old.Interface(), conf.Interface()) {
+		return
+	}
+	change := FieldChange{Path: path, Old: old.Interface(), New: conf.Interface()}
+	if secret {
+		change.Old, change.New = SecretMask, SecretMask
+	} else if hasSecret(old.Type()) {
+		change.Old, change.New = redacted(old).Interface(), redacted(conf).Interface()
+	}
+	*changes = append(*changes, change)
+}
+
+// joinPath joins the key of the field in the config file to path.
+func joinPath(path string, field reflect.StructField) string {
+	if path == "" {
+		return fieldKey(field)
+	}
+	return fmt.Sprintf("%s.%s", path, fieldKey(field))
+}
+
+// fieldKey returns the key of the field in the config file, its mapstructure name or its name without a tag.
+func fieldKey(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,63 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	share := Share{Secret: "openIM123", IMAdminUserID: []string{"imAdmin"}}
+	redacted := Redact(share).(*Share)
+	assert.Equal(t, SecretMask, redacted.Secret)
+	assert.Equal(t, share.IMAdminUserID, redacted.IMAdminUserID)
+	assert.Equal(t, "openIM123", share.Secret)
+
+	third := Third{}
+	third.Object.Aws.AccessKeyID = "id"
+	third.Object.Aws.SecretAccessKey = "key"
+	redactedThird := Redact(&third).(*Third)
+	assert.Equal(t, "id", redactedThird.Object.Aws.AccessKeyID)
+	assert.Equal(t, SecretMask, redactedThird.Object.Aws.SecretAccessKey)
+	assert.Equal(t, "", redactedThird.Object.Aws.SessionToken)
+	assert.Equal(t, "key", third.Object.Aws.SecretAccessKey)
+}
+
+func TestRestoreSecrets(t *testing.T) {
+	old := Redis{Address: []string{"127.0.0.1:6379"}, Password: "openIM123"}
+	conf := &Redis{Address: []string{"127.0.0.1:16379"}, Password: SecretMask}
+	RestoreSecrets(conf, old)
+	assert.Equal(t, "openIM123", conf.Password)
+
+	conf.Password = "changed"
+	RestoreSecrets(conf, &old)
+	assert.Equal(t, "changed", conf.Password)
+}
+
+func TestDiff(t *testing.T) {
+	old := Share{Secret: "openIM123", Tracing: Tracing{SampleRatio: 0.1}}
+	conf := old
+	assert.Empty(t, Diff(old, &conf))
+
+	conf.Secret = "openIM456"
+	conf.Tracing.SampleRatio = 0.5
+	changes := Diff(old, &conf)
+	assert.Equal(t, []FieldChange{
+		{Path: "secret", Old: SecretMask, New: SecretMask},
+		{Path: "tracing.sampleRatio", Old: 0.1, New: 0.5},
+	}, changes)
+}
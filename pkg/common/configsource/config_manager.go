@@ -0,0 +1,118 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configsource
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/tools/discovery"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+)
+
+// restartDelay gathers the changes written together, such as the files of a reset, into a single restart.
+const restartDelay = time.Second
+
+var (
+	ShutDowns []func() error
+)
+
+func RegisterShutDown(shutDown ...func() error) {
+	ShutDowns = append(ShutDowns, shutDown...)
+}
+
+// ConfigManager restarts the server when one of its configs changes in the source.
+type ConfigManager struct {
+	source           Source
+	watchConfigNames []string
+	lock             sync.Mutex
+	timer            *time.Timer
+}
+
+func NewConfigManager(source Source, configNames []string) *ConfigManager {
+	return &ConfigManager{source: source, watchConfigNames: configNames}
+}
+
+// Watch opens the source of the deployment and restarts the server when one of configNames changes.
+func Watch(ctx context.Context, discovery *config.Discovery, runtimeEnv string, configPath string, registry discovery.SvcDiscoveryRegistry, configNames []string) error {
+	source, err := New(discovery, runtimeEnv, configPath, registry)
+	if err != nil {
+		return err
+	}
+	return NewConfigManager(source, configNames).Watch(ctx)
+}
+
+func (c *ConfigManager) Watch(ctx context.Context) error {
+	return c.source.Watch(ctx, c.watchConfigNames, func(name string) {
+		log.ZInfo(ctx, "config changed", "configName", name)
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		if c.timer != nil {
+			c.timer.Reset(restartDelay)
+			return
+		}
+		c.timer = time.AfterFunc(restartDelay, func() {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			if err := restartServer(ctx); err != nil {
+				log.ZError(ctx, "restart server err", err)
+			}
+			c.timer = nil
+		})
+	})
+}
+
+func restartServer(ctx context.Context) error {
+	exePath, err := os.Executable()
+	if err != nil {
+		return errs.New("get executable path fail").Wrap()
+	}
+
+	args := os.Args
+	env := os.Environ()
+
+	cmd := exec.Command(exePath, args[1:]...)
+	cmd.Env = env
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+
+	if runtime.GOOS != "windows" {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	log.ZInfo(ctx, "shutdown server")
+	for _, f := range ShutDowns {
+		if err = f(); err != nil {
+			log.ZError(ctx, "shutdown fail", err)
+		}
+	}
+
+	log.ZInfo(ctx, "restart server")
+	err = cmd.Start()
+	if err != nil {
+		return errs.New("restart server fail").Wrap()
+	}
+	log.ZInfo(ctx, "cmd start over")
+
+	os.Exit(0)
+	return nil
+}
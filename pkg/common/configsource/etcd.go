@@ -0,0 +1,246 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	disetcd "github.com/openimsdk/open-im-server/v3/pkg/common/discovery/etcd"
+	"github.com/openimsdk/tools/discovery"
+	"github.com/openimsdk/tools/discovery/etcd"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// etcdSource keeps the configs in json under disetcd.ConfigKeyPrefix, the services load them when the config
+// center is enabled.
+type etcdSource struct {
+	client *clientv3.Client
+	// owned is set when the client is not shared with the registry
+	owned bool
+}
+
+// NewEtcd returns the etcd source with the client of registry, or with a new client of conf when the services
+// are not registered in etcd.
+func NewEtcd(conf *config.Etcd, registry discovery.SvcDiscoveryRegistry) (Source, error) {
+	if impl, ok := registry.(*etcd.SvcDiscoveryRegistryImpl); ok {
+		return &etcdSource{client: impl.GetClient()}, nil
+	}
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:          conf.Address,
+		Username:           conf.Username,
+		Password:           conf.Password,
+		DialTimeout:        time.Second * 10,
+		MaxCallSendMsgSize: 20 * 1024 * 1024,
+	})
+	if err != nil {
+		return nil, errs.WrapMsg(err, "create etcd client failed", "address", conf.Address)
+	}
+	return &etcdSource{client: client, owned: true}, nil
+}
+
+func buildHistoryKey(version int64) string {
+	// zero padded so that the records are sorted by version
+	return fmt.Sprintf("%s%020d", disetcd.ConfigHistoryKeyPrefix, version)
+}
+
+// Load reads the config in etcd, a config missing from etcd is put there from conf.
+func (e *etcdSource) Load(ctx context.Context, configName string, conf any) error {
+	key := disetcd.BuildKey(configName)
+	resp, err := e.client.Get(ctx, key)
+	if err != nil {
+		log.ZWarn(ctx, "get config from etcd failed", errs.Wrap(err), "configName", configName)
+		return nil
+	}
+	if resp.Count == 0 {
+		data, err := json.Marshal(conf)
+		if err != nil {
+			return errs.ErrArgs.WithDetail(err.Error()).Wrap()
+		}
+		if _, err := e.client.Put(ctx, key, string(data)); err != nil {
+			log.ZWarn(ctx, "put config to etcd failed", errs.Wrap(err), "configName", configName)
+		}
+		return nil
+	}
+	if err := json.Unmarshal(resp.Kvs[0].Value, conf); err != nil {
+		return errs.WrapMsg(err, "failed to unmarshal config from etcd", "configName", configName)
+	}
+	return nil
+}
+
+func (e *etcdSource) Save(ctx context.Context, record *Record) error {
+	return e.save(ctx, record)
+}
+
+// save writes the configs of record and the record under the next version in a single transaction, ops are
+// committed along.
+func (e *etcdSource) save(ctx context.Context, record *Record, ops ...clientv3.Op) error {
+	for {
+		resp, err := e.client.Get(ctx, disetcd.ConfigVersionKey)
+		if err != nil {
+			return errs.WrapMsg(err, "get config version failed")
+		}
+		var (
+			version  int64
+			revision int64
+		)
+		if resp.Count > 0 {
+			version, err = strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64)
+			if err != nil {
+				return errs.WrapMsg(err, "invalid config version", "version", string(resp.Kvs[0].Value))
+			}
+			revision = resp.Kvs[0].ModRevision
+		}
+		record.Version = version + 1
+		record.CreateTime = time.Now().UnixMilli()
+		data, err := json.Marshal(record)
+		if err != nil {
+			return errs.Wrap(err)
+		}
+		puts := make([]clientv3.Op, 0, len(record.Changes)+len(ops)+2)
+		for _, change := range record.Changes {
+			puts = append(puts, clientv3.OpPut(disetcd.BuildKey(change.ConfigName), change.Data))
+		}
+		puts = append(puts, ops...)
+		puts = append(puts,
+			clientv3.OpPut(disetcd.ConfigVersionKey, strconv.FormatInt(record.Version, 10)),
+			clientv3.OpPut(buildHistoryKey(record.Version), string(data)),
+		)
+		txnResp, err := e.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(disetcd.ConfigVersionKey), "=", revision)).
+			Then(puts...).
+			Commit()
+		if err != nil {
+			return errs.WrapMsg(err, "commit etcd txn failed")
+		}
+		if txnResp.Succeeded {
+			return nil
+		}
+		// another change took the version, retry with the next one
+	}
+}
+
+func (e *etcdSource) GetRecord(ctx context.Context, version int64) (*Record, error) {
+	resp, err := e.client.Get(ctx, buildHistoryKey(version))
+	if err != nil {
+		return nil, errs.WrapMsg(err, "get config record failed", "version", version)
+	}
+	if resp.Count == 0 {
+		return nil, nil
+	}
+	var record Record
+	if err := json.Unmarshal(resp.Kvs[0].Value, &record); err != nil {
+		return nil, errs.WrapMsg(err, "invalid config record", "version", version)
+	}
+	return &record, nil
+}
+
+func (e *etcdSource) GetRecords(ctx context.Context, configName string, offset int, count int) (int, []*Record, error) {
+	resp, err := e.client.Get(ctx, disetcd.ConfigHistoryKeyPrefix, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend))
+	if err != nil {
+		return 0, nil, errs.WrapMsg(err, "get config records failed")
+	}
+	records := make([]*Record, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var record Record
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return 0, nil, errs.WrapMsg(err, "invalid config record", "key", string(kv.Key))
+		}
+		records = append(records, &record)
+	}
+	records = filter(records, configName)
+	return len(records), page(records, offset, count), nil
+}
+
+func (e *etcdSource) Enabled(ctx context.Context) (bool, error) {
+	resp, err := e.client.Get(ctx, disetcd.BuildKey(disetcd.EnableConfigCenterKey))
+	if err != nil {
+		return false, errs.WrapMsg(err, "get enable config center failed")
+	}
+	if resp.Count == 0 {
+		return false, nil
+	}
+	switch value := string(resp.Kvs[0].Value); value {
+	case disetcd.Enable:
+		return true, nil
+	case disetcd.Disable:
+		return false, nil
+	default:
+		return false, errs.New("unknown EnableConfigCenter value", "value", value).Wrap()
+	}
+}
+
+func (e *etcdSource) SetEnabled(ctx context.Context, enable bool, record *Record) error {
+	value := disetcd.Disable
+	if enable {
+		value = disetcd.Enable
+	}
+	put := clientv3.OpPut(disetcd.BuildKey(disetcd.EnableConfigCenterKey), value)
+	if enable && record != nil && len(record.Changes) > 0 {
+		return e.save(ctx, record, put)
+	}
+	if _, err := e.client.Do(ctx, put); err != nil {
+		return errs.WrapMsg(err, "put enable config center failed")
+	}
+	return nil
+}
+
+func (e *etcdSource) Restart(ctx context.Context) error {
+	_, err := e.client.Put(ctx, disetcd.BuildKey(disetcd.RestartKey), strconv.FormatInt(time.Now().Unix(), 10))
+	if err != nil {
+		return errs.WrapMsg(err, "restart etcd put key failed")
+	}
+	return nil
+}
+
+// Watch only reports the modified configs, the ones put by Load when they are missing are not changes.
+func (e *etcdSource) Watch(ctx context.Context, configNames []string, fn func(name string)) error {
+	names := make(map[string]string, len(configNames)+1)
+	for _, name := range configNames {
+		names[disetcd.BuildKey(name)] = name
+	}
+	names[disetcd.BuildKey(disetcd.RestartKey)] = RestartName
+	for key := range names {
+		watchChan := e.client.Watch(ctx, key, clientv3.WithPrefix())
+		go func() {
+			for watchResp := range watchChan {
+				if watchResp.Err() != nil {
+					log.ZError(ctx, "watch err", errs.Wrap(watchResp.Err()))
+					continue
+				}
+				for _, event := range watchResp.Events {
+					name, ok := names[string(event.Kv.Key)]
+					if ok && event.Type == clientv3.EventTypePut && (event.IsModify() || name == RestartName) {
+						fn(name)
+					}
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+func (e *etcdSource) Close() error {
+	if !e.owned {
+		return nil
+	}
+	return errs.Wrap(e.client.Close())
+}
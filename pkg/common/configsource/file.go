@@ -0,0 +1,311 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configsource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+)
+
+const (
+	// historyDir holds a json file per record in the config directory.
+	historyDir = ".history"
+	// restartFile is written to ask the services watching the directory to restart.
+	restartFile = ".restart"
+)
+
+// fileSource writes the configs to the config files the services load, it is always enabled. The files of a
+// directory are written by the config manager of a single api.
+type fileSource struct {
+	dir  string
+	lock sync.Mutex
+}
+
+func NewFile(dir string) (Source, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "config directory not found", "dir", dir)
+	}
+	if !info.IsDir() {
+		return nil, errs.ErrArgs.WrapMsg("config path is not a directory", "dir", dir)
+	}
+	return &fileSource{dir: dir}, nil
+}
+
+func (f *fileSource) configPath(configName string) (string, error) {
+	if configName == "" || filepath.Base(configName) != configName || strings.HasPrefix(configName, ".") {
+		return "", errs.ErrArgs.WrapMsg("invalid config name", "configName", configName)
+	}
+	return filepath.Join(f.dir, configName), nil
+}
+
+func (f *fileSource) historyPath(version int64) string {
+	// zero padded so that the records are sorted by version
+	return filepath.Join(f.dir, historyDir, fmt.Sprintf("%020d.json", version))
+}
+
+// Load leaves conf unchanged, the services load the config files themselves.
+func (f *fileSource) Load(ctx context.Context, configName string, conf any) error {
+	return nil
+}
+
+// Save merges the configs into their files, keeping the comments. The files are staged first and the record is
+// written before they replace the config files, so that a change is not applied without its record, the record is
+// removed when a file can not be replaced. The record masks the secrets, and the files only get the secrets the
+// change sets.
+func (f *fileSource) Save(ctx context.Context, record *Record) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	stored, err := redactRecord(record)
+	if err != nil {
+		return err
+	}
+	staged := make(map[string]string, len(record.Changes))
+	defer func() {
+		for _, tmp := range staged {
+			_ = os.Remove(tmp)
+		}
+	}()
+	for _, change := range record.Changes {
+		path, err := f.configPath(change.ConfigName)
+		if err != nil {
+			return err
+		}
+		perm := os.FileMode(0644)
+		data, err := os.ReadFile(path)
+		if err == nil {
+			if info, err := os.Stat(path); err == nil {
+				perm = info.Mode().Perm()
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return errs.WrapMsg(err, "read config file failed", "path", path)
+		}
+		data, err = config.MergeYAML(data, change.Value, change.Diff)
+		if err != nil {
+			return err
+		}
+		tmp, err := stageFile(path, data, perm)
+		if err != nil {
+			return err
+		}
+		staged[path] = tmp
+	}
+	versions, err := f.versions()
+	if err != nil {
+		return err
+	}
+	stored.Version = 1
+	if len(versions) > 0 {
+		stored.Version = versions[0] + 1
+	}
+	stored.CreateTime = time.Now().UnixMilli()
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	if err := os.MkdirAll(filepath.Join(f.dir, historyDir), 0700); err != nil {
+		return errs.WrapMsg(err, "create config history directory failed", "dir", f.dir)
+	}
+	historyPath := f.historyPath(stored.Version)
+	if err := writeFile(historyPath, data, 0600); err != nil {
+		return err
+	}
+	for path, tmp := range staged {
+		if err := os.Rename(tmp, path); err != nil {
+			if err := os.Remove(historyPath); err != nil {
+				log.ZWarn(ctx, "remove config record of a failed save failed", err, "version", stored.Version)
+			}
+			return errs.WrapMsg(err, "rename temporary file failed", "path", path)
+		}
+		delete(staged, path)
+	}
+	record.Version, record.CreateTime = stored.Version, stored.CreateTime
+	return nil
+}
+
+// versions returns the versions of the records from the newest one.
+func (f *fileSource) versions() ([]int64, error) {
+	entries, err := os.ReadDir(filepath.Join(f.dir, historyDir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errs.WrapMsg(err, "read config history directory failed", "dir", f.dir)
+	}
+	versions := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if version, err := strconv.ParseInt(name, 10, 64); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	return versions, nil
+}
+
+func (f *fileSource) GetRecord(ctx context.Context, version int64) (*Record, error) {
+	data, err := os.ReadFile(f.historyPath(version))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errs.WrapMsg(err, "read config record failed", "version", version)
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, errs.WrapMsg(err, "invalid config record", "version", version)
+	}
+	return &record, nil
+}
+
+func (f *fileSource) GetRecords(ctx context.Context, configName string, offset int, count int) (int, []*Record, error) {
+	versions, err := f.versions()
+	if err != nil {
+		return 0, nil, err
+	}
+	records := make([]*Record, 0, len(versions))
+	for _, version := range versions {
+		record, err := f.GetRecord(ctx, version)
+		if err != nil {
+			return 0, nil, err
+		}
+		if record != nil {
+			records = append(records, record)
+		}
+	}
+	records = filter(records, configName)
+	return len(records), page(records, offset, count), nil
+}
+
+func (f *fileSource) Enabled(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+func (f *fileSource) SetEnabled(ctx context.Context, enable bool, record *Record) error {
+	if !enable {
+		return errs.ErrArgs.WrapMsg("the config files are always used, the config manager can not be disabled")
+	}
+	return nil
+}
+
+func (f *fileSource) Restart(ctx context.Context) error {
+	return writeFile(filepath.Join(f.dir, restartFile), []byte(strconv.FormatInt(time.Now().Unix(), 10)), 0644)
+}
+
+// Watch reports the writes of the config files, the ones of the config manager as well as the edits by hand.
+func (f *fileSource) Watch(ctx context.Context, configNames []string, fn func(name string)) error {
+	names := make(map[string]string, len(configNames)+1)
+	for _, name := range configNames {
+		names[name] = name
+	}
+	names[restartFile] = RestartName
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errs.WrapMsg(err, "create config file watcher failed")
+	}
+	// the directory is watched since an atomic write replaces the file
+	if err := watcher.Add(f.dir); err != nil {
+		_ = watcher.Close()
+		return errs.WrapMsg(err, "watch config directory failed", "dir", f.dir)
+	}
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+					continue
+				}
+				if name, ok := names[filepath.Base(event.Name)]; ok {
+					fn(name)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.ZError(ctx, "watch config files err", errs.Wrap(err), "dir", f.dir)
+			}
+		}
+	}()
+	return nil
+}
+
+func (f *fileSource) Close() error {
+	return nil
+}
+
+// writeFile replaces the file with data by renaming a temporary file, the readers see the old or the new content.
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := stageFile(path, data, perm)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return errs.WrapMsg(err, "rename temporary file failed", "path", path)
+	}
+	return nil
+}
+
+// stageFile writes data to a temporary file next to path, which replaces path once renamed to it.
+func stageFile(path string, data []byte, perm os.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return "", errs.WrapMsg(err, "create temporary file failed", "path", path)
+	}
+	if err := writeTemp(tmp, data, perm); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", errs.WrapMsg(err, "write temporary file failed", "path", path)
+	}
+	return tmp.Name(), nil
+}
+
+func writeTemp(tmp *os.File, data []byte, perm os.FileMode) error {
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(tmp.Name(), perm)
+}
@@ -0,0 +1,156 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configsource
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const testShare = `# secret of the server
+secret: openIM123
+imAdminUserID: [ imAdmin ]
+multiLogin:
+  policy: 1
+  maxNumOneEnd: 30
+`
+
+func shareChange(t *testing.T, data string, maxNumOneEnd int) Change {
+	var share config.Share
+	assert.NoError(t, config.LoadData([]byte(data), "", &share))
+	old := share
+	share.MultiLogin.MaxNumOneEnd = maxNumOneEnd
+	return Change{ConfigName: config.ShareFileName, Diff: config.Diff(&old, &share), Value: &share}
+}
+
+func TestFileSave(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, config.ShareFileName)
+	assert.NoError(t, os.WriteFile(path, []byte(testShare), 0640))
+	source, err := NewFile(dir)
+	assert.NoError(t, err)
+
+	for i, maxNumOneEnd := range []int{10, 20} {
+		record := &Record{Action: ActionSet, UserID: "imAdmin", Changes: []Change{shareChange(t, testShare, maxNumOneEnd)}}
+		assert.NoError(t, source.Save(ctx, record))
+		assert.Equal(t, int64(i+1), record.Version)
+	}
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "# secret of the server"))
+	var share config.Share
+	assert.NoError(t, config.LoadData(data, "", &share))
+	assert.Equal(t, 20, share.MultiLogin.MaxNumOneEnd)
+	assert.Equal(t, "openIM123", share.Secret)
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	record, err := source.GetRecord(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionSet, record.Action)
+	assert.Equal(t, "multiLogin.maxNumOneEnd", record.Changes[0].Diff[0].Path)
+	record, err = source.GetRecord(ctx, 3)
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+
+	total, records, err := source.GetRecords(ctx, config.ShareFileName, 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, int64(2), records[0].Version)
+	total, _, err = source.GetRecords(ctx, config.RedisConfigFileName, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, total)
+
+	err = source.Save(ctx, &Record{Changes: []Change{{ConfigName: "../share.yml", Value: &share}}})
+	assert.Error(t, err)
+}
+
+func TestFileSaveSecrets(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, config.ShareFileName)
+	assert.NoError(t, os.WriteFile(path, []byte(testShare), 0640))
+	source, err := NewFile(dir)
+	assert.NoError(t, err)
+
+	// the secret loaded from the environment is neither written to the file nor to the record
+	change := shareChange(t, testShare, 10)
+	change.Value.(*config.Share).Secret = "fromEnv"
+	assert.NoError(t, source.Save(ctx, &Record{Action: ActionSet, Changes: []Change{change}}))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "secret: openIM123")
+	assert.NotContains(t, string(data), "fromEnv")
+	record, err := source.GetRecord(ctx, 1)
+	assert.NoError(t, err)
+	assert.NotContains(t, record.Changes[0].Data, "fromEnv")
+	assert.Contains(t, record.Changes[0].Data, config.SecretMask)
+
+	// no file is written when a config of the change can not be
+	invalid := shareChange(t, testShare, 30)
+	invalid.ConfigName = "../share.yml"
+	assert.Error(t, source.Save(ctx, &Record{Action: ActionSet, Changes: []Change{shareChange(t, testShare, 20), invalid}}))
+	var share config.Share
+	data, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, config.LoadData(data, "", &share))
+	assert.Equal(t, 10, share.MultiLogin.MaxNumOneEnd)
+	record, err = source.GetRecord(ctx, 2)
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestFileWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, config.ShareFileName), []byte(testShare), 0644))
+	source, err := NewFile(dir)
+	assert.NoError(t, err)
+
+	names := make(chan string, 16)
+	assert.NoError(t, source.Watch(ctx, []string{config.ShareFileName}, func(name string) { names <- name }))
+
+	wait := func(expected string) {
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case name := <-names:
+				if name == expected {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("%s is not reported", expected)
+			}
+		}
+	}
+	record := &Record{Action: ActionSet, Changes: []Change{shareChange(t, testShare, 10)}}
+	assert.NoError(t, source.Save(ctx, record))
+	wait(config.ShareFileName)
+	assert.NoError(t, source.Restart(ctx))
+	wait(RestartName)
+}
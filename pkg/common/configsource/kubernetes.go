@@ -0,0 +1,395 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// restartAnnotation is updated on the configmap to ask the services watching it to restart.
+	restartAnnotation = "openim.io/restart"
+	// historySuffix names the configmap holding the records of the configmap.
+	historySuffix = "-history"
+	// maxHistory is the number of records kept.
+	maxHistory = 100
+	// maxHistorySize is the size of the records kept, under the 1MiB limit of a configmap with room for its
+	// metadata.
+	maxHistorySize = 900 << 10
+)
+
+// kubernetesSource writes the configs to the configmap mounted as the config files, the keys of the configmap
+// are the config file names. It is always enabled.
+type kubernetesSource struct {
+	client      kubernetes.Interface
+	namespace   string
+	name        string
+	historySize int
+}
+
+// NewKubernetes returns the source of the configmap name in namespace with the in-cluster config.
+func NewKubernetes(namespace string, name string) (Source, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, errs.WrapMsg(err, "create in-cluster config failed")
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "create kubernetes client failed")
+	}
+	return newKubernetes(client, namespace, name)
+}
+
+func newKubernetes(client kubernetes.Interface, namespace string, name string) (Source, error) {
+	if name == "" {
+		return nil, errs.ErrArgs.WrapMsg("kubernetes configMap is not set")
+	}
+	return &kubernetesSource{client: client, namespace: namespace, name: name, historySize: maxHistorySize}, nil
+}
+
+func (k *kubernetesSource) configMaps() corev1.ConfigMapInterface {
+	return k.client.CoreV1().ConfigMaps(k.namespace)
+}
+
+// Load reads the config from the configmap rather than from the mounted file, which is updated by the kubelet
+// after a delay.
+func (k *kubernetesSource) Load(ctx context.Context, configName string, conf any) error {
+	cm, err := k.configMaps().Get(ctx, k.name, metav1.GetOptions{})
+	if err != nil {
+		log.ZWarn(ctx, "get config from configmap failed", errs.Wrap(err), "configMap", k.name, "configName", configName)
+		return nil
+	}
+	data, ok := cm.Data[configName]
+	if !ok {
+		return nil
+	}
+	return config.LoadData([]byte(data), config.EnvPrefixMap[configName], conf)
+}
+
+// Save merges the configs into the configmap, keeping the comments. The record is written first, so that a
+// change is not applied without its record, and removed when the configmap can not be updated. The record masks
+// the secrets, and the configmap only gets the secrets the change sets.
+func (k *kubernetesSource) Save(ctx context.Context, record *Record) error {
+	values := make([]any, len(record.Changes))
+	for i := range record.Changes {
+		value, err := changeValue(&record.Changes[i])
+		if err != nil {
+			return err
+		}
+		values[i] = value
+	}
+	stored, err := redactRecord(record)
+	if err != nil {
+		return err
+	}
+	if err := k.saveRecord(ctx, stored); err != nil {
+		return err
+	}
+	record.Version, record.CreateTime = stored.Version, stored.CreateTime
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := k.configMaps().Get(ctx, k.name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		for i, change := range record.Changes {
+			data, err := config.MergeYAML([]byte(cm.Data[change.ConfigName]), values[i], change.Diff)
+			if err != nil {
+				return err
+			}
+			cm.Data[change.ConfigName] = string(data)
+		}
+		_, err = k.configMaps().Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		if err := k.deleteRecord(ctx, record.Version); err != nil {
+			log.ZWarn(ctx, "delete config record of a failed save failed", err, "version", record.Version)
+		}
+		return errs.WrapMsg(err, "update configmap failed", "configMap", k.name)
+	}
+	return nil
+}
+
+// saveRecord stores record under the next version in the history configmap, dropping the oldest records beyond
+// maxHistory or the history size.
+func (k *kubernetesSource) saveRecord(ctx context.Context, record *Record) error {
+	historyName := k.name + historySuffix
+	err := retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		cm, err := k.configMaps().Get(ctx, historyName, metav1.GetOptions{})
+		exists := err == nil
+		if apierrors.IsNotFound(err) {
+			cm, err = &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: historyName, Namespace: k.namespace}}, nil
+		}
+		if err != nil {
+			return err
+		}
+		versions := historyVersions(cm)
+		record.Version = 1
+		if len(versions) > 0 {
+			record.Version = versions[0] + 1
+		}
+		record.CreateTime = time.Now().UnixMilli()
+		data, err := json.Marshal(record)
+		if err != nil {
+			return errs.Wrap(err)
+		}
+		size := len(historyKey(record.Version)) + len(data)
+		if size > k.historySize {
+			return errs.ErrArgs.WrapMsg("config record is too large", "size", size, "max", k.historySize)
+		}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[historyKey(record.Version)] = string(data)
+		for i, version := range versions {
+			key := historyKey(version)
+			if size += len(key) + len(cm.Data[key]); i+1 >= maxHistory || size > k.historySize {
+				for _, version := range versions[i:] {
+					delete(cm.Data, historyKey(version))
+				}
+				break
+			}
+		}
+		if exists {
+			_, err = k.configMaps().Update(ctx, cm, metav1.UpdateOptions{})
+		} else {
+			_, err = k.configMaps().Create(ctx, cm, metav1.CreateOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		if errs.ErrArgs.Is(err) {
+			return err
+		}
+		return errs.WrapMsg(err, "save config record failed", "configMap", historyName)
+	}
+	return nil
+}
+
+// deleteRecord removes the record of version from the history configmap.
+func (k *kubernetesSource) deleteRecord(ctx context.Context, version int64) error {
+	historyName := k.name + historySuffix
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := k.configMaps().Get(ctx, historyName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if _, ok := cm.Data[historyKey(version)]; !ok {
+			return nil
+		}
+		delete(cm.Data, historyKey(version))
+		_, err = k.configMaps().Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+	return errs.WrapMsg(err, "delete config record failed", "configMap", historyName, "version", version)
+}
+
+func historyKey(version int64) string {
+	// zero padded so that the records are sorted by version
+	return fmt.Sprintf("%020d", version)
+}
+
+// historyVersions returns the versions of the records of cm from the newest one.
+func historyVersions(cm *v1.ConfigMap) []int64 {
+	versions := make([]int64, 0, len(cm.Data))
+	for key := range cm.Data {
+		if version, err := strconv.ParseInt(key, 10, 64); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	return versions
+}
+
+func (k *kubernetesSource) getHistory(ctx context.Context) (*v1.ConfigMap, error) {
+	cm, err := k.configMaps().Get(ctx, k.name+historySuffix, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return &v1.ConfigMap{}, nil
+		}
+		return nil, errs.WrapMsg(err, "get config records failed", "configMap", k.name+historySuffix)
+	}
+	return cm, nil
+}
+
+func (k *kubernetesSource) GetRecord(ctx context.Context, version int64) (*Record, error) {
+	cm, err := k.getHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := cm.Data[historyKey(version)]
+	if !ok {
+		return nil, nil
+	}
+	var record Record
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return nil, errs.WrapMsg(err, "invalid config record", "version", version)
+	}
+	return &record, nil
+}
+
+func (k *kubernetesSource) GetRecords(ctx context.Context, configName string, offset int, count int) (int, []*Record, error) {
+	cm, err := k.getHistory(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	versions := historyVersions(cm)
+	records := make([]*Record, 0, len(versions))
+	for _, version := range versions {
+		var record Record
+		if err := json.Unmarshal([]byte(cm.Data[historyKey(version)]), &record); err != nil {
+			return 0, nil, errs.WrapMsg(err, "invalid config record", "version", version)
+		}
+		records = append(records, &record)
+	}
+	records = filter(records, configName)
+	return len(records), page(records, offset, count), nil
+}
+
+func (k *kubernetesSource) Enabled(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+func (k *kubernetesSource) SetEnabled(ctx context.Context, enable bool, record *Record) error {
+	if !enable {
+		return errs.ErrArgs.WrapMsg("the configmap is always used, the config manager can not be disabled")
+	}
+	return nil
+}
+
+func (k *kubernetesSource) Restart(ctx context.Context) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := k.configMaps().Get(ctx, k.name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if cm.Annotations == nil {
+			cm.Annotations = make(map[string]string)
+		}
+		cm.Annotations[restartAnnotation] = strconv.FormatInt(time.Now().UnixNano(), 10)
+		_, err = k.configMaps().Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return errs.WrapMsg(err, "restart update configmap failed", "configMap", k.name)
+	}
+	return nil
+}
+
+// Watch compares the watched configs and the restart annotation of each update of the configmap with the
+// previous one, the watch is opened again when it is closed by the api server.
+func (k *kubernetesSource) Watch(ctx context.Context, configNames []string, fn func(name string)) error {
+	cm, err := k.configMaps().Get(ctx, k.name, metav1.GetOptions{})
+	if err != nil {
+		return errs.WrapMsg(err, "get configmap failed", "configMap", k.name)
+	}
+	go func() {
+		last := cm
+		for ctx.Err() == nil {
+			var err error
+			last, err = k.watch(ctx, last, configNames, fn)
+			if err != nil {
+				log.ZError(ctx, "watch configmap err", err, "configMap", k.name)
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+	return nil
+}
+
+// watch reports the changes from last until the watch is closed, it returns the last seen configmap.
+func (k *kubernetesSource) watch(ctx context.Context, last *v1.ConfigMap, configNames []string, fn func(name string)) (*v1.ConfigMap, error) {
+	w, err := k.configMaps().Watch(ctx, metav1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", k.name).String(),
+		ResourceVersion: last.ResourceVersion,
+	})
+	if err != nil {
+		return k.resync(ctx, last, configNames, fn), errs.WrapMsg(err, "watch configmap failed")
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return last, nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return last, nil
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				cm, ok := event.Object.(*v1.ConfigMap)
+				if !ok {
+					continue
+				}
+				notify(last, cm, configNames, fn)
+				last = cm
+			case watch.Error:
+				// the resource version is too old, compare with the current configmap and watch from it
+				return k.resync(ctx, last, configNames, fn), errs.New("watch configmap error", "status", apierrors.FromObject(event.Object).Error()).Wrap()
+			}
+		}
+	}
+}
+
+// resync compares the current configmap with last, it returns last when the configmap can not be read.
+func (k *kubernetesSource) resync(ctx context.Context, last *v1.ConfigMap, configNames []string, fn func(name string)) *v1.ConfigMap {
+	cm, err := k.configMaps().Get(ctx, k.name, metav1.GetOptions{})
+	if err != nil {
+		return last
+	}
+	notify(last, cm, configNames, fn)
+	return cm
+}
+
+func notify(last *v1.ConfigMap, cm *v1.ConfigMap, configNames []string, fn func(name string)) {
+	for _, name := range configNames {
+		if last.Data[name] != cm.Data[name] {
+			fn(name)
+		}
+	}
+	if last.Annotations[restartAnnotation] != cm.Annotations[restartAnnotation] {
+		fn(RestartName)
+	}
+}
+
+func (k *kubernetesSource) Close() error {
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configsource
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestKubernetesSave(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "openim-config", Namespace: "default"},
+		Data:       map[string]string{config.ShareFileName: testShare},
+	})
+	source, err := newKubernetes(client, "default", "openim-config")
+	assert.NoError(t, err)
+
+	for i, maxNumOneEnd := range []int{10, 20} {
+		record := &Record{Action: ActionSet, UserID: "imAdmin", Changes: []Change{shareChange(t, testShare, maxNumOneEnd)}}
+		assert.NoError(t, source.Save(ctx, record))
+		assert.Equal(t, int64(i+1), record.Version)
+	}
+	cm, err := client.CoreV1().ConfigMaps("default").Get(ctx, "openim-config", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(cm.Data[config.ShareFileName], "# secret of the server"))
+
+	var share config.Share
+	assert.NoError(t, source.Load(ctx, config.ShareFileName, &share))
+	assert.Equal(t, 20, share.MultiLogin.MaxNumOneEnd)
+	assert.Equal(t, "openIM123", share.Secret)
+
+	total, records, err := source.GetRecords(ctx, "", 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, int64(1), records[0].Version)
+	record, err := source.GetRecord(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "imAdmin", record.UserID)
+
+	assert.NoError(t, source.Restart(ctx))
+	restarted, err := client.CoreV1().ConfigMaps("default").Get(ctx, "openim-config", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, restarted.Annotations[restartAnnotation])
+
+	var names []string
+	notify(cm, restarted, []string{config.ShareFileName}, func(name string) { names = append(names, name) })
+	assert.Equal(t, []string{RestartName}, names)
+}
+
+func TestKubernetesHistory(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "openim-config", Namespace: "default"},
+		Data:       map[string]string{config.ShareFileName: testShare},
+	})
+	source, err := newKubernetes(client, "default", "openim-config")
+	assert.NoError(t, err)
+	// the size of a record is padded by its user id, the data of its changes is made from their values
+	newRecord := func(padding string, maxNumOneEnd int) *Record {
+		return &Record{Action: ActionSet, UserID: "imAdmin" + padding, Changes: []Change{shareChange(t, testShare, maxNumOneEnd)}}
+	}
+
+	// the records beyond the size of the history are dropped, the oldest first
+	source.(*kubernetesSource).historySize = 3000
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, source.Save(ctx, newRecord(strings.Repeat("a", 700), 10+i)))
+	}
+	total, records, err := source.GetRecords(ctx, "", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, int64(5), records[0].Version)
+	assert.Equal(t, int64(4), records[1].Version)
+
+	// a record larger than the history is rejected before the change is applied
+	assert.Error(t, source.Save(ctx, newRecord(strings.Repeat("a", 4000), 30)))
+	var share config.Share
+	assert.NoError(t, source.Load(ctx, config.ShareFileName, &share))
+	assert.Equal(t, 14, share.MultiLogin.MaxNumOneEnd)
+
+	// the record of a change which can not be applied is removed
+	client.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.UpdateAction).GetObject().(*v1.ConfigMap).Name != "openim-config" {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "openim-config", nil)
+	})
+	assert.Error(t, source.Save(ctx, newRecord("", 40)))
+	total, _, err = source.GetRecords(ctx, "", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	record, err := source.GetRecord(ctx, 6)
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+}
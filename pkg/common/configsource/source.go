@@ -0,0 +1,212 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package configsource stores the configs written by the config manager of the api and tells the services when
+// they change. The configs live in etcd, in the config files or in the kubernetes configmap mounted as the config
+// files, depending on the deployment.
+package configsource
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/tools/discovery"
+	"github.com/openimsdk/tools/errs"
+)
+
+const (
+	SourceEtcd       = "etcd"
+	SourceFile       = "file"
+	SourceKubernetes = "kubernetes"
+)
+
+const (
+	ActionSet      = "set"
+	ActionReset    = "reset"
+	ActionRollback = "rollback"
+)
+
+// RestartName is the name passed to the watchers when a restart is asked.
+const RestartName = "restart"
+
+// Change is a config file written by a change. Data is the config in json and holds the secrets in plain, the
+// records of the file and kubernetes sources mask them. Value is the config struct written by the file and
+// kubernetes sources.
+type Change struct {
+	ConfigName string               `json:"configName"`
+	Diff       []config.FieldChange `json:"diff"`
+	Data       string               `json:"data"`
+	Value      any                  `json:"-"`
+}
+
+// Record is a versioned change of the configs made by an admin.
+type Record struct {
+	Version int64  `json:"version"`
+	Action  string `json:"action"`
+	UserID  string `json:"userID"`
+	// RollbackVersion is the version restored by a rollback.
+	RollbackVersion int64    `json:"rollbackVersion,omitempty"`
+	CreateTime      int64    `json:"createTime"`
+	Changes         []Change `json:"changes"`
+}
+
+func (r *Record) hasConfig(configName string) bool {
+	for _, change := range r.Changes {
+		if change.ConfigName == configName {
+			return true
+		}
+	}
+	return false
+}
+
+// Source stores the configs and the records of their changes.
+type Source interface {
+	// Load reads the config from the source into conf, conf is left unchanged when the source does not hold it.
+	Load(ctx context.Context, configName string, conf any) error
+	// Save writes the configs of record and stores the record under the next version.
+	Save(ctx context.Context, record *Record) error
+	// GetRecord returns the record of version, nil if there is none.
+	GetRecord(ctx context.Context, version int64) (*Record, error)
+	// GetRecords returns the records from the newest one, skipping offset and returning at most count of them,
+	// filtered by configName when it is not empty.
+	GetRecords(ctx context.Context, configName string, offset int, count int) (int, []*Record, error)
+	// Enabled reports whether the services load their configs from the source.
+	Enabled(ctx context.Context) (bool, error)
+	// SetEnabled switches the services between the source and the config files, the configs of record are
+	// saved along when the source is enabled.
+	SetEnabled(ctx context.Context, enable bool, record *Record) error
+	// Restart asks the services watching the source to restart.
+	Restart(ctx context.Context) error
+	// Watch calls fn with the name of a config of configNames when it changes, or with RestartName when a
+	// restart is asked, until ctx is done.
+	Watch(ctx context.Context, configNames []string, fn func(name string)) error
+	Close() error
+}
+
+// Name returns the source of the deployment, the configured one or the one matching its discovery.
+func Name(discovery *config.Discovery, runtimeEnv string) string {
+	if discovery.ConfigSource != "" {
+		return discovery.ConfigSource
+	}
+	if runtimeEnv == config.KUBERNETES {
+		return SourceKubernetes
+	}
+	if discovery.Enable == config.ETCD {
+		return SourceEtcd
+	}
+	return SourceFile
+}
+
+// New opens the source of the deployment. The etcd source shares the client of registry when the services
+// are registered in etcd, the file source writes the files of configPath.
+func New(discovery *config.Discovery, runtimeEnv string, configPath string, registry discovery.SvcDiscoveryRegistry) (Source, error) {
+	switch name := Name(discovery, runtimeEnv); name {
+	case SourceEtcd:
+		return NewEtcd(&discovery.Etcd, registry)
+	case SourceFile:
+		if runtimeEnv == config.KUBERNETES {
+			configPath = os.Getenv(config.MountConfigFilePath)
+		}
+		return NewFile(configPath)
+	case SourceKubernetes:
+		return NewKubernetes(discovery.Kubernetes.Namespace, discovery.Kubernetes.ConfigMap)
+	default:
+		return nil, errs.ErrArgs.WrapMsg("unsupported config source", "configSource", name)
+	}
+}
+
+// filter returns the records changing configName, all of them when it is empty.
+func filter(records []*Record, configName string) []*Record {
+	if configName == "" {
+		return records
+	}
+	res := make([]*Record, 0, len(records))
+	for _, record := range records {
+		if record.hasConfig(configName) {
+			res = append(res, record)
+		}
+	}
+	return res
+}
+
+// page returns count records from offset, all of them when count is not positive.
+func page(records []*Record, offset int, count int) []*Record {
+	if offset >= len(records) {
+		return nil
+	}
+	records = records[offset:]
+	if count > 0 && count < len(records) {
+		records = records[:count]
+	}
+	return records
+}
+
+// VersionChanges returns the changes restoring the configs to their state at version, records are all the records
+// from the newest one. A config changed after version is restored to its data in the newest record up to version
+// holding it, the configs held by no record up to version are returned in missing and can not be restored.
+func VersionChanges(records []*Record, version int64) (changes []Change, missing []string) {
+	changed := make(map[string]struct{})
+	restored := make(map[string]struct{})
+	for _, record := range records {
+		for _, change := range record.Changes {
+			if record.Version > version {
+				changed[change.ConfigName] = struct{}{}
+				continue
+			}
+			if _, ok := changed[change.ConfigName]; !ok {
+				continue
+			}
+			if _, ok := restored[change.ConfigName]; ok {
+				continue
+			}
+			restored[change.ConfigName] = struct{}{}
+			changes = append(changes, change)
+		}
+	}
+	for configName := range changed {
+		if _, ok := restored[configName]; !ok {
+			missing = append(missing, configName)
+		}
+	}
+	return changes, missing
+}
+
+// changeValue returns the config struct of a change written by the file and kubernetes sources.
+func changeValue(change *Change) (any, error) {
+	if change.Value == nil {
+		return nil, errs.ErrArgs.WrapMsg("config value of the change is missing", "configName", change.ConfigName)
+	}
+	return change.Value, nil
+}
+
+// redactRecord returns a copy of the record stored by the file and kubernetes sources, its data masks the secrets
+// as they may come from the environment of the api.
+func redactRecord(record *Record) (*Record, error) {
+	stored := *record
+	stored.Changes = make([]Change, len(record.Changes))
+	for i, change := range record.Changes {
+		value, err := changeValue(&change)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(config.Redact(value))
+		if err != nil {
+			return nil, errs.Wrap(err)
+		}
+		stored.Changes[i] = Change{ConfigName: change.ConfigName, Diff: change.Diff, Data: string(data)}
+	}
+	return &stored, nil
+}
@@ -0,0 +1,43 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configsource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionChanges(t *testing.T) {
+	records := []*Record{
+		{Version: 5, Changes: []Change{{ConfigName: "share", Data: "share5"}, {ConfigName: "log", Data: "log5"}}},
+		{Version: 4, Changes: []Change{{ConfigName: "redis", Data: "redis4"}}},
+		{Version: 3, Changes: []Change{{ConfigName: "share", Data: "share3"}}},
+		{Version: 2, Changes: []Change{{ConfigName: "redis", Data: "redis2"}}},
+		{Version: 1, Changes: []Change{{ConfigName: "share", Data: "share1"}, {ConfigName: "redis", Data: "redis1"}}},
+	}
+	changes, missing := VersionChanges(records, 3)
+	data := make(map[string]string)
+	for _, change := range changes {
+		data[change.ConfigName] = change.Data
+	}
+	// share changed by 5 is restored from 3, redis changed by 4 from 2, log is held by no version up to 3
+	assert.Equal(t, map[string]string{"share": "share3", "redis": "redis2"}, data)
+	assert.Equal(t, []string{"log"}, missing)
+
+	changes, missing = VersionChanges(records, 5)
+	assert.Empty(t, changes)
+	assert.Empty(t, missing)
+}
@@ -14,161 +14,246 @@
 
 package direct
 
-//import (
-//	"context"
-//	"fmt"
-//
-//	config2 "github.com/openimsdk/open-im-server/v3/pkg/common/config"
-//	"github.com/openimsdk/tools/errs"
-//	"google.golang.org/grpc"
-//	"google.golang.org/grpc/credentials/insecure"
-//)
-//
-//type ServiceAddresses map[string][]int
-//
-//func getServiceAddresses(rpcRegisterName *config2.RpcRegisterName,
-//	rpcPort *config2.RpcPort, longConnSvrPort []int) ServiceAddresses {
-//	return ServiceAddresses{
-//		rpcRegisterName.OpenImUserName:           rpcPort.OpenImUserPort,
-//		rpcRegisterName.OpenImFriendName:         rpcPort.OpenImFriendPort,
-//		rpcRegisterName.OpenImMsgName:            rpcPort.OpenImMessagePort,
-//		rpcRegisterName.OpenImMessageGatewayName: longConnSvrPort,
-//		rpcRegisterName.OpenImGroupName:          rpcPort.OpenImGroupPort,
-//		rpcRegisterName.OpenImAuthName:           rpcPort.OpenImAuthPort,
-//		rpcRegisterName.OpenImPushName:           rpcPort.OpenImPushPort,
-//		rpcRegisterName.OpenImConversationName:   rpcPort.OpenImConversationPort,
-//		rpcRegisterName.OpenImThirdName:          rpcPort.OpenImThirdPort,
-//	}
-//}
-//
-//type ConnDirect struct {
-//	additionalOpts        []grpc.DialOption
-//	currentServiceAddress string
-//	conns                 map[string][]*grpc.ClientConn
-//	resolverDirect        *ResolverDirect
-//	config                *config2.GlobalConfig
-//}
-//
-//func (cd *ConnDirect) GetClientLocalConns() map[string][]*grpc.ClientConn {
-//	return nil
-//}
-//
-//func (cd *ConnDirect) GetUserIdHashGatewayHost(ctx context.Context, userId string) (string, error) {
-//	return "", nil
-//}
-//
-//func (cd *ConnDirect) Register(serviceName, host string, port int, opts ...grpc.DialOption) error {
-//	return nil
-//}
-//
-//func (cd *ConnDirect) UnRegister() error {
-//	return nil
-//}
-//
-//func (cd *ConnDirect) CreateRpcRootNodes(serviceNames []string) error {
-//	return nil
-//}
-//
-//func (cd *ConnDirect) RegisterConf2Registry(key string, conf []byte) error {
-//	return nil
-//}
-//
-//func (cd *ConnDirect) GetConfFromRegistry(key string) ([]byte, error) {
-//	return nil, nil
-//}
-//
-//func (cd *ConnDirect) Close() {
-//
-//}
-//
-//func NewConnDirect(config *config2.GlobalConfig) (*ConnDirect, error) {
-//	return &ConnDirect{
-//		conns:          make(map[string][]*grpc.ClientConn),
-//		resolverDirect: NewResolverDirect(),
-//		config:         config,
-//	}, nil
-//}
-//
-//func (cd *ConnDirect) GetConns(ctx context.Context,
-//	serviceName string, opts ...grpc.DialOption) ([]*grpc.ClientConn, error) {
-//
-//	if conns, exists := cd.conns[serviceName]; exists {
-//		return conns, nil
-//	}
-//	ports := getServiceAddresses(&cd.config.RpcRegisterName,
-//		&cd.config.RpcPort, cd.config.LongConnSvr.OpenImMessageGatewayPort)[serviceName]
-//	var connections []*grpc.ClientConn
-//	for _, port := range ports {
-//		conn, err := cd.dialServiceWithoutResolver(ctx, fmt.Sprintf(cd.config.Rpc.ListenIP+":%d", port), append(cd.additionalOpts, opts...)...)
-//		if err != nil {
-//			return nil, errs.Wrap(fmt.Errorf("connect to port %d failed,serviceName %s, IP %s", port, serviceName, cd.config.Rpc.ListenIP))
-//		}
-//		connections = append(connections, conn)
-//	}
-//
-//	if len(connections) == 0 {
-//		return nil, errs.New("no connections found for service", "serviceName", serviceName).Wrap()
-//	}
-//	return connections, nil
-//}
-//
-//func (cd *ConnDirect) GetConn(ctx context.Context, serviceName string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
-//	// Get service addresses
-//	addresses := getServiceAddresses(&cd.config.RpcRegisterName,
-//		&cd.config.RpcPort, cd.config.LongConnSvr.OpenImMessageGatewayPort)
-//	address, ok := addresses[serviceName]
-//	if !ok {
-//		return nil, errs.New("unknown service name", "serviceName", serviceName).Wrap()
-//	}
-//	var result string
-//	for _, addr := range address {
-//		if result != "" {
-//			result = result + "," + fmt.Sprintf(cd.config.Rpc.ListenIP+":%d", addr)
-//		} else {
-//			result = fmt.Sprintf(cd.config.Rpc.ListenIP+":%d", addr)
-//		}
-//	}
-//	// Try to dial a new connection
-//	conn, err := cd.dialService(ctx, result, append(cd.additionalOpts, opts...)...)
-//	if err != nil {
-//		return nil, errs.WrapMsg(err, "address", result)
-//	}
-//
-//	// Store the new connection
-//	cd.conns[serviceName] = append(cd.conns[serviceName], conn)
-//	return conn, nil
-//}
-//
-//func (cd *ConnDirect) GetSelfConnTarget() string {
-//	return cd.currentServiceAddress
-//}
-//
-//func (cd *ConnDirect) AddOption(opts ...grpc.DialOption) {
-//	cd.additionalOpts = append(cd.additionalOpts, opts...)
-//}
-//
-//func (cd *ConnDirect) CloseConn(conn *grpc.ClientConn) {
-//	if conn != nil {
-//		conn.Close()
-//	}
-//}
-//
-//func (cd *ConnDirect) dialService(ctx context.Context, address string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
-//	options := append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-//	conn, err := grpc.DialContext(ctx, cd.resolverDirect.Scheme()+":///"+address, options...)
-//
-//	if err != nil {
-//		return nil, errs.WrapMsg(err, "address", address)
-//	}
-//	return conn, nil
-//}
-//
-//func (cd *ConnDirect) dialServiceWithoutResolver(ctx context.Context, address string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
-//	options := append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-//	conn, err := grpc.DialContext(ctx, address, options...)
-//
-//	if err != nil {
-//		return nil, errs.Wrap(err)
-//	}
-//	return conn, nil
-//}
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/mcontext"
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	defaultHealthCheckInterval = 10 * time.Second
+	healthCheckTimeout         = 3 * time.Second
+
+	// serviceConfig enables round-robin and the client side health check of grpc for the conn returned by GetConn.
+	serviceConfig = `{"loadBalancingPolicy":"round_robin","healthCheckConfig":{"serviceName":""}}`
+)
+
+// ConnDirect is a service discovery that reaches every rpc service at the fixed addresses of the discovery config.
+type ConnDirect struct {
+	addresses   map[string][]string
+	dialOptions []grpc.DialOption
+	interval    time.Duration
+	selfTarget  string
+
+	mu        sync.RWMutex
+	connMap   map[string]*grpc.ClientConn // address -> conn
+	unhealthy map[string]struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewConnDirect creates a service discovery from the direct addresses of the discovery config.
+func NewConnDirect(discovery *config.Discovery, options ...grpc.DialOption) (*ConnDirect, error) {
+	addresses := make(map[string][]string)
+	for serviceName, addrs := range discovery.GetServiceAddresses() {
+		if serviceName == "" || len(addrs) == 0 {
+			continue
+		}
+		for _, addr := range addrs {
+			if strings.ContainsRune(addr, EndpointSepChar) {
+				return nil, errs.New("invalid direct address", "serviceName", serviceName, "address", addr).Wrap()
+			}
+		}
+		addresses[serviceName] = addrs
+	}
+	if len(addresses) == 0 {
+		return nil, errs.New("no direct address configured").Wrap()
+	}
+	interval := time.Duration(discovery.Direct.HealthCheckInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	cd := &ConnDirect{
+		addresses:   addresses,
+		dialOptions: options,
+		interval:    interval,
+		connMap:     make(map[string]*grpc.ClientConn),
+		unhealthy:   make(map[string]struct{}),
+		done:        make(chan struct{}),
+	}
+	go cd.healthCheck()
+	return cd, nil
+}
+
+func (cd *ConnDirect) getAddresses(serviceName string) ([]string, error) {
+	addrs, ok := cd.addresses[serviceName]
+	if !ok {
+		return nil, errs.New("no direct address for service", "serviceName", serviceName).Wrap()
+	}
+	return addrs, nil
+}
+
+// GetConns returns a conn for every healthy address of the service.
+func (cd *ConnDirect) GetConns(ctx context.Context, serviceName string, opts ...grpc.DialOption) ([]*grpc.ClientConn, error) {
+	addrs, err := cd.getAddresses(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	conns := make([]*grpc.ClientConn, 0, len(addrs))
+	for _, addr := range addrs {
+		conn, healthy, err := cd.getAddrConn(ctx, addr, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if healthy {
+			conns = append(conns, conn)
+		}
+	}
+	return conns, nil
+}
+
+func (cd *ConnDirect) getAddrConn(ctx context.Context, addr string, opts ...grpc.DialOption) (*grpc.ClientConn, bool, error) {
+	cd.mu.RLock()
+	conn, ok := cd.connMap[addr]
+	_, unhealthy := cd.unhealthy[addr]
+	cd.mu.RUnlock()
+	if ok {
+		return conn, !unhealthy, nil
+	}
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+	if conn, ok := cd.connMap[addr]; ok {
+		_, unhealthy := cd.unhealthy[addr]
+		return conn, !unhealthy, nil
+	}
+	conn, err := grpc.DialContext(ctx, addr, append(append([]grpc.DialOption{}, cd.dialOptions...), opts...)...)
+	if err != nil {
+		return nil, false, errs.WrapMsg(err, "dial direct address failed", "address", addr)
+	}
+	cd.connMap[addr] = conn
+	return conn, true, nil
+}
+
+// GetConn returns a conn that balances requests over all addresses of the service with round-robin,
+// addresses failing the grpc health check are skipped until they recover.
+func (cd *ConnDirect) GetConn(ctx context.Context, serviceName string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	addrs, err := cd.getAddresses(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	target := fmt.Sprintf("%s:///%s", scheme, strings.Join(addrs, string(EndpointSepChar)))
+	cd.mu.RLock()
+	dialOpts := append(append(append([]grpc.DialOption{}, cd.dialOptions...), opts...), grpc.WithDefaultServiceConfig(serviceConfig))
+	cd.mu.RUnlock()
+	conn, err := grpc.DialContext(ctx, target, dialOpts...)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "dial direct target failed", "target", target)
+	}
+	return conn, nil
+}
+
+// GetSelfConnTarget returns the address registered by the current service.
+func (cd *ConnDirect) GetSelfConnTarget() string {
+	return cd.selfTarget
+}
+
+// AddOption appends gRPC dial options to the existing options, the cached conns are closed so that they are redialed with the new options.
+func (cd *ConnDirect) AddOption(opts ...grpc.DialOption) {
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+	cd.resetConnMap()
+	cd.dialOptions = append(cd.dialOptions, opts...)
+}
+
+// CloseConn closes a given gRPC client connection.
+func (cd *ConnDirect) CloseConn(conn *grpc.ClientConn) {
+	conn.Close()
+}
+
+// Register only records the address of the current service, the addresses of the other services are static.
+func (cd *ConnDirect) Register(serviceName, host string, port int, opts ...grpc.DialOption) error {
+	cd.selfTarget = fmt.Sprintf("%s:%d", host, port)
+	return nil
+}
+
+func (cd *ConnDirect) UnRegister() error {
+	return nil
+}
+
+// Close stops the health check and closes all cached conns.
+func (cd *ConnDirect) Close() {
+	cd.closeOnce.Do(func() { close(cd.done) })
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+	cd.resetConnMap()
+}
+
+func (cd *ConnDirect) GetUserIdHashGatewayHost(ctx context.Context, userId string) (string, error) {
+	return "", nil
+}
+
+func (cd *ConnDirect) resetConnMap() {
+	for _, conn := range cd.connMap {
+		_ = conn.Close()
+	}
+	cd.connMap = make(map[string]*grpc.ClientConn)
+	cd.unhealthy = make(map[string]struct{})
+}
+
+func (cd *ConnDirect) healthCheck() {
+	ticker := time.NewTicker(cd.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-cd.done:
+			return
+		case <-ticker.C:
+			cd.checkConns()
+		}
+	}
+}
+
+func (cd *ConnDirect) checkConns() {
+	cd.mu.RLock()
+	conns := make(map[string]*grpc.ClientConn, len(cd.connMap))
+	for addr, conn := range cd.connMap {
+		conns[addr] = conn
+	}
+	cd.mu.RUnlock()
+	unhealthy := make(map[string]struct{})
+	for addr, conn := range conns {
+		if err := checkConn(conn); err != nil {
+			log.ZWarn(context.Background(), "direct address is unhealthy", err, "address", addr)
+			unhealthy[addr] = struct{}{}
+		}
+	}
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+	for addr, conn := range conns {
+		if cd.connMap[addr] != conn {
+			continue
+		}
+		if _, ok := unhealthy[addr]; ok {
+			cd.unhealthy[addr] = struct{}{}
+		} else {
+			delete(cd.unhealthy, addr)
+		}
+	}
+}
+
+// checkConn calls the grpc health service registered by startrpc on every rpc server.
+func checkConn(conn *grpc.ClientConn) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	ctx = mcontext.SetOperationID(ctx, "direct_health_check_"+strconv.FormatInt(time.Now().UnixMilli(), 10))
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return errs.New("health check not serving", "status", resp.GetStatus().String()).Wrap()
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package direct
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func startHealthServer(t *testing.T) (string, *health.Server) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+	return listener.Addr().String(), hs
+}
+
+func TestConnDirect(t *testing.T) {
+	addr1, _ := startHealthServer(t)
+	addr2, hs2 := startHealthServer(t)
+
+	var discovery config.Discovery
+	discovery.RpcService.User = "user-rpc-service"
+	discovery.Direct.Addresses.User = []string{addr1, addr2}
+	cd, err := NewConnDirect(&discovery, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cd.Close()
+
+	ctx := context.Background()
+	_, err = cd.GetConn(ctx, "friend-rpc-service")
+	assert.Error(t, err)
+
+	conn, err := cd.GetConn(ctx, "user-rpc-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	conn.Close()
+
+	conns, err := cd.GetConns(ctx, "user-rpc-service")
+	assert.NoError(t, err)
+	assert.Len(t, conns, 2)
+
+	hs2.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	cd.checkConns()
+	conns, err = cd.GetConns(ctx, "user-rpc-service")
+	assert.NoError(t, err)
+	assert.Len(t, conns, 1)
+
+	hs2.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	cd.checkConns()
+	conns, err = cd.GetConns(ctx, "user-rpc-service")
+	assert.NoError(t, err)
+	assert.Len(t, conns, 2)
+}
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/discovery/direct"
 	"github.com/openimsdk/tools/discovery"
 	"google.golang.org/grpc"
 
@@ -46,6 +47,12 @@ func NewDiscoveryRegister(discovery *config.Discovery, runtimeEnv string, watchN
 			etcd.WithDialTimeout(10*time.Second),
 			etcd.WithMaxCallSendMsgSize(20*1024*1024),
 			etcd.WithUsernameAndPassword(discovery.Etcd.Username, discovery.Etcd.Password))
+	case config.DIRECT:
+		return direct.NewConnDirect(discovery,
+			grpc.WithDefaultCallOptions(
+				grpc.MaxCallSendMsgSize(1024*1024*20),
+			),
+		)
 	default:
 		return nil, errs.New("unsupported discovery type", "type", discovery.Enable).Wrap()
 	}
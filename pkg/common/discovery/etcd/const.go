@@ -7,3 +7,15 @@ const (
 	Enable                = "enable"
 	Disable               = "disable"
 )
+
+const (
+	// ConfigVersionKey holds the version of the last config change.
+	ConfigVersionKey = "/open-im/config-version"
+	// ConfigHistoryKeyPrefix prefixes the change records, they live outside ConfigKeyPrefix so that the config
+	// watchers ignore them.
+	ConfigHistoryKeyPrefix = "/open-im/config-history/"
+)
+
+func BuildKey(s string) string {
+	return ConfigKeyPrefix + s
+}
@@ -50,6 +50,7 @@ const (
 	NoPermissionError   = 1002 // Insufficient permission
 	DuplicateKeyError   = 1003
 	RecordNotFoundError = 1004 // Record does not exist
+	RateLimitExceeded   = 1005 // Too many requests
 
 	// Account error codes.
 	UserIDNotFoundError    = 1101 // UserID does not exist or is not registered
@@ -70,10 +71,17 @@ const (
 	RelationshipAlreadyError = 1304 // Already in a friend relationship
 
 	// Message error codes.
-	MessageHasReadDisable = 1401
-	MutedInGroup          = 1402 // Member muted in the group
-	MutedGroup            = 1403 // Group is muted
-	MsgAlreadyRevoke      = 1404 // Message already revoked
+	MessageHasReadDisable  = 1401
+	MutedInGroup           = 1402 // Member muted in the group
+	MutedGroup             = 1403 // Group is muted
+	MsgAlreadyRevoke       = 1404 // Message already revoked
+	MsgRejected            = 1405 // Message rejected by an interceptor
+	MsgContentTooLong      = 1406 // Message content exceeds the max length
+	MsgContentTypeDenied   = 1407 // Message content type not allowed in the session type
+	SensitiveWordError     = 1408 // Text contains sensitive words
+	MsgRevokeExpired       = 1409 // Message revoke window has passed
+	MsgEditExpired         = 1410 // Message edit window has passed
+	ScheduledMsgNotPending = 1411 // Scheduled message already sent or canceled
 
 	// Token error codes.
 	TokenExpiredError     = 1501
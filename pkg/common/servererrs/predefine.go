@@ -22,11 +22,12 @@ var (
 	ErrCallback         = errs.NewCodeError(CallbackError, "CallbackError")
 	ErrCallbackContinue = errs.NewCodeError(CallbackError, "ErrCallbackContinue")
 
-	ErrInternalServer = errs.NewCodeError(ServerInternalError, "ServerInternalError")
-	ErrArgs           = errs.NewCodeError(ArgsError, "ArgsError")
-	ErrNoPermission   = errs.NewCodeError(NoPermissionError, "NoPermissionError")
-	ErrDuplicateKey   = errs.NewCodeError(DuplicateKeyError, "DuplicateKeyError")
-	ErrRecordNotFound = errs.NewCodeError(RecordNotFoundError, "RecordNotFoundError")
+	ErrInternalServer    = errs.NewCodeError(ServerInternalError, "ServerInternalError")
+	ErrArgs              = errs.NewCodeError(ArgsError, "ArgsError")
+	ErrNoPermission      = errs.NewCodeError(NoPermissionError, "NoPermissionError")
+	ErrDuplicateKey      = errs.NewCodeError(DuplicateKeyError, "DuplicateKeyError")
+	ErrRecordNotFound    = errs.NewCodeError(RecordNotFoundError, "RecordNotFoundError")
+	ErrRateLimitExceeded = errs.NewCodeError(RateLimitExceeded, "RateLimitExceeded")
 
 	ErrUserIDNotFound  = errs.NewCodeError(UserIDNotFoundError, "UserIDNotFoundError")
 	ErrGroupIDNotFound = errs.NewCodeError(GroupIDNotFoundError, "GroupIDNotFoundError")
@@ -58,6 +59,14 @@ var (
 	ErrMutedGroup       = errs.NewCodeError(MutedGroup, "MutedGroup")
 	ErrMsgAlreadyRevoke = errs.NewCodeError(MsgAlreadyRevoke, "MsgAlreadyRevoke")
 
+	ErrMsgRejected            = errs.NewCodeError(MsgRejected, "MsgRejected")
+	ErrMsgContentTooLong      = errs.NewCodeError(MsgContentTooLong, "MsgContentTooLong")
+	ErrMsgContentTypeDenied   = errs.NewCodeError(MsgContentTypeDenied, "MsgContentTypeDenied")
+	ErrSensitiveWord          = errs.NewCodeError(SensitiveWordError, "SensitiveWordError")
+	ErrMsgRevokeExpired       = errs.NewCodeError(MsgRevokeExpired, "MsgRevokeExpired")
+	ErrMsgEditExpired         = errs.NewCodeError(MsgEditExpired, "MsgEditExpired")
+	ErrScheduledMsgNotPending = errs.NewCodeError(ScheduledMsgNotPending, "ScheduledMsgNotPending")
+
 	ErrConnOverMaxNumLimit = errs.NewCodeError(ConnOverMaxNumLimit, "ConnOverMaxNumLimit")
 
 	ErrConnArgsErr          = errs.NewCodeError(ConnArgsErr, "args err, need token, sendID, platformID")
@@ -27,16 +27,19 @@ import (
 	"time"
 
 	conf "github.com/openimsdk/open-im-server/v3/pkg/common/config"
-	disetcd "github.com/openimsdk/open-im-server/v3/pkg/common/discovery/etcd"
 	"github.com/openimsdk/tools/discovery/etcd"
 	"github.com/openimsdk/tools/utils/datautil"
 	"github.com/openimsdk/tools/utils/jsonutil"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 
 	"github.com/openimsdk/tools/utils/runtimeenv"
 
+	"github.com/openimsdk/open-im-server/v3/pkg/common/configsource"
 	kdisc "github.com/openimsdk/open-im-server/v3/pkg/common/discovery"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/prommetrics"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
 	"github.com/openimsdk/tools/discovery"
 	"github.com/openimsdk/tools/errs"
 	"github.com/openimsdk/tools/log"
@@ -47,7 +50,7 @@ import (
 )
 
 // Start rpc server.
-func Start[T any](ctx context.Context, discovery *conf.Discovery, prometheusConfig *conf.Prometheus, listenIP,
+func Start[T any](ctx context.Context, discovery *conf.Discovery, configPath string, prometheusConfig *conf.Prometheus, tracingConfig *conf.Tracing, listenIP,
 	registerIP string, autoSetPorts bool, rpcPorts []int, index int, rpcRegisterName string, notification *conf.Notification, config T,
 	watchConfigNames []string, watchServiceNames []string,
 	rpcFn func(ctx context.Context, config T, client discovery.SvcDiscoveryRegistry, server *grpc.Server) error,
@@ -103,6 +106,16 @@ func Start[T any](ctx context.Context, discovery *conf.Discovery, prometheusConf
 	defer client.Close()
 	client.AddOption(mw.GrpcClient(), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, "round_robin")))
 
+	shutdownTracing, err := tracing.Init(ctx, rpcRegisterName, tracingConfig)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+	if tracingConfig.Enable {
+		client.AddOption(tracing.GrpcDialOption())
+		options = append(options, tracing.GrpcServerOption())
+	}
+
 	// var reg *prometheus.Registry
 	// var metric *grpcprometheus.ServerMetrics
 	if prometheusConfig.Enable {
@@ -170,6 +183,7 @@ func Start[T any](ctx context.Context, discovery *conf.Discovery, prometheusConf
 
 	defer listener.Close()
 	srv := grpc.NewServer(options...)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
 
 	err = rpcFn(ctx, config, client, srv)
 	if err != nil {
@@ -194,9 +208,8 @@ func Start[T any](ctx context.Context, discovery *conf.Discovery, prometheusConf
 		}
 	}()
 
-	if discovery.Enable == conf.ETCD {
-		cm := disetcd.NewConfigManager(client.(*etcd.SvcDiscoveryRegistryImpl).GetClient(), watchConfigNames)
-		cm.Watch(ctx)
+	if err := configsource.Watch(ctx, discovery, runTimeEnv, configPath, client, watchConfigNames); err != nil {
+		log.ZWarn(ctx, "watch config failed", err)
 	}
 
 	sigs := make(chan os.Signal, 1)
@@ -0,0 +1,38 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cachekey
+
+import "strconv"
+
+const (
+	mqStream  = "MQ_STREAM:"
+	mqLease   = "MQ_LEASE:"
+	mqMembers = "MQ_MEMBERS:"
+)
+
+// GetMQStreamKey returns the key of the stream of a partition of a topic.
+func GetMQStreamKey(topic string, partition int) string {
+	return mqStream + topic + ":" + strconv.Itoa(partition)
+}
+
+// GetMQLeaseKey returns the key holding the consumer owning a partition of a topic within a group.
+func GetMQLeaseKey(topic string, groupID string, partition int) string {
+	return mqLease + topic + ":" + groupID + ":" + strconv.Itoa(partition)
+}
+
+// GetMQMembersKey returns the key of the consumers of a topic within a group.
+func GetMQMembersKey(topic string, groupID string) string {
+	return mqMembers + topic + ":" + groupID
+}
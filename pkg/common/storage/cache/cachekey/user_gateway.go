@@ -0,0 +1,17 @@
+package cachekey
+
+import "time"
+
+const (
+	UserGatewayKey    = "USER_GATEWAY:"
+	UserGatewayExpire = time.Minute * 3
+	// UserGatewayNodeKey holds the last time every gateway node renewed the records of its users.
+	UserGatewayNodeKey = "USER_GATEWAY_NODE"
+	// UserGatewayNodeFresh is how long the records of a node stay complete after it renewed them,
+	// it leaves room for one missed renewal before the records expire.
+	UserGatewayNodeFresh = UserGatewayExpire * 2 / 3
+)
+
+func GetUserGatewayKey(userID string) string {
+	return UserGatewayKey + userID
+}
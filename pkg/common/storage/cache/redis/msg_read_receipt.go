@@ -0,0 +1,60 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/dtm-labs/rockscache"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/cachekey"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	groupReadReceiptExpireTime = time.Second * 60 * 60 * 12
+)
+
+type MsgReadReceiptCacheRedis struct {
+	batchDeleter *BatchDeleterRedis
+	expireTime   time.Duration
+	rcClient     *rockscache.Client
+	receiptDB    database.MsgReadReceipt
+}
+
+func NewMsgReadReceiptCacheRedis(rdb redis.UniversalClient, receiptDB database.MsgReadReceipt, options *rockscache.Options) cache.MsgReadReceiptCache {
+	return &MsgReadReceiptCacheRedis{
+		batchDeleter: NewBatchDeleterRedis(rdb, options, nil),
+		expireTime:   groupReadReceiptExpireTime,
+		rcClient:     rockscache.NewClient(rdb, *options),
+		receiptDB:    receiptDB,
+	}
+}
+
+func (m *MsgReadReceiptCacheRedis) IsGroupReadReceiptEnabled(ctx context.Context, groupID string) (bool, error) {
+	return getCache(ctx, m.rcClient, cachekey.GetGroupReadReceiptKey(groupID), m.expireTime, func(ctx context.Context) (bool, error) {
+		setting, err := m.receiptDB.TakeGroupReadReceipt(ctx, groupID)
+		if err != nil {
+			return false, err
+		}
+		return setting != nil && setting.Enable, nil
+	})
+}
+
+func (m *MsgReadReceiptCacheRedis) DelGroupReadReceipt(ctx context.Context, groupID string) error {
+	return m.batchDeleter.ExecDelWithKeys(ctx, []string{cachekey.GetGroupReadReceiptKey(groupID)})
+}
@@ -422,6 +422,15 @@ func (s *seqConversationCacheRedis) GetMaxSeq(ctx context.Context, conversationI
 	return s.Malloc(ctx, conversationID, 0)
 }
 
+// SetMaxSeq overwrites the max seq stored in mongo and drops the allocated seq segment in redis,
+// the next Malloc reloads the segment starting from the new value.
+func (s *seqConversationCacheRedis) SetMaxSeq(ctx context.Context, conversationID string, seq int64) error {
+	if err := s.mgo.SetMaxSeq(ctx, conversationID, seq); err != nil {
+		return err
+	}
+	return errs.Wrap(s.rdb.Del(ctx, s.getSeqMallocKey(conversationID)).Err())
+}
+
 func (s *seqConversationCacheRedis) GetMaxSeqWithTime(ctx context.Context, conversationID string) (database.SeqTime, error) {
 	seq, mill, err := s.mallocTime(ctx, conversationID, 0)
 	if err != nil {
@@ -0,0 +1,117 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/cachekey"
+	"github.com/openimsdk/tools/errs"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewUserGateway stores the nodes of a user in a sorted set, the score of every node is the time its record expires.
+func NewUserGateway(rdb redis.UniversalClient) cache.UserGatewayCache {
+	return &userGateway{
+		rdb:    rdb,
+		expire: cachekey.UserGatewayExpire,
+	}
+}
+
+type userGateway struct {
+	rdb    redis.UniversalClient
+	expire time.Duration
+}
+
+func (s *userGateway) getUserGatewayKey(userID string) string {
+	return cachekey.GetUserGatewayKey(userID)
+}
+
+func (s *userGateway) SetUserGateway(ctx context.Context, node string, userIDs ...string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	score := float64(now.Add(s.expire).Unix())
+	pipe := s.rdb.Pipeline()
+	for _, userID := range userIDs {
+		key := s.getUserGatewayKey(userID)
+		pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Unix(), 10))
+		pipe.ZAdd(ctx, key, redis.Z{Score: score, Member: node})
+		pipe.Expire(ctx, key, s.expire)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func (s *userGateway) DelUserGateway(ctx context.Context, node string, userIDs ...string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	pipe := s.rdb.Pipeline()
+	for _, userID := range userIDs {
+		pipe.ZRem(ctx, s.getUserGatewayKey(userID), node)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func (s *userGateway) GetUserGateway(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	if len(userIDs) == 0 {
+		return map[string][]string{}, nil
+	}
+	min := strconv.FormatInt(time.Now().Unix(), 10)
+	pipe := s.rdb.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, 0, len(userIDs))
+	for _, userID := range userIDs {
+		cmds = append(cmds, pipe.ZRangeByScore(ctx, s.getUserGatewayKey(userID), &redis.ZRangeBy{Min: min, Max: "+inf"}))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, errs.Wrap(err)
+	}
+	result := make(map[string][]string, len(userIDs))
+	for i, cmd := range cmds {
+		nodes, err := cmd.Result()
+		if err != nil {
+			return nil, errs.Wrap(err)
+		}
+		if len(nodes) > 0 {
+			result[userIDs[i]] = nodes
+		}
+	}
+	return result, nil
+}
+
+func (s *userGateway) SetNodeRenewed(ctx context.Context, node string) error {
+	now := time.Now()
+	pipe := s.rdb.Pipeline()
+	pipe.ZAdd(ctx, cachekey.UserGatewayNodeKey, redis.Z{Score: float64(now.Unix()), Member: node})
+	// the nodes gone for long are dropped, their records expired long ago
+	pipe.ZRemRangeByScore(ctx, cachekey.UserGatewayNodeKey, "-inf", strconv.FormatInt(now.Add(-s.expire*10).Unix(), 10))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func (s *userGateway) GetNodeRenewed(ctx context.Context) (map[string]time.Time, error) {
+	res, err := s.rdb.ZRangeWithScores(ctx, cachekey.UserGatewayNodeKey, 0, -1).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, errs.Wrap(err)
+	}
+	nodes := make(map[string]time.Time, len(res))
+	for _, z := range res {
+		node, ok := z.Member.(string)
+		if !ok {
+			continue
+		}
+		nodes[node] = time.Unix(int64(z.Score), 0)
+	}
+	return nodes, nil
+}
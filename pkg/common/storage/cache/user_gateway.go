@@ -0,0 +1,19 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// UserGatewayCache records which msg gateway nodes the users are connected to.
+type UserGatewayCache interface {
+	// SetUserGateway records the users as connected to the node and renews the expiration of the record.
+	SetUserGateway(ctx context.Context, node string, userIDs ...string) error
+	DelUserGateway(ctx context.Context, node string, userIDs ...string) error
+	// GetUserGateway returns the nodes of every user whose record has not expired, users without any node are omitted.
+	GetUserGateway(ctx context.Context, userIDs []string) (map[string][]string, error)
+	// SetNodeRenewed records that the node has renewed the records of all its users.
+	SetNodeRenewed(ctx context.Context, node string) error
+	// GetNodeRenewed returns the last time every node renewed the records of its users.
+	GetNodeRenewed(ctx context.Context) (map[string]time.Time, error)
+}
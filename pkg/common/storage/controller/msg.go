@@ -26,20 +26,22 @@ import (
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/open-im-server/v3/pkg/mq"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
 
 	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
 
-	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/convert"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
 	"github.com/openimsdk/protocol/constant"
 	pbmsg "github.com/openimsdk/protocol/msg"
 	"github.com/openimsdk/protocol/sdkws"
 	"github.com/openimsdk/tools/errs"
 	"github.com/openimsdk/tools/log"
-	"github.com/openimsdk/tools/mq/kafka"
 	"github.com/openimsdk/tools/utils/datautil"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -51,6 +53,17 @@ const (
 type CommonMsgDatabase interface {
 	// RevokeMsg revokes a message in a conversation.
 	RevokeMsg(ctx context.Context, conversationID string, seq int64, revoke *model.RevokeModel) error
+	// EditMsg replaces the content of a message and keeps the replaced content in the edit history.
+	EditMsg(ctx context.Context, conversationID string, seq int64, content string, editTime int64) error
+	// AddReaction adds the reaction of userID to a message, it reports whether the reaction is added.
+	AddReaction(ctx context.Context, conversationID string, seq int64, reaction string, userID string) (bool, error)
+	// DeleteReaction removes the reaction of userID from a message, it reports whether the reaction is removed.
+	DeleteReaction(ctx context.Context, conversationID string, seq int64, reaction string, userID string) (bool, error)
+	// GetReactions returns the users of the reactions of a message.
+	GetReactions(ctx context.Context, conversationID string, seq int64) (map[string][]string, error)
+	// FindSendSeqs returns the seqs of the messages of sendID in a conversation which are not revoked, the newest first.
+	// Only the seqs less than beforeSeq are returned when it is not 0.
+	FindSendSeqs(ctx context.Context, conversationID string, sendID string, beforeSeq int64, limit int) ([]int64, error)
 	// MarkSingleChatMsgsAsRead marks messages as read for a single chat by sequence numbers.
 	MarkSingleChatMsgsAsRead(ctx context.Context, userID string, conversationID string, seqs []int64) error
 	// GetMsgBySeqsRange retrieves messages from MongoDB by a range of sequence numbers.
@@ -63,7 +76,7 @@ type CommonMsgDatabase interface {
 	DeleteUserMsgsBySeqs(ctx context.Context, userID string, conversationID string, seqs []int64) error
 	// DeleteMsgsPhysicalBySeqs physically deletes messages by emptying them based on sequence numbers.
 	DeleteMsgsPhysicalBySeqs(ctx context.Context, conversationID string, seqs []int64) error
-	//SetMaxSeq(ctx context.Context, conversationID string, maxSeq int64) error
+	SetMaxSeq(ctx context.Context, conversationID string, maxSeq int64) error
 	GetMaxSeqs(ctx context.Context, conversationIDs []string) (map[string]int64, error)
 	GetMaxSeq(ctx context.Context, conversationID string) (int64, error)
 	SetMinSeqs(ctx context.Context, seqs map[string]int64) error
@@ -82,6 +95,8 @@ type CommonMsgDatabase interface {
 	SetSendMsgStatus(ctx context.Context, id string, status int32) error
 	GetSendMsgStatus(ctx context.Context, id string) (int32, error)
 	SearchMessage(ctx context.Context, req *pbmsg.SearchMessageReq) (total int64, msgData []*pbmsg.SearchedMsgData, err error)
+	// GetSearchedMsgs returns the stored messages of the seqs, the deleted messages are skipped.
+	GetSearchedMsgs(ctx context.Context, conversationID string, seqs []int64) ([]*pbmsg.SearchedMsgData, error)
 	FindOneByDocIDs(ctx context.Context, docIDs []string, seqs map[string]int64) (map[string]*sdkws.MsgData, error)
 
 	// to mq
@@ -100,24 +115,19 @@ type CommonMsgDatabase interface {
 	GetLastMessageSeqByTime(ctx context.Context, conversationID string, time int64) (int64, error)
 
 	GetLastMessage(ctx context.Context, conversationIDS []string, userID string) (map[string]*sdkws.MsgData, error)
+
+	// GetConversationMinMaxSeqInMongoAndCache returns the seq range of the messages stored in mongo and the seq range recorded in cache.
+	GetConversationMinMaxSeqInMongoAndCache(ctx context.Context, conversationID string) (minSeqMongo, maxSeqMongo, minSeqCache, maxSeqCache int64, err error)
 }
 
-func NewCommonMsgDatabase(msgDocModel database.Msg, msg cache.MsgCache, seqUser cache.SeqUser, seqConversation cache.SeqConversationCache, kafkaConf *config.Kafka) (CommonMsgDatabase, error) {
-	conf, err := kafka.BuildProducerConfig(*kafkaConf.Build())
-	if err != nil {
-		return nil, err
-	}
-	producerToRedis, err := kafka.NewKafkaProducer(conf, kafkaConf.Address, kafkaConf.ToRedisTopic)
-	if err != nil {
-		return nil, err
-	}
+func NewCommonMsgDatabase(msgDocModel database.Msg, msg cache.MsgCache, seqUser cache.SeqUser, seqConversation cache.SeqConversationCache, producerToRedis mq.Producer) CommonMsgDatabase {
 	return &commonMsgDatabase{
 		msgDocDatabase:  msgDocModel,
 		msgCache:        msg,
 		seqUser:         seqUser,
 		seqConversation: seqConversation,
 		producer:        producerToRedis,
-	}, nil
+	}
 }
 
 type commonMsgDatabase struct {
@@ -126,12 +136,13 @@ type commonMsgDatabase struct {
 	msgCache        cache.MsgCache
 	seqConversation cache.SeqConversationCache
 	seqUser         cache.SeqUser
-	producer        *kafka.Producer
+	producer        mq.Producer
 }
 
-func (db *commonMsgDatabase) MsgToMQ(ctx context.Context, key string, msg2mq *sdkws.MsgData) error {
-	_, _, err := db.producer.SendMessage(ctx, key, msg2mq)
-	return err
+func (db *commonMsgDatabase) MsgToMQ(ctx context.Context, key string, msg2mq *sdkws.MsgData) (err error) {
+	ctx, span := tracing.Start(ctx, "CommonMsgDatabase.MsgToMQ", attribute.String("key", key))
+	defer func() { tracing.End(span, err) }()
+	return db.producer.SendMessage(ctx, key, msg2mq)
 }
 
 func (db *commonMsgDatabase) batchInsertBlock(ctx context.Context, conversationID string, fields []any, key int8, firstSeq int64) error {
@@ -243,6 +254,60 @@ func (db *commonMsgDatabase) RevokeMsg(ctx context.Context, conversationID strin
 	return db.msgCache.DelMessageBySeqs(ctx, conversationID, []int64{seq})
 }
 
+func (db *commonMsgDatabase) EditMsg(ctx context.Context, conversationID string, seq int64, content string, editTime int64) error {
+	msgs, err := db.msgDocDatabase.FindSeqs(ctx, conversationID, []int64{seq})
+	if err != nil {
+		return err
+	}
+	if len(msgs) == 0 {
+		return errs.ErrRecordNotFound.WrapMsg("msg not found", "conversationID", conversationID, "seq", seq)
+	}
+	docID := db.msgTable.GetDocID(conversationID, seq)
+	if err := db.msgDocDatabase.EditMsg(ctx, docID, db.msgTable.GetMsgIndex(seq), msgs[0].Msg.Content, content, editTime); err != nil {
+		return err
+	}
+	return db.msgCache.DelMessageBySeqs(ctx, conversationID, []int64{seq})
+}
+
+func (db *commonMsgDatabase) AddReaction(ctx context.Context, conversationID string, seq int64, reaction string, userID string) (bool, error) {
+	added, err := db.msgDocDatabase.AddReaction(ctx, db.msgTable.GetDocID(conversationID, seq), db.msgTable.GetMsgIndex(seq), reaction, userID)
+	if err != nil || !added {
+		return false, err
+	}
+	return true, db.msgCache.DelMessageBySeqs(ctx, conversationID, []int64{seq})
+}
+
+func (db *commonMsgDatabase) DeleteReaction(ctx context.Context, conversationID string, seq int64, reaction string, userID string) (bool, error) {
+	deleted, err := db.msgDocDatabase.DeleteReaction(ctx, db.msgTable.GetDocID(conversationID, seq), db.msgTable.GetMsgIndex(seq), reaction, userID)
+	if err != nil || !deleted {
+		return false, err
+	}
+	return true, db.msgCache.DelMessageBySeqs(ctx, conversationID, []int64{seq})
+}
+
+func (db *commonMsgDatabase) GetReactions(ctx context.Context, conversationID string, seq int64) (map[string][]string, error) {
+	msgs, err := db.msgCache.GetMessageBySeqs(ctx, conversationID, []int64{seq})
+	if err != nil {
+		return nil, err
+	}
+	reactions := make(map[string][]string)
+	for _, msg := range msgs {
+		if msg.Msg == nil || msg.Msg.Seq != seq {
+			continue
+		}
+		for reaction, userIDs := range msg.Reactions {
+			if len(userIDs) > 0 {
+				reactions[reaction] = userIDs
+			}
+		}
+	}
+	return reactions, nil
+}
+
+func (db *commonMsgDatabase) FindSendSeqs(ctx context.Context, conversationID string, sendID string, beforeSeq int64, limit int) ([]int64, error) {
+	return db.msgDocDatabase.FindSendSeqs(ctx, conversationID, sendID, beforeSeq, limit)
+}
+
 func (db *commonMsgDatabase) MarkSingleChatMsgsAsRead(ctx context.Context, userID string, conversationID string, totalSeqs []int64) error {
 	for docID, seqs := range db.msgTable.GetDocIDSeqsMap(conversationID, totalSeqs) {
 		var indexes []int64
@@ -386,7 +451,9 @@ func (db *commonMsgDatabase) findMsgInfoBySeq(ctx context.Context, userID, docID
 // For new users joining the group, if they don't need to receive old messages,
 // "userMinSeq" can be set as the same value as the conversation's "maxSeq" at the moment they join the group.
 // This ensures that their message retrieval starts from the point they joined.
-func (db *commonMsgDatabase) GetMsgBySeqsRange(ctx context.Context, userID string, conversationID string, begin, end, num, userMaxSeq int64) (int64, int64, []*sdkws.MsgData, error) {
+func (db *commonMsgDatabase) GetMsgBySeqsRange(ctx context.Context, userID string, conversationID string, begin, end, num, userMaxSeq int64) (_ int64, _ int64, _ []*sdkws.MsgData, err error) {
+	ctx, span := tracing.Start(ctx, "CommonMsgDatabase.GetMsgBySeqsRange", attribute.String("conversationID", conversationID))
+	defer func() { tracing.End(span, err) }()
 	userMinSeq, err := db.seqUser.GetUserMinSeq(ctx, conversationID, userID)
 	if err != nil && !errors.Is(err, redis.Nil) {
 		return 0, 0, nil, err
@@ -442,7 +509,9 @@ func (db *commonMsgDatabase) GetMsgBySeqsRange(ctx context.Context, userID strin
 	return minSeq, maxSeq, successMsgs, nil
 }
 
-func (db *commonMsgDatabase) GetMsgBySeqs(ctx context.Context, userID string, conversationID string, seqs []int64) (int64, int64, []*sdkws.MsgData, error) {
+func (db *commonMsgDatabase) GetMsgBySeqs(ctx context.Context, userID string, conversationID string, seqs []int64) (_ int64, _ int64, _ []*sdkws.MsgData, err error) {
+	ctx, span := tracing.Start(ctx, "CommonMsgDatabase.GetMsgBySeqs", attribute.String("conversationID", conversationID))
+	defer func() { tracing.End(span, err) }()
 	userMinSeq, err := db.seqUser.GetUserMinSeq(ctx, conversationID, userID)
 	if err != nil {
 		return 0, 0, nil, err
@@ -569,6 +638,10 @@ func (db *commonMsgDatabase) GetMaxSeq(ctx context.Context, conversationID strin
 	return db.seqConversation.GetMaxSeq(ctx, conversationID)
 }
 
+func (db *commonMsgDatabase) SetMaxSeq(ctx context.Context, conversationID string, maxSeq int64) error {
+	return db.seqConversation.SetMaxSeq(ctx, conversationID, maxSeq)
+}
+
 func (db *commonMsgDatabase) SetMinSeqs(ctx context.Context, seqs map[string]int64) error {
 	return db.seqConversation.SetMinSeqs(ctx, seqs)
 }
@@ -612,7 +685,11 @@ func (db *commonMsgDatabase) GetSendMsgStatus(ctx context.Context, id string) (i
 func (db *commonMsgDatabase) GetConversationMinMaxSeqInMongoAndCache(ctx context.Context, conversationID string) (minSeqMongo, maxSeqMongo, minSeqCache, maxSeqCache int64, err error) {
 	minSeqMongo, maxSeqMongo, err = db.GetMinMaxSeqMongo(ctx, conversationID)
 	if err != nil {
-		return
+		if !errors.Is(errs.Unwrap(err), model.ErrMsgListNotExist) {
+			return
+		}
+		// no message is stored in mongo
+		minSeqMongo, maxSeqMongo = 0, 0
 	}
 	minSeqCache, err = db.seqConversation.GetMinSeq(ctx, conversationID)
 	if err != nil {
@@ -658,18 +735,29 @@ func (db *commonMsgDatabase) SearchMessage(ctx context.Context, req *pbmsg.Searc
 		return 0, nil, err
 	}
 	for _, msg := range msgs {
-		if msg.IsRead {
-			msg.Msg.IsRead = true
-		}
-		searchedMsgData := &pbmsg.SearchedMsgData{MsgData: convert.MsgDB2Pb(msg.Msg)}
+		totalMsgs = append(totalMsgs, toSearchedMsgData(msg))
+	}
+	return total, totalMsgs, nil
+}
 
-		if msg.Revoke != nil {
-			searchedMsgData.IsRevoked = true
-		}
+func (db *commonMsgDatabase) GetSearchedMsgs(ctx context.Context, conversationID string, seqs []int64) ([]*pbmsg.SearchedMsgData, error) {
+	msgs, err := db.msgDocDatabase.FindSeqs(ctx, conversationID, seqs)
+	if err != nil {
+		return nil, err
+	}
+	return datautil.Slice(msgs, toSearchedMsgData), nil
+}
 
-		totalMsgs = append(totalMsgs, searchedMsgData)
+func toSearchedMsgData(msg *model.MsgInfoModel) *pbmsg.SearchedMsgData {
+	if msg.IsRead {
+		msg.Msg.IsRead = true
 	}
-	return total, totalMsgs, nil
+	searchedMsgData := &pbmsg.SearchedMsgData{MsgData: convert.MsgDB2Pb(msg.Msg)}
+
+	if msg.Revoke != nil {
+		searchedMsgData.IsRevoked = true
+	}
+	return searchedMsgData
 }
 
 func (db *commonMsgDatabase) FindOneByDocIDs(ctx context.Context, conversationIDs []string, seqs map[string]int64) (map[string]*sdkws.MsgData, error) {
@@ -754,6 +842,12 @@ func (db *commonMsgDatabase) handlerDeleteAndRevoked(ctx context.Context, userID
 			msg.Msg.Content = ""
 			msg.Msg.Status = constant.MsgDeleted
 		}
+		if len(msg.EditHistory) > 0 {
+			if msg.Msg.Options == nil {
+				msg.Msg.Options = make(map[string]bool)
+			}
+			msg.Msg.Options[msgprocessor.IsEdited] = true
+		}
 		if msg.Revoke == nil {
 			continue
 		}
@@ -795,12 +889,59 @@ func (db *commonMsgDatabase) handlerQuote(ctx context.Context, userID, conversat
 	}
 }
 
+// handlerReactions sets the reaction counts in the attached info of the messages which are not revoked.
+func (db *commonMsgDatabase) handlerReactions(ctx context.Context, userID string, msgs []*model.MsgInfoModel) {
+	for _, msg := range msgs {
+		if msg == nil || msg.Msg == nil || msg.Revoke != nil || len(msg.Reactions) == 0 {
+			continue
+		}
+		counts := msgprocessor.CountReactions(msg.Reactions, userID)
+		if len(counts) == 0 {
+			continue
+		}
+		attachedInfo, err := msgprocessor.SetAttachedInfo(msg.Msg.AttachedInfo, msgprocessor.AttachedInfoReactions, counts)
+		if err != nil {
+			log.ZWarn(ctx, "set attached reactions failed", err, "seq", msg.Msg.Seq)
+			continue
+		}
+		msg.Msg.AttachedInfo = attachedInfo
+	}
+}
+
+// handlerThreads sets the thread summaries in the attached info of the root messages which are not revoked.
+func (db *commonMsgDatabase) handlerThreads(ctx context.Context, msgs []*model.MsgInfoModel) {
+	for _, msg := range msgs {
+		if msg == nil || msg.Msg == nil || msg.Revoke != nil || msg.Thread == nil {
+			continue
+		}
+		summary := &msgprocessor.ThreadSummary{ReplyCount: msg.Thread.ReplyCount}
+		if last := msg.Thread.LastReply; last != nil {
+			summary.LastReply = &msgprocessor.ThreadReply{
+				Seq:            last.Seq,
+				SendID:         last.SendID,
+				SenderNickname: last.SenderNickname,
+				ContentType:    last.ContentType,
+				Text:           last.Text,
+				SendTime:       last.SendTime,
+			}
+		}
+		attachedInfo, err := msgprocessor.SetAttachedInfo(msg.Msg.AttachedInfo, msgprocessor.AttachedInfoThread, summary)
+		if err != nil {
+			log.ZWarn(ctx, "set attached thread failed", err, "seq", msg.Msg.Seq)
+			continue
+		}
+		msg.Msg.AttachedInfo = attachedInfo
+	}
+}
+
 func (db *commonMsgDatabase) GetMessageBySeqs(ctx context.Context, conversationID string, userID string, seqs []int64) ([]*sdkws.MsgData, error) {
 	msgs, err := db.msgCache.GetMessageBySeqs(ctx, conversationID, seqs)
 	if err != nil {
 		return nil, err
 	}
 	db.handlerDeleteAndRevoked(ctx, userID, msgs)
+	db.handlerReactions(ctx, userID, msgs)
+	db.handlerThreads(ctx, msgs)
 	db.handlerQuote(ctx, userID, conversationID, msgs)
 	seqMsgs := make(map[int64]*model.MsgInfoModel)
 	for i, msg := range msgs {
@@ -835,6 +976,8 @@ func (db *commonMsgDatabase) GetLastMessage(ctx context.Context, conversationIDs
 		}
 		tmp := []*model.MsgInfoModel{msg}
 		db.handlerDeleteAndRevoked(ctx, userID, tmp)
+		db.handlerReactions(ctx, userID, tmp)
+		db.handlerThreads(ctx, tmp)
 		db.handlerQuote(ctx, userID, conversationID, tmp)
 		res[conversationID] = convert.MsgDB2Pb(msg.Msg)
 	}
@@ -0,0 +1,120 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/utils/datautil"
+)
+
+// readReceiptLimit is the number of receipts marked read at once, a read of more messages is marked page by page.
+const readReceiptLimit = 1000
+
+type MsgReadReceiptDatabase interface {
+	SetGroupReadReceipt(ctx context.Context, groupID string, operatorUserID string, enable bool) error
+	IsGroupReadReceiptEnabled(ctx context.Context, groupID string) (bool, error)
+	// CreateReceipts creates the read receipts of the stored group messages with the read receipt option.
+	CreateReceipts(ctx context.Context, conversationID string, msgs []*sdkws.MsgData) error
+	// MarkRead records that userID read the messages of seqs in (fromSeq, toSeq] sent since joinTime, and returns
+	// the receipts of the messages newly read without their readers.
+	MarkRead(ctx context.Context, conversationID string, userID string, fromSeq int64, toSeq int64, joinTime int64) ([]*model.MsgReadReceipt, error)
+	FindReceipts(ctx context.Context, conversationID string, seqs []int64) ([]*model.MsgReadReceipt, error)
+}
+
+func NewMsgReadReceiptDatabase(receipt database.MsgReadReceipt, cache cache.MsgReadReceiptCache) MsgReadReceiptDatabase {
+	return &msgReadReceiptDatabase{receipt: receipt, cache: cache}
+}
+
+type msgReadReceiptDatabase struct {
+	receipt database.MsgReadReceipt
+	cache   cache.MsgReadReceiptCache
+}
+
+func (m *msgReadReceiptDatabase) SetGroupReadReceipt(ctx context.Context, groupID string, operatorUserID string, enable bool) error {
+	setting := &model.GroupReadReceipt{
+		GroupID:        groupID,
+		Enable:         enable,
+		OperatorUserID: operatorUserID,
+		UpdateTime:     time.Now(),
+	}
+	if err := m.receipt.SetGroupReadReceipt(ctx, setting); err != nil {
+		return err
+	}
+	return m.cache.DelGroupReadReceipt(ctx, groupID)
+}
+
+func (m *msgReadReceiptDatabase) IsGroupReadReceiptEnabled(ctx context.Context, groupID string) (bool, error) {
+	return m.cache.IsGroupReadReceiptEnabled(ctx, groupID)
+}
+
+func (m *msgReadReceiptDatabase) CreateReceipts(ctx context.Context, conversationID string, msgs []*sdkws.MsgData) error {
+	return m.receipt.CreateReceipts(ctx, NewMsgReadReceipts(conversationID, msgs))
+}
+
+// NewMsgReadReceipts returns the unread receipts of the group messages with the read receipt option.
+func NewMsgReadReceipts(conversationID string, msgs []*sdkws.MsgData) []*model.MsgReadReceipt {
+	var receipts []*model.MsgReadReceipt
+	for _, msg := range msgs {
+		if msg.SessionType != constant.ReadGroupChatType || !msgprocessor.Options(msg.Options).IsReadReceipt() {
+			continue
+		}
+		receipts = append(receipts, &model.MsgReadReceipt{
+			ConversationID: conversationID,
+			Seq:            msg.Seq,
+			GroupID:        msg.GroupID,
+			SendID:         msg.SendID,
+			SendTime:       msg.SendTime,
+			ReadUsers:      []*model.MsgReadMember{},
+		})
+	}
+	return receipts
+}
+
+func (m *msgReadReceiptDatabase) MarkRead(ctx context.Context, conversationID string, userID string, fromSeq int64, toSeq int64, joinTime int64) ([]*model.MsgReadReceipt, error) {
+	var read []*model.MsgReadReceipt
+	reader := &model.MsgReadMember{UserID: userID, ReadTime: time.Now().UnixMilli()}
+	for toSeq > fromSeq {
+		receipts, err := m.receipt.FindUnreadReceipts(ctx, conversationID, userID, fromSeq, toSeq, joinTime, readReceiptLimit)
+		if err != nil {
+			return nil, err
+		}
+		if len(receipts) == 0 {
+			break
+		}
+		seqs := datautil.Slice(receipts, func(receipt *model.MsgReadReceipt) int64 { return receipt.Seq })
+		if err := m.receipt.AddReader(ctx, conversationID, seqs, reader); err != nil {
+			return nil, err
+		}
+		read = append(read, receipts...)
+		if len(receipts) < readReceiptLimit {
+			break
+		}
+		// the receipts are found the last ones first, the next page is below the last one found
+		toSeq = receipts[len(receipts)-1].Seq - 1
+	}
+	return read, nil
+}
+
+func (m *msgReadReceiptDatabase) FindReceipts(ctx context.Context, conversationID string, seqs []int64) ([]*model.MsgReadReceipt, error) {
+	return m.receipt.FindReceipts(ctx, conversationID, seqs)
+}
@@ -0,0 +1,113 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database/bleve"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database/mgo"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/errs"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MsgSearchDatabase interface {
+	// IndexMsgs adds the stored messages of a conversation to the search index, or replaces them once edited.
+	IndexMsgs(ctx context.Context, conversationID string, msgs []*sdkws.MsgData) error
+	// DeleteMsgs removes the deleted or revoked messages of the seqs from the index.
+	DeleteMsgs(ctx context.Context, conversationID string, seqs []int64) error
+	// DeleteMsgsBefore removes the cleared messages of the conversation whose seq is less than minSeq.
+	DeleteMsgsBefore(ctx context.Context, conversationID string, minSeq int64) error
+	// HideMsgs hides the messages of the seqs deleted by the user for themselves.
+	HideMsgs(ctx context.Context, userID string, conversationID string, seqs []int64) error
+	// HideMsgsBefore hides the messages cleared by the user for themselves, whose seq is less than minSeq.
+	HideMsgsBefore(ctx context.Context, userID string, conversationID string, minSeq int64) error
+	SearchMsgs(ctx context.Context, query *model.MsgSearchQuery) (int64, []*model.MsgSearch, error)
+}
+
+// NewMsgSearchDatabase creates the search index selected by conf, it returns nil when the index is disabled.
+func NewMsgSearchDatabase(conf *config.SearchIndex, db *mongo.Database) (MsgSearchDatabase, error) {
+	var (
+		index database.MsgSearchIndex
+		err   error
+	)
+	switch conf.Enable {
+	case "":
+		return nil, nil
+	case config.SearchIndexMongo:
+		index, err = mgo.NewMsgSearchMongo(db)
+	case config.SearchIndexBleve:
+		index, err = bleve.NewMsgSearchBleve(conf.Bleve.Dir)
+	default:
+		err = errs.New("invalid search index enable", "enable", conf.Enable).Wrap()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msgSearchDatabase{index: index}, nil
+}
+
+type msgSearchDatabase struct {
+	index database.MsgSearchIndex
+}
+
+func (m *msgSearchDatabase) IndexMsgs(ctx context.Context, conversationID string, msgs []*sdkws.MsgData) error {
+	if msgprocessor.IsNotification(conversationID) {
+		return nil
+	}
+	docs := make([]*model.MsgSearch, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg == nil || msg.Seq == 0 {
+			continue
+		}
+		docs = append(docs, &model.MsgSearch{
+			ConversationID: conversationID,
+			Seq:            msg.Seq,
+			SendID:         msg.SendID,
+			RecvID:         msg.RecvID,
+			GroupID:        msg.GroupID,
+			SessionType:    msg.SessionType,
+			ContentType:    msg.ContentType,
+			SendTime:       msg.SendTime,
+			Text:           msgprocessor.GetMsgText(msg.ContentType, msg.Content),
+		})
+	}
+	return m.index.Index(ctx, docs)
+}
+
+func (m *msgSearchDatabase) DeleteMsgs(ctx context.Context, conversationID string, seqs []int64) error {
+	return m.index.Delete(ctx, conversationID, seqs)
+}
+
+func (m *msgSearchDatabase) DeleteMsgsBefore(ctx context.Context, conversationID string, minSeq int64) error {
+	return m.index.DeleteBefore(ctx, conversationID, minSeq)
+}
+
+func (m *msgSearchDatabase) HideMsgs(ctx context.Context, userID string, conversationID string, seqs []int64) error {
+	return m.index.Hide(ctx, userID, conversationID, seqs)
+}
+
+func (m *msgSearchDatabase) HideMsgsBefore(ctx context.Context, userID string, conversationID string, minSeq int64) error {
+	return m.index.HideBefore(ctx, userID, conversationID, minSeq)
+}
+
+func (m *msgSearchDatabase) SearchMsgs(ctx context.Context, query *model.MsgSearchQuery) (int64, []*model.MsgSearch, error) {
+	return m.index.Search(ctx, query)
+}
@@ -0,0 +1,100 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+	"github.com/openimsdk/protocol/sdkws"
+)
+
+type MsgThreadDatabase interface {
+	// AddReplies records the thread replies among the stored messages of a conversation and updates the
+	// summaries of their root messages.
+	AddReplies(ctx context.Context, conversationID string, msgs []*sdkws.MsgData) error
+	// FindReplySeqs pages the seqs of the replies of a root message, see database.MsgThread.
+	FindReplySeqs(ctx context.Context, conversationID string, rootSeq int64, startSeq int64, limit int, desc bool) ([]int64, error)
+}
+
+func NewMsgThreadDatabase(thread database.MsgThread, msgDocDatabase database.Msg, msgCache cache.MsgCache) MsgThreadDatabase {
+	return &msgThreadDatabase{thread: thread, msgDocDatabase: msgDocDatabase, msgCache: msgCache, msgTable: &model.MsgDocModel{}}
+}
+
+type msgThreadDatabase struct {
+	thread         database.MsgThread
+	msgDocDatabase database.Msg
+	msgCache       cache.MsgCache
+	msgTable       *model.MsgDocModel
+}
+
+func (m *msgThreadDatabase) AddReplies(ctx context.Context, conversationID string, msgs []*sdkws.MsgData) error {
+	var (
+		replies   []*model.MsgThreadReply
+		lastReply = make(map[int64]*sdkws.MsgData)
+	)
+	for _, msg := range msgs {
+		rootSeq := msgprocessor.GetThreadRootSeq(msg.AttachedInfo)
+		if rootSeq <= 0 || rootSeq >= msg.Seq {
+			continue
+		}
+		replies = append(replies, &model.MsgThreadReply{
+			ConversationID: conversationID,
+			RootSeq:        rootSeq,
+			Seq:            msg.Seq,
+			SendID:         msg.SendID,
+			SendTime:       msg.SendTime,
+		})
+		if last, ok := lastReply[rootSeq]; !ok || last.Seq < msg.Seq {
+			lastReply[rootSeq] = msg
+		}
+	}
+	if len(replies) == 0 {
+		return nil
+	}
+	if err := m.thread.AddReplies(ctx, replies); err != nil {
+		return err
+	}
+	rootSeqs := make([]int64, 0, len(lastReply))
+	for rootSeq, last := range lastReply {
+		count, err := m.thread.CountReplies(ctx, conversationID, rootSeq)
+		if err != nil {
+			return err
+		}
+		thread := &model.MsgThreadModel{
+			ReplyCount: count,
+			LastReply: &model.MsgThreadReplyModel{
+				Seq:            last.Seq,
+				SendID:         last.SendID,
+				SenderNickname: last.SenderNickname,
+				ContentType:    last.ContentType,
+				Text:           msgprocessor.GetThreadReplyText(last.ContentType, last.Content),
+				SendTime:       last.SendTime,
+			},
+		}
+		if err := m.msgDocDatabase.SetThread(ctx, m.msgTable.GetDocID(conversationID, rootSeq), m.msgTable.GetMsgIndex(rootSeq), thread); err != nil {
+			return err
+		}
+		rootSeqs = append(rootSeqs, rootSeq)
+	}
+	return m.msgCache.DelMessageBySeqs(ctx, conversationID, rootSeqs)
+}
+
+func (m *msgThreadDatabase) FindReplySeqs(ctx context.Context, conversationID string, rootSeq int64, startSeq int64, limit int, desc bool) ([]int64, error) {
+	return m.thread.FindReplySeqs(ctx, conversationID, rootSeq, startSeq, limit, desc)
+}
@@ -0,0 +1,120 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/cachekey"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/tools/db/pagination"
+	"github.com/openimsdk/tools/errs"
+	"github.com/redis/go-redis/v9"
+)
+
+// unlockScript deletes the lock only when it is still held by the token.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+type ScheduledMsgDatabase interface {
+	CreateScheduledMsg(ctx context.Context, msg *model.ScheduledMsg) error
+	TakeScheduledMsg(ctx context.Context, scheduleID string) (*model.ScheduledMsg, error)
+	// FindScheduledMsgs pages the messages, see database.ScheduledMsg.
+	FindScheduledMsgs(ctx context.Context, ownerUserID string, statuses []int32, pagination pagination.Pagination) (int64, []*model.ScheduledMsg, error)
+	FindDueScheduledMsgs(ctx context.Context, now time.Time, limit int) ([]*model.ScheduledMsg, error)
+	CountPendingScheduledMsgs(ctx context.Context, ownerUserID string) (int64, error)
+	// UpdateScheduledMsg updates the message when its status is status, and reports whether it is updated.
+	// Changing the status this way claims the message, so that it is sent once even by concurrent dispatchers.
+	UpdateScheduledMsg(ctx context.Context, scheduleID string, status int32, data map[string]any) (bool, error)
+	// UpdateStaleScheduledMsgs updates the messages in status last updated before before, it recovers the messages
+	// left sending by a stopped dispatcher.
+	UpdateStaleScheduledMsgs(ctx context.Context, status int32, before time.Time, data map[string]any) (int64, error)
+	// LockDispatch tries to take the dispatch lock for ttl with the token, it reports whether the lock is taken.
+	LockDispatch(ctx context.Context, token string, ttl time.Duration) (bool, error)
+	// RenewDispatch extends the dispatch lock held by the token to ttl, it reports whether the lock is still held.
+	RenewDispatch(ctx context.Context, token string, ttl time.Duration) (bool, error)
+	// UnlockDispatch releases the dispatch lock if it is still held by the token.
+	UnlockDispatch(ctx context.Context, token string) error
+}
+
+func NewScheduledMsgDatabase(db database.ScheduledMsg, rdb redis.UniversalClient) ScheduledMsgDatabase {
+	return &scheduledMsgDatabase{db: db, rdb: rdb}
+}
+
+type scheduledMsgDatabase struct {
+	db  database.ScheduledMsg
+	rdb redis.UniversalClient
+}
+
+func (s *scheduledMsgDatabase) CreateScheduledMsg(ctx context.Context, msg *model.ScheduledMsg) error {
+	return s.db.Create(ctx, msg)
+}
+
+func (s *scheduledMsgDatabase) TakeScheduledMsg(ctx context.Context, scheduleID string) (*model.ScheduledMsg, error) {
+	return s.db.Take(ctx, scheduleID)
+}
+
+func (s *scheduledMsgDatabase) FindScheduledMsgs(ctx context.Context, ownerUserID string, statuses []int32, pagination pagination.Pagination) (int64, []*model.ScheduledMsg, error) {
+	return s.db.FindPage(ctx, ownerUserID, statuses, pagination)
+}
+
+func (s *scheduledMsgDatabase) FindDueScheduledMsgs(ctx context.Context, now time.Time, limit int) ([]*model.ScheduledMsg, error) {
+	return s.db.FindDue(ctx, now, limit)
+}
+
+func (s *scheduledMsgDatabase) CountPendingScheduledMsgs(ctx context.Context, ownerUserID string) (int64, error) {
+	return s.db.CountPending(ctx, ownerUserID)
+}
+
+func (s *scheduledMsgDatabase) UpdateScheduledMsg(ctx context.Context, scheduleID string, status int32, data map[string]any) (bool, error) {
+	return s.db.Update(ctx, scheduleID, status, data)
+}
+
+func (s *scheduledMsgDatabase) UpdateStaleScheduledMsgs(ctx context.Context, status int32, before time.Time, data map[string]any) (int64, error) {
+	return s.db.UpdateStale(ctx, status, before, data)
+}
+
+func (s *scheduledMsgDatabase) LockDispatch(ctx context.Context, token string, ttl time.Duration) (bool, error) {
+	ok, err := s.rdb.SetNX(ctx, cachekey.ScheduledMsgDispatchLock, token, ttl).Result()
+	if err != nil {
+		return false, errs.Wrap(err)
+	}
+	return ok, nil
+}
+
+func (s *scheduledMsgDatabase) RenewDispatch(ctx context.Context, token string, ttl time.Duration) (bool, error) {
+	res, err := renewScript.Run(ctx, s.rdb, []string{cachekey.ScheduledMsgDispatchLock}, token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, errs.Wrap(err)
+	}
+	return res == 1, nil
+}
+
+func (s *scheduledMsgDatabase) UnlockDispatch(ctx context.Context, token string) error {
+	return errs.Wrap(unlockScript.Run(ctx, s.rdb, []string{cachekey.ScheduledMsgDispatchLock}, token).Err())
+}
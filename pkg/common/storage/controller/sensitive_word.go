@@ -0,0 +1,72 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/cachekey"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/redis/go-redis/v9"
+)
+
+type SensitiveWordDatabase interface {
+	// SetWordList creates or replaces a list and notifies the filters to reload.
+	SetWordList(ctx context.Context, list *model.SensitiveWordList) error
+	// DeleteWordLists deletes the lists and notifies the filters to reload.
+	DeleteWordLists(ctx context.Context, listIDs []string) error
+	// FindWordLists returns the lists, all of them when listIDs is empty.
+	FindWordLists(ctx context.Context, listIDs []string) ([]*model.SensitiveWordList, error)
+}
+
+func NewSensitiveWordDatabase(db database.SensitiveWord, rdb redis.UniversalClient) SensitiveWordDatabase {
+	return &sensitiveWordDatabase{db: db, rdb: rdb}
+}
+
+type sensitiveWordDatabase struct {
+	db  database.SensitiveWord
+	rdb redis.UniversalClient
+}
+
+func (s *sensitiveWordDatabase) SetWordList(ctx context.Context, list *model.SensitiveWordList) error {
+	if err := s.db.Set(ctx, list); err != nil {
+		return err
+	}
+	s.publishChange(ctx, list.ListID)
+	return nil
+}
+
+func (s *sensitiveWordDatabase) DeleteWordLists(ctx context.Context, listIDs []string) error {
+	if err := s.db.Delete(ctx, listIDs); err != nil {
+		return err
+	}
+	s.publishChange(ctx, listIDs...)
+	return nil
+}
+
+func (s *sensitiveWordDatabase) FindWordLists(ctx context.Context, listIDs []string) ([]*model.SensitiveWordList, error) {
+	return s.db.Find(ctx, listIDs)
+}
+
+// publishChange notifies the filters of all the processes, a missed notification is caught up by the periodic reload.
+func (s *sensitiveWordDatabase) publishChange(ctx context.Context, listIDs ...string) {
+	if err := s.rdb.Publish(ctx, cachekey.SensitiveWordChannel, strings.Join(listIDs, ",")).Err(); err != nil {
+		log.ZWarn(ctx, "publish sensitive word change failed", errs.Wrap(err), "listIDs", listIDs)
+	}
+}
@@ -0,0 +1,397 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bleve keeps the message search index in an embedded bleve index on the local disk.
+package bleve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/open-im-server/v3/pkg/util/tokenizer"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/utils/datautil"
+)
+
+// batchSize is the number of messages read at once when the messages before a seq are deleted or hidden.
+const batchSize = 1000
+
+var (
+	indexLock sync.Mutex
+	indexes   = make(map[string]*MsgSearchBleve)
+)
+
+// indexName is the name of the index in its dir, so that the dir can be an existing volume.
+const indexName = "msg_search"
+
+// NewMsgSearchBleve opens the index in dir or creates it, an index is opened once by a process and shared by
+// its instances.
+func NewMsgSearchBleve(dir string) (*MsgSearchBleve, error) {
+	if dir == "" {
+		return nil, errs.ErrArgs.WrapMsg("the bleve search index needs a dir")
+	}
+	indexLock.Lock()
+	defer indexLock.Unlock()
+	if m, ok := indexes[dir]; ok {
+		return m, nil
+	}
+	path := filepath.Join(dir, indexName)
+	index, err := bleve.Open(path)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		index, err = bleve.New(path, newIndexMapping())
+	}
+	if err != nil {
+		return nil, errs.WrapMsg(err, "open bleve index failed", "dir", dir)
+	}
+	m := &MsgSearchBleve{index: index}
+	indexes[dir] = m
+	return m, nil
+}
+
+func newIndexMapping() mapping.IndexMapping {
+	filter := func(field *mapping.FieldMapping) *mapping.FieldMapping {
+		field.Store = false
+		field.IncludeInAll = false
+		field.IncludeTermVectors = false
+		return field
+	}
+	doc := bleve.NewDocumentStaticMapping()
+	for _, name := range []string{"conversation_id", "send_id", "recv_id", "group_id", "hidden_by"} {
+		doc.AddFieldMappingsAt(name, filter(bleve.NewKeywordFieldMapping()))
+	}
+	for _, name := range []string{"seq", "session_type", "content_type", "send_time"} {
+		doc.AddFieldMappingsAt(name, filter(bleve.NewNumericFieldMapping()))
+	}
+	// the tokens are split by the tokenizer package and indexed as they are
+	tokens := filter(bleve.NewKeywordFieldMapping())
+	tokens.DocValues = false
+	doc.AddFieldMappingsAt("tokens", tokens)
+	// source is the stored message, it is returned by the searches and kept when a message is hidden
+	source := bleve.NewTextFieldMapping()
+	source.Index = false
+	source.IncludeInAll = false
+	source.IncludeTermVectors = false
+	source.DocValues = false
+	doc.AddFieldMappingsAt("source", source)
+
+	im := bleve.NewIndexMapping()
+	im.DefaultMapping = doc
+	im.DefaultAnalyzer = keyword.Name
+	im.StoreDynamic = false
+	im.IndexDynamic = false
+	im.DocValuesDynamic = false
+	return im
+}
+
+type MsgSearchBleve struct {
+	index bleve.Index
+}
+
+// msgSearchDoc is the document of a message, the fields are indexed and source is stored.
+type msgSearchDoc struct {
+	ConversationID string   `json:"conversation_id"`
+	Seq            int64    `json:"seq"`
+	SendID         string   `json:"send_id"`
+	RecvID         string   `json:"recv_id"`
+	GroupID        string   `json:"group_id"`
+	SessionType    int32    `json:"session_type"`
+	ContentType    int32    `json:"content_type"`
+	SendTime       int64    `json:"send_time"`
+	Tokens         []string `json:"tokens"`
+	HiddenBy       []string `json:"hidden_by"`
+	Source         string   `json:"source"`
+}
+
+func docID(conversationID string, seq int64) string {
+	return conversationID + ":" + strconv.FormatInt(seq, 10)
+}
+
+func newDoc(msg *model.MsgSearch) (*msgSearchDoc, error) {
+	// the text is not stored, its tokens are
+	stored := *msg
+	stored.Text = ""
+	source, err := json.Marshal(&stored)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &msgSearchDoc{
+		ConversationID: msg.ConversationID,
+		Seq:            msg.Seq,
+		SendID:         msg.SendID,
+		RecvID:         msg.RecvID,
+		GroupID:        msg.GroupID,
+		SessionType:    msg.SessionType,
+		ContentType:    msg.ContentType,
+		SendTime:       msg.SendTime,
+		Tokens:         msg.Tokens,
+		HiddenBy:       msg.HiddenBy,
+		Source:         string(source),
+	}, nil
+}
+
+func termQuery(field string, term string) *query.TermQuery {
+	q := bleve.NewTermQuery(term)
+	q.SetField(field)
+	return q
+}
+
+func numericQuery(field string, min, max *float64, minInclusive, maxInclusive bool) *query.NumericRangeQuery {
+	q := bleve.NewNumericRangeInclusiveQuery(min, max, &minInclusive, &maxInclusive)
+	q.SetField(field)
+	return q
+}
+
+func numericEqualQuery(field string, value float64) *query.NumericRangeQuery {
+	return numericQuery(field, &value, &value, true, true)
+}
+
+func seqBeforeQuery(conversationID string, seq int64) *query.ConjunctionQuery {
+	max := float64(seq)
+	return bleve.NewConjunctionQuery(termQuery("conversation_id", conversationID), numericQuery("seq", nil, &max, false, false))
+}
+
+// search returns the total number of matched messages and the stored messages of the page of req.
+func (m *MsgSearchBleve) search(ctx context.Context, req *bleve.SearchRequest) (int64, []*model.MsgSearch, error) {
+	req.Fields = []string{"source"}
+	res, err := m.index.SearchInContext(ctx, req)
+	if err != nil {
+		return 0, nil, errs.Wrap(err)
+	}
+	msgs := make([]*model.MsgSearch, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		source, _ := hit.Fields["source"].(string)
+		var msg model.MsgSearch
+		if err := json.Unmarshal([]byte(source), &msg); err != nil {
+			return 0, nil, errs.WrapMsg(err, "invalid bleve document", "id", hit.ID)
+		}
+		msgs = append(msgs, &msg)
+	}
+	return int64(res.Total), msgs, nil
+}
+
+// find returns the stored messages matched by q, size is the max number of messages.
+func (m *MsgSearchBleve) find(ctx context.Context, q query.Query, size int) ([]*model.MsgSearch, error) {
+	_, msgs, err := m.search(ctx, bleve.NewSearchRequestOptions(q, size, 0, false))
+	return msgs, err
+}
+
+// put indexes the messages, a message of the same conversation id and seq is replaced.
+func (m *MsgSearchBleve) put(msgs []*model.MsgSearch) error {
+	batch := m.index.NewBatch()
+	for _, msg := range msgs {
+		doc, err := newDoc(msg)
+		if err != nil {
+			return err
+		}
+		if err := batch.Index(docID(msg.ConversationID, msg.Seq), doc); err != nil {
+			return errs.Wrap(err)
+		}
+	}
+	return errs.Wrap(m.index.Batch(batch))
+}
+
+func (m *MsgSearchBleve) Index(ctx context.Context, msgs []*model.MsgSearch) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		ids = append(ids, docID(msg.ConversationID, msg.Seq))
+	}
+	indexed, err := m.find(ctx, bleve.NewDocIDQuery(ids), len(ids))
+	if err != nil {
+		return err
+	}
+	hiddenBy := make(map[string][]string, len(indexed))
+	for _, msg := range indexed {
+		hiddenBy[docID(msg.ConversationID, msg.Seq)] = msg.HiddenBy
+	}
+	for i, msg := range msgs {
+		msg.Tokens = tokenizer.Index(msg.Text)
+		msg.HiddenBy = hiddenBy[ids[i]]
+	}
+	return m.put(msgs)
+}
+
+func (m *MsgSearchBleve) Delete(ctx context.Context, conversationID string, seqs []int64) error {
+	if len(seqs) == 0 {
+		return nil
+	}
+	batch := m.index.NewBatch()
+	for _, seq := range seqs {
+		batch.Delete(docID(conversationID, seq))
+	}
+	return errs.Wrap(m.index.Batch(batch))
+}
+
+func (m *MsgSearchBleve) DeleteBefore(ctx context.Context, conversationID string, seq int64) error {
+	for {
+		msgs, err := m.find(ctx, seqBeforeQuery(conversationID, seq), batchSize)
+		if err != nil {
+			return err
+		}
+		if len(msgs) == 0 {
+			return nil
+		}
+		if err := m.Delete(ctx, conversationID, datautil.Slice(msgs, func(e *model.MsgSearch) int64 { return e.Seq })); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *MsgSearchBleve) hide(ctx context.Context, userID string, q query.Query) (int, error) {
+	visible := bleve.NewBooleanQuery()
+	visible.AddMust(q)
+	visible.AddMustNot(termQuery("hidden_by", userID))
+	msgs, err := m.find(ctx, visible, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+	for _, msg := range msgs {
+		msg.HiddenBy = append(msg.HiddenBy, userID)
+	}
+	return len(msgs), m.put(msgs)
+}
+
+func (m *MsgSearchBleve) Hide(ctx context.Context, userID string, conversationID string, seqs []int64) error {
+	if len(seqs) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(seqs))
+	for _, seq := range seqs {
+		ids = append(ids, docID(conversationID, seq))
+	}
+	for i := 0; i < len(ids); i += batchSize {
+		_, err := m.hide(ctx, userID, bleve.NewDocIDQuery(ids[i:min(i+batchSize, len(ids))]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MsgSearchBleve) HideBefore(ctx context.Context, userID string, conversationID string, seq int64) error {
+	for {
+		n, err := m.hide(ctx, userID, seqBeforeQuery(conversationID, seq))
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+	}
+}
+
+// boostQuery is a leaf query of a search.
+type boostQuery interface {
+	query.Query
+	SetBoost(b float64)
+}
+
+// Search matches the messages containing most of the query tokens, they are ranked by the tf-idf score of the
+// matched tokens, which shrinks with the token count of a message like the mongo index does.
+func (m *MsgSearchBleve) Search(ctx context.Context, search *model.MsgSearchQuery) (int64, []*model.MsgSearch, error) {
+	tokens := tokenizer.Query(search.Keyword)
+	// only the keyword is scored, the filters must not change the ranking
+	filter := func(q boostQuery) query.Query {
+		if len(tokens) > 0 {
+			q.SetBoost(0)
+		}
+		return q
+	}
+	var must []query.Query
+	if len(search.ConversationIDs) > 0 {
+		conversations := bleve.NewDisjunctionQuery()
+		for _, conversationID := range search.ConversationIDs {
+			conversations.AddQuery(filter(termQuery("conversation_id", conversationID)))
+		}
+		must = append(must, conversations)
+	}
+	if search.SendID != "" {
+		must = append(must, filter(termQuery("send_id", search.SendID)))
+	}
+	if search.RecvID != "" {
+		must = append(must, bleve.NewDisjunctionQuery(
+			filter(termQuery("recv_id", search.RecvID)),
+			filter(termQuery("group_id", search.RecvID)),
+		))
+	}
+	if search.SessionType != 0 {
+		must = append(must, filter(numericEqualQuery("session_type", float64(search.SessionType))))
+	}
+	if len(search.ContentTypes) > 0 {
+		contentTypes := bleve.NewDisjunctionQuery()
+		for _, contentType := range search.ContentTypes {
+			contentTypes.AddQuery(filter(numericEqualQuery("content_type", float64(contentType))))
+		}
+		must = append(must, contentTypes)
+	}
+	if search.StartTime > 0 || search.EndTime > 0 {
+		var start, end *float64
+		if search.StartTime > 0 {
+			start = datautil.ToPtr(float64(search.StartTime))
+		}
+		if search.EndTime > 0 {
+			end = datautil.ToPtr(float64(search.EndTime))
+		}
+		must = append(must, filter(numericQuery("send_time", start, end, true, false)))
+	}
+	sortBy := []string{"-send_time"}
+	if len(tokens) > 0 {
+		keyword := bleve.NewDisjunctionQuery()
+		for _, token := range tokens {
+			keyword.AddQuery(termQuery("tokens", token))
+		}
+		// a long query may miss a quarter of its tokens
+		keyword.SetMin(float64(len(tokens) - len(tokens)/4))
+		must = append(must, keyword)
+		sortBy = []string{"-_score", "-send_time"}
+	}
+	if len(must) == 0 {
+		must = append(must, bleve.NewMatchAllQuery())
+	}
+	q := bleve.NewBooleanQuery()
+	q.AddMust(must...)
+	if search.UserID != "" {
+		q.AddMustNot(termQuery("hidden_by", search.UserID))
+	}
+	size := search.Limit
+	if size <= 0 {
+		size = math.MaxInt32
+	}
+	req := bleve.NewSearchRequestOptions(q, size, search.Offset, false)
+	req.SortBy(sortBy)
+	total, hits, err := m.search(ctx, req)
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, hit := range hits {
+		hit.Tokens = nil
+		hit.HiddenBy = nil
+	}
+	return total, hits, nil
+}
@@ -0,0 +1,130 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bleve
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIndex(t *testing.T) *MsgSearchBleve {
+	m, err := NewMsgSearchBleve(t.TempDir())
+	require.NoError(t, err)
+	msgs := []*model.MsgSearch{
+		{ConversationID: "si_a_b", Seq: 1, SendID: "a", RecvID: "b", SessionType: 1, ContentType: 101, SendTime: 1000, Text: "hello world"},
+		{ConversationID: "si_a_b", Seq: 2, SendID: "b", RecvID: "a", SessionType: 1, ContentType: 101, SendTime: 2000, Text: "你好世界"},
+		{ConversationID: "si_a_b", Seq: 3, SendID: "a", RecvID: "b", SessionType: 1, ContentType: 106, SendTime: 3000, Text: "hello"},
+		{ConversationID: "sg_g", Seq: 1, SendID: "a", GroupID: "g", SessionType: 3, ContentType: 101, SendTime: 4000, Text: "hello everyone in the world of openim"},
+		{ConversationID: "sg_g", Seq: 2, SendID: "c", GroupID: "g", SessionType: 3, ContentType: 101, SendTime: 5000, Text: "大家好，世界和平"},
+	}
+	require.NoError(t, m.Index(context.Background(), msgs))
+	return m
+}
+
+type searchHit struct {
+	ConversationID string
+	Seq            int64
+}
+
+func search(t *testing.T, m *MsgSearchBleve, query *model.MsgSearchQuery) (int64, []searchHit) {
+	total, msgs, err := m.Search(context.Background(), query)
+	require.NoError(t, err)
+	hits := make([]searchHit, 0, len(msgs))
+	for _, msg := range msgs {
+		hits = append(hits, searchHit{msg.ConversationID, msg.Seq})
+	}
+	return total, hits
+}
+
+func TestSearch(t *testing.T) {
+	m := newTestIndex(t)
+
+	// the shorter message matching all the tokens ranks first
+	total, hits := search(t, m, &model.MsgSearchQuery{Keyword: "Hello World"})
+	assert.EqualValues(t, 2, total)
+	assert.Equal(t, []searchHit{{"si_a_b", 1}, {"sg_g", 1}}, hits)
+
+	total, hits = search(t, m, &model.MsgSearchQuery{Keyword: "世界"})
+	assert.EqualValues(t, 2, total)
+	assert.ElementsMatch(t, []searchHit{{"si_a_b", 2}, {"sg_g", 2}}, hits)
+
+	// without keyword the messages are sorted by send time
+	total, hits = search(t, m, &model.MsgSearchQuery{ConversationIDs: []string{"si_a_b"}, Limit: 2})
+	assert.EqualValues(t, 3, total)
+	assert.Equal(t, []searchHit{{"si_a_b", 3}, {"si_a_b", 2}}, hits)
+
+	total, hits = search(t, m, &model.MsgSearchQuery{ConversationIDs: []string{"si_a_b"}, Offset: 2, Limit: 2})
+	assert.EqualValues(t, 3, total)
+	assert.Equal(t, []searchHit{{"si_a_b", 1}}, hits)
+
+	_, hits = search(t, m, &model.MsgSearchQuery{Keyword: "hello", SendID: "a", ContentTypes: []int32{101}})
+	assert.ElementsMatch(t, []searchHit{{"si_a_b", 1}, {"sg_g", 1}}, hits)
+
+	_, hits = search(t, m, &model.MsgSearchQuery{RecvID: "g"})
+	assert.Equal(t, []searchHit{{"sg_g", 2}, {"sg_g", 1}}, hits)
+
+	_, hits = search(t, m, &model.MsgSearchQuery{SessionType: 1, StartTime: 2000, EndTime: 3000})
+	assert.Equal(t, []searchHit{{"si_a_b", 2}}, hits)
+
+	total, hits = search(t, m, &model.MsgSearchQuery{Keyword: "goodbye"})
+	assert.Zero(t, total)
+	assert.Empty(t, hits)
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+	m := newTestIndex(t)
+
+	require.NoError(t, m.Delete(ctx, "si_a_b", []int64{1}))
+	total, hits := search(t, m, &model.MsgSearchQuery{Keyword: "hello"})
+	assert.EqualValues(t, 2, total)
+	assert.ElementsMatch(t, []searchHit{{"si_a_b", 3}, {"sg_g", 1}}, hits)
+
+	require.NoError(t, m.DeleteBefore(ctx, "sg_g", 3))
+	total, hits = search(t, m, &model.MsgSearchQuery{})
+	assert.EqualValues(t, 2, total)
+	assert.Equal(t, []searchHit{{"si_a_b", 3}, {"si_a_b", 2}}, hits)
+}
+
+func TestHide(t *testing.T) {
+	ctx := context.Background()
+	m := newTestIndex(t)
+
+	require.NoError(t, m.Hide(ctx, "a", "si_a_b", []int64{1}))
+	total, hits := search(t, m, &model.MsgSearchQuery{Keyword: "hello", UserID: "a"})
+	assert.EqualValues(t, 2, total)
+	assert.ElementsMatch(t, []searchHit{{"si_a_b", 3}, {"sg_g", 1}}, hits)
+	total, _ = search(t, m, &model.MsgSearchQuery{Keyword: "hello", UserID: "b"})
+	assert.EqualValues(t, 3, total)
+
+	// an edited message stays hidden
+	require.NoError(t, m.Index(ctx, []*model.MsgSearch{
+		{ConversationID: "si_a_b", Seq: 1, SendID: "a", RecvID: "b", SessionType: 1, ContentType: 101, SendTime: 1000, Text: "hello there"},
+	}))
+	total, _ = search(t, m, &model.MsgSearchQuery{Keyword: "hello", UserID: "a"})
+	assert.EqualValues(t, 2, total)
+	_, hits = search(t, m, &model.MsgSearchQuery{Keyword: "there", UserID: "b"})
+	assert.Equal(t, []searchHit{{"si_a_b", 1}}, hits)
+
+	require.NoError(t, m.HideBefore(ctx, "b", "si_a_b", 3))
+	_, hits = search(t, m, &model.MsgSearchQuery{ConversationIDs: []string{"si_a_b"}, UserID: "b"})
+	assert.Equal(t, []searchHit{{"si_a_b", 3}}, hits)
+	_, hits = search(t, m, &model.MsgSearchQuery{ConversationIDs: []string{"si_a_b"}, UserID: "a"})
+	assert.Equal(t, []searchHit{{"si_a_b", 3}, {"si_a_b", 2}}, hits)
+}
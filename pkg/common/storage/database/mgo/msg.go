@@ -3,6 +3,7 @@ package mgo
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database"
@@ -1110,3 +1111,85 @@ func (m *MsgMgo) FindSeqs(ctx context.Context, conversationID string, seqs []int
 	}
 	return result, nil
 }
+
+func (m *MsgMgo) FindSendSeqs(ctx context.Context, conversationID string, sendID string, beforeSeq int64, limit int) ([]int64, error) {
+	match := bson.M{
+		"msgs.msg.send_id": sendID,
+		"msgs.revoke":      nil,
+	}
+	if beforeSeq > 0 {
+		match["msgs.msg.seq"] = bson.M{"$lt": beforeSeq}
+	}
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{
+			"doc_id":           primitive.Regex{Pattern: "^" + regexp.QuoteMeta(conversationID+":")},
+			"msgs.msg.send_id": sendID,
+		}},
+		bson.M{"$unwind": "$msgs"},
+		bson.M{"$match": match},
+		bson.M{"$sort": bson.M{"msgs.msg.seq": -1}},
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": limit})
+	}
+	pipeline = append(pipeline, bson.M{"$project": bson.M{"_id": 0, "seq": "$msgs.msg.seq"}})
+	type sendSeq struct {
+		Seq int64 `bson:"seq"`
+	}
+	res, err := mongoutil.Aggregate[sendSeq](ctx, m.coll, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	return datautil.Slice(res, func(s sendSeq) int64 { return s.Seq }), nil
+}
+
+func (m *MsgMgo) EditMsg(ctx context.Context, docID string, index int64, oldContent string, content string, editTime int64) error {
+	prefix := fmt.Sprintf("msgs.%d.", index)
+	filter := bson.M{
+		"doc_id":               docID,
+		prefix + "msg.content": oldContent,
+		prefix + "revoke":      nil,
+	}
+	update := bson.M{
+		"$set":  bson.M{prefix + "msg.content": content},
+		"$push": bson.M{prefix + "edit_history": &model.MsgEditModel{Content: oldContent, EditTime: editTime}},
+	}
+	res, err := mongoutil.UpdateOneResult(ctx, m.coll, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errs.ErrRecordNotFound.WrapMsg("msg is revoked or changed", "docID", docID, "index", index)
+	}
+	return nil
+}
+
+func (m *MsgMgo) AddReaction(ctx context.Context, docID string, index int64, reaction string, userID string) (bool, error) {
+	filter := bson.M{
+		"doc_id":                          docID,
+		fmt.Sprintf("msgs.%d.msg", index): bson.M{"$ne": nil},
+	}
+	update := bson.M{"$addToSet": bson.M{fmt.Sprintf("msgs.%d.reactions.%s", index, reaction): userID}}
+	res, err := mongoutil.UpdateOneResult(ctx, m.coll, filter, update)
+	if err != nil {
+		return false, err
+	}
+	if res.MatchedCount == 0 {
+		return false, errs.ErrRecordNotFound.WrapMsg("msg not found", "docID", docID, "index", index)
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (m *MsgMgo) DeleteReaction(ctx context.Context, docID string, index int64, reaction string, userID string) (bool, error) {
+	field := fmt.Sprintf("msgs.%d.reactions.%s", index, reaction)
+	res, err := mongoutil.UpdateOneResult(ctx, m.coll, bson.M{"doc_id": docID, field: userID}, bson.M{"$pull": bson.M{field: userID}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (m *MsgMgo) SetThread(ctx context.Context, docID string, index int64, thread *model.MsgThreadModel) error {
+	_, err := m.UpdateMsg(ctx, docID, index, "thread", thread)
+	return err
+}
@@ -0,0 +1,121 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/tools/db/mongoutil"
+	"github.com/openimsdk/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewMsgReadReceiptMongo(db *mongo.Database) (database.MsgReadReceipt, error) {
+	groupColl := db.Collection(database.GroupReadReceiptName)
+	_, err := groupColl.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "group_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	coll := db.Collection(database.MsgReadReceiptName)
+	_, err = coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "conversation_id", Value: 1},
+			{Key: "seq", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &MsgReadReceiptMongo{groupColl: groupColl, coll: coll}, nil
+}
+
+type MsgReadReceiptMongo struct {
+	groupColl *mongo.Collection
+	coll      *mongo.Collection
+}
+
+func (m *MsgReadReceiptMongo) SetGroupReadReceipt(ctx context.Context, setting *model.GroupReadReceipt) error {
+	_, err := m.groupColl.ReplaceOne(ctx, bson.M{"group_id": setting.GroupID}, setting, options.Replace().SetUpsert(true))
+	return errs.Wrap(err)
+}
+
+func (m *MsgReadReceiptMongo) TakeGroupReadReceipt(ctx context.Context, groupID string) (*model.GroupReadReceipt, error) {
+	setting, err := mongoutil.FindOne[*model.GroupReadReceipt](ctx, m.groupColl, bson.M{"group_id": groupID})
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return setting, nil
+}
+
+func (m *MsgReadReceiptMongo) CreateReceipts(ctx context.Context, receipts []*model.MsgReadReceipt) error {
+	if len(receipts) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(receipts))
+	for _, receipt := range receipts {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"conversation_id": receipt.ConversationID, "seq": receipt.Seq}).
+			SetUpdate(bson.M{"$setOnInsert": receipt}).
+			SetUpsert(true))
+	}
+	_, err := m.coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return errs.Wrap(err)
+}
+
+func (m *MsgReadReceiptMongo) FindUnreadReceipts(ctx context.Context, conversationID string, userID string, fromSeq int64, toSeq int64, minSendTime int64, limit int) ([]*model.MsgReadReceipt, error) {
+	filter := bson.M{
+		"conversation_id":    conversationID,
+		"seq":                bson.M{"$gt": fromSeq, "$lte": toSeq},
+		"send_time":          bson.M{"$gte": minSendTime},
+		"send_id":            bson.M{"$ne": userID},
+		"read_users.user_id": bson.M{"$ne": userID},
+	}
+	opts := options.Find().
+		SetSort(bson.M{"seq": -1}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"_id": 0, "read_users": 0})
+	return mongoutil.Find[*model.MsgReadReceipt](ctx, m.coll, filter, opts)
+}
+
+func (m *MsgReadReceiptMongo) AddReader(ctx context.Context, conversationID string, seqs []int64, reader *model.MsgReadMember) error {
+	if len(seqs) == 0 {
+		return nil
+	}
+	filter := bson.M{
+		"conversation_id":    conversationID,
+		"seq":                bson.M{"$in": seqs},
+		"read_users.user_id": bson.M{"$ne": reader.UserID},
+	}
+	return mongoutil.Ignore(mongoutil.UpdateMany(ctx, m.coll, filter, bson.M{"$push": bson.M{"read_users": reader}}))
+}
+
+func (m *MsgReadReceiptMongo) FindReceipts(ctx context.Context, conversationID string, seqs []int64) ([]*model.MsgReadReceipt, error) {
+	if len(seqs) == 0 {
+		return nil, nil
+	}
+	filter := bson.M{"conversation_id": conversationID, "seq": bson.M{"$in": seqs}}
+	return mongoutil.Find[*model.MsgReadReceipt](ctx, m.coll, filter, options.Find().SetProjection(bson.M{"_id": 0}))
+}
@@ -0,0 +1,195 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/open-im-server/v3/pkg/util/tokenizer"
+	"github.com/openimsdk/tools/db/mongoutil"
+	"github.com/openimsdk/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NewMsgSearchMongo creates an inverted index of the messages in mongo, the tokens of every message are stored
+// in a multikey indexed array so that no search engine has to be deployed.
+func NewMsgSearchMongo(db *mongo.Database) (*MsgSearchMongo, error) {
+	coll := db.Collection(database.MsgSearchName)
+	_, err := coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "conversation_id", Value: 1},
+				{Key: "seq", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "tokens", Value: 1},
+				{Key: "send_time", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "conversation_id", Value: 1},
+				{Key: "send_time", Value: -1},
+			},
+		},
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &MsgSearchMongo{coll: coll}, nil
+}
+
+type MsgSearchMongo struct {
+	coll *mongo.Collection
+}
+
+func (m *MsgSearchMongo) Index(ctx context.Context, msgs []*model.MsgSearch) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(msgs))
+	for _, msg := range msgs {
+		// hidden_by is left as is so that a re-indexed message stays hidden
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"conversation_id": msg.ConversationID, "seq": msg.Seq}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"send_id":      msg.SendID,
+				"recv_id":      msg.RecvID,
+				"group_id":     msg.GroupID,
+				"session_type": msg.SessionType,
+				"content_type": msg.ContentType,
+				"send_time":    msg.SendTime,
+				"tokens":       tokenizer.Index(msg.Text),
+			}}).
+			SetUpsert(true))
+	}
+	_, err := m.coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return errs.Wrap(err)
+}
+
+func (m *MsgSearchMongo) Delete(ctx context.Context, conversationID string, seqs []int64) error {
+	if len(seqs) == 0 {
+		return nil
+	}
+	return mongoutil.DeleteMany(ctx, m.coll, bson.M{"conversation_id": conversationID, "seq": bson.M{"$in": seqs}})
+}
+
+func (m *MsgSearchMongo) DeleteBefore(ctx context.Context, conversationID string, seq int64) error {
+	return mongoutil.DeleteMany(ctx, m.coll, bson.M{"conversation_id": conversationID, "seq": bson.M{"$lt": seq}})
+}
+
+func (m *MsgSearchMongo) Hide(ctx context.Context, userID string, conversationID string, seqs []int64) error {
+	if len(seqs) == 0 {
+		return nil
+	}
+	filter := bson.M{"conversation_id": conversationID, "seq": bson.M{"$in": seqs}}
+	_, err := mongoutil.UpdateMany(ctx, m.coll, filter, bson.M{"$addToSet": bson.M{"hidden_by": userID}})
+	return err
+}
+
+func (m *MsgSearchMongo) HideBefore(ctx context.Context, userID string, conversationID string, seq int64) error {
+	filter := bson.M{"conversation_id": conversationID, "seq": bson.M{"$lt": seq}}
+	_, err := mongoutil.UpdateMany(ctx, m.coll, filter, bson.M{"$addToSet": bson.M{"hidden_by": userID}})
+	return err
+}
+
+// Search matches the messages containing most of the query tokens, the score of a message grows with the number
+// of matched tokens and shrinks with the square root of its token count.
+func (m *MsgSearchMongo) Search(ctx context.Context, query *model.MsgSearchQuery) (int64, []*model.MsgSearch, error) {
+	filter := bson.M{}
+	if len(query.ConversationIDs) > 0 {
+		filter["conversation_id"] = bson.M{"$in": query.ConversationIDs}
+	}
+	if query.SendID != "" {
+		filter["send_id"] = query.SendID
+	}
+	if query.RecvID != "" {
+		filter["$or"] = bson.A{
+			bson.M{"recv_id": query.RecvID},
+			bson.M{"group_id": query.RecvID},
+		}
+	}
+	if query.UserID != "" {
+		filter["hidden_by"] = bson.M{"$ne": query.UserID}
+	}
+	if query.SessionType != 0 {
+		filter["session_type"] = query.SessionType
+	}
+	if len(query.ContentTypes) > 0 {
+		filter["content_type"] = bson.M{"$in": query.ContentTypes}
+	}
+	if query.StartTime > 0 || query.EndTime > 0 {
+		sendTime := bson.M{}
+		if query.StartTime > 0 {
+			sendTime["$gte"] = query.StartTime
+		}
+		if query.EndTime > 0 {
+			sendTime["$lt"] = query.EndTime
+		}
+		filter["send_time"] = sendTime
+	}
+	pipeline := bson.A{}
+	tokens := tokenizer.Query(query.Keyword)
+	if len(tokens) > 0 {
+		filter["tokens"] = bson.M{"$in": tokens}
+		matched := bson.M{"$size": bson.M{"$setIntersection": bson.A{"$tokens", tokens}}}
+		pipeline = append(pipeline,
+			bson.M{"$match": filter},
+			bson.M{"$addFields": bson.M{"matched": matched}},
+			// a long query may miss a quarter of its tokens
+			bson.M{"$match": bson.M{"matched": bson.M{"$gte": len(tokens) - len(tokens)/4}}},
+			bson.M{"$addFields": bson.M{"score": bson.M{"$divide": bson.A{
+				"$matched",
+				bson.M{"$sqrt": bson.M{"$max": bson.A{bson.M{"$size": "$tokens"}, 1}}},
+			}}}},
+			bson.M{"$sort": bson.D{{Key: "score", Value: -1}, {Key: "send_time", Value: -1}}},
+		)
+	} else {
+		pipeline = append(pipeline,
+			bson.M{"$match": filter},
+			bson.M{"$sort": bson.D{{Key: "send_time", Value: -1}}},
+		)
+	}
+	hits := bson.A{bson.M{"$skip": query.Offset}}
+	if query.Limit > 0 {
+		hits = append(hits, bson.M{"$limit": query.Limit})
+	}
+	hits = append(hits, bson.M{"$project": bson.M{"tokens": 0, "hidden_by": 0, "matched": 0, "score": 0}})
+	pipeline = append(pipeline, bson.M{"$facet": bson.M{
+		"total": bson.A{bson.M{"$count": "count"}},
+		"hits":  hits,
+	}})
+	type searchResult struct {
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+		Hits []*model.MsgSearch `bson:"hits"`
+	}
+	res, err := mongoutil.Aggregate[*searchResult](ctx, m.coll, pipeline)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(res) == 0 || len(res[0].Total) == 0 {
+		return 0, nil, nil
+	}
+	return res[0].Total[0].Count, res[0].Hits, nil
+}
@@ -0,0 +1,90 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/tools/db/mongoutil"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/utils/datautil"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewMsgThreadMongo(db *mongo.Database) (database.MsgThread, error) {
+	coll := db.Collection(database.MsgThreadReplyName)
+	_, err := coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "conversation_id", Value: 1},
+			{Key: "root_seq", Value: 1},
+			{Key: "seq", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &MsgThreadMongo{coll: coll}, nil
+}
+
+type MsgThreadMongo struct {
+	coll *mongo.Collection
+}
+
+func (m *MsgThreadMongo) AddReplies(ctx context.Context, replies []*model.MsgThreadReply) error {
+	if len(replies) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(replies))
+	for _, reply := range replies {
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"conversation_id": reply.ConversationID, "root_seq": reply.RootSeq, "seq": reply.Seq}).
+			SetReplacement(reply).
+			SetUpsert(true))
+	}
+	_, err := m.coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return errs.Wrap(err)
+}
+
+func (m *MsgThreadMongo) CountReplies(ctx context.Context, conversationID string, rootSeq int64) (int64, error) {
+	return mongoutil.Count(ctx, m.coll, bson.M{"conversation_id": conversationID, "root_seq": rootSeq})
+}
+
+func (m *MsgThreadMongo) FindReplySeqs(ctx context.Context, conversationID string, rootSeq int64, startSeq int64, limit int, desc bool) ([]int64, error) {
+	// a limit of 0 would not limit the replies
+	if limit <= 0 {
+		return nil, errs.ErrArgs.WrapMsg("limit must be positive", "limit", limit)
+	}
+	filter := bson.M{"conversation_id": conversationID, "root_seq": rootSeq}
+	sort := 1
+	if desc {
+		sort = -1
+		if startSeq > 0 {
+			filter["seq"] = bson.M{"$lt": startSeq}
+		}
+	} else {
+		filter["seq"] = bson.M{"$gt": startSeq}
+	}
+	opts := options.Find().SetSort(bson.M{"seq": sort}).SetLimit(int64(limit)).SetProjection(bson.M{"_id": 0, "seq": 1})
+	replies, err := mongoutil.Find[*model.MsgThreadReply](ctx, m.coll, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	return datautil.Slice(replies, func(reply *model.MsgThreadReply) int64 { return reply.Seq }), nil
+}
@@ -0,0 +1,105 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/tools/db/mongoutil"
+	"github.com/openimsdk/tools/db/pagination"
+	"github.com/openimsdk/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewScheduledMsgMongo(db *mongo.Database) (database.ScheduledMsg, error) {
+	coll := db.Collection(database.ScheduledMsgName)
+	_, err := coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "schedule_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "send_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "owner_user_id", Value: 1}, {Key: "send_at", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &ScheduledMsgMongo{coll: coll}, nil
+}
+
+type ScheduledMsgMongo struct {
+	coll *mongo.Collection
+}
+
+func (s *ScheduledMsgMongo) Create(ctx context.Context, msg *model.ScheduledMsg) error {
+	return mongoutil.InsertMany(ctx, s.coll, []*model.ScheduledMsg{msg})
+}
+
+func (s *ScheduledMsgMongo) Take(ctx context.Context, scheduleID string) (*model.ScheduledMsg, error) {
+	return mongoutil.FindOne[*model.ScheduledMsg](ctx, s.coll, bson.M{"schedule_id": scheduleID})
+}
+
+func (s *ScheduledMsgMongo) FindPage(ctx context.Context, ownerUserID string, statuses []int32, pagination pagination.Pagination) (int64, []*model.ScheduledMsg, error) {
+	filter := bson.M{}
+	if ownerUserID != "" {
+		filter["owner_user_id"] = ownerUserID
+	}
+	if len(statuses) > 0 {
+		filter["status"] = bson.M{"$in": statuses}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "send_at", Value: 1}, {Key: "schedule_id", Value: 1}})
+	return mongoutil.FindPage[*model.ScheduledMsg](ctx, s.coll, filter, pagination, opts)
+}
+
+func (s *ScheduledMsgMongo) FindDue(ctx context.Context, now time.Time, limit int) ([]*model.ScheduledMsg, error) {
+	filter := bson.M{"status": model.ScheduledMsgPending, "send_at": bson.M{"$lte": now}}
+	opts := options.Find().SetSort(bson.M{"send_at": 1}).SetLimit(int64(limit))
+	return mongoutil.Find[*model.ScheduledMsg](ctx, s.coll, filter, opts)
+}
+
+func (s *ScheduledMsgMongo) CountPending(ctx context.Context, ownerUserID string) (int64, error) {
+	return mongoutil.Count(ctx, s.coll, bson.M{"owner_user_id": ownerUserID, "status": model.ScheduledMsgPending})
+}
+
+func (s *ScheduledMsgMongo) Update(ctx context.Context, scheduleID string, status int32, data map[string]any) (bool, error) {
+	if len(data) == 0 {
+		return false, nil
+	}
+	res, err := mongoutil.UpdateOneResult(ctx, s.coll, bson.M{"schedule_id": scheduleID, "status": status}, bson.M{"$set": data})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func (s *ScheduledMsgMongo) UpdateStale(ctx context.Context, status int32, before time.Time, data map[string]any) (int64, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	res, err := mongoutil.UpdateMany(ctx, s.coll, bson.M{"status": status, "update_time": bson.M{"$lt": before}}, bson.M{"$set": data})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
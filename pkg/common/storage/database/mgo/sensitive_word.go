@@ -0,0 +1,73 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/database"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/tools/db/mongoutil"
+	"github.com/openimsdk/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewSensitiveWordMongo(db *mongo.Database) (database.SensitiveWord, error) {
+	coll := db.Collection(database.SensitiveWordName)
+	_, err := coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "list_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &SensitiveWordMgo{coll: coll}, nil
+}
+
+type SensitiveWordMgo struct {
+	coll *mongo.Collection
+}
+
+func (s *SensitiveWordMgo) Set(ctx context.Context, list *model.SensitiveWordList) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"name":        list.Name,
+			"action":      list.Action,
+			"words":       list.Words,
+			"update_time": now,
+		},
+		"$setOnInsert": bson.M{"create_time": now},
+	}
+	return mongoutil.UpdateOne(ctx, s.coll, bson.M{"list_id": list.ListID}, update, false, options.Update().SetUpsert(true))
+}
+
+func (s *SensitiveWordMgo) Delete(ctx context.Context, listIDs []string) error {
+	if len(listIDs) == 0 {
+		return nil
+	}
+	return mongoutil.DeleteMany(ctx, s.coll, bson.M{"list_id": bson.M{"$in": listIDs}})
+}
+
+func (s *SensitiveWordMgo) Find(ctx context.Context, listIDs []string) ([]*model.SensitiveWordList, error) {
+	filter := bson.M{}
+	if len(listIDs) > 0 {
+		filter["list_id"] = bson.M{"$in": listIDs}
+	}
+	return mongoutil.Find[*model.SensitiveWordList](ctx, s.coll, filter, options.Find().SetSort(bson.M{"create_time": 1}))
+}
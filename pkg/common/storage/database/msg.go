@@ -41,4 +41,16 @@ type Msg interface {
 	GetLastMessageSeqByTime(ctx context.Context, conversationID string, time int64) (int64, error)
 	GetLastMessage(ctx context.Context, conversationID string) (*model.MsgInfoModel, error)
 	FindSeqs(ctx context.Context, conversationID string, seqs []int64) ([]*model.MsgInfoModel, error)
+	// EditMsg replaces the content of the message at index when it is still oldContent and appends oldContent to
+	// the edit history, it returns errs.ErrRecordNotFound when the message is revoked or changed in the meantime.
+	EditMsg(ctx context.Context, docID string, index int64, oldContent string, content string, editTime int64) error
+	// AddReaction adds userID to the users of the reaction of the message at index, it reports whether it is added.
+	AddReaction(ctx context.Context, docID string, index int64, reaction string, userID string) (bool, error)
+	// DeleteReaction removes userID from the users of the reaction of the message at index, it reports whether it is removed.
+	DeleteReaction(ctx context.Context, docID string, index int64, reaction string, userID string) (bool, error)
+	// SetThread sets the thread summary of the root message at index.
+	SetThread(ctx context.Context, docID string, index int64, thread *model.MsgThreadModel) error
+	// FindSendSeqs returns the seqs of the messages of sendID in the conversation which are not revoked, the newest first.
+	// Only the seqs less than beforeSeq are returned when it is not 0.
+	FindSendSeqs(ctx context.Context, conversationID string, sendID string, beforeSeq int64, limit int) ([]int64, error)
 }
@@ -0,0 +1,37 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+)
+
+type MsgReadReceipt interface {
+	// SetGroupReadReceipt creates or replaces the read receipt setting of a group.
+	SetGroupReadReceipt(ctx context.Context, setting *model.GroupReadReceipt) error
+	// TakeGroupReadReceipt returns the read receipt setting of a group, nil when the group never set it.
+	TakeGroupReadReceipt(ctx context.Context, groupID string) (*model.GroupReadReceipt, error)
+	// CreateReceipts creates the read receipts of the messages, the receipts already created are ignored.
+	CreateReceipts(ctx context.Context, receipts []*model.MsgReadReceipt) error
+	// FindUnreadReceipts returns at most limit receipts of the messages of seqs in (fromSeq, toSeq] sent after
+	// minSendTime, neither sent nor read by userID, the last ones first. The readers are not returned.
+	FindUnreadReceipts(ctx context.Context, conversationID string, userID string, fromSeq int64, toSeq int64, minSendTime int64, limit int) ([]*model.MsgReadReceipt, error)
+	// AddReader adds the reader to the receipts of the messages of seqs not read by it yet.
+	AddReader(ctx context.Context, conversationID string, seqs []int64, reader *model.MsgReadMember) error
+	// FindReceipts returns the receipts of the messages of seqs.
+	FindReceipts(ctx context.Context, conversationID string, seqs []int64) ([]*model.MsgReadReceipt, error)
+}
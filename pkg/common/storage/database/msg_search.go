@@ -0,0 +1,39 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+)
+
+// MsgSearchIndex is a full-text index of the messages, it is fed by the msg rpc with the messages of the mongo
+// topic and pruned when the messages are deleted, cleared or revoked.
+type MsgSearchIndex interface {
+	// Index adds or replaces the messages, identified by conversation id and seq, the users hiding a replaced
+	// message keep hiding it.
+	Index(ctx context.Context, msgs []*model.MsgSearch) error
+	// Delete removes the messages of the seqs.
+	Delete(ctx context.Context, conversationID string, seqs []int64) error
+	// DeleteBefore removes the messages of the conversation whose seq is less than seq.
+	DeleteBefore(ctx context.Context, conversationID string, seq int64) error
+	// Hide hides the messages of the seqs from the searches of the user.
+	Hide(ctx context.Context, userID string, conversationID string, seqs []int64) error
+	// HideBefore hides the messages of the conversation whose seq is less than seq from the searches of the user.
+	HideBefore(ctx context.Context, userID string, conversationID string, seq int64) error
+	// Search returns the total number of matched messages and the page of the query.
+	Search(ctx context.Context, query *model.MsgSearchQuery) (int64, []*model.MsgSearch, error)
+}
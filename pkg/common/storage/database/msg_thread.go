@@ -0,0 +1,31 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+)
+
+type MsgThread interface {
+	// AddReplies records the replies, the replies already recorded are ignored.
+	AddReplies(ctx context.Context, replies []*model.MsgThreadReply) error
+	// CountReplies returns the number of the replies of a root message.
+	CountReplies(ctx context.Context, conversationID string, rootSeq int64) (int64, error)
+	// FindReplySeqs returns at most limit seqs of the replies of a root message after the seq startSeq,
+	// in ascending order, or in descending order before startSeq when desc is true. limit must be positive.
+	FindReplySeqs(ctx context.Context, conversationID string, rootSeq int64, startSeq int64, limit int, desc bool) ([]int64, error)
+}
@@ -18,4 +18,10 @@ const (
 	SeqConversationName     = "seq"
 	SeqUserName             = "seq_user"
 	StreamMsgName           = "stream_msg"
+	MsgSearchName           = "msg_search"
+	SensitiveWordName       = "sensitive_word"
+	MsgThreadReplyName      = "msg_thread_reply"
+	ScheduledMsgName        = "scheduled_msg"
+	GroupReadReceiptName    = "group_read_receipt"
+	MsgReadReceiptName      = "msg_read_receipt"
 )
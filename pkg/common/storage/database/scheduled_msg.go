@@ -0,0 +1,38 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/tools/db/pagination"
+)
+
+type ScheduledMsg interface {
+	Create(ctx context.Context, msg *model.ScheduledMsg) error
+	Take(ctx context.Context, scheduleID string) (*model.ScheduledMsg, error)
+	// FindPage pages the messages by SendAt, the messages of all the owners when ownerUserID is empty, and of all
+	// the statuses when statuses is empty.
+	FindPage(ctx context.Context, ownerUserID string, statuses []int32, pagination pagination.Pagination) (int64, []*model.ScheduledMsg, error)
+	// FindDue returns at most limit pending messages due at now, the earliest first.
+	FindDue(ctx context.Context, now time.Time, limit int) ([]*model.ScheduledMsg, error)
+	CountPending(ctx context.Context, ownerUserID string) (int64, error)
+	// Update updates the message when its status is status, and reports whether it is updated.
+	Update(ctx context.Context, scheduleID string, status int32, data map[string]any) (bool, error)
+	// UpdateStale updates the messages in status last updated before before, and returns how many are updated.
+	UpdateStale(ctx context.Context, status int32, before time.Time, data map[string]any) (int64, error)
+}
@@ -0,0 +1,29 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+)
+
+type SensitiveWord interface {
+	// Set creates the list or replaces the name, the action and the words of it.
+	Set(ctx context.Context, list *model.SensitiveWordList) error
+	Delete(ctx context.Context, listIDs []string) error
+	// Find returns the lists, all of them when listIDs is empty.
+	Find(ctx context.Context, listIDs []string) ([]*model.SensitiveWordList, error)
+}
@@ -75,11 +75,23 @@ type MsgDataModel struct {
 	Ex               string            `bson:"ex"`
 }
 
+// MsgEditModel is a replaced content of an edited message, EditTime is when it was replaced.
+type MsgEditModel struct {
+	Content  string `bson:"content"`
+	EditTime int64  `bson:"edit_time"`
+}
+
 type MsgInfoModel struct {
 	Msg     *MsgDataModel `bson:"msg"`
 	Revoke  *RevokeModel  `bson:"revoke"`
 	DelList []string      `bson:"del_list"`
 	IsRead  bool          `bson:"is_read"`
+	// EditHistory holds the replaced contents of the message, the oldest first.
+	EditHistory []*MsgEditModel `bson:"edit_history,omitempty"`
+	// Reactions maps the reactions to the users who reacted with them.
+	Reactions map[string][]string `bson:"reactions,omitempty"`
+	// Thread is the summary of the replies when the message is the root of a thread.
+	Thread *MsgThreadModel `bson:"thread,omitempty"`
 }
 
 type UserCount struct {
@@ -0,0 +1,42 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// GroupReadReceipt records whether a group opted in to the read receipts of its messages.
+type GroupReadReceipt struct {
+	GroupID        string    `bson:"group_id"`
+	Enable         bool      `bson:"enable"`
+	OperatorUserID string    `bson:"operator_user_id"`
+	UpdateTime     time.Time `bson:"update_time"`
+}
+
+// MsgReadReceipt is the read state of a message sent in a group opting in to the read receipts. The members who
+// did not read the message are the members who joined the group before SendTime, are not in ReadUsers and whose
+// read seq of the conversation is below Seq, ReadUsers misses the reads made before the receipt was created.
+type MsgReadReceipt struct {
+	ConversationID string           `bson:"conversation_id"`
+	Seq            int64            `bson:"seq"`
+	GroupID        string           `bson:"group_id"`
+	SendID         string           `bson:"send_id"`
+	SendTime       int64            `bson:"send_time"`
+	ReadUsers      []*MsgReadMember `bson:"read_users"`
+}
+
+type MsgReadMember struct {
+	UserID   string `bson:"user_id"`
+	ReadTime int64  `bson:"read_time"`
+}
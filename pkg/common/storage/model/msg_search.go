@@ -0,0 +1,50 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+// MsgSearch is the entry of a message in the search index.
+type MsgSearch struct {
+	ConversationID string `bson:"conversation_id"`
+	Seq            int64  `bson:"seq"`
+	SendID         string `bson:"send_id"`
+	RecvID         string `bson:"recv_id"`
+	GroupID        string `bson:"group_id"`
+	SessionType    int32  `bson:"session_type"`
+	ContentType    int32  `bson:"content_type"`
+	SendTime       int64  `bson:"send_time"`
+	// Text is analyzed by the index and not stored.
+	Text   string   `bson:"-"`
+	Tokens []string `bson:"tokens"`
+	// HiddenBy are the users who deleted the message for themselves only.
+	HiddenBy []string `bson:"hidden_by"`
+}
+
+// MsgSearchQuery filters the indexed messages, the empty fields are not filtered.
+// Messages matching the keyword are ranked by relevance, otherwise by send time.
+type MsgSearchQuery struct {
+	Keyword         string
+	ConversationIDs []string
+	SendID          string
+	// RecvID matches the receiver of single chat messages and the group of group messages.
+	RecvID       string
+	SessionType  int32
+	ContentTypes []int32
+	StartTime    int64 // milliseconds, inclusive
+	EndTime      int64 // milliseconds, exclusive
+	// UserID excludes the messages hidden by the user.
+	UserID string
+	Offset int
+	Limit  int
+}
@@ -0,0 +1,41 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+// MsgThreadReply records that the message of Seq replies to the root message of RootSeq in a conversation,
+// the replies of a root are paged through these records instead of scanning the conversation.
+type MsgThreadReply struct {
+	ConversationID string `bson:"conversation_id"`
+	RootSeq        int64  `bson:"root_seq"`
+	Seq            int64  `bson:"seq"`
+	SendID         string `bson:"send_id"`
+	SendTime       int64  `bson:"send_time"`
+}
+
+// MsgThreadModel is the summary of the thread of a root message, it is stored on the root message.
+type MsgThreadModel struct {
+	ReplyCount int64                `bson:"reply_count"`
+	LastReply  *MsgThreadReplyModel `bson:"last_reply"`
+}
+
+// MsgThreadReplyModel summarizes the last reply of a thread, Text is the beginning of the text of the reply.
+type MsgThreadReplyModel struct {
+	Seq            int64  `bson:"seq"`
+	SendID         string `bson:"send_id"`
+	SenderNickname string `bson:"sender_nickname"`
+	ContentType    int32  `bson:"content_type"`
+	Text           string `bson:"text"`
+	SendTime       int64  `bson:"send_time"`
+}
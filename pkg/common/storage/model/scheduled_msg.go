@@ -0,0 +1,43 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// The status of a scheduled message, only the pending ones can be updated or canceled.
+const (
+	ScheduledMsgPending  int32 = 1
+	ScheduledMsgSending  int32 = 2
+	ScheduledMsgSent     int32 = 3
+	ScheduledMsgFailed   int32 = 4
+	ScheduledMsgCanceled int32 = 5
+)
+
+// ScheduledMsg is a message sent by the scheduler at SendAt. MsgData is the protobuf of the message, which is sent
+// to every user of RecvIDs when it is not empty, otherwise to the receiver of the message.
+type ScheduledMsg struct {
+	ScheduleID  string    `bson:"schedule_id"`
+	OwnerUserID string    `bson:"owner_user_id"`
+	MsgData     []byte    `bson:"msg_data"`
+	RecvIDs     []string  `bson:"recv_ids"`
+	SendAt      time.Time `bson:"send_at"`
+	Status      int32     `bson:"status"`
+	// FailedIDs are the receivers the message failed to be sent to, ErrMsg is the last error.
+	FailedIDs  []string  `bson:"failed_ids"`
+	ErrMsg     string    `bson:"err_msg"`
+	CreateTime time.Time `bson:"create_time"`
+	UpdateTime time.Time `bson:"update_time"`
+	SentTime   time.Time `bson:"sent_time"`
+}
@@ -0,0 +1,583 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package local implements s3.Interface on the local filesystem, the presigned urls point to openim-api
+// which serves the uploads and downloads with the same directory.
+package local
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mcontext"
+	"github.com/openimsdk/tools/s3"
+)
+
+const Engine = "local"
+
+// Prefix is the path of the api routes serving the objects.
+const Prefix = "/local_object"
+
+const (
+	minPartSize int64 = 1024 * 1024 * 5        // 5MB
+	maxPartSize int64 = 1024 * 1024 * 1024 * 5 // 5GB
+	maxNumSize  int64 = 10000
+)
+
+const (
+	objectDir = "objects"
+	metaDir   = "metas"
+	uploadDir = "uploads"
+	tempDir   = "temp"
+
+	metaSuffix     = ".json"
+	uploadMetaFile = "upload.json"
+	partSeparator  = ","
+)
+
+// Query parameters of the signed urls.
+const (
+	QueryUploadID    = "uploadId"
+	QueryPartNumber  = "partNumber"
+	QueryExpires     = "expires"
+	QuerySignature   = "signature"
+	QuerySize        = "size"
+	QueryContentType = "contentType"
+	QueryFilename    = "filename"
+)
+
+// FormFile is the name of the file field of form uploads.
+const FormFile = "file"
+
+// ObjectMeta is stored next to every object and part.
+type ObjectMeta struct {
+	ETag         string    `json:"etag"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"contentType"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+type uploadMeta struct {
+	Name      string    `json:"name"`
+	Initiated time.Time `json:"initiated"`
+}
+
+type Local struct {
+	dir     string
+	baseURL string
+	secret  []byte
+}
+
+// New opens the engine of conf. The urls are signed with conf.Secret, or with a key derived from shareSecret, the
+// secret of share.yml, when it is empty.
+func New(conf *config.Local, shareSecret string) (*Local, error) {
+	if conf.Dir == "" {
+		return nil, errs.New("local object dir is empty").Wrap()
+	}
+	if conf.BaseURL == "" {
+		return nil, errs.New("local object baseURL is empty").Wrap()
+	}
+	secret := []byte(conf.Secret)
+	if len(secret) == 0 {
+		if shareSecret == "" {
+			return nil, errs.New("local object secret and share secret are empty").Wrap()
+		}
+		secret = deriveSecret(shareSecret)
+	}
+	dir, err := filepath.Abs(conf.Dir)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "invalid local object dir", "dir", conf.Dir)
+	}
+	for _, sub := range []string{objectDir, metaDir, uploadDir, tempDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, errs.WrapMsg(err, "create local object dir failed", "dir", dir)
+		}
+	}
+	return &Local{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(conf.BaseURL, "/"),
+		secret:  secret,
+	}, nil
+}
+
+func (l *Local) Engine() string {
+	return Engine
+}
+
+func (l *Local) PartLimit() *s3.PartLimit {
+	return &s3.PartLimit{
+		MinPartSize: minPartSize,
+		MaxPartSize: maxPartSize,
+		MaxNumSize:  maxNumSize,
+	}
+}
+
+func (l *Local) PartSize(ctx context.Context, size int64) (int64, error) {
+	if size <= 0 {
+		return 0, errs.New("size must be greater than 0").Wrap()
+	}
+	if size > maxPartSize*maxNumSize {
+		return 0, errs.New("size must be less than the maximum allowed limit").Wrap()
+	}
+	if size <= minPartSize*maxNumSize {
+		return minPartSize, nil
+	}
+	partSize := size / maxNumSize
+	if size%maxNumSize != 0 {
+		partSize++
+	}
+	return partSize, nil
+}
+
+// cleanName returns the object name as a relative slash separated path that cannot escape the directory.
+func cleanName(name string) (string, error) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "", errs.ErrArgs.WrapMsg("invalid object name")
+	}
+	return name, nil
+}
+
+func (l *Local) objectPath(name string) string {
+	return filepath.Join(l.dir, objectDir, filepath.FromSlash(name))
+}
+
+func (l *Local) metaPath(name string) string {
+	return filepath.Join(l.dir, metaDir, filepath.FromSlash(name)+metaSuffix)
+}
+
+func (l *Local) uploadPath(uploadID string) (string, error) {
+	if uploadID == "" || strings.ContainsAny(uploadID, `/\.`) {
+		return "", errs.ErrArgs.WrapMsg("invalid upload id", "uploadID", uploadID)
+	}
+	return filepath.Join(l.dir, uploadDir, uploadID), nil
+}
+
+func partPath(uploadPath string, partNumber int) string {
+	return filepath.Join(uploadPath, strconv.Itoa(partNumber))
+}
+
+func randID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func readJSON(file string, v any) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	return errs.Wrap(json.Unmarshal(data, v))
+}
+
+func writeJSON(file string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return errs.Wrap(err)
+	}
+	return errs.Wrap(os.WriteFile(file, data, 0o644))
+}
+
+// writeFile writes r to a temporary file which is renamed to file once complete, so that readers never see a partial file.
+func (l *Local) writeFile(file string, r io.Reader) (*ObjectMeta, error) {
+	tmp, err := os.CreateTemp(filepath.Join(l.dir, tempDir), "object_")
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	defer os.Remove(tmp.Name())
+	hash := md5.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &ObjectMeta{
+		ETag:         hex.EncodeToString(hash.Sum(nil)),
+		Size:         size,
+		LastModified: time.Now(),
+	}, nil
+}
+
+// PutObject stores the content of r as the object.
+func (l *Local) PutObject(ctx context.Context, name string, contentType string, r io.Reader) (*ObjectMeta, error) {
+	name, err := cleanName(name)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := l.writeFile(l.objectPath(name), r)
+	if err != nil {
+		return nil, err
+	}
+	meta.ContentType = contentType
+	if err := writeJSON(l.metaPath(name), meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// UploadPart stores the content of r as a part of the multipart upload.
+func (l *Local) UploadPart(ctx context.Context, uploadID string, name string, partNumber int, r io.Reader) (*ObjectMeta, error) {
+	if partNumber <= 0 || int64(partNumber) > maxNumSize {
+		return nil, errs.ErrArgs.WrapMsg("invalid part number", "partNumber", partNumber)
+	}
+	uploadPath, _, err := l.getUpload(uploadID, name)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := l.writeFile(partPath(uploadPath, partNumber), r)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON(partPath(uploadPath, partNumber)+metaSuffix, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// OpenObject opens the object for reading, the caller must close the returned file.
+func (l *Local) OpenObject(ctx context.Context, name string) (*os.File, *ObjectMeta, error) {
+	name, err := cleanName(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	var meta ObjectMeta
+	if err := readJSON(l.metaPath(name), &meta); err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(l.objectPath(name))
+	if err != nil {
+		return nil, nil, errs.Wrap(err)
+	}
+	return file, &meta, nil
+}
+
+func (l *Local) getUpload(uploadID string, name string) (string, *uploadMeta, error) {
+	uploadPath, err := l.uploadPath(uploadID)
+	if err != nil {
+		return "", nil, err
+	}
+	name, err = cleanName(name)
+	if err != nil {
+		return "", nil, err
+	}
+	var upload uploadMeta
+	if err := readJSON(filepath.Join(uploadPath, uploadMetaFile), &upload); err != nil {
+		return "", nil, err
+	}
+	if upload.Name != name {
+		return "", nil, errs.ErrArgs.WrapMsg("upload id does not match the object name", "uploadID", uploadID, "name", name)
+	}
+	return uploadPath, &upload, nil
+}
+
+func (l *Local) InitiateMultipartUpload(ctx context.Context, name string) (*s3.InitiateMultipartUploadResult, error) {
+	name, err := cleanName(name)
+	if err != nil {
+		return nil, err
+	}
+	uploadID := randID()
+	uploadPath, err := l.uploadPath(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON(filepath.Join(uploadPath, uploadMetaFile), &uploadMeta{Name: name, Initiated: time.Now()}); err != nil {
+		return nil, err
+	}
+	return &s3.InitiateMultipartUploadResult{
+		Bucket:   Engine,
+		Key:      name,
+		UploadID: uploadID,
+	}, nil
+}
+
+// CompleteMultipartUpload joins the parts in order, the etag of every part must match the md5 of its content.
+// The etag of the object is the md5 of the joined part etags, the same as the hash of the upload.
+func (l *Local) CompleteMultipartUpload(ctx context.Context, uploadID string, name string, parts []s3.Part) (*s3.CompleteMultipartUploadResult, error) {
+	uploadPath, upload, err := l.getUpload(uploadID, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, errs.ErrArgs.WrapMsg("no part to complete")
+	}
+	etags := make([]string, len(parts))
+	readers := make([]io.Reader, len(parts))
+	for i, part := range parts {
+		var meta ObjectMeta
+		if err := readJSON(partPath(uploadPath, part.PartNumber)+metaSuffix, &meta); err != nil {
+			return nil, err
+		}
+		etag := strings.ToLower(strings.Trim(part.ETag, `"`))
+		if meta.ETag != etag {
+			return nil, errs.ErrArgs.WrapMsg("part etag mismatching", "partNumber", part.PartNumber, "etag", part.ETag, "actual", meta.ETag)
+		}
+		file, err := os.Open(partPath(uploadPath, part.PartNumber))
+		if err != nil {
+			return nil, errs.Wrap(err)
+		}
+		defer file.Close()
+		etags[i] = etag
+		readers[i] = file
+	}
+	meta, err := l.writeFile(l.objectPath(upload.Name), io.MultiReader(readers...))
+	if err != nil {
+		return nil, err
+	}
+	md5Sum := md5.Sum([]byte(strings.Join(etags, partSeparator)))
+	meta.ETag = hex.EncodeToString(md5Sum[:])
+	if err := writeJSON(l.metaPath(upload.Name), meta); err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(uploadPath); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &s3.CompleteMultipartUploadResult{
+		Location: l.objectURL(upload.Name),
+		Bucket:   Engine,
+		Key:      upload.Name,
+		ETag:     meta.ETag,
+	}, nil
+}
+
+func (l *Local) AbortMultipartUpload(ctx context.Context, uploadID string, name string) error {
+	uploadPath, _, err := l.getUpload(uploadID, name)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(os.RemoveAll(uploadPath))
+}
+
+func (l *Local) ListUploadedParts(ctx context.Context, uploadID string, name string, partNumberMarker int, maxParts int) (*s3.ListUploadedPartsResult, error) {
+	uploadPath, upload, err := l.getUpload(uploadID, name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(uploadPath)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	var partNumbers []int
+	for _, entry := range entries {
+		partNumber, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), metaSuffix))
+		if err != nil || !strings.HasSuffix(entry.Name(), metaSuffix) || partNumber <= partNumberMarker {
+			continue
+		}
+		partNumbers = append(partNumbers, partNumber)
+	}
+	sort.Ints(partNumbers)
+	res := &s3.ListUploadedPartsResult{
+		Key:      upload.Name,
+		UploadID: uploadID,
+		MaxParts: maxParts,
+	}
+	for _, partNumber := range partNumbers {
+		if maxParts > 0 && len(res.UploadedParts) >= maxParts {
+			break
+		}
+		var meta ObjectMeta
+		if err := readJSON(partPath(uploadPath, partNumber)+metaSuffix, &meta); err != nil {
+			return nil, err
+		}
+		res.UploadedParts = append(res.UploadedParts, s3.UploadedPart{
+			PartNumber:   partNumber,
+			LastModified: meta.LastModified,
+			ETag:         meta.ETag,
+			Size:         meta.Size,
+		})
+		res.NextPartNumberMarker = partNumber
+	}
+	return res, nil
+}
+
+func (l *Local) DeleteObject(ctx context.Context, name string) error {
+	name, err := cleanName(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(l.metaPath(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errs.Wrap(err)
+	}
+	if err := os.Remove(l.objectPath(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func (l *Local) CopyObject(ctx context.Context, src string, dst string) (*s3.CopyObjectInfo, error) {
+	file, meta, err := l.OpenObject(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	dst, err = cleanName(dst)
+	if err != nil {
+		return nil, err
+	}
+	copyMeta, err := l.writeFile(l.objectPath(dst), file)
+	if err != nil {
+		return nil, err
+	}
+	// keep the etag of multipart objects which is not the md5 of the content
+	copyMeta.ETag = meta.ETag
+	copyMeta.ContentType = meta.ContentType
+	if err := writeJSON(l.metaPath(dst), copyMeta); err != nil {
+		return nil, err
+	}
+	return &s3.CopyObjectInfo{
+		Key:  dst,
+		ETag: copyMeta.ETag,
+	}, nil
+}
+
+func (l *Local) StatObject(ctx context.Context, name string) (*s3.ObjectInfo, error) {
+	name, err := cleanName(name)
+	if err != nil {
+		return nil, err
+	}
+	var meta ObjectMeta
+	if err := readJSON(l.metaPath(name), &meta); err != nil {
+		return nil, err
+	}
+	return &s3.ObjectInfo{
+		ETag:         meta.ETag,
+		Key:          name,
+		Size:         meta.Size,
+		LastModified: meta.LastModified,
+	}, nil
+}
+
+func (l *Local) IsNotFound(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+func (l *Local) objectURL(name string) string {
+	return l.baseURL + Prefix + "/" + name
+}
+
+func (l *Local) AuthSign(ctx context.Context, uploadID string, name string, expire time.Duration, partNumbers []int) (*s3.AuthSignResult, error) {
+	if _, _, err := l.getUpload(uploadID, name); err != nil {
+		return nil, err
+	}
+	name, _ = cleanName(name)
+	expires := strconv.FormatInt(time.Now().Add(expire).Unix(), 10)
+	result := s3.AuthSignResult{
+		URL:   l.objectURL(name),
+		Query: url.Values{QueryUploadID: {uploadID}, QueryExpires: {expires}},
+		Parts: make([]s3.SignPart, len(partNumbers)),
+	}
+	for i, partNumber := range partNumbers {
+		query := url.Values{
+			QueryUploadID:   {uploadID},
+			QueryExpires:    {expires},
+			QueryPartNumber: {strconv.Itoa(partNumber)},
+		}
+		result.Parts[i] = s3.SignPart{
+			PartNumber: partNumber,
+			Query: url.Values{
+				QueryPartNumber: {strconv.Itoa(partNumber)},
+				QuerySignature:  {l.Sign(name, OpPut, query)},
+			},
+		}
+	}
+	return &result, nil
+}
+
+func (l *Local) PresignedPutObject(ctx context.Context, name string, expire time.Duration) (string, error) {
+	name, err := cleanName(name)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{QueryExpires: {strconv.FormatInt(time.Now().Add(expire).Unix(), 10)}}
+	query.Set(QuerySignature, l.Sign(name, OpPut, query))
+	return l.objectURL(name) + "?" + query.Encode(), nil
+}
+
+// AccessURL returns a signed download url, image thumbnails are not supported and the original object is returned.
+func (l *Local) AccessURL(ctx context.Context, name string, expire time.Duration, opt *s3.AccessURLOption) (string, error) {
+	name, err := cleanName(name)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{QueryExpires: {strconv.FormatInt(time.Now().Add(expire).Unix(), 10)}}
+	if opt != nil {
+		if opt.ContentType != "" {
+			query.Set(QueryContentType, opt.ContentType)
+		}
+		if opt.Filename != "" {
+			query.Set(QueryFilename, opt.Filename)
+		}
+	}
+	query.Set(QuerySignature, l.Sign(name, OpGet, query))
+	return l.objectURL(name) + "?" + query.Encode(), nil
+}
+
+// FormData returns the fields of a signed form upload, size limits the length of the file when it is greater than 0.
+func (l *Local) FormData(ctx context.Context, name string, size int64, contentType string, duration time.Duration) (*s3.FormData, error) {
+	name, err := cleanName(name)
+	if err != nil {
+		return nil, err
+	}
+	expires := time.Now().Add(duration)
+	form := url.Values{QueryExpires: {strconv.FormatInt(expires.Unix(), 10)}}
+	if size > 0 {
+		form.Set(QuerySize, strconv.FormatInt(size, 10))
+	}
+	if contentType != "" {
+		form.Set(QueryContentType, contentType)
+	}
+	form.Set(QuerySignature, l.Sign(name, OpForm, form))
+	formData := make(map[string]string, len(form))
+	for key := range form {
+		formData[key] = form.Get(key)
+	}
+	return &s3.FormData{
+		URL:  l.objectURL(name),
+		File: FormFile,
+		// the upload is a post request of openim-api which requires an operation id
+		Header:       http.Header{constant.OperationID: {mcontext.GetOperationID(ctx)}},
+		FormData:     formData,
+		Expires:      expires,
+		SuccessCodes: []int{http.StatusOK},
+	}, nil
+}
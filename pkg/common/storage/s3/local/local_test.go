@@ -0,0 +1,173 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/tools/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLocal(t *testing.T) *Local {
+	l, err := New(&config.Local{Dir: t.TempDir(), BaseURL: "http://127.0.0.1:10002/", Secret: "secret"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestObject(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+
+	_, err := l.StatObject(ctx, "a/b.txt")
+	assert.True(t, l.IsNotFound(err))
+
+	_, err = l.PutObject(ctx, "../a/b.txt", "text/plain", strings.NewReader("hello"))
+	assert.NoError(t, err)
+	info, err := l.StatObject(ctx, "a/b.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, md5Hex("hello"), info.ETag)
+	assert.EqualValues(t, 5, info.Size)
+
+	copyInfo, err := l.CopyObject(ctx, "a/b.txt", "c.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, info.ETag, copyInfo.ETag)
+	file, meta, err := l.OpenObject(ctx, "c.txt")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(file)
+	file.Close()
+	assert.Equal(t, "hello", string(data))
+	assert.Equal(t, "text/plain", meta.ContentType)
+
+	assert.NoError(t, l.DeleteObject(ctx, "a/b.txt"))
+	assert.NoError(t, l.DeleteObject(ctx, "a/b.txt"))
+	_, err = l.StatObject(ctx, "a/b.txt")
+	assert.True(t, l.IsNotFound(err))
+}
+
+func TestMultipartUpload(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+
+	upload, err := l.InitiateMultipartUpload(ctx, "hash/abc")
+	assert.NoError(t, err)
+	contents := []string{"part1", "part2", "part3"}
+	for i := len(contents) - 1; i >= 0; i-- {
+		_, err := l.UploadPart(ctx, upload.UploadID, "hash/abc", i+1, strings.NewReader(contents[i]))
+		assert.NoError(t, err)
+	}
+	_, err = l.UploadPart(ctx, upload.UploadID, "other", 1, strings.NewReader("x"))
+	assert.Error(t, err)
+
+	listed, err := l.ListUploadedParts(ctx, upload.UploadID, "hash/abc", 1, 0)
+	assert.NoError(t, err)
+	assert.Len(t, listed.UploadedParts, 2)
+	assert.Equal(t, 2, listed.UploadedParts[0].PartNumber)
+
+	parts := make([]s3.Part, len(contents))
+	etags := make([]string, len(contents))
+	for i, content := range contents {
+		etags[i] = md5Hex(content)
+		parts[i] = s3.Part{PartNumber: i + 1, ETag: etags[i]}
+	}
+	_, err = l.CompleteMultipartUpload(ctx, upload.UploadID, "hash/abc", []s3.Part{{PartNumber: 1, ETag: etags[1]}})
+	assert.Error(t, err)
+	result, err := l.CompleteMultipartUpload(ctx, upload.UploadID, "hash/abc", parts)
+	assert.NoError(t, err)
+	assert.Equal(t, md5Hex(strings.Join(etags, partSeparator)), result.ETag)
+
+	file, _, err := l.OpenObject(ctx, "hash/abc")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(file)
+	file.Close()
+	assert.Equal(t, strings.Join(contents, ""), string(data))
+
+	_, err = l.ListUploadedParts(ctx, upload.UploadID, "hash/abc", 0, 0)
+	assert.True(t, l.IsNotFound(err))
+}
+
+func TestSign(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+
+	rawURL, err := l.PresignedPutObject(ctx, "a.txt", time.Minute)
+	assert.NoError(t, err)
+	u, err := url.Parse(rawURL)
+	assert.NoError(t, err)
+	assert.Equal(t, Prefix+"/a.txt", u.Path)
+	assert.NoError(t, l.Verify("a.txt", OpPut, u.Query()))
+	assert.Error(t, l.Verify("b.txt", OpPut, u.Query()))
+
+	query := u.Query()
+	query.Set(QueryExpires, "1")
+	assert.Error(t, l.Verify("a.txt", OpPut, query))
+
+	rawURL, err = l.AccessURL(ctx, "a.txt", -time.Minute, &s3.AccessURLOption{Filename: "a.txt"})
+	assert.NoError(t, err)
+	u, _ = url.Parse(rawURL)
+	assert.Error(t, l.Verify("a.txt", OpGet, u.Query()))
+
+	// a download url does not allow an upload to the same object
+	rawURL, err = l.AccessURL(ctx, "a.txt", time.Minute, nil)
+	assert.NoError(t, err)
+	u, _ = url.Parse(rawURL)
+	assert.NoError(t, l.Verify("a.txt", OpGet, u.Query()))
+	assert.Error(t, l.Verify("a.txt", OpPut, u.Query()))
+	assert.Error(t, l.Verify("a.txt", OpForm, u.Query()))
+
+	upload, err := l.InitiateMultipartUpload(ctx, "b")
+	assert.NoError(t, err)
+	sign, err := l.AuthSign(ctx, upload.UploadID, "b", time.Minute, []int{1, 2})
+	assert.NoError(t, err)
+	for _, part := range sign.Parts {
+		query := url.Values{}
+		for key, values := range sign.Query {
+			query[key] = values
+		}
+		for key, values := range part.Query {
+			query[key] = values
+		}
+		assert.NoError(t, l.Verify("b", OpPut, query))
+		assert.Error(t, l.Verify("b", OpGet, query))
+	}
+}
+
+func TestDeriveSecret(t *testing.T) {
+	_, err := New(&config.Local{Dir: t.TempDir(), BaseURL: "http://127.0.0.1:10002"}, "")
+	assert.Error(t, err)
+
+	l, err := New(&config.Local{Dir: t.TempDir(), BaseURL: "http://127.0.0.1:10002"}, "openIM123")
+	assert.NoError(t, err)
+	assert.NotEqual(t, []byte("openIM123"), l.secret)
+	rawURL, err := l.PresignedPutObject(context.Background(), "a.txt", time.Minute)
+	assert.NoError(t, err)
+	u, _ := url.Parse(rawURL)
+	assert.NoError(t, l.Verify("a.txt", OpPut, u.Query()))
+}
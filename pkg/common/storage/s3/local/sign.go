@@ -0,0 +1,78 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/openimsdk/tools/errs"
+)
+
+// Operations a signed url grants, a url only serves the operation it is signed for.
+const (
+	OpGet  = "get"
+	OpPut  = "put"
+	OpForm = "form"
+)
+
+// deriveSecret returns the signing key derived from the secret of share.yml, the key differs from the secret itself
+// so that the urls do not expose anything usable to sign tokens.
+func deriveSecret(shareSecret string) []byte {
+	mac := hmac.New(sha256.New, []byte(shareSecret))
+	mac.Write([]byte("openim local object"))
+	return mac.Sum(nil)
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the object name, the operation and the sorted query, the signature
+// itself is excluded.
+func (l *Local) Sign(name string, op string, query url.Values) string {
+	values := make(url.Values, len(query))
+	for key, value := range query {
+		if key != QuerySignature {
+			values[key] = value
+		}
+	}
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(name))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(op))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(values.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature, the operation and the expiry of the query of a url returned by the engine.
+func (l *Local) Verify(name string, op string, query url.Values) error {
+	name, err := cleanName(name)
+	if err != nil {
+		return err
+	}
+	expires, err := strconv.ParseInt(query.Get(QueryExpires), 10, 64)
+	if err != nil {
+		return errs.ErrArgs.WrapMsg("invalid expires", "expires", query.Get(QueryExpires))
+	}
+	if !hmac.Equal([]byte(query.Get(QuerySignature)), []byte(l.Sign(name, op, query))) {
+		return errs.ErrNoPermission.WrapMsg("signature mismatching")
+	}
+	if time.Now().Unix() > expires {
+		return errs.ErrNoPermission.WrapMsg("url expired")
+	}
+	return nil
+}
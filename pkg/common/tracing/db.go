@@ -0,0 +1,102 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/tools/db/mongoutil"
+	"github.com/openimsdk/tools/db/redisutil"
+	"github.com/openimsdk/tools/db/tx"
+	"github.com/openimsdk/tools/errs"
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+)
+
+// MongoDB is the mongo database of a service, implemented by mongoutil.Client.
+type MongoDB interface {
+	GetDB() *mongo.Database
+	GetTx() tx.Tx
+}
+
+type mongoClient struct {
+	db *mongo.Database
+	tx tx.Tx
+}
+
+func (c *mongoClient) GetDB() *mongo.Database {
+	return c.db
+}
+
+func (c *mongoClient) GetTx() tx.Tx {
+	return c.tx
+}
+
+// NewMongoDB connects to mongo with mongoutil.NewMongoDB, the library has no option to set the command monitor,
+// so when the tracing is enabled the connection of mongoutil.NewMongoDB in openimsdk/tools v0.0.50-alpha.70 is
+// mirrored with a span for every command. Keep it in line with the library when the library is upgraded.
+func NewMongoDB(ctx context.Context, conf *mongoutil.Config, tracing *config.Tracing) (MongoDB, error) {
+	if !tracing.Enable {
+		return mongoutil.NewMongoDB(ctx, conf)
+	}
+	if err := conf.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	opts := options.Client().ApplyURI(conf.Uri).SetMaxPoolSize(uint64(conf.MaxPoolSize)).SetMonitor(otelmongo.NewMonitor())
+	var (
+		cli *mongo.Client
+		err error
+	)
+	for i := 0; i < conf.MaxRetry; i++ {
+		if cli, err = mongo.Connect(ctx, opts); err == nil {
+			if err = cli.Ping(ctx, nil); err == nil {
+				break
+			}
+		}
+		// the library does not retry a canceled context nor a failed authentication
+		if cmdErr, ok := err.(mongo.CommandError); ctx.Err() != nil || (ok && (cmdErr.Code == 13 || cmdErr.Code == 18)) {
+			break
+		}
+		time.Sleep(time.Second / 2)
+	}
+	if err != nil {
+		return nil, errs.WrapMsg(err, "failed to connect to MongoDB", "URI", conf.Uri)
+	}
+	mtx, err := mongoutil.NewMongoTx(ctx, cli)
+	if err != nil {
+		return nil, err
+	}
+	return &mongoClient{db: cli.Database(conf.Database), tx: mtx}, nil
+}
+
+// NewRedisClient connects to redis as redisutil.NewRedisClient, with a span for every command when the tracing is
+// enabled.
+func NewRedisClient(ctx context.Context, conf *redisutil.Config, tracing *config.Tracing) (redis.UniversalClient, error) {
+	rdb, err := redisutil.NewRedisClient(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	if tracing.Enable {
+		if err := redisotel.InstrumentTracing(rdb); err != nil {
+			return nil, errs.WrapMsg(err, "instrument redis tracing failed")
+		}
+	}
+	return rdb, nil
+}
@@ -0,0 +1,181 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing exports the spans of the services to an OTLP collector. The trace context goes along with the
+// rpc calls, the http requests and the mq messages, so that a message is followed from the api or the gateway to
+// the push.
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+const (
+	SamplerAlwaysOn                = "always_on"
+	SamplerAlwaysOff               = "always_off"
+	SamplerTraceIDRatio            = "traceidratio"
+	SamplerParentBasedTraceIDRatio = "parentbased_traceidratio"
+)
+
+const tracerName = "github.com/openimsdk/open-im-server/v3"
+
+// propagator carries the W3C trace context and baggage, it is used whether the tracing is enabled or not so that
+// the trace context of the callers is passed on.
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+func init() {
+	otel.SetTextMapPropagator(propagator)
+}
+
+// Init exports the spans of the service to the collector of conf, the returned function flushes the spans left
+// and must be called when the service stops. Nothing is exported when the tracing is disabled.
+func Init(ctx context.Context, serviceName string, conf *config.Tracing) (func(), error) {
+	if !conf.Enable {
+		return func() {}, nil
+	}
+	sampler, err := newSampler(conf)
+	if err != nil {
+		return nil, err
+	}
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(conf.Endpoint)}
+	if conf.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "create otlp trace exporter failed", "endpoint", conf.Endpoint)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.ZWarn(context.Background(), "opentelemetry error", err)
+	}))
+	log.ZInfo(ctx, "tracing enabled", "serviceName", serviceName, "endpoint", conf.Endpoint, "sampler", conf.Sampler, "sampleRatio", conf.SampleRatio)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			log.ZWarn(ctx, "shutdown tracing failed", err)
+		}
+	}, nil
+}
+
+// newSampler returns the sampler of conf, the names are the ones of OTEL_TRACES_SAMPLER.
+func newSampler(conf *config.Tracing) (sdktrace.Sampler, error) {
+	if conf.SampleRatio < 0 || conf.SampleRatio > 1 {
+		return nil, errs.ErrArgs.WrapMsg("tracing sampleRatio must be in [0, 1]", "sampleRatio", conf.SampleRatio)
+	}
+	switch conf.Sampler {
+	case SamplerAlwaysOn:
+		return sdktrace.AlwaysSample(), nil
+	case SamplerAlwaysOff:
+		return sdktrace.NeverSample(), nil
+	case SamplerTraceIDRatio:
+		return sdktrace.TraceIDRatioBased(conf.SampleRatio), nil
+	case "", SamplerParentBasedTraceIDRatio:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio)), nil
+	default:
+		return nil, errs.ErrArgs.WrapMsg("unknown tracing sampler", "sampler", conf.Sampler)
+	}
+}
+
+// Start starts a span of the service, the span is a child of the span of ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartConsumer starts the span of handling a message of the mq, ctx carries the trace context of the producer.
+func StartConsumer(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(attrs...))
+}
+
+// WithSpanContext returns ctx with the span of from as the parent of the spans started from it.
+func WithSpanContext(ctx context.Context, from context.Context) context.Context {
+	return trace.ContextWithSpanContext(ctx, trace.SpanContextFromContext(from))
+}
+
+// End records err on the span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// GrpcServerOption starts a span for every call handled by the server.
+func GrpcServerOption() grpc.ServerOption {
+	return grpc.StatsHandler(otelgrpc.NewServerHandler())
+}
+
+// GrpcDialOption starts a span for every call of the client and sends the trace context along.
+func GrpcDialOption() grpc.DialOption {
+	return grpc.WithStatsHandler(otelgrpc.NewClientHandler())
+}
+
+// GinMiddleware starts a span for every request of the router named by its route. The engine must have
+// ContextWithFallback set so that the handlers passing the gin context on find the span.
+func GinMiddleware(serviceName string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName)
+}
+
+// Inject returns the trace context of ctx to be carried by a message.
+func Inject(ctx context.Context) map[string]string {
+	carrier := make(propagation.MapCarrier)
+	propagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract returns ctx with the trace context carried by a message.
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// IsField reports whether key is a header of the trace context.
+func IsField(key string) bool {
+	for _, field := range propagator.Fields() {
+		if field == key {
+			return true
+		}
+	}
+	return false
+}
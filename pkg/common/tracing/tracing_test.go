@@ -0,0 +1,78 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewSampler(t *testing.T) {
+	tests := []struct {
+		conf config.Tracing
+		desc string
+		err  bool
+	}{
+		{conf: config.Tracing{Sampler: SamplerAlwaysOn}, desc: "AlwaysOnSampler"},
+		{conf: config.Tracing{Sampler: SamplerAlwaysOff}, desc: "AlwaysOffSampler"},
+		{conf: config.Tracing{Sampler: SamplerTraceIDRatio, SampleRatio: 0.5}, desc: "TraceIDRatioBased{0.5}"},
+		{conf: config.Tracing{SampleRatio: 0.25}, desc: "ParentBased{root:TraceIDRatioBased{0.25}"},
+		{conf: config.Tracing{Sampler: SamplerParentBasedTraceIDRatio, SampleRatio: 1.5}, err: true},
+		{conf: config.Tracing{Sampler: "jaeger_remote"}, err: true},
+	}
+	for _, test := range tests {
+		sampler, err := newSampler(&test.conf)
+		if test.err {
+			if err == nil {
+				t.Errorf("sampler %q ratio %v should fail", test.conf.Sampler, test.conf.SampleRatio)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("sampler %q: %v", test.conf.Sampler, err)
+		}
+		if desc := sampler.Description(); !strings.HasPrefix(desc, test.desc) {
+			t.Errorf("sampler %q got %q want %q", test.conf.Sampler, desc, test.desc)
+		}
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	if carrier := Inject(context.Background()); carrier != nil {
+		t.Fatalf("inject without span got %v", carrier)
+	}
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	carrier := Inject(trace.ContextWithSpanContext(context.Background(), spanContext))
+	for key := range carrier {
+		if !IsField(key) {
+			t.Errorf("injected key %q is not a field", key)
+		}
+	}
+	extracted := trace.SpanContextFromContext(Extract(context.Background(), carrier))
+	if !extracted.IsRemote() || extracted.TraceID() != spanContext.TraceID() || extracted.SpanID() != spanContext.SpanID() || !extracted.IsSampled() {
+		t.Errorf("extracted %v want %v", extracted, spanContext)
+	}
+	if IsField("operationID") {
+		t.Error("operationID is not a field of the trace context")
+	}
+}
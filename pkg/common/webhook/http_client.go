@@ -15,31 +15,51 @@
 package webhook
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/openimsdk/open-im-server/v3/pkg/callbackstruct"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
 	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/tools/errs"
 	"github.com/openimsdk/tools/log"
 	"github.com/openimsdk/tools/mcontext"
 	"github.com/openimsdk/tools/mq/memamq"
-	"github.com/openimsdk/tools/utils/httputil"
-	"net/http"
+	"github.com/openimsdk/tools/utils/datautil"
+	"github.com/redis/go-redis/v9"
 )
 
 type Client struct {
-	client *httputil.HTTPClient
+	client *http.Client
 	url    string
+	secret string
+	retry  config.WebhookRetry
 	queue  *memamq.MemoryQueue
+	stream *streamQueue
+
+	afterSubscribers []config.AfterSubscriber
 }
 
+// AfterFilter reports whether an after callback is posted to the target.
+type AfterFilter func(target *config.AfterSubscriber) bool
+
 const (
 	webhookWorkerCount = 2
 	webhookBufferSize  = 100
 )
 
-func NewWebhookClient(url string, options ...*memamq.MemoryQueue) *Client {
+// NewWebhookClient creates a client for the callbacks of conf, after callbacks are persisted in a redis stream
+// when conf.Queue is enabled, rdb is only used in that case.
+func NewWebhookClient(conf *config.Webhooks, rdb redis.UniversalClient, options ...*memamq.MemoryQueue) *Client {
 	var queue *memamq.MemoryQueue
 	if len(options) > 0 && options[0] != nil {
 		queue = options[0]
@@ -47,40 +67,216 @@ func NewWebhookClient(url string, options ...*memamq.MemoryQueue) *Client {
 		queue = memamq.NewMemoryQueue(webhookWorkerCount, webhookBufferSize)
 	}
 
-	http.DefaultTransport.(*http.Transport).MaxConnsPerHost = 100 // Enhance the default number of max connections per host
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxConnsPerHost = 100 // Enhance the default number of max connections per host
 
-	return &Client{
-		client: httputil.NewHTTPClient(httputil.NewClientConfig()),
-		url:    url,
+	c := &Client{
+		client: &http.Client{
+			Timeout:   15 * time.Second,
+			Transport: transport,
+		},
+		url:    conf.URL,
+		secret: conf.Secret,
+		retry:  conf.Retry,
 		queue:  queue,
+
+		afterSubscribers: conf.AfterSubscribers,
+	}
+	if conf.Queue.Enable && rdb != nil {
+		c.stream = newStreamQueue(rdb, &conf.Queue, c.deliverStream)
+		go c.stream.run(webhookWorkerCount)
 	}
+	return c
 }
 
 func (c *Client) SyncPost(ctx context.Context, command string, req callbackstruct.CallbackReq, resp callbackstruct.CallbackResp, before *config.BeforeConfig) error {
-	return c.post(ctx, command, req, resp, before.Timeout)
+	return c.post(ctx, c.getURL(before.URL, command), req, resp, before.Timeout)
 }
 
+// AsyncPost posts the after callback to the url of the event when it is enabled, and to all subscribers of the event.
 func (c *Client) AsyncPost(ctx context.Context, command string, req callbackstruct.CallbackReq, resp callbackstruct.CallbackResp, after *config.AfterConfig) {
+	c.AsyncPostFilter(ctx, command, req, resp, after, nil)
+}
+
+// AsyncPostFilter is AsyncPost that only posts to the targets accepted by filter, a nil filter accepts all targets.
+func (c *Client) AsyncPostFilter(ctx context.Context, command string, req callbackstruct.CallbackReq, resp callbackstruct.CallbackResp, after *config.AfterConfig, filter AfterFilter) {
+	targets := c.getAfterTargets(after)
+	if filter != nil {
+		targets = datautil.Filter(targets, func(target *config.AfterSubscriber) (*config.AfterSubscriber, bool) {
+			return target, filter(target)
+		})
+	}
+	if len(targets) == 0 {
+		return
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		log.ZError(ctx, "webhook marshal failed", err, "command", command)
+		return
+	}
+	for i, target := range targets {
+		task := &webhookTask{
+			URL:            c.getURL(target.URL, command),
+			Body:           body,
+			Timeout:        target.Timeout,
+			OperationID:    mcontext.GetOperationID(ctx),
+			OpUserID:       mcontext.GetOpUserID(ctx),
+			OpUserPlatform: mcontext.GetOpUserPlatform(ctx),
+			ConnID:         mcontext.GetConnID(ctx),
+		}
+		output := resp
+		if i > 0 || output == nil {
+			// the deliveries run concurrently, only one of them may write into resp
+			output = &callbackstruct.CommonCallbackResp{}
+		}
+		c.pushTask(ctx, task, output)
+	}
+}
+
+// getAfterTargets returns the url of the event when it is enabled followed by the subscribers of the event and of all events.
+func (c *Client) getAfterTargets(after *config.AfterConfig) []*config.AfterSubscriber {
+	targets := make([]*config.AfterSubscriber, 0, 1+len(after.Subscribers)+len(c.afterSubscribers))
 	if after.Enable {
-		c.queue.Push(func() { c.post(ctx, command, req, resp, after.Timeout) })
+		targets = append(targets, &config.AfterSubscriber{
+			URL:          after.URL,
+			Timeout:      after.Timeout,
+			AttentionIds: after.AttentionIds,
+			AllowedTypes: after.AllowedTypes,
+			DeniedTypes:  after.DeniedTypes,
+		})
+	}
+	for i := range after.Subscribers {
+		targets = append(targets, &after.Subscribers[i])
+	}
+	for i := range c.afterSubscribers {
+		targets = append(targets, &c.afterSubscribers[i])
+	}
+	return targets
+}
+
+// getURL returns the url of the command, url overrides the global url when it is not empty.
+func (c *Client) getURL(url string, command string) string {
+	if url == "" {
+		url = c.url
+	}
+	return strings.TrimSuffix(url, "/") + "/" + command
+}
+
+func (c *Client) pushTask(ctx context.Context, task *webhookTask, output callbackstruct.CallbackResp) {
+	if c.stream != nil {
+		err := c.stream.push(ctx, task)
+		if err == nil {
+			return
+		}
+		log.ZError(ctx, "webhook push to stream failed, fall back to memory queue", err, "url", task.URL)
+	}
+	c.enqueue(ctx, task, output)
+}
+
+func (c *Client) enqueue(ctx context.Context, task *webhookTask, output callbackstruct.CallbackResp) {
+	if err := c.queue.Push(func() { c.deliverMemory(task, output) }); err != nil {
+		log.ZError(ctx, "webhook queue push failed, callback dropped", err, "url", task.URL, "body", string(task.Body))
 	}
 }
 
-func (c *Client) post(ctx context.Context, command string, input interface{}, output callbackstruct.CallbackResp, timeout int) error {
+// deliverMemory delivers a task of the memory queue, a retry is pushed back to the queue once its backoff has
+// passed so that the workers never wait on a failing target.
+func (c *Client) deliverMemory(task *webhookTask, output callbackstruct.CallbackResp) {
+	interval, retry := c.deliver(task, output)
+	if !retry {
+		return
+	}
+	task.Attempt++
+	time.AfterFunc(interval, func() {
+		c.enqueue(mcontext.SetOperationID(context.Background(), task.OperationID), task, output)
+	})
+}
+
+func (c *Client) post(ctx context.Context, fullURL string, input interface{}, output callbackstruct.CallbackResp, timeout int) error {
 	ctx = mcontext.WithMustInfoCtx([]string{mcontext.GetOperationID(ctx), mcontext.GetOpUserID(ctx), mcontext.GetOpUserPlatform(ctx), mcontext.GetConnID(ctx)})
-	fullURL := c.url + "/" + command
-	log.ZInfo(ctx, "webhook", "url", fullURL, "input", input, "config", timeout)
+	body, err := json.Marshal(input)
+	if err != nil {
+		return errs.WrapMsg(err, "webhook marshal failed", "url", fullURL)
+	}
+	return c.postBody(ctx, fullURL, body, output, timeout)
+}
+
+// postBody posts the signed body and parses the response into output.
+func (c *Client) postBody(ctx context.Context, fullURL string, body []byte, output callbackstruct.CallbackResp, timeout int) error {
+	log.ZInfo(ctx, "webhook", "url", fullURL, "input", string(body), "config", timeout)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Second*time.Duration(timeout))
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fullURL, bytes.NewReader(body))
+	if err != nil {
+		return servererrs.ErrNetwork.WrapMsg(err.Error(), "post url", fullURL)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 	operationID, _ := ctx.Value(constant.OperationID).(string)
-	b, err := c.client.Post(ctx, fullURL, map[string]string{constant.OperationID: operationID}, input, timeout)
+	req.Header.Set(constant.OperationID, operationID)
+	if c.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, Sign(c.secret, timestamp, body))
+	}
+	resp, err := c.client.Do(req)
 	if err != nil {
 		return servererrs.ErrNetwork.WrapMsg(err.Error(), "post url", fullURL)
 	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return servererrs.ErrNetwork.WrapMsg(err.Error(), "post url", fullURL)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return servererrs.ErrNetwork.WrapMsg(fmt.Sprintf("unexpected status code %d", resp.StatusCode), "post url", fullURL, "response", string(b))
+	}
 	if err = json.Unmarshal(b, output); err != nil {
 		return servererrs.ErrData.WithDetail(err.Error() + " response format error")
 	}
 	if err := output.Parse(); err != nil {
 		return err
 	}
-	log.ZInfo(ctx, "webhook success", "url", fullURL, "input", input, "response", string(b))
+	log.ZInfo(ctx, "webhook success", "url", fullURL, "input", string(body), "response", string(b))
 	return nil
 }
+
+// deliver posts the task once. Network failures and unexpected status codes are retried with exponential backoff
+// until the retries run out, it returns the interval before the next attempt in that case. Errors returned by the
+// callback server itself are not retried.
+func (c *Client) deliver(task *webhookTask, output callbackstruct.CallbackResp) (time.Duration, bool) {
+	ctx := mcontext.WithMustInfoCtx([]string{task.OperationID, task.OpUserID, task.OpUserPlatform, task.ConnID})
+	err := c.postBody(ctx, task.URL, task.Body, output, task.Timeout)
+	if err == nil {
+		return 0, false
+	}
+	if isRetryable(err) && task.Attempt < c.retry.MaxTimes {
+		interval := backoff(c.retry, task.Attempt)
+		log.ZWarn(ctx, "webhook failed, retry later", err, "url", task.URL, "retry", task.Attempt+1, "interval", interval)
+		return interval, true
+	}
+	log.ZError(ctx, "webhook failed", err, "url", task.URL, "body", string(task.Body), "attempt", task.Attempt)
+	return 0, false
+}
+
+// deliverStream is called by the stream queue, the response of after callbacks is only checked for errors.
+func (c *Client) deliverStream(task *webhookTask) (time.Duration, bool) {
+	return c.deliver(task, &callbackstruct.CommonCallbackResp{})
+}
+
+func isRetryable(err error) bool {
+	var codeErr errs.CodeError
+	return errors.As(err, &codeErr) && codeErr.Code() == servererrs.NetworkError
+}
+
+// backoff returns the interval before the retry after the given number of failed retries.
+func backoff(retry config.WebhookRetry, retried int) time.Duration {
+	interval := time.Duration(max(retry.InitialInterval, 1)) * time.Second
+	maxInterval := time.Duration(max(retry.MaxInterval, retry.InitialInterval, 1)) * time.Second
+	for i := 0; i < retried && interval < maxInterval; i++ {
+		interval *= 2
+	}
+	return min(interval, maxInterval)
+}
@@ -13,3 +13,184 @@
 // limitations under the License.
 
 package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/callbackstruct"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"callbackCommand":"callbackAfterSendSingleMsgCommand"}`)
+	signature := Sign("secret", "1700000000", body)
+	assert.True(t, Verify("secret", "1700000000", body, signature))
+	assert.False(t, Verify("secret", "1700000001", body, signature))
+	assert.False(t, Verify("other", "1700000000", body, signature))
+}
+
+func TestBackoff(t *testing.T) {
+	retry := config.WebhookRetry{MaxTimes: 5, InitialInterval: 1, MaxInterval: 5}
+	assert.Equal(t, time.Second, backoff(retry, 0))
+	assert.Equal(t, 2*time.Second, backoff(retry, 1))
+	assert.Equal(t, 4*time.Second, backoff(retry, 2))
+	assert.Equal(t, 5*time.Second, backoff(retry, 3))
+	assert.Equal(t, 5*time.Second, backoff(retry, 10))
+}
+
+func TestDeliverRetry(t *testing.T) {
+	var calls, rejected atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify("secret", r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)) {
+			rejected.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"actionCode":0,"errCode":0}`))
+	}))
+	defer srv.Close()
+
+	conf := &config.Webhooks{
+		URL:    srv.URL,
+		Secret: "secret",
+		Retry:  config.WebhookRetry{MaxTimes: 2, InitialInterval: 1, MaxInterval: 1},
+	}
+	c := NewWebhookClient(conf, nil)
+	task := &webhookTask{URL: srv.URL + "/callbackAfterSendSingleMsgCommand", Body: []byte(`{}`), Timeout: 5}
+	interval, retry := c.deliver(task, &callbackstruct.CommonCallbackResp{})
+	assert.True(t, retry)
+	assert.Equal(t, time.Second, interval)
+	_, retry = c.deliver(task, &callbackstruct.CommonCallbackResp{})
+	assert.False(t, retry)
+	assert.Equal(t, int32(2), calls.Load())
+
+	conf.Secret = "wrong"
+	c = NewWebhookClient(conf, nil)
+	err := c.post(context.Background(), srv.URL+"/callbackBeforeSendSingleMsgCommand", struct{}{}, &callbackstruct.CommonCallbackResp{}, 5)
+	assert.Error(t, err)
+	// the request reached the server and was refused for its signature
+	assert.Equal(t, int32(1), rejected.Load())
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestDeliverRetryExhausted(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	c := NewWebhookClient(&config.Webhooks{URL: srv.URL, Retry: config.WebhookRetry{MaxTimes: 2, InitialInterval: 1}}, nil)
+	task := &webhookTask{URL: srv.URL + "/callbackAfterSendSingleMsgCommand", Body: []byte(`{}`), Timeout: 5, Attempt: 2}
+	_, retry := c.deliver(task, &callbackstruct.CommonCallbackResp{})
+	assert.False(t, retry)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// TestMemoryRetryFreesWorkers checks that a target waiting for its retry does not hold the workers of the memory queue.
+func TestMemoryRetryFreesWorkers(t *testing.T) {
+	var failing, healthy atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/failing/callbackAfterSendSingleMsgCommand" {
+			failing.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		healthy.Add(1)
+		_, _ = w.Write([]byte(`{"actionCode":0,"errCode":0}`))
+	}))
+	defer srv.Close()
+
+	c := NewWebhookClient(&config.Webhooks{URL: srv.URL, Retry: config.WebhookRetry{MaxTimes: 1, InitialInterval: 1}}, nil)
+	ctx := context.Background()
+	for i := 0; i < webhookWorkerCount; i++ {
+		c.pushTask(ctx, &webhookTask{URL: srv.URL + "/failing/callbackAfterSendSingleMsgCommand", Body: []byte(`{}`), Timeout: 5}, &callbackstruct.CommonCallbackResp{})
+	}
+	c.pushTask(ctx, &webhookTask{URL: srv.URL + "/callbackAfterSendSingleMsgCommand", Body: []byte(`{}`), Timeout: 5}, &callbackstruct.CommonCallbackResp{})
+	// the healthy target is delivered well before the backoff of the failing ones
+	assert.Eventually(t, func() bool { return healthy.Load() == 1 }, 500*time.Millisecond, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return failing.Load() == int32(webhookWorkerCount*2) }, 3*time.Second, 50*time.Millisecond)
+}
+
+func TestGetURL(t *testing.T) {
+	c := NewWebhookClient(&config.Webhooks{URL: "http://127.0.0.1:10006/callbackExample"}, nil)
+	assert.Equal(t, "http://127.0.0.1:10006/callbackExample/callbackBeforeSendSingleMsgCommand", c.getURL("", "callbackBeforeSendSingleMsgCommand"))
+	// the url of the event overrides the global one, a trailing slash is dropped
+	assert.Equal(t, "http://127.0.0.1:10008/msg/callbackBeforeSendSingleMsgCommand", c.getURL("http://127.0.0.1:10008/msg/", "callbackBeforeSendSingleMsgCommand"))
+}
+
+func TestGetAfterTargets(t *testing.T) {
+	c := NewWebhookClient(&config.Webhooks{
+		URL:              "http://127.0.0.1:10006/callbackExample",
+		AfterSubscribers: []config.AfterSubscriber{{URL: "http://127.0.0.1:10007/analytics", Timeout: 3}},
+	}, nil)
+
+	after := &config.AfterConfig{
+		Timeout:     5,
+		Subscribers: []config.AfterSubscriber{{URL: "http://127.0.0.1:10009/audit", AllowedTypes: []string{"101"}}},
+	}
+	// the event itself is disabled, only its subscribers and the global ones are posted
+	targets := c.getAfterTargets(after)
+	assert.Len(t, targets, 2)
+	assert.Equal(t, "http://127.0.0.1:10009/audit", targets[0].URL)
+	assert.Equal(t, []string{"101"}, targets[0].AllowedTypes)
+	assert.Equal(t, "http://127.0.0.1:10007/analytics", targets[1].URL)
+
+	after.Enable = true
+	after.AttentionIds = []string{"u1"}
+	targets = c.getAfterTargets(after)
+	assert.Len(t, targets, 3)
+	assert.Equal(t, "", targets[0].URL)
+	assert.Equal(t, 5, targets[0].Timeout)
+	assert.Equal(t, []string{"u1"}, targets[0].AttentionIds)
+	assert.Equal(t, "http://127.0.0.1:10006/callbackExample/callbackAfterSendSingleMsgCommand", c.getURL(targets[0].URL, "callbackAfterSendSingleMsgCommand"))
+}
+
+func TestAsyncPostFilter(t *testing.T) {
+	var mu sync.Mutex
+	paths := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths[r.URL.Path]++
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"actionCode":0,"errCode":0}`))
+	}))
+	defer srv.Close()
+
+	c := NewWebhookClient(&config.Webhooks{
+		URL:              srv.URL + "/global",
+		AfterSubscribers: []config.AfterSubscriber{{URL: srv.URL + "/analytics", DeniedTypes: []string{"101"}}},
+	}, nil)
+	after := &config.AfterConfig{
+		Enable:      true,
+		Timeout:     5,
+		Subscribers: []config.AfterSubscriber{{URL: srv.URL + "/audit", Timeout: 5}},
+	}
+	// the filter drops the global subscriber as it denies the type of the message
+	filter := func(target *config.AfterSubscriber) bool { return len(target.DeniedTypes) == 0 }
+	c.AsyncPostFilter(context.Background(), "callbackAfterSendSingleMsgCommand", &callbackstruct.CallbackAfterSendSingleMsgReq{}, &callbackstruct.CallbackAfterSendSingleMsgResp{}, after, filter)
+
+	get := func(path string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return paths[path]
+	}
+	assert.Eventually(t, func() bool {
+		return get("/global/callbackAfterSendSingleMsgCommand") == 1 && get("/audit/callbackAfterSendSingleMsgCommand") == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, get("/analytics/callbackAfterSendSingleMsgCommand"))
+}
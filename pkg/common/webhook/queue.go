@@ -0,0 +1,257 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultStreamName   = "openim_webhook"
+	defaultStreamMaxLen = 100000
+	streamGroup         = "webhook"
+	streamTaskField     = "task"
+	streamReadCount     = 10
+	streamReadBlock     = 5 * time.Second
+	// streamClaimIdle is the time after which a task not acknowledged by a crashed consumer is taken over, it must
+	// be longer than a consumer takes to deliver a read batch once. The retries wait in the retry set, they do not
+	// keep the task pending.
+	streamClaimIdle = 10 * time.Minute
+	// streamRetryPoll is how often the due retries are moved back to the stream.
+	streamRetryPoll  = time.Second
+	streamRetryBatch = 100
+)
+
+// moveDueRetries moves the retries whose time has come from the retry set back to the stream in one step.
+var moveDueRetries = redis.NewScript(`
+local due = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+for _, task in ipairs(due) do
+	redis.call("ZREM", KEYS[1], task)
+	redis.call("XADD", KEYS[2], "MAXLEN", "~", ARGV[3], "*", ARGV[4], task)
+end
+return #due
+`)
+
+// webhookTask is an after callback waiting for delivery, it holds everything needed to deliver it in another process.
+type webhookTask struct {
+	URL            string          `json:"url"`
+	Body           json.RawMessage `json:"body"`
+	Timeout        int             `json:"timeout"`
+	OperationID    string          `json:"operationID"`
+	OpUserID       string          `json:"opUserID"`
+	OpUserPlatform string          `json:"opUserPlatform"`
+	ConnID         string          `json:"connID"`
+	// Attempt is the number of failed deliveries of the task.
+	Attempt int `json:"attempt,omitempty"`
+	// RetryOf is the stream id of the last failed delivery, it keeps apart the equal tasks waiting in the retry set.
+	RetryOf string `json:"retryOf,omitempty"`
+}
+
+// streamQueue persists webhook tasks in a redis stream, a task is acknowledged after it is delivered or finally failed.
+// A task to retry is acknowledged and parked in a sorted set scored by its due time until it is moved back to the
+// stream.
+type streamQueue struct {
+	rdb      redis.UniversalClient
+	stream   string
+	retry    string
+	maxLen   int64
+	consumer string
+	// deliver attempts a task once and returns the interval before the next attempt when it is to be retried.
+	deliver func(task *webhookTask) (time.Duration, bool)
+}
+
+func newStreamQueue(rdb redis.UniversalClient, conf *config.WebhookQueue, deliver func(task *webhookTask) (time.Duration, bool)) *streamQueue {
+	stream := conf.Stream
+	if stream == "" {
+		stream = defaultStreamName
+	}
+	maxLen := conf.MaxLen
+	if maxLen <= 0 {
+		maxLen = defaultStreamMaxLen
+	}
+	hostname, _ := os.Hostname()
+	return &streamQueue{
+		rdb:    rdb,
+		stream: stream,
+		// the hash tag keeps the retry set in the slot of the stream for the cluster mode
+		retry:    "{" + stream + "}:retry",
+		maxLen:   maxLen,
+		consumer: fmt.Sprintf("%s_%d_%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		deliver:  deliver,
+	}
+}
+
+func (q *streamQueue) push(ctx context.Context, task *webhookTask) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	return errs.Wrap(q.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: q.stream,
+		MaxLen: q.maxLen,
+		Approx: true,
+		Values: map[string]any{streamTaskField: data},
+	}).Err())
+}
+
+func (q *streamQueue) createGroup(ctx context.Context) error {
+	err := q.rdb.XGroupCreateMkStream(ctx, q.stream, streamGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func (q *streamQueue) run(workerCount int) {
+	ctx := context.Background()
+	for {
+		err := q.createGroup(ctx)
+		if err == nil {
+			break
+		}
+		log.ZError(ctx, "webhook stream create group failed", err, "stream", q.stream)
+		time.Sleep(streamReadBlock)
+	}
+	for i := 0; i < workerCount; i++ {
+		go q.work(ctx)
+	}
+	go q.pollRetries(ctx)
+}
+
+// pollRetries moves the due retries back to the stream.
+func (q *streamQueue) pollRetries(ctx context.Context) {
+	ticker := time.NewTicker(streamRetryPoll)
+	defer ticker.Stop()
+	for range ticker.C {
+		for {
+			n, err := moveDueRetries.Run(ctx, q.rdb, []string{q.retry, q.stream},
+				time.Now().UnixMilli(), streamRetryBatch, q.maxLen, streamTaskField).Int()
+			if err != nil {
+				log.ZError(ctx, "webhook stream move retries failed", err, "stream", q.stream)
+				break
+			}
+			if n < streamRetryBatch {
+				break
+			}
+		}
+	}
+}
+
+func (q *streamQueue) work(ctx context.Context) {
+	for {
+		messages, err := q.claim(ctx)
+		if err == nil && len(messages) == 0 {
+			messages, err = q.read(ctx)
+		}
+		if err != nil {
+			log.ZError(ctx, "webhook stream read failed", err, "stream", q.stream)
+			time.Sleep(streamReadBlock)
+			continue
+		}
+		for _, message := range messages {
+			q.handle(ctx, message)
+		}
+	}
+}
+
+// claim takes over the tasks left pending by consumers that stopped before acknowledging them.
+func (q *streamQueue) claim(ctx context.Context) ([]redis.XMessage, error) {
+	messages, _, err := q.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   q.stream,
+		Group:    streamGroup,
+		Consumer: q.consumer,
+		MinIdle:  streamClaimIdle,
+		Start:    "0-0",
+		Count:    streamReadCount,
+	}).Result()
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return messages, nil
+}
+
+func (q *streamQueue) read(ctx context.Context) ([]redis.XMessage, error) {
+	streams, err := q.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    streamGroup,
+		Consumer: q.consumer,
+		Streams:  []string{q.stream, ">"},
+		Count:    streamReadCount,
+		Block:    streamReadBlock,
+	}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	var messages []redis.XMessage
+	for _, stream := range streams {
+		messages = append(messages, stream.Messages...)
+	}
+	return messages, nil
+}
+
+func (q *streamQueue) handle(ctx context.Context, message redis.XMessage) {
+	var task webhookTask
+	data, _ := message.Values[streamTaskField].(string)
+	if err := json.Unmarshal([]byte(data), &task); err != nil {
+		log.ZError(ctx, "webhook stream invalid task", err, "id", message.ID, "values", message.Values)
+	} else if interval, retry := q.deliver(&task); retry {
+		task.Attempt++
+		task.RetryOf = message.ID
+		if err := q.retryLater(ctx, message.ID, &task, interval); err != nil {
+			log.ZError(ctx, "webhook stream retry failed", err, "id", message.ID)
+		}
+		return
+	}
+	// a task that still fails after all retries is dropped, the error has been logged
+	if err := q.ack(ctx, message.ID); err != nil {
+		log.ZError(ctx, "webhook stream ack failed", err, "id", message.ID)
+	}
+}
+
+// retryLater parks the task in the retry set until interval has passed and acknowledges the delivered message.
+func (q *streamQueue) retryLater(ctx context.Context, id string, task *webhookTask, interval time.Duration) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	_, err = q.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, q.retry, redis.Z{Score: float64(time.Now().Add(interval).UnixMilli()), Member: data})
+		pipe.XAck(ctx, q.stream, streamGroup, id)
+		pipe.XDel(ctx, q.stream, id)
+		return nil
+	})
+	return errs.Wrap(err)
+}
+
+func (q *streamQueue) ack(ctx context.Context, id string) error {
+	pipe := q.rdb.Pipeline()
+	pipe.XAck(ctx, q.stream, streamGroup, id)
+	pipe.XDel(ctx, q.stream, id)
+	_, err := pipe.Exec(ctx)
+	return errs.Wrap(err)
+}
@@ -0,0 +1,147 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mq
+
+import (
+	"context"
+
+	"github.com/IBM/sarama"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mq/kafka"
+	"google.golang.org/protobuf/proto"
+)
+
+// kafkaHeaderKeys are the keys of the headers of the context infos, in the order of mcontext.WithMustInfoCtx.
+var kafkaHeaderKeys = []string{constant.OperationID, constant.OpUserID, constant.OpUserPlatform, constant.ConnID}
+
+type kafkaBuilder struct {
+	conf         *config.Kafka
+	producerConf *sarama.Config
+}
+
+func newKafkaBuilder(conf *config.Kafka) (*kafkaBuilder, error) {
+	producerConf, err := kafka.BuildProducerConfig(*conf.Build())
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaBuilder{conf: conf, producerConf: producerConf}, nil
+}
+
+func (b *kafkaBuilder) GetTopicProducer(_ context.Context, topic string) (Producer, error) {
+	producer, err := kafka.NewProducer(b.producerConf, b.conf.Address)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaProducer{producer: producer, topic: topic}, nil
+}
+
+// GetTopicConsumer returns a consumer committing the acked offsets periodically.
+func (b *kafkaBuilder) GetTopicConsumer(_ context.Context, topic string, groupID string) (Consumer, error) {
+	group, err := kafka.NewMConsumerGroup(b.conf.Build(), groupID, []string{topic}, true)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaConsumer{group: group}, nil
+}
+
+type kafkaProducer struct {
+	producer sarama.SyncProducer
+	topic    string
+}
+
+// SendMessage sends msg with the context infos and the trace context as headers.
+func (p *kafkaProducer) SendMessage(ctx context.Context, key string, msg proto.Message) error {
+	value, header, trace, err := marshal(ctx, msg)
+	if err != nil {
+		return err
+	}
+	if key == "" || len(value) == 0 {
+		return errs.ErrArgs.WrapMsg("kafka message key or value is empty", "key", key)
+	}
+	headers := make([]sarama.RecordHeader, 0, len(header)+len(trace))
+	for i, info := range header {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(kafkaHeaderKeys[i]), Value: []byte(info)})
+	}
+	for k, v := range trace {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+	_, _, err = p.producer.SendMessage(&sarama.ProducerMessage{
+		Topic:   p.topic,
+		Key:     sarama.StringEncoder(key),
+		Value:   sarama.ByteEncoder(value),
+		Headers: headers,
+	})
+	if err != nil {
+		return errs.WrapMsg(err, "kafka send message failed", "topic", p.topic, "key", key)
+	}
+	return nil
+}
+
+type kafkaConsumer struct {
+	group *kafka.MConsumerGroup
+}
+
+func (c *kafkaConsumer) Subscribe(ctx context.Context, handle Handler) error {
+	c.group.RegisterHandleAndConsumer(ctx, kafkaHandler(handle))
+	return nil
+}
+
+func (c *kafkaConsumer) Close() error {
+	return c.group.Close()
+}
+
+// kafkaHandler handles the messages of a claimed partition one after another.
+type kafkaHandler Handler
+
+func (kafkaHandler) Setup(sarama.ConsumerGroupSession) error { return nil }
+
+func (kafkaHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (h kafkaHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		msg := msg
+		h(&Message{
+			ctx:   kafkaHeaderContext(msg.Headers),
+			key:   string(msg.Key),
+			value: msg.Value,
+			ack:   func() { session.MarkMessage(msg, "") },
+		})
+	}
+	return nil
+}
+
+// kafkaHeaderContext returns the context of the headers of a message, the headers of the trace context are missing
+// from the messages sent before it was carried.
+func kafkaHeaderContext(headers []*sarama.RecordHeader) context.Context {
+	var (
+		header []string
+		trace  map[string]string
+	)
+	for _, h := range headers {
+		key := string(h.Key)
+		if tracing.IsField(key) {
+			if trace == nil {
+				trace = make(map[string]string)
+			}
+			trace[key] = string(h.Value)
+			continue
+		}
+		header = append(header, string(h.Value))
+	}
+	return headerContext(header, trace)
+}
@@ -0,0 +1,164 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mq
+
+import (
+	"context"
+	"sync"
+
+	"github.com/openimsdk/tools/errs"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	memoryPartitions = 16
+	memoryBufferSize = 1024
+)
+
+// defaultMemoryBroker carries the topics of the memory backend of the process. It only connects the producers and
+// the consumers running in the same process, so the backend only works when all the components run in one process.
+var defaultMemoryBroker = newMemoryBroker()
+
+// memoryBroker is the in-process backend. A message is delivered to every group of the topic when it is sent, and
+// sending to a topic without a group fails rather than dropping the message. The messages are lost with the
+// process and Ack does nothing.
+type memoryBroker struct {
+	lock   sync.Mutex
+	topics map[string]*memoryTopic
+}
+
+func newMemoryBroker() *memoryBroker {
+	return &memoryBroker{topics: make(map[string]*memoryTopic)}
+}
+
+func (b *memoryBroker) topic(name string) *memoryTopic {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	topic, ok := b.topics[name]
+	if !ok {
+		topic = &memoryTopic{name: name, groups: make(map[string]*memoryGroup)}
+		b.topics[name] = topic
+	}
+	return topic
+}
+
+func (b *memoryBroker) GetTopicProducer(_ context.Context, topic string) (Producer, error) {
+	return b.topic(topic), nil
+}
+
+func (b *memoryBroker) GetTopicConsumer(_ context.Context, topic string, groupID string) (Consumer, error) {
+	return &memoryConsumer{group: b.topic(topic).group(groupID), closed: make(chan struct{})}, nil
+}
+
+type memoryMessage struct {
+	key    string
+	value  []byte
+	header []string
+	trace  map[string]string
+}
+
+type memoryTopic struct {
+	name   string
+	lock   sync.RWMutex
+	groups map[string]*memoryGroup
+}
+
+func (t *memoryTopic) group(groupID string) *memoryGroup {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	group, ok := t.groups[groupID]
+	if !ok {
+		group = &memoryGroup{partitions: make([]chan *memoryMessage, memoryPartitions)}
+		for i := range group.partitions {
+			group.partitions[i] = make(chan *memoryMessage, memoryBufferSize)
+		}
+		t.groups[groupID] = group
+	}
+	return group
+}
+
+// SendMessage queues the message for every group of the topic, it waits while a queue is full.
+func (t *memoryTopic) SendMessage(ctx context.Context, key string, msg proto.Message) error {
+	value, header, trace, err := marshal(ctx, msg)
+	if err != nil {
+		return err
+	}
+	message := &memoryMessage{key: key, value: value, header: header, trace: trace}
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if len(t.groups) == 0 {
+		return errs.New("mq topic has no consumer group in the process", "topic", t.name, "key", key).Wrap()
+	}
+	for _, group := range t.groups {
+		select {
+		case group.partitions[partition(key, memoryPartitions)] <- message:
+		case <-ctx.Done():
+			return errs.WrapMsg(ctx.Err(), "mq send message canceled", "key", key)
+		}
+	}
+	return nil
+}
+
+type memoryGroup struct {
+	lock       sync.Mutex
+	subscribed bool
+	partitions []chan *memoryMessage
+}
+
+type memoryConsumer struct {
+	group     *memoryGroup
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Subscribe consumes every partition of the group in its own goroutine, a group has a single subscriber at a time.
+func (c *memoryConsumer) Subscribe(ctx context.Context, handle Handler) error {
+	c.group.lock.Lock()
+	if c.group.subscribed {
+		c.group.lock.Unlock()
+		return errs.ErrArgs.WrapMsg("the mq group already has a subscriber")
+	}
+	c.group.subscribed = true
+	c.group.lock.Unlock()
+	defer func() {
+		c.group.lock.Lock()
+		c.group.subscribed = false
+		c.group.lock.Unlock()
+	}()
+	var wg sync.WaitGroup
+	for _, messages := range c.group.partitions {
+		wg.Add(1)
+		go func(messages <-chan *memoryMessage) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-c.closed:
+					return
+				case message := <-messages:
+					handle(&Message{ctx: headerContext(message.header, message.trace), key: message.key, value: message.value, ack: func() {}})
+				}
+			}
+		}(messages)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (c *memoryConsumer) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
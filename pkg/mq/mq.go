@@ -0,0 +1,127 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mq carries the messages between the services through the message queue selected in the kafka config.
+// The topics and the consumer groups are the ones of the config whatever the backend.
+package mq
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/tracing"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mcontext"
+	"github.com/openimsdk/tools/utils/stringutil"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	BackendKafka  = "kafka"
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+)
+
+// Producer sends the messages of a topic.
+type Producer interface {
+	// SendMessage sends msg with the context infos of ctx, the messages of a key are consumed in the order
+	// they are sent.
+	SendMessage(ctx context.Context, key string, msg proto.Message) error
+}
+
+// Handler handles a message of a topic, it acks the message once it is handled.
+type Handler func(msg *Message)
+
+// Consumer consumes a topic within a consumer group.
+type Consumer interface {
+	// Subscribe passes the messages to handle until ctx is done or the consumer is closed. A group consumes a
+	// message once, and the messages of a key one after another in the order they were sent. The messages
+	// not acked may be delivered again when their partition moves to another consumer of the group.
+	Subscribe(ctx context.Context, handle Handler) error
+	Close() error
+}
+
+// Builder creates the producers and the consumers of a backend.
+type Builder interface {
+	GetTopicProducer(ctx context.Context, topic string) (Producer, error)
+	GetTopicConsumer(ctx context.Context, topic string, groupID string) (Consumer, error)
+}
+
+// NewBuilder returns the builder of the backend of conf, rdb is only used by the redis backend. The memory backend
+// only connects the components running in the same process.
+func NewBuilder(conf *config.Kafka, rdb redis.UniversalClient) (Builder, error) {
+	switch conf.Backend {
+	case "", BackendKafka:
+		return newKafkaBuilder(conf)
+	case BackendRedis:
+		if rdb == nil {
+			return nil, errs.ErrArgs.WrapMsg("the redis mq backend needs a redis client")
+		}
+		return newRedisBuilder(&conf.RedisStream, rdb), nil
+	case BackendMemory:
+		return defaultMemoryBroker, nil
+	default:
+		return nil, errs.ErrArgs.WrapMsg("unknown mq backend", "backend", conf.Backend)
+	}
+}
+
+// Message is a message of a topic.
+type Message struct {
+	ctx   context.Context
+	key   string
+	value []byte
+	ack   func()
+}
+
+// Context returns a context carrying the context infos and the trace context of the producer.
+func (m *Message) Context() context.Context {
+	return m.ctx
+}
+
+func (m *Message) Key() string {
+	return m.key
+}
+
+func (m *Message) Value() []byte {
+	return m.value
+}
+
+// Ack marks the message as consumed by the group.
+func (m *Message) Ack() {
+	m.ack()
+}
+
+// marshal encodes msg, the context infos and the trace context of ctx carried with it.
+func marshal(ctx context.Context, msg proto.Message) ([]byte, []string, map[string]string, error) {
+	value, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, nil, nil, errs.WrapMsg(err, "mq proto marshal error")
+	}
+	operationID, opUserID, platform, connID, err := mcontext.GetCtxInfos(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return value, []string{operationID, opUserID, platform, connID}, tracing.Inject(ctx), nil
+}
+
+// headerContext returns the context of the context infos and the trace context carried by a message.
+func headerContext(header []string, trace map[string]string) context.Context {
+	return tracing.Extract(mcontext.WithMustInfoCtx(header), trace)
+}
+
+// partition returns the partition of key among n partitions.
+func partition(key string, n int) int {
+	return int(stringutil.GetHashCode(key) % uint32(n))
+}
@@ -0,0 +1,143 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mq
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/mcontext"
+	"google.golang.org/protobuf/proto"
+)
+
+func testContext() context.Context {
+	return mcontext.WithMustInfoCtx([]string{"operationID", "opUserID", "1", "connID"})
+}
+
+// collect subscribes to consumer and returns the seqs received per key once n messages are received.
+func collect(t *testing.T, consumer Consumer, n int, send func()) map[string][]int64 {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		lock     sync.Mutex
+		received = make(map[string][]int64)
+		count    int
+		done     = make(chan struct{})
+	)
+	go consumer.Subscribe(ctx, func(msg *Message) {
+		var data sdkws.MsgData
+		if err := proto.Unmarshal(msg.Value(), &data); err != nil {
+			t.Error(err)
+		}
+		if operationID := mcontext.GetOperationID(msg.Context()); operationID != "operationID" {
+			t.Errorf("operationID got %q", operationID)
+		}
+		msg.Ack()
+		lock.Lock()
+		defer lock.Unlock()
+		received[msg.Key()] = append(received[msg.Key()], data.Seq)
+		if count++; count == n {
+			close(done)
+		}
+	})
+	send()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("received %d of %d messages", count, n)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	return received
+}
+
+func TestMemoryKeyOrder(t *testing.T) {
+	ctx := testContext()
+	broker := newMemoryBroker()
+	producer, _ := broker.GetTopicProducer(ctx, "topic")
+	first, _ := broker.GetTopicConsumer(ctx, "topic", "first")
+	second, _ := broker.GetTopicConsumer(ctx, "topic", "second")
+	const keys, perKey = 8, 50
+	send := func() {
+		for seq := int64(1); seq <= perKey; seq++ {
+			for k := 0; k < keys; k++ {
+				if err := producer.SendMessage(ctx, "key"+strconv.Itoa(k), &sdkws.MsgData{Seq: seq}); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	received := collect(t, first, keys*perKey, send)
+	// every group receives all the messages
+	if got := collect(t, second, keys*perKey, func() {}); len(got) != keys {
+		t.Fatalf("second group got %d keys", len(got))
+	}
+	for key, seqs := range received {
+		for i, seq := range seqs {
+			if seq != int64(i+1) {
+				t.Fatalf("key %s got seq %d at %d", key, seq, i)
+			}
+		}
+	}
+}
+
+func TestMemorySingleSubscriber(t *testing.T) {
+	ctx := testContext()
+	broker := newMemoryBroker()
+	consumer, _ := broker.GetTopicConsumer(ctx, "topic", "group")
+	subscribed := make(chan error, 1)
+	go func() { subscribed <- consumer.Subscribe(context.Background(), func(*Message) {}) }()
+	time.Sleep(50 * time.Millisecond)
+	other, _ := broker.GetTopicConsumer(ctx, "topic", "group")
+	if err := other.Subscribe(context.Background(), func(*Message) {}); err == nil {
+		t.Fatal("a second subscriber of the group should fail")
+	}
+	if err := consumer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-subscribed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscribe did not return after close")
+	}
+}
+
+func TestMemoryNoGroup(t *testing.T) {
+	ctx := testContext()
+	producer, _ := newMemoryBroker().GetTopicProducer(ctx, "topic")
+	if err := producer.SendMessage(ctx, "key", &sdkws.MsgData{Seq: 1}); err == nil {
+		t.Fatal("sending to a topic without a group should fail")
+	}
+}
+
+func TestNewBuilder(t *testing.T) {
+	if _, err := NewBuilder(&config.Kafka{Backend: BackendMemory}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBuilder(&config.Kafka{Backend: BackendRedis}, nil); err == nil {
+		t.Fatal("the redis backend without a client should fail")
+	}
+	if _, err := NewBuilder(&config.Kafka{Backend: "nats"}, nil); err == nil {
+		t.Fatal("an unknown backend should fail")
+	}
+}
@@ -0,0 +1,345 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/cachekey"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	redisDefaultPartitions = 16
+	// redisLeaseTTL is how long a consumer keeps its partitions without renewing them.
+	redisLeaseTTL = 15 * time.Second
+	// redisRebalanceInterval is how often a consumer renews its partitions and balances them with the group.
+	redisRebalanceInterval = 5 * time.Second
+	redisReadBlock         = time.Second
+	redisReadCount         = 100
+	redisRetryInterval     = time.Second
+
+	redisFieldKey    = "key"
+	redisFieldValue  = "value"
+	redisFieldHeader = "header"
+	redisFieldTrace  = "trace"
+)
+
+var (
+	// renewLeaseScript extends the lease of KEYS[1] when it is held by ARGV[1].
+	renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+	// releaseLeaseScript deletes the lease of KEYS[1] when it is held by ARGV[1].
+	releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+)
+
+// redisBuilder is the Redis Streams backend. A topic is split into partition streams by the hash of the keys,
+// and the partitions are shared out among the consumers of a group with leases, so that a partition is read by a
+// single consumer at a time and the messages of a key keep their order.
+type redisBuilder struct {
+	rdb        redis.UniversalClient
+	partitions int
+	maxLen     int64
+}
+
+func newRedisBuilder(conf *config.RedisStream, rdb redis.UniversalClient) *redisBuilder {
+	partitions := conf.Partitions
+	if partitions <= 0 {
+		partitions = redisDefaultPartitions
+	}
+	return &redisBuilder{rdb: rdb, partitions: partitions, maxLen: conf.MaxLen}
+}
+
+func (b *redisBuilder) GetTopicProducer(_ context.Context, topic string) (Producer, error) {
+	return &redisProducer{rdb: b.rdb, topic: topic, partitions: b.partitions, maxLen: b.maxLen}, nil
+}
+
+// GetTopicConsumer creates the group on the streams of the topic, a new group starts with the messages kept in the
+// streams, so that the messages sent before the first consumer of the group started are not skipped.
+func (b *redisBuilder) GetTopicConsumer(ctx context.Context, topic string, groupID string) (Consumer, error) {
+	for i := 0; i < b.partitions; i++ {
+		err := b.rdb.XGroupCreateMkStream(ctx, cachekey.GetMQStreamKey(topic, i), groupID, "0").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return nil, errs.WrapMsg(err, "redis create mq group failed", "topic", topic, "groupID", groupID)
+		}
+	}
+	return &redisConsumer{
+		rdb:        b.rdb,
+		topic:      topic,
+		group:      groupID,
+		partitions: b.partitions,
+		id:         uuid.New().String(),
+		closed:     make(chan struct{}),
+	}, nil
+}
+
+type redisProducer struct {
+	rdb        redis.UniversalClient
+	topic      string
+	partitions int
+	maxLen     int64
+}
+
+func (p *redisProducer) SendMessage(ctx context.Context, key string, msg proto.Message) error {
+	value, header, trace, err := marshal(ctx, msg)
+	if err != nil {
+		return err
+	}
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	traceData, err := json.Marshal(trace)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	err = p.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: cachekey.GetMQStreamKey(p.topic, partition(key, p.partitions)),
+		MaxLen: p.maxLen,
+		Approx: true,
+		Values: []any{redisFieldKey, key, redisFieldValue, value, redisFieldHeader, headerData, redisFieldTrace, traceData},
+	}).Err()
+	if err != nil {
+		return errs.WrapMsg(err, "redis send mq message failed", "topic", p.topic, "key", key)
+	}
+	return nil
+}
+
+type redisConsumer struct {
+	rdb        redis.UniversalClient
+	topic      string
+	group      string
+	partitions int
+	// id identifies the consumer in the leases of the partitions.
+	id        string
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// redisClaim is a partition read by the consumer.
+type redisClaim struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (c *redisConsumer) Subscribe(ctx context.Context, handle Handler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	claims := make(map[int]*redisClaim)
+	defer func() {
+		for p := range claims {
+			c.stop(claims, p)
+		}
+		if err := c.rdb.ZRem(context.Background(), cachekey.GetMQMembersKey(c.topic, c.group), c.id).Err(); err != nil {
+			log.ZWarn(ctx, "redis remove mq member failed", err, "topic", c.topic, "groupID", c.group)
+		}
+	}()
+	ticker := time.NewTicker(redisRebalanceInterval)
+	defer ticker.Stop()
+	for {
+		c.rebalance(ctx, claims, handle)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *redisConsumer) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+// rebalance renews the leases of the claimed partitions and claims or releases partitions so that every live
+// consumer of the group reads about the same number of them.
+func (c *redisConsumer) rebalance(ctx context.Context, claims map[int]*redisClaim, handle Handler) {
+	quota, err := c.quota(ctx)
+	if err != nil {
+		log.ZWarn(ctx, "redis mq heartbeat failed", err, "topic", c.topic, "groupID", c.group)
+		return
+	}
+	for p := range claims {
+		ok, err := renewLeaseScript.Run(ctx, c.rdb, []string{c.leaseKey(p)}, c.id, redisLeaseTTL.Milliseconds()).Int()
+		if err != nil || ok == 0 {
+			log.ZWarn(ctx, "redis mq partition lease lost", err, "topic", c.topic, "groupID", c.group, "partition", p)
+			c.stop(claims, p)
+		}
+	}
+	for p := range claims {
+		if len(claims) <= quota {
+			break
+		}
+		c.stop(claims, p)
+	}
+	start := rand.Intn(c.partitions)
+	for i := 0; i < c.partitions && len(claims) < quota; i++ {
+		p := (start + i) % c.partitions
+		if _, ok := claims[p]; ok {
+			continue
+		}
+		ok, err := c.rdb.SetNX(ctx, c.leaseKey(p), c.id, redisLeaseTTL).Result()
+		if err != nil {
+			log.ZWarn(ctx, "redis mq partition lease failed", err, "topic", c.topic, "groupID", c.group, "partition", p)
+			return
+		}
+		if ok {
+			claimCtx, cancel := context.WithCancel(ctx)
+			claim := &redisClaim{cancel: cancel, done: make(chan struct{})}
+			claims[p] = claim
+			go c.consume(claimCtx, p, handle, claim.done)
+		}
+	}
+}
+
+// quota records the heartbeat of the consumer and returns the number of partitions it should read.
+func (c *redisConsumer) quota(ctx context.Context) (int, error) {
+	key := cachekey.GetMQMembersKey(c.topic, c.group)
+	now := time.Now()
+	pipe := c.rdb.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.UnixMilli()), Member: c.id})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Add(-redisLeaseTTL).UnixMilli(), 10))
+	members := pipe.ZCard(ctx, key)
+	pipe.Expire(ctx, key, redisLeaseTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, errs.Wrap(err)
+	}
+	n := int(members.Val())
+	if n <= 0 {
+		n = 1
+	}
+	return (c.partitions + n - 1) / n, nil
+}
+
+// stop stops reading partition p and releases its lease.
+func (c *redisConsumer) stop(claims map[int]*redisClaim, p int) {
+	claim := claims[p]
+	delete(claims, p)
+	claim.cancel()
+	<-claim.done
+	if err := releaseLeaseScript.Run(context.Background(), c.rdb, []string{c.leaseKey(p)}, c.id).Err(); err != nil {
+		log.ZWarn(context.Background(), "redis mq partition release failed", err, "topic", c.topic, "groupID", c.group, "partition", p)
+	}
+}
+
+func (c *redisConsumer) leaseKey(p int) string {
+	return cachekey.GetMQLeaseKey(c.topic, c.group, p)
+}
+
+// consume reads partition p until ctx is done. The messages delivered to the previous owner of the partition
+// and not acked are read first, the partition is read with the same consumer name whoever owns it.
+func (c *redisConsumer) consume(ctx context.Context, p int, handle Handler, done chan struct{}) {
+	defer close(done)
+	defer func() {
+		if r := recover(); r != nil {
+			log.ZPanic(ctx, "redis mq consume panic", errs.ErrPanic(r))
+		}
+	}()
+	stream := cachekey.GetMQStreamKey(c.topic, p)
+	consumer := "p" + strconv.Itoa(p)
+	id := "0"
+	for ctx.Err() == nil {
+		streams, err := c.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    c.group,
+			Consumer: consumer,
+			Streams:  []string{stream, id},
+			Count:    redisReadCount,
+			Block:    redisReadBlock,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.ZWarn(ctx, "redis mq read failed", err, "stream", stream, "groupID", c.group)
+			select {
+			case <-ctx.Done():
+			case <-time.After(redisRetryInterval):
+			}
+			continue
+		}
+		var messages []redis.XMessage
+		if len(streams) > 0 {
+			messages = streams[0].Messages
+		}
+		for _, message := range messages {
+			if ctx.Err() != nil {
+				return
+			}
+			c.handle(stream, message, handle)
+		}
+		if id != ">" {
+			if len(messages) < redisReadCount {
+				id = ">"
+			} else {
+				id = messages[len(messages)-1].ID
+			}
+		}
+	}
+}
+
+func (c *redisConsumer) handle(stream string, message redis.XMessage, handle Handler) {
+	ack := func() {
+		if err := c.rdb.XAck(context.Background(), stream, c.group, message.ID).Err(); err != nil {
+			log.ZWarn(context.Background(), "redis mq ack failed", err, "stream", stream, "id", message.ID)
+		}
+	}
+	key, _ := message.Values[redisFieldKey].(string)
+	value, _ := message.Values[redisFieldValue].(string)
+	headerData, _ := message.Values[redisFieldHeader].(string)
+	var header []string
+	if err := json.Unmarshal([]byte(headerData), &header); err != nil {
+		// a pending message trimmed from the stream has no values
+		log.ZWarn(context.Background(), "redis mq message dropped", err, "stream", stream, "id", message.ID)
+		ack()
+		return
+	}
+	// the messages sent before the trace context was carried have no trace field
+	var trace map[string]string
+	if traceData, ok := message.Values[redisFieldTrace].(string); ok {
+		_ = json.Unmarshal([]byte(traceData), &trace)
+	}
+	handle(&Message{ctx: headerContext(header, trace), key: key, value: []byte(value), ack: ack})
+}
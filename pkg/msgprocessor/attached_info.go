@@ -0,0 +1,41 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgprocessor
+
+import (
+	"encoding/json"
+
+	"github.com/openimsdk/tools/errs"
+)
+
+// SetAttachedInfo sets a field of the attached info of a message and keeps the other fields set by the clients.
+func SetAttachedInfo(attachedInfo string, key string, value any) (string, error) {
+	fields := make(map[string]json.RawMessage)
+	if attachedInfo != "" {
+		if err := json.Unmarshal([]byte(attachedInfo), &fields); err != nil {
+			return "", errs.WrapMsg(err, "attached info is not a json object")
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", errs.Wrap(err)
+	}
+	fields[key] = data
+	res, err := json.Marshal(fields)
+	if err != nil {
+		return "", errs.Wrap(err)
+	}
+	return string(res), nil
+}
@@ -0,0 +1,52 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgprocessor
+
+import (
+	"encoding/json"
+
+	"github.com/openimsdk/protocol/constant"
+)
+
+// msgText holds the text fields of the message contents, only the field of the content type is set.
+type msgText struct {
+	Content     string `json:"content"`
+	Text        string `json:"text"`
+	FileName    string `json:"fileName"`
+	Description string `json:"description"`
+}
+
+// GetMsgText returns the text written by the sender of a message, it is empty for contents without text.
+func GetMsgText(contentType int32, content []byte) string {
+	switch contentType {
+	case constant.Text, constant.AtText, constant.Quote, constant.File, constant.Location:
+	default:
+		return ""
+	}
+	var text msgText
+	if err := json.Unmarshal(content, &text); err != nil {
+		return ""
+	}
+	switch contentType {
+	case constant.Text:
+		return text.Content
+	case constant.AtText, constant.Quote:
+		return text.Text
+	case constant.File:
+		return text.FileName
+	default:
+		return text.Description
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgprocessor
+
+// Content types of the notifications which are not defined in github.com/openimsdk/protocol/constant, they are
+// in the range of the notifications so that the clients handle them like the other notifications.
+const (
+	// MsgEditNotification tells the members of a conversation that a message is edited.
+	MsgEditNotification = 2110
+	// MsgReactionNotification tells the members of a conversation that a reaction is added to or removed from a message.
+	MsgReactionNotification = 2111
+	// MsgReadReceiptNotification tells the sender of group messages with read receipts that a member read them.
+	MsgReadReceiptNotification = 2112
+)
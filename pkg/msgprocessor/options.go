@@ -16,6 +16,13 @@ package msgprocessor
 
 import "github.com/openimsdk/protocol/constant"
 
+const (
+	// IsEdited is set in the options of the messages pulled after they are edited.
+	IsEdited = "isEdited"
+	// IsReadReceipt is set in the options of the group messages whose readers are recorded.
+	IsReadReceipt = "isReadReceipt"
+)
+
 type (
 	Options    map[string]bool
 	OptionsOpt func(Options)
@@ -171,3 +178,8 @@ func (o Options) IsSenderConversationUpdate() bool {
 func (o Options) IsReactionFromCache() bool {
 	return o.Is(constant.IsReactionFromCache)
 }
+
+// IsReadReceipt reports whether the readers of the message are recorded, it is false when the option is missing.
+func (o Options) IsReadReceipt() bool {
+	return o[IsReadReceipt]
+}
@@ -0,0 +1,76 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgprocessor
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/openimsdk/tools/errs"
+)
+
+const (
+	// AttachedInfoReactions is the key of the reaction counts in the attached info of the pulled messages.
+	AttachedInfoReactions = "reactions"
+	// MaxReactionLength is the max bytes of a reaction.
+	MaxReactionLength = 64
+)
+
+// ReactionCount is the number of the users who reacted to a message with a reaction, Reacted reports whether
+// the user pulling the message is one of them.
+type ReactionCount struct {
+	Reaction string `json:"reaction"`
+	Count    int    `json:"count"`
+	Reacted  bool   `json:"reacted"`
+}
+
+// CheckReaction checks that a reaction can be used as a key of the reactions stored in mongo.
+func CheckReaction(reaction string) error {
+	if reaction == "" {
+		return errs.ErrArgs.WrapMsg("reaction is empty")
+	}
+	if len(reaction) > MaxReactionLength {
+		return errs.ErrArgs.WrapMsg("reaction is too long", "length", len(reaction), "max", MaxReactionLength)
+	}
+	if strings.ContainsAny(reaction, ".$") {
+		return errs.ErrArgs.WrapMsg("reaction must not contain '.' or '$'", "reaction", reaction)
+	}
+	return nil
+}
+
+// CountReactions counts the users of the reactions, the most used reactions first.
+func CountReactions(reactions map[string][]string, userID string) []*ReactionCount {
+	counts := make([]*ReactionCount, 0, len(reactions))
+	for reaction, userIDs := range reactions {
+		if len(userIDs) == 0 {
+			continue
+		}
+		count := &ReactionCount{Reaction: reaction, Count: len(userIDs)}
+		for _, id := range userIDs {
+			if id == userID {
+				count.Reacted = true
+				break
+			}
+		}
+		counts = append(counts, count)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Reaction < counts[j].Reaction
+	})
+	return counts
+}
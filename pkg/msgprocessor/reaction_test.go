@@ -0,0 +1,62 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgprocessor
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCountReactions(t *testing.T) {
+	counts := CountReactions(map[string][]string{
+		"👍": {"u1", "u2"},
+		"😂": {"u3"},
+		"🎉": {"u2"},
+		"❤": {},
+	}, "u2")
+	want := []*ReactionCount{
+		{Reaction: "👍", Count: 2, Reacted: true},
+		{Reaction: "🎉", Count: 1, Reacted: true},
+		{Reaction: "😂", Count: 1},
+	}
+	if !reflect.DeepEqual(counts, want) {
+		data, _ := json.Marshal(counts)
+		t.Fatalf("got %s", data)
+	}
+}
+
+func TestSetAttachedReactions(t *testing.T) {
+	attachedInfo, err := SetAttachedInfo(`{"isPrivateChat":true}`, AttachedInfoReactions, []*ReactionCount{{Reaction: "👍", Count: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields struct {
+		IsPrivateChat bool             `json:"isPrivateChat"`
+		Reactions     []*ReactionCount `json:"reactions"`
+	}
+	if err := json.Unmarshal([]byte(attachedInfo), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if !fields.IsPrivateChat || len(fields.Reactions) != 1 || fields.Reactions[0].Reaction != "👍" {
+		t.Fatalf("unexpected attached info %s", attachedInfo)
+	}
+	if _, err := SetAttachedInfo("not json", AttachedInfoReactions, nil); err == nil {
+		t.Fatal("invalid attached info should fail")
+	}
+	if err := CheckReaction("a.b"); err == nil {
+		t.Fatal("reaction with a dot should fail")
+	}
+}
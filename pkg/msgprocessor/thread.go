@@ -0,0 +1,65 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgprocessor
+
+import "encoding/json"
+
+const (
+	// AttachedInfoThreadRootSeq is the key of the seq of the root message in the attached info of a thread reply,
+	// the root is in the conversation of the reply.
+	AttachedInfoThreadRootSeq = "threadRootSeq"
+	// AttachedInfoThread is the key of the thread summary in the attached info of the pulled root messages.
+	AttachedInfoThread = "thread"
+	// maxThreadReplyText is the max runes of the text of the last reply in a thread summary.
+	maxThreadReplyText = 100
+)
+
+// ThreadSummary is the summary of the replies of a root message.
+type ThreadSummary struct {
+	ReplyCount int64        `json:"replyCount"`
+	LastReply  *ThreadReply `json:"lastReply"`
+}
+
+type ThreadReply struct {
+	Seq            int64  `json:"seq"`
+	SendID         string `json:"sendID"`
+	SenderNickname string `json:"senderNickname"`
+	ContentType    int32  `json:"contentType"`
+	Text           string `json:"text"`
+	SendTime       int64  `json:"sendTime"`
+}
+
+// GetThreadRootSeq returns the seq of the root message of a thread reply, 0 when the message is not a reply.
+func GetThreadRootSeq(attachedInfo string) int64 {
+	if attachedInfo == "" {
+		return 0
+	}
+	var info struct {
+		ThreadRootSeq int64 `json:"threadRootSeq"`
+	}
+	if err := json.Unmarshal([]byte(attachedInfo), &info); err != nil {
+		return 0
+	}
+	return info.ThreadRootSeq
+}
+
+// GetThreadReplyText returns the beginning of the text of a reply for the thread summary.
+func GetThreadReplyText(contentType int32, content []byte) string {
+	text := []rune(GetMsgText(contentType, content))
+	if len(text) > maxThreadReplyText {
+		text = text[:maxThreadReplyText]
+	}
+	return string(text)
+}
@@ -0,0 +1,44 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgprocessor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openimsdk/protocol/constant"
+)
+
+func TestGetThreadRootSeq(t *testing.T) {
+	tests := map[string]int64{
+		"":                                     0,
+		"not json":                             0,
+		`{"isPrivateChat":true}`:               0,
+		`{"threadRootSeq":12,"progress":null}`: 12,
+	}
+	for attachedInfo, want := range tests {
+		if got := GetThreadRootSeq(attachedInfo); got != want {
+			t.Errorf("%q: got %d want %d", attachedInfo, got, want)
+		}
+	}
+}
+
+func TestGetThreadReplyText(t *testing.T) {
+	long := strings.Repeat("回", maxThreadReplyText+10)
+	text := GetThreadReplyText(constant.Text, []byte(`{"content":"`+long+`"}`))
+	if len([]rune(text)) != maxThreadReplyText {
+		t.Fatalf("got %d runes", len([]rune(text)))
+	}
+}
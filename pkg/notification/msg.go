@@ -22,6 +22,7 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
 	"github.com/openimsdk/protocol/constant"
 	"github.com/openimsdk/protocol/msg"
 	"github.com/openimsdk/protocol/sdkws"
@@ -74,9 +75,12 @@ func newContentTypeConf(conf *config.Notification) map[int32]config.Notification
 		constant.ConversationUnreadNotification:      conf.ConversationChanged,
 		constant.ConversationPrivateChatNotification: conf.ConversationSetPrivate,
 		// msg
-		constant.MsgRevokeNotification:  {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
-		constant.HasReadReceipt:         {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
-		constant.DeleteMsgsNotification: {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
+		constant.MsgRevokeNotification:          {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
+		constant.HasReadReceipt:                 {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
+		constant.DeleteMsgsNotification:         {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
+		msgprocessor.MsgEditNotification:        {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
+		msgprocessor.MsgReactionNotification:    {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
+		msgprocessor.MsgReadReceiptNotification: {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
 	}
 }
 
@@ -190,7 +194,7 @@ func WithRpcGetUserName() NotificationOptions {
 	}
 }
 
-func (s *NotificationSender) send(ctx context.Context, sendID, recvID string, contentType, sessionType int32, m proto.Message, opts ...NotificationOptions) {
+func (s *NotificationSender) send(ctx context.Context, sendID, recvID string, contentType, sessionType int32, m any, opts ...NotificationOptions) {
 	//ctx = mcontext.WithMustInfoCtx([]string{mcontext.GetOperationID(ctx), mcontext.GetOpUserID(ctx), mcontext.GetOpUserPlatform(ctx), mcontext.GetConnID(ctx)})
 	ctx = context.WithoutCancel(ctx)
 	ctx, cancel := context.WithTimeout(ctx, time.Second*time.Duration(5))
@@ -254,6 +258,14 @@ func (s *NotificationSender) NotificationWithSessionType(ctx context.Context, se
 	}
 }
 
+// NotificationDetailWithSessionType sends a notification whose detail is not a protobuf message, it is used by the
+// content types defined in pkg/msgprocessor.
+func (s *NotificationSender) NotificationDetailWithSessionType(ctx context.Context, sendID, recvID string, contentType, sessionType int32, detail any, opts ...NotificationOptions) {
+	if err := s.queue.Push(func() { s.send(ctx, sendID, recvID, contentType, sessionType, detail, opts...) }); err != nil {
+		log.ZWarn(ctx, "Push to queue failed", err, "sendID", sendID, "recvID", recvID, "detail", jsonutil.StructToJsonString(detail))
+	}
+}
+
 func (s *NotificationSender) Notification(ctx context.Context, sendID, recvID string, contentType int32, m proto.Message, opts ...NotificationOptions) {
 	s.NotificationWithSessionType(ctx, sendID, recvID, contentType, s.sessionTypeConf[contentType], m, opts...)
 }
@@ -0,0 +1,510 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package msgext defines the msgext service, it extends the msg service of github.com/openimsdk/protocol/msg
+// and is served by the msg rpc with the JSON codec of pkg/protocol/jsoncodec.
+package msgext
+
+import (
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/msg"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/errs"
+)
+
+type SearchMsgReq struct {
+	Keyword        string `json:"keyword"`
+	ConversationID string `json:"conversationID"`
+	SendID         string `json:"sendID"`
+	// RecvID is the receiver of single chat messages or the group of group messages.
+	RecvID       string  `json:"recvID"`
+	SessionType  int32   `json:"sessionType"`
+	ContentType  int32   `json:"contentType"`
+	ContentTypes []int32 `json:"contentTypes"`
+	// SendTime is a date formatted as 2006-01-02, it is kept for the requests of /msg/search_msg.
+	SendTime   string                   `json:"sendTime"`
+	StartTime  int64                    `json:"startTime"`
+	EndTime    int64                    `json:"endTime"`
+	Pagination *sdkws.RequestPagination `json:"pagination"`
+}
+
+func (x *SearchMsgReq) Check() error {
+	if x.Pagination == nil || x.Pagination.PageNumber <= 0 || x.Pagination.ShowNumber <= 0 {
+		return errs.ErrArgs.WrapMsg("pagination is invalid")
+	}
+	if x.StartTime > 0 && x.EndTime > 0 && x.StartTime >= x.EndTime {
+		return errs.ErrArgs.WrapMsg("startTime must be less than endTime")
+	}
+	return nil
+}
+
+// UseIndex reports whether the request uses a field that only the search index supports.
+func (x *SearchMsgReq) UseIndex() bool {
+	return x.Keyword != "" || x.ConversationID != "" || len(x.ContentTypes) > 0 || x.StartTime > 0 || x.EndTime > 0
+}
+
+type SearchMsgResp struct {
+	ChatLogs    []*msg.SearchChatLog `json:"chatLogs"`
+	ChatLogsNum int32                `json:"chatLogsNum"`
+}
+
+// SensitiveWordList is a list of sensitive words sharing an action, the times are in milliseconds.
+type SensitiveWordList struct {
+	ListID     string   `json:"listID"`
+	Name       string   `json:"name"`
+	Action     int32    `json:"action"`
+	Words      []string `json:"words"`
+	CreateTime int64    `json:"createTime"`
+	UpdateTime int64    `json:"updateTime"`
+}
+
+type SetSensitiveWordListReq struct {
+	List *SensitiveWordList `json:"list"`
+}
+
+func (x *SetSensitiveWordListReq) Check() error {
+	if x.List == nil {
+		return errs.ErrArgs.WrapMsg("list is empty")
+	}
+	if x.List.ListID == "" {
+		return errs.ErrArgs.WrapMsg("listID is empty")
+	}
+	if x.List.Action < 1 || x.List.Action > 3 {
+		return errs.ErrArgs.WrapMsg("action must be 1 (flag), 2 (replace) or 3 (reject)")
+	}
+	return nil
+}
+
+type SetSensitiveWordListResp struct{}
+
+type DeleteSensitiveWordListsReq struct {
+	ListIDs []string `json:"listIDs"`
+}
+
+func (x *DeleteSensitiveWordListsReq) Check() error {
+	if len(x.ListIDs) == 0 {
+		return errs.ErrArgs.WrapMsg("listIDs is empty")
+	}
+	return nil
+}
+
+type DeleteSensitiveWordListsResp struct{}
+
+// GetSensitiveWordListsReq gets the lists of ListIDs, or all the lists when ListIDs is empty.
+type GetSensitiveWordListsReq struct {
+	ListIDs []string `json:"listIDs"`
+}
+
+func (x *GetSensitiveWordListsReq) Check() error {
+	return nil
+}
+
+type GetSensitiveWordListsResp struct {
+	Lists []*SensitiveWordList `json:"lists"`
+}
+
+type BatchRevokeMsgReq struct {
+	UserID         string  `json:"userID"`
+	ConversationID string  `json:"conversationID"`
+	Seqs           []int64 `json:"seqs"`
+}
+
+func (x *BatchRevokeMsgReq) Check() error {
+	if x.UserID == "" {
+		return errs.ErrArgs.WrapMsg("userID is empty")
+	}
+	if x.ConversationID == "" {
+		return errs.ErrArgs.WrapMsg("conversationID is empty")
+	}
+	if len(x.Seqs) == 0 {
+		return errs.ErrArgs.WrapMsg("seqs is empty")
+	}
+	return nil
+}
+
+// RevokeMsgFailure is a message which failed to be revoked, ErrCode is the code of the error.
+type RevokeMsgFailure struct {
+	Seq     int64  `json:"seq"`
+	ErrCode int    `json:"errCode"`
+	ErrMsg  string `json:"errMsg"`
+}
+
+func NewRevokeMsgFailure(seq int64, err error) *RevokeMsgFailure {
+	failure := &RevokeMsgFailure{Seq: seq, ErrCode: errs.ServerInternalError, ErrMsg: err.Error()}
+	if codeErr, ok := errs.Unwrap(err).(errs.CodeError); ok {
+		failure.ErrCode = codeErr.Code()
+		failure.ErrMsg = codeErr.Msg()
+	}
+	return failure
+}
+
+type BatchRevokeMsgResp struct {
+	RevokedSeqs []int64             `json:"revokedSeqs"`
+	Failures    []*RevokeMsgFailure `json:"failures"`
+}
+
+// RevokeUserGroupMsgsReq revokes the messages UserID sent in GroupID, the newest first. BeforeSeq is the NextSeq of
+// the previous response to revoke the older messages, 0 starts from the newest.
+type RevokeUserGroupMsgsReq struct {
+	GroupID   string `json:"groupID"`
+	UserID    string `json:"userID"`
+	BeforeSeq int64  `json:"beforeSeq"`
+}
+
+func (x *RevokeUserGroupMsgsReq) Check() error {
+	if x.GroupID == "" {
+		return errs.ErrArgs.WrapMsg("groupID is empty")
+	}
+	if x.UserID == "" {
+		return errs.ErrArgs.WrapMsg("userID is empty")
+	}
+	if x.BeforeSeq < 0 {
+		return errs.ErrArgs.WrapMsg("beforeSeq is invalid")
+	}
+	return nil
+}
+
+// RevokeUserGroupMsgsResp is the result of a batch of at most revoke.maxBatchSize messages, NextSeq is not 0 when
+// older messages remain to be revoked by another request.
+type RevokeUserGroupMsgsResp struct {
+	ConversationID string              `json:"conversationID"`
+	RevokedSeqs    []int64             `json:"revokedSeqs"`
+	Failures       []*RevokeMsgFailure `json:"failures"`
+	NextSeq        int64               `json:"nextSeq"`
+}
+
+// EditMsgReq replaces the content of a message sent by UserID, Content is the JSON of the element of the
+// content type of the message.
+type EditMsgReq struct {
+	UserID         string `json:"userID"`
+	ConversationID string `json:"conversationID"`
+	Seq            int64  `json:"seq"`
+	Content        string `json:"content"`
+}
+
+func (x *EditMsgReq) Check() error {
+	if x.UserID == "" {
+		return errs.ErrArgs.WrapMsg("userID is empty")
+	}
+	if x.ConversationID == "" {
+		return errs.ErrArgs.WrapMsg("conversationID is empty")
+	}
+	if x.Seq <= 0 {
+		return errs.ErrArgs.WrapMsg("seq is invalid")
+	}
+	if x.Content == "" {
+		return errs.ErrArgs.WrapMsg("content is empty")
+	}
+	return nil
+}
+
+type EditMsgResp struct {
+	EditTime int64 `json:"editTime"`
+}
+
+// MsgEditTips is the detail of the notification of an edited message, Content is the new content.
+type MsgEditTips struct {
+	EditorUserID   string `json:"editorUserID"`
+	ConversationID string `json:"conversationID"`
+	Seq            int64  `json:"seq"`
+	ClientMsgID    string `json:"clientMsgID"`
+	SessionType    int32  `json:"sessionType"`
+	ContentType    int32  `json:"contentType"`
+	Content        string `json:"content"`
+	EditTime       int64  `json:"editTime"`
+}
+
+// AddReactionReq adds the reaction of UserID to a message, Reaction is usually an emoji.
+type AddReactionReq struct {
+	UserID         string `json:"userID"`
+	ConversationID string `json:"conversationID"`
+	Seq            int64  `json:"seq"`
+	Reaction       string `json:"reaction"`
+}
+
+func (x *AddReactionReq) Check() error {
+	return checkReactionReq(x.UserID, x.ConversationID, x.Seq)
+}
+
+type AddReactionResp struct{}
+
+type DeleteReactionReq struct {
+	UserID         string `json:"userID"`
+	ConversationID string `json:"conversationID"`
+	Seq            int64  `json:"seq"`
+	Reaction       string `json:"reaction"`
+}
+
+func (x *DeleteReactionReq) Check() error {
+	return checkReactionReq(x.UserID, x.ConversationID, x.Seq)
+}
+
+type DeleteReactionResp struct{}
+
+// GetReactionsReq gets the users of the reactions of a message.
+type GetReactionsReq struct {
+	UserID         string `json:"userID"`
+	ConversationID string `json:"conversationID"`
+	Seq            int64  `json:"seq"`
+}
+
+func (x *GetReactionsReq) Check() error {
+	return checkReactionReq(x.UserID, x.ConversationID, x.Seq)
+}
+
+type MsgReaction struct {
+	Reaction string   `json:"reaction"`
+	UserIDs  []string `json:"userIDs"`
+}
+
+type GetReactionsResp struct {
+	Reactions []*MsgReaction `json:"reactions"`
+}
+
+// MsgReactionTips is the detail of the notification of a reaction added to or removed from a message.
+type MsgReactionTips struct {
+	UserID         string `json:"userID"`
+	ConversationID string `json:"conversationID"`
+	Seq            int64  `json:"seq"`
+	ClientMsgID    string `json:"clientMsgID"`
+	SessionType    int32  `json:"sessionType"`
+	Reaction       string `json:"reaction"`
+	// Added is false when the reaction is removed.
+	Added bool  `json:"added"`
+	Time  int64 `json:"time"`
+}
+
+func checkReactionReq(userID string, conversationID string, seq int64) error {
+	if userID == "" {
+		return errs.ErrArgs.WrapMsg("userID is empty")
+	}
+	if conversationID == "" {
+		return errs.ErrArgs.WrapMsg("conversationID is empty")
+	}
+	if seq <= 0 {
+		return errs.ErrArgs.WrapMsg("seq is invalid")
+	}
+	return nil
+}
+
+// MaxThreadRepliesCount is the max number of replies of a page of a thread.
+const MaxThreadRepliesCount = 100
+
+// GetThreadRepliesReq pages the replies of the root message RootSeq, the replies after StartSeq are returned in
+// ascending order, or the replies before StartSeq in descending order when Desc is true, StartSeq 0 starts from
+// the first or the last reply.
+type GetThreadRepliesReq struct {
+	UserID         string `json:"userID"`
+	ConversationID string `json:"conversationID"`
+	RootSeq        int64  `json:"rootSeq"`
+	StartSeq       int64  `json:"startSeq"`
+	Count          int32  `json:"count"`
+	Desc           bool   `json:"desc"`
+}
+
+func (x *GetThreadRepliesReq) Check() error {
+	if x.UserID == "" {
+		return errs.ErrArgs.WrapMsg("userID is empty")
+	}
+	if x.ConversationID == "" {
+		return errs.ErrArgs.WrapMsg("conversationID is empty")
+	}
+	if x.RootSeq <= 0 {
+		return errs.ErrArgs.WrapMsg("rootSeq is invalid")
+	}
+	if x.StartSeq < 0 {
+		return errs.ErrArgs.WrapMsg("startSeq is invalid")
+	}
+	if x.Count <= 0 || x.Count > MaxThreadRepliesCount {
+		return errs.ErrArgs.WrapMsg("count is invalid", "count", x.Count, "max", MaxThreadRepliesCount)
+	}
+	return nil
+}
+
+type GetThreadRepliesResp struct {
+	Msgs  []*sdkws.MsgData `json:"msgs"`
+	IsEnd bool             `json:"isEnd"`
+}
+
+// ScheduledMsg is a message sent at SendAt, the times are in milliseconds. The message is sent to every user of
+// RecvIDs when it is not empty, otherwise to the receiver of MsgData. Status is 1 pending, 2 sending, 3 sent,
+// 4 failed or 5 canceled.
+type ScheduledMsg struct {
+	ScheduleID  string         `json:"scheduleID"`
+	OwnerUserID string         `json:"ownerUserID"`
+	MsgData     *sdkws.MsgData `json:"msgData"`
+	RecvIDs     []string       `json:"recvIDs"`
+	SendAt      int64          `json:"sendAt"`
+	Status      int32          `json:"status"`
+	// FailedIDs are the receivers the message failed to be sent to, ErrMsg is the last error.
+	FailedIDs  []string `json:"failedIDs"`
+	ErrMsg     string   `json:"errMsg"`
+	CreateTime int64    `json:"createTime"`
+	UpdateTime int64    `json:"updateTime"`
+	SentTime   int64    `json:"sentTime"`
+}
+
+// ScheduleMsgReq schedules MsgData to be sent at SendAt through the normal send path, RecvIDs are only allowed
+// to the app managers and not to the group messages.
+type ScheduleMsgReq struct {
+	MsgData *sdkws.MsgData `json:"msgData"`
+	RecvIDs []string       `json:"recvIDs"`
+	SendAt  int64          `json:"sendAt"`
+}
+
+func (x *ScheduleMsgReq) Check() error {
+	if x.MsgData == nil {
+		return errs.ErrArgs.WrapMsg("msgData is nil")
+	}
+	if x.MsgData.SendID == "" {
+		return errs.ErrArgs.WrapMsg("sendID is empty")
+	}
+	if x.SendAt <= 0 {
+		return errs.ErrArgs.WrapMsg("sendAt is invalid")
+	}
+	if len(x.RecvIDs) > 0 && x.MsgData.SessionType == constant.ReadGroupChatType {
+		return errs.ErrArgs.WrapMsg("recvIDs are not allowed for group msgs")
+	}
+	return nil
+}
+
+type ScheduleMsgResp struct {
+	ScheduleID string `json:"scheduleID"`
+}
+
+// GetScheduledMsgsReq pages the scheduled messages of OwnerUserID by send time, the app managers get the ones of
+// all the owners when OwnerUserID is empty, all the statuses are returned when Statuses is empty.
+type GetScheduledMsgsReq struct {
+	OwnerUserID string                   `json:"ownerUserID"`
+	Statuses    []int32                  `json:"statuses"`
+	Pagination  *sdkws.RequestPagination `json:"pagination"`
+}
+
+func (x *GetScheduledMsgsReq) Check() error {
+	if x.Pagination == nil || x.Pagination.PageNumber <= 0 || x.Pagination.ShowNumber <= 0 {
+		return errs.ErrArgs.WrapMsg("pagination is invalid")
+	}
+	return nil
+}
+
+type GetScheduledMsgsResp struct {
+	Total int64           `json:"total"`
+	Msgs  []*ScheduledMsg `json:"msgs"`
+}
+
+// CancelScheduledMsgReq cancels a pending scheduled message.
+type CancelScheduledMsgReq struct {
+	ScheduleID string `json:"scheduleID"`
+}
+
+func (x *CancelScheduledMsgReq) Check() error {
+	if x.ScheduleID == "" {
+		return errs.ErrArgs.WrapMsg("scheduleID is empty")
+	}
+	return nil
+}
+
+type CancelScheduledMsgResp struct{}
+
+// UpdateScheduledMsgReq updates a pending scheduled message, SendAt 0 and an empty Content keep the send time and
+// the content. Content is the JSON of the element of the content type of the message.
+type UpdateScheduledMsgReq struct {
+	ScheduleID string `json:"scheduleID"`
+	SendAt     int64  `json:"sendAt"`
+	Content    string `json:"content"`
+}
+
+func (x *UpdateScheduledMsgReq) Check() error {
+	if x.ScheduleID == "" {
+		return errs.ErrArgs.WrapMsg("scheduleID is empty")
+	}
+	if x.SendAt < 0 {
+		return errs.ErrArgs.WrapMsg("sendAt is invalid")
+	}
+	if x.SendAt == 0 && x.Content == "" {
+		return errs.ErrArgs.WrapMsg("nothing to update")
+	}
+	return nil
+}
+
+type UpdateScheduledMsgResp struct{}
+
+// SetGroupReadReceiptReq turns the read receipts of the messages of a group on or off, it is allowed to the owner
+// and the admins of the group.
+type SetGroupReadReceiptReq struct {
+	UserID  string `json:"userID"`
+	GroupID string `json:"groupID"`
+	Enable  bool   `json:"enable"`
+}
+
+func (x *SetGroupReadReceiptReq) Check() error {
+	if x.UserID == "" {
+		return errs.ErrArgs.WrapMsg("userID is empty")
+	}
+	if x.GroupID == "" {
+		return errs.ErrArgs.WrapMsg("groupID is empty")
+	}
+	return nil
+}
+
+type SetGroupReadReceiptResp struct{}
+
+// GetMsgReadReceiptsReq gets the read receipts of the group messages of Seqs sent by UserID.
+type GetMsgReadReceiptsReq struct {
+	UserID         string  `json:"userID"`
+	ConversationID string  `json:"conversationID"`
+	Seqs           []int64 `json:"seqs"`
+}
+
+func (x *GetMsgReadReceiptsReq) Check() error {
+	if x.UserID == "" {
+		return errs.ErrArgs.WrapMsg("userID is empty")
+	}
+	if x.ConversationID == "" {
+		return errs.ErrArgs.WrapMsg("conversationID is empty")
+	}
+	if len(x.Seqs) == 0 || len(x.Seqs) > 100 {
+		return errs.ErrArgs.WrapMsg("seqs length must be in [1, 100]")
+	}
+	return nil
+}
+
+type MsgReadUser struct {
+	UserID   string `json:"userID"`
+	ReadTime int64  `json:"readTime"`
+}
+
+// MsgReadReceipt is the read state of a message, the unread users are the current members of the group who joined
+// before the message was sent and did not read it, the sender excluded.
+type MsgReadReceipt struct {
+	Seq           int64          `json:"seq"`
+	ReadCount     int32          `json:"readCount"`
+	UnreadCount   int32          `json:"unreadCount"`
+	ReadUsers     []*MsgReadUser `json:"readUsers"`
+	UnreadUserIDs []string       `json:"unreadUserIDs"`
+}
+
+// GetMsgReadReceiptsResp has the receipts of the seqs with read receipts, the other seqs are missing.
+type GetMsgReadReceiptsResp struct {
+	Receipts []*MsgReadReceipt `json:"receipts"`
+}
+
+// MsgReadReceiptTips is the detail of the notification sent to the sender of the messages of Seqs read by UserID.
+type MsgReadReceiptTips struct {
+	UserID         string  `json:"userID"`
+	ConversationID string  `json:"conversationID"`
+	GroupID        string  `json:"groupID"`
+	Seqs           []int64 `json:"seqs"`
+	ReadTime       int64   `json:"readTime"`
+}
@@ -0,0 +1,692 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgext
+
+import (
+	"context"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/protocol/jsoncodec"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	MsgExt_SearchMsg_FullMethodName                = "/openim.msgext.msgext/SearchMsg"
+	MsgExt_SetSensitiveWordList_FullMethodName     = "/openim.msgext.msgext/SetSensitiveWordList"
+	MsgExt_DeleteSensitiveWordLists_FullMethodName = "/openim.msgext.msgext/DeleteSensitiveWordLists"
+	MsgExt_GetSensitiveWordLists_FullMethodName    = "/openim.msgext.msgext/GetSensitiveWordLists"
+	MsgExt_BatchRevokeMsg_FullMethodName           = "/openim.msgext.msgext/BatchRevokeMsg"
+	MsgExt_RevokeUserGroupMsgs_FullMethodName      = "/openim.msgext.msgext/RevokeUserGroupMsgs"
+	MsgExt_EditMsg_FullMethodName                  = "/openim.msgext.msgext/EditMsg"
+	MsgExt_AddReaction_FullMethodName              = "/openim.msgext.msgext/AddReaction"
+	MsgExt_DeleteReaction_FullMethodName           = "/openim.msgext.msgext/DeleteReaction"
+	MsgExt_GetReactions_FullMethodName             = "/openim.msgext.msgext/GetReactions"
+	MsgExt_GetThreadReplies_FullMethodName         = "/openim.msgext.msgext/GetThreadReplies"
+	MsgExt_ScheduleMsg_FullMethodName              = "/openim.msgext.msgext/ScheduleMsg"
+	MsgExt_GetScheduledMsgs_FullMethodName         = "/openim.msgext.msgext/GetScheduledMsgs"
+	MsgExt_CancelScheduledMsg_FullMethodName       = "/openim.msgext.msgext/CancelScheduledMsg"
+	MsgExt_UpdateScheduledMsg_FullMethodName       = "/openim.msgext.msgext/UpdateScheduledMsg"
+	MsgExt_SetGroupReadReceipt_FullMethodName      = "/openim.msgext.msgext/SetGroupReadReceipt"
+	MsgExt_GetMsgReadReceipts_FullMethodName       = "/openim.msgext.msgext/GetMsgReadReceipts"
+)
+
+// MsgExtClient is the client API for the msgext service, every call uses the JSON codec.
+type MsgExtClient interface {
+	SearchMsg(ctx context.Context, in *SearchMsgReq, opts ...grpc.CallOption) (*SearchMsgResp, error)
+	SetSensitiveWordList(ctx context.Context, in *SetSensitiveWordListReq, opts ...grpc.CallOption) (*SetSensitiveWordListResp, error)
+	DeleteSensitiveWordLists(ctx context.Context, in *DeleteSensitiveWordListsReq, opts ...grpc.CallOption) (*DeleteSensitiveWordListsResp, error)
+	GetSensitiveWordLists(ctx context.Context, in *GetSensitiveWordListsReq, opts ...grpc.CallOption) (*GetSensitiveWordListsResp, error)
+	BatchRevokeMsg(ctx context.Context, in *BatchRevokeMsgReq, opts ...grpc.CallOption) (*BatchRevokeMsgResp, error)
+	RevokeUserGroupMsgs(ctx context.Context, in *RevokeUserGroupMsgsReq, opts ...grpc.CallOption) (*RevokeUserGroupMsgsResp, error)
+	EditMsg(ctx context.Context, in *EditMsgReq, opts ...grpc.CallOption) (*EditMsgResp, error)
+	AddReaction(ctx context.Context, in *AddReactionReq, opts ...grpc.CallOption) (*AddReactionResp, error)
+	DeleteReaction(ctx context.Context, in *DeleteReactionReq, opts ...grpc.CallOption) (*DeleteReactionResp, error)
+	GetReactions(ctx context.Context, in *GetReactionsReq, opts ...grpc.CallOption) (*GetReactionsResp, error)
+	GetThreadReplies(ctx context.Context, in *GetThreadRepliesReq, opts ...grpc.CallOption) (*GetThreadRepliesResp, error)
+	ScheduleMsg(ctx context.Context, in *ScheduleMsgReq, opts ...grpc.CallOption) (*ScheduleMsgResp, error)
+	GetScheduledMsgs(ctx context.Context, in *GetScheduledMsgsReq, opts ...grpc.CallOption) (*GetScheduledMsgsResp, error)
+	CancelScheduledMsg(ctx context.Context, in *CancelScheduledMsgReq, opts ...grpc.CallOption) (*CancelScheduledMsgResp, error)
+	UpdateScheduledMsg(ctx context.Context, in *UpdateScheduledMsgReq, opts ...grpc.CallOption) (*UpdateScheduledMsgResp, error)
+	SetGroupReadReceipt(ctx context.Context, in *SetGroupReadReceiptReq, opts ...grpc.CallOption) (*SetGroupReadReceiptResp, error)
+	GetMsgReadReceipts(ctx context.Context, in *GetMsgReadReceiptsReq, opts ...grpc.CallOption) (*GetMsgReadReceiptsResp, error)
+}
+
+type msgExtClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMsgExtClient(cc grpc.ClientConnInterface) MsgExtClient {
+	return &msgExtClient{cc}
+}
+
+func (c *msgExtClient) SearchMsg(ctx context.Context, in *SearchMsgReq, opts ...grpc.CallOption) (*SearchMsgResp, error) {
+	out := new(SearchMsgResp)
+	err := c.cc.Invoke(ctx, MsgExt_SearchMsg_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) SetSensitiveWordList(ctx context.Context, in *SetSensitiveWordListReq, opts ...grpc.CallOption) (*SetSensitiveWordListResp, error) {
+	out := new(SetSensitiveWordListResp)
+	err := c.cc.Invoke(ctx, MsgExt_SetSensitiveWordList_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) DeleteSensitiveWordLists(ctx context.Context, in *DeleteSensitiveWordListsReq, opts ...grpc.CallOption) (*DeleteSensitiveWordListsResp, error) {
+	out := new(DeleteSensitiveWordListsResp)
+	err := c.cc.Invoke(ctx, MsgExt_DeleteSensitiveWordLists_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) GetSensitiveWordLists(ctx context.Context, in *GetSensitiveWordListsReq, opts ...grpc.CallOption) (*GetSensitiveWordListsResp, error) {
+	out := new(GetSensitiveWordListsResp)
+	err := c.cc.Invoke(ctx, MsgExt_GetSensitiveWordLists_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) BatchRevokeMsg(ctx context.Context, in *BatchRevokeMsgReq, opts ...grpc.CallOption) (*BatchRevokeMsgResp, error) {
+	out := new(BatchRevokeMsgResp)
+	err := c.cc.Invoke(ctx, MsgExt_BatchRevokeMsg_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) RevokeUserGroupMsgs(ctx context.Context, in *RevokeUserGroupMsgsReq, opts ...grpc.CallOption) (*RevokeUserGroupMsgsResp, error) {
+	out := new(RevokeUserGroupMsgsResp)
+	err := c.cc.Invoke(ctx, MsgExt_RevokeUserGroupMsgs_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) EditMsg(ctx context.Context, in *EditMsgReq, opts ...grpc.CallOption) (*EditMsgResp, error) {
+	out := new(EditMsgResp)
+	err := c.cc.Invoke(ctx, MsgExt_EditMsg_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) AddReaction(ctx context.Context, in *AddReactionReq, opts ...grpc.CallOption) (*AddReactionResp, error) {
+	out := new(AddReactionResp)
+	err := c.cc.Invoke(ctx, MsgExt_AddReaction_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) DeleteReaction(ctx context.Context, in *DeleteReactionReq, opts ...grpc.CallOption) (*DeleteReactionResp, error) {
+	out := new(DeleteReactionResp)
+	err := c.cc.Invoke(ctx, MsgExt_DeleteReaction_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) GetReactions(ctx context.Context, in *GetReactionsReq, opts ...grpc.CallOption) (*GetReactionsResp, error) {
+	out := new(GetReactionsResp)
+	err := c.cc.Invoke(ctx, MsgExt_GetReactions_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) GetThreadReplies(ctx context.Context, in *GetThreadRepliesReq, opts ...grpc.CallOption) (*GetThreadRepliesResp, error) {
+	out := new(GetThreadRepliesResp)
+	err := c.cc.Invoke(ctx, MsgExt_GetThreadReplies_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) ScheduleMsg(ctx context.Context, in *ScheduleMsgReq, opts ...grpc.CallOption) (*ScheduleMsgResp, error) {
+	out := new(ScheduleMsgResp)
+	err := c.cc.Invoke(ctx, MsgExt_ScheduleMsg_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) GetScheduledMsgs(ctx context.Context, in *GetScheduledMsgsReq, opts ...grpc.CallOption) (*GetScheduledMsgsResp, error) {
+	out := new(GetScheduledMsgsResp)
+	err := c.cc.Invoke(ctx, MsgExt_GetScheduledMsgs_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) CancelScheduledMsg(ctx context.Context, in *CancelScheduledMsgReq, opts ...grpc.CallOption) (*CancelScheduledMsgResp, error) {
+	out := new(CancelScheduledMsgResp)
+	err := c.cc.Invoke(ctx, MsgExt_CancelScheduledMsg_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) UpdateScheduledMsg(ctx context.Context, in *UpdateScheduledMsgReq, opts ...grpc.CallOption) (*UpdateScheduledMsgResp, error) {
+	out := new(UpdateScheduledMsgResp)
+	err := c.cc.Invoke(ctx, MsgExt_UpdateScheduledMsg_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) SetGroupReadReceipt(ctx context.Context, in *SetGroupReadReceiptReq, opts ...grpc.CallOption) (*SetGroupReadReceiptResp, error) {
+	out := new(SetGroupReadReceiptResp)
+	err := c.cc.Invoke(ctx, MsgExt_SetGroupReadReceipt_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *msgExtClient) GetMsgReadReceipts(ctx context.Context, in *GetMsgReadReceiptsReq, opts ...grpc.CallOption) (*GetMsgReadReceiptsResp, error) {
+	out := new(GetMsgReadReceiptsResp)
+	err := c.cc.Invoke(ctx, MsgExt_GetMsgReadReceipts_FullMethodName, in, out, append([]grpc.CallOption{jsoncodec.CallOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MsgExtServer is the server API for the msgext service.
+type MsgExtServer interface {
+	SearchMsg(context.Context, *SearchMsgReq) (*SearchMsgResp, error)
+	SetSensitiveWordList(context.Context, *SetSensitiveWordListReq) (*SetSensitiveWordListResp, error)
+	DeleteSensitiveWordLists(context.Context, *DeleteSensitiveWordListsReq) (*DeleteSensitiveWordListsResp, error)
+	GetSensitiveWordLists(context.Context, *GetSensitiveWordListsReq) (*GetSensitiveWordListsResp, error)
+	BatchRevokeMsg(context.Context, *BatchRevokeMsgReq) (*BatchRevokeMsgResp, error)
+	RevokeUserGroupMsgs(context.Context, *RevokeUserGroupMsgsReq) (*RevokeUserGroupMsgsResp, error)
+	EditMsg(context.Context, *EditMsgReq) (*EditMsgResp, error)
+	AddReaction(context.Context, *AddReactionReq) (*AddReactionResp, error)
+	DeleteReaction(context.Context, *DeleteReactionReq) (*DeleteReactionResp, error)
+	GetReactions(context.Context, *GetReactionsReq) (*GetReactionsResp, error)
+	GetThreadReplies(context.Context, *GetThreadRepliesReq) (*GetThreadRepliesResp, error)
+	ScheduleMsg(context.Context, *ScheduleMsgReq) (*ScheduleMsgResp, error)
+	GetScheduledMsgs(context.Context, *GetScheduledMsgsReq) (*GetScheduledMsgsResp, error)
+	CancelScheduledMsg(context.Context, *CancelScheduledMsgReq) (*CancelScheduledMsgResp, error)
+	UpdateScheduledMsg(context.Context, *UpdateScheduledMsgReq) (*UpdateScheduledMsgResp, error)
+	SetGroupReadReceipt(context.Context, *SetGroupReadReceiptReq) (*SetGroupReadReceiptResp, error)
+	GetMsgReadReceipts(context.Context, *GetMsgReadReceiptsReq) (*GetMsgReadReceiptsResp, error)
+	mustEmbedUnimplementedMsgExtServer()
+}
+
+// UnimplementedMsgExtServer must be embedded to have forward compatible implementations.
+type UnimplementedMsgExtServer struct{}
+
+func (UnimplementedMsgExtServer) SearchMsg(context.Context, *SearchMsgReq) (*SearchMsgResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchMsg not implemented")
+}
+func (UnimplementedMsgExtServer) SetSensitiveWordList(context.Context, *SetSensitiveWordListReq) (*SetSensitiveWordListResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetSensitiveWordList not implemented")
+}
+func (UnimplementedMsgExtServer) DeleteSensitiveWordLists(context.Context, *DeleteSensitiveWordListsReq) (*DeleteSensitiveWordListsResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSensitiveWordLists not implemented")
+}
+func (UnimplementedMsgExtServer) GetSensitiveWordLists(context.Context, *GetSensitiveWordListsReq) (*GetSensitiveWordListsResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSensitiveWordLists not implemented")
+}
+func (UnimplementedMsgExtServer) BatchRevokeMsg(context.Context, *BatchRevokeMsgReq) (*BatchRevokeMsgResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchRevokeMsg not implemented")
+}
+func (UnimplementedMsgExtServer) RevokeUserGroupMsgs(context.Context, *RevokeUserGroupMsgsReq) (*RevokeUserGroupMsgsResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeUserGroupMsgs not implemented")
+}
+func (UnimplementedMsgExtServer) EditMsg(context.Context, *EditMsgReq) (*EditMsgResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EditMsg not implemented")
+}
+func (UnimplementedMsgExtServer) AddReaction(context.Context, *AddReactionReq) (*AddReactionResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddReaction not implemented")
+}
+func (UnimplementedMsgExtServer) DeleteReaction(context.Context, *DeleteReactionReq) (*DeleteReactionResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteReaction not implemented")
+}
+func (UnimplementedMsgExtServer) GetReactions(context.Context, *GetReactionsReq) (*GetReactionsResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReactions not implemented")
+}
+func (UnimplementedMsgExtServer) GetThreadReplies(context.Context, *GetThreadRepliesReq) (*GetThreadRepliesResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetThreadReplies not implemented")
+}
+func (UnimplementedMsgExtServer) ScheduleMsg(context.Context, *ScheduleMsgReq) (*ScheduleMsgResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ScheduleMsg not implemented")
+}
+func (UnimplementedMsgExtServer) GetScheduledMsgs(context.Context, *GetScheduledMsgsReq) (*GetScheduledMsgsResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetScheduledMsgs not implemented")
+}
+func (UnimplementedMsgExtServer) CancelScheduledMsg(context.Context, *CancelScheduledMsgReq) (*CancelScheduledMsgResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelScheduledMsg not implemented")
+}
+func (UnimplementedMsgExtServer) UpdateScheduledMsg(context.Context, *UpdateScheduledMsgReq) (*UpdateScheduledMsgResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateScheduledMsg not implemented")
+}
+func (UnimplementedMsgExtServer) SetGroupReadReceipt(context.Context, *SetGroupReadReceiptReq) (*SetGroupReadReceiptResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetGroupReadReceipt not implemented")
+}
+func (UnimplementedMsgExtServer) GetMsgReadReceipts(context.Context, *GetMsgReadReceiptsReq) (*GetMsgReadReceiptsResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMsgReadReceipts not implemented")
+}
+func (UnimplementedMsgExtServer) mustEmbedUnimplementedMsgExtServer() {}
+
+func RegisterMsgExtServer(s grpc.ServiceRegistrar, srv MsgExtServer) {
+	s.RegisterService(&MsgExt_ServiceDesc, srv)
+}
+
+func _MsgExt_SearchMsg_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(SearchMsgReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).SearchMsg(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_SearchMsg_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).SearchMsg(ctx, req.(*SearchMsgReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_SetSensitiveWordList_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(SetSensitiveWordListReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).SetSensitiveWordList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_SetSensitiveWordList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).SetSensitiveWordList(ctx, req.(*SetSensitiveWordListReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_DeleteSensitiveWordLists_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(DeleteSensitiveWordListsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).DeleteSensitiveWordLists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_DeleteSensitiveWordLists_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).DeleteSensitiveWordLists(ctx, req.(*DeleteSensitiveWordListsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_GetSensitiveWordLists_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(GetSensitiveWordListsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).GetSensitiveWordLists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_GetSensitiveWordLists_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).GetSensitiveWordLists(ctx, req.(*GetSensitiveWordListsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_BatchRevokeMsg_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(BatchRevokeMsgReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).BatchRevokeMsg(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_BatchRevokeMsg_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).BatchRevokeMsg(ctx, req.(*BatchRevokeMsgReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_RevokeUserGroupMsgs_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(RevokeUserGroupMsgsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).RevokeUserGroupMsgs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_RevokeUserGroupMsgs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).RevokeUserGroupMsgs(ctx, req.(*RevokeUserGroupMsgsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_EditMsg_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(EditMsgReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).EditMsg(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_EditMsg_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).EditMsg(ctx, req.(*EditMsgReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_AddReaction_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(AddReactionReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).AddReaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_AddReaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).AddReaction(ctx, req.(*AddReactionReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_DeleteReaction_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(DeleteReactionReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).DeleteReaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_DeleteReaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).DeleteReaction(ctx, req.(*DeleteReactionReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_GetReactions_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(GetReactionsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).GetReactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_GetReactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).GetReactions(ctx, req.(*GetReactionsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_GetThreadReplies_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(GetThreadRepliesReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).GetThreadReplies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_GetThreadReplies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).GetThreadReplies(ctx, req.(*GetThreadRepliesReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_ScheduleMsg_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(ScheduleMsgReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).ScheduleMsg(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_ScheduleMsg_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).ScheduleMsg(ctx, req.(*ScheduleMsgReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_GetScheduledMsgs_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(GetScheduledMsgsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).GetScheduledMsgs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_GetScheduledMsgs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).GetScheduledMsgs(ctx, req.(*GetScheduledMsgsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_CancelScheduledMsg_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(CancelScheduledMsgReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).CancelScheduledMsg(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_CancelScheduledMsg_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).CancelScheduledMsg(ctx, req.(*CancelScheduledMsgReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_UpdateScheduledMsg_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(UpdateScheduledMsgReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).UpdateScheduledMsg(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_UpdateScheduledMsg_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).UpdateScheduledMsg(ctx, req.(*UpdateScheduledMsgReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_SetGroupReadReceipt_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(SetGroupReadReceiptReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).SetGroupReadReceipt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_SetGroupReadReceipt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).SetGroupReadReceipt(ctx, req.(*SetGroupReadReceiptReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MsgExt_GetMsgReadReceipts_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(GetMsgReadReceiptsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MsgExtServer).GetMsgReadReceipts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MsgExt_GetMsgReadReceipts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MsgExtServer).GetMsgReadReceipts(ctx, req.(*GetMsgReadReceiptsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MsgExt_ServiceDesc is the grpc.ServiceDesc for the msgext service.
+var MsgExt_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "openim.msgext.msgext",
+	HandlerType: (*MsgExtServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SearchMsg",
+			Handler:    _MsgExt_SearchMsg_Handler,
+		},
+		{
+			MethodName: "SetSensitiveWordList",
+			Handler:    _MsgExt_SetSensitiveWordList_Handler,
+		},
+		{
+			MethodName: "DeleteSensitiveWordLists",
+			Handler:    _MsgExt_DeleteSensitiveWordLists_Handler,
+		},
+		{
+			MethodName: "GetSensitiveWordLists",
+			Handler:    _MsgExt_GetSensitiveWordLists_Handler,
+		},
+		{
+			MethodName: "BatchRevokeMsg",
+			Handler:    _MsgExt_BatchRevokeMsg_Handler,
+		},
+		{
+			MethodName: "RevokeUserGroupMsgs",
+			Handler:    _MsgExt_RevokeUserGroupMsgs_Handler,
+		},
+		{
+			MethodName: "EditMsg",
+			Handler:    _MsgExt_EditMsg_Handler,
+		},
+		{
+			MethodName: "AddReaction",
+			Handler:    _MsgExt_AddReaction_Handler,
+		},
+		{
+			MethodName: "DeleteReaction",
+			Handler:    _MsgExt_DeleteReaction_Handler,
+		},
+		{
+			MethodName: "GetReactions",
+			Handler:    _MsgExt_GetReactions_Handler,
+		},
+		{
+			MethodName: "GetThreadReplies",
+			Handler:    _MsgExt_GetThreadReplies_Handler,
+		},
+		{
+			MethodName: "ScheduleMsg",
+			Handler:    _MsgExt_ScheduleMsg_Handler,
+		},
+		{
+			MethodName: "GetScheduledMsgs",
+			Handler:    _MsgExt_GetScheduledMsgs_Handler,
+		},
+		{
+			MethodName: "CancelScheduledMsg",
+			Handler:    _MsgExt_CancelScheduledMsg_Handler,
+		},
+		{
+			MethodName: "UpdateScheduledMsg",
+			Handler:    _MsgExt_UpdateScheduledMsg_Handler,
+		},
+		{
+			MethodName: "SetGroupReadReceipt",
+			Handler:    _MsgExt_SetGroupReadReceipt_Handler,
+		},
+		{
+			MethodName: "GetMsgReadReceipts",
+			Handler:    _MsgExt_GetMsgReadReceipts_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "msgext",
+}
@@ -0,0 +1,88 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgext
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/msg"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type testServer struct {
+	UnimplementedMsgExtServer
+}
+
+func (testServer) SearchMsg(_ context.Context, req *SearchMsgReq) (*SearchMsgResp, error) {
+	return &SearchMsgResp{
+		ChatLogs:    []*msg.SearchChatLog{{ChatLog: &msg.ChatLog{SendID: req.SendID}, IsRevoked: true}},
+		ChatLogsNum: req.Pagination.ShowNumber,
+	}, nil
+}
+
+func TestMsgExtClient(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	RegisterMsgExtServer(srv, testServer{})
+	go srv.Serve(listener)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	resp, err := NewMsgExtClient(conn).SearchMsg(context.Background(), &SearchMsgReq{
+		SendID:     "user1",
+		Pagination: &sdkws.RequestPagination{PageNumber: 1, ShowNumber: 20},
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 20, resp.ChatLogsNum)
+	if assert.Len(t, resp.ChatLogs, 1) {
+		assert.Equal(t, "user1", resp.ChatLogs[0].ChatLog.SendID)
+		assert.True(t, resp.ChatLogs[0].IsRevoked)
+	}
+}
+
+func TestGetThreadRepliesReqCheck(t *testing.T) {
+	req := GetThreadRepliesReq{UserID: "user1", ConversationID: "sg_group1", RootSeq: 1}
+	for _, count := range []int32{-1, 0, MaxThreadRepliesCount + 1} {
+		req.Count = count
+		assert.Error(t, req.Check(), count)
+	}
+	req.Count = MaxThreadRepliesCount
+	assert.NoError(t, req.Check())
+}
+
+func TestScheduleMsgReqCheck(t *testing.T) {
+	assert.Error(t, (&ScheduleMsgReq{SendAt: 1}).Check())
+	req := ScheduleMsgReq{MsgData: &sdkws.MsgData{SendID: "user1", RecvID: "user2", SessionType: constant.SingleChatType}, SendAt: 1}
+	assert.NoError(t, req.Check())
+	req.RecvIDs = []string{"user2", "user3"}
+	assert.NoError(t, req.Check())
+	req.MsgData = &sdkws.MsgData{SendID: "user1", GroupID: "group1", SessionType: constant.ReadGroupChatType}
+	assert.Error(t, req.Check())
+	req.RecvIDs = nil
+	assert.NoError(t, req.Check())
+}
@@ -0,0 +1,80 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit throttles the requests of the users with token buckets stored in redis, so that a limit is
+// shared by all the instances of a service.
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/tools/errs"
+	"github.com/redis/go-redis/v9"
+)
+
+// Limiter takes the tokens of the buckets.
+type Limiter interface {
+	// Take takes a token of the bucket of key holding burst tokens refilled at rate tokens per second. It returns
+	// false and the time until a token is refilled when the bucket is empty.
+	Take(ctx context.Context, key string, rate float64, burst int) (bool, time.Duration, error)
+}
+
+// takeScript refills the bucket for the time elapsed since its last take and takes a token, the bucket expires
+// once it would be full again. It returns whether a token is taken and the milliseconds until the next token.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "time")
+local tokens = tonumber(bucket[1])
+local last = tonumber(bucket[2])
+if tokens == nil or last == nil then
+	tokens = burst
+	last = now
+end
+if now > last then
+	tokens = math.min(burst, tokens + (now - last) * rate / 1000)
+end
+local taken = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	taken = 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "time", tostring(math.max(now, last)))
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) * 1000 / rate) + 1000)
+return {taken, wait}
+`)
+
+func NewRedisLimiter(rdb redis.UniversalClient) Limiter {
+	return &redisLimiter{rdb: rdb}
+}
+
+type redisLimiter struct {
+	rdb redis.UniversalClient
+}
+
+func (r *redisLimiter) Take(ctx context.Context, key string, rate float64, burst int) (bool, time.Duration, error) {
+	res, err := takeScript.Run(ctx, r.rdb, []string{key}, rate, burst, time.Now().UnixMilli()).Int64Slice()
+	if err != nil {
+		return false, 0, errs.Wrap(err)
+	}
+	if len(res) != 2 {
+		return false, 0, errs.New("invalid rate limit script result", "result", res).Wrap()
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
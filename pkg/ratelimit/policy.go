@@ -0,0 +1,231 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/cachekey"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/redis/go-redis/v9"
+)
+
+// The subjects the buckets of a rule are kept for.
+const (
+	KeyUser     = "user"
+	KeyPlatform = "platform"
+	KeyIP       = "ip"
+)
+
+// Subject is the sender of a request, the rules keyed by a field missing from the subject do not apply.
+type Subject struct {
+	UserID     string
+	PlatformID int
+	IP         string
+}
+
+func (s *Subject) key(key string) string {
+	switch key {
+	case KeyUser:
+		return s.UserID
+	case KeyPlatform:
+		if s.UserID == "" {
+			return ""
+		}
+		return s.UserID + ":" + strconv.Itoa(s.PlatformID)
+	case KeyIP:
+		return s.IP
+	default:
+		return ""
+	}
+}
+
+type rule struct {
+	name  string
+	match map[string]struct{}
+	key   string
+	rate  float64
+	burst int
+}
+
+func (r *rule) matches(route string) bool {
+	if len(r.match) == 0 {
+		return true
+	}
+	_, ok := r.match[route]
+	return ok
+}
+
+// Policy checks the requests with the rules of the config.
+type Policy struct {
+	limiter Limiter
+	rules   []*rule
+	proxies Proxies
+}
+
+// New creates the policy of conf with the redis limiter, it returns nil when the rate limit is disabled.
+func New(conf *config.RateLimit, rdb redis.UniversalClient) (*Policy, error) {
+	if !conf.Enable {
+		return nil, nil
+	}
+	proxies, err := NewProxies(conf.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	p, err := NewPolicy(conf.Rules, NewRedisLimiter(rdb))
+	if err != nil {
+		return nil, err
+	}
+	p.proxies = proxies
+	return p, nil
+}
+
+func NewPolicy(rules []config.RateLimitRule, limiter Limiter) (*Policy, error) {
+	p := &Policy{limiter: limiter}
+	names := make(map[string]struct{}, len(rules))
+	for i, conf := range rules {
+		if conf.Name == "" {
+			return nil, errs.New("rate limit rule name is empty", "index", i).Wrap()
+		}
+		if _, ok := names[conf.Name]; ok {
+			return nil, errs.New("duplicate rate limit rule name", "name", conf.Name).Wrap()
+		}
+		names[conf.Name] = struct{}{}
+		switch conf.Key {
+		case KeyUser, KeyPlatform, KeyIP:
+		default:
+			return nil, errs.New("invalid rate limit rule key", "name", conf.Name, "key", conf.Key).Wrap()
+		}
+		if conf.Rate <= 0 || conf.Burst < 1 {
+			return nil, errs.New("rate limit rule rate and burst must be positive", "name", conf.Name, "rate", conf.Rate, "burst", conf.Burst).Wrap()
+		}
+		r := &rule{name: conf.Name, key: conf.Key, rate: conf.Rate, burst: conf.Burst}
+		if len(conf.Match) > 0 {
+			r.match = make(map[string]struct{}, len(conf.Match))
+			for _, route := range conf.Match {
+				r.match[strings.TrimSpace(route)] = struct{}{}
+			}
+		}
+		p.rules = append(p.rules, r)
+	}
+	return p, nil
+}
+
+// Check takes a token of every rule matching the route for the subject, and returns
+// servererrs.ErrRateLimitExceeded when a bucket is empty. The rules are skipped when redis fails, so that an
+// outage of redis does not stop the service.
+func (p *Policy) Check(ctx context.Context, route string, subject Subject) error {
+	for _, r := range p.rules {
+		if !r.matches(route) {
+			continue
+		}
+		id := subject.key(r.key)
+		if id == "" {
+			continue
+		}
+		ok, wait, err := p.limiter.Take(ctx, cachekey.GetRateLimitKey(r.name, id), r.rate, r.burst)
+		if err != nil {
+			log.ZWarn(ctx, "rate limit take failed", err, "rule", r.name, "subject", id)
+			continue
+		}
+		if !ok {
+			return servererrs.ErrRateLimitExceeded.WrapMsg("too many requests", "rule", r.name, "retryAfter", wait.Milliseconds())
+		}
+	}
+	return nil
+}
+
+// ClientIP returns the ip of the client of the request, see Proxies.ClientIP.
+func (p *Policy) ClientIP(req *http.Request) string {
+	return p.proxies.ClientIP(req)
+}
+
+// Proxies are the networks of the trusted proxies, the forwarded headers are only read from them.
+type Proxies []*net.IPNet
+
+// NewProxies parses the trusted proxies, given as CIDRs or single addresses.
+func NewProxies(cidrs []string) (Proxies, error) {
+	proxies := make(Proxies, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, errs.New("invalid trusted proxy", "proxy", cidr).Wrap()
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errs.WrapMsg(err, "invalid trusted proxy", "proxy", cidr)
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies, nil
+}
+
+func (p Proxies) trusted(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, ipNet := range p {
+		if ipNet.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the ip of the client of the request. The forwarded headers are only read when the request comes
+// from a trusted proxy, X-Forwarded-For is then walked from the last hop and its first untrusted address is the
+// client, so that a client cannot choose its ip by sending the headers itself.
+func (p Proxies) ClientIP(req *http.Request) string {
+	remoteIP, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		remoteIP = req.RemoteAddr
+	}
+	if !p.trusted(remoteIP) {
+		return remoteIP
+	}
+	if forwarded := req.Header.Get("X-Forwarded-For"); forwarded != "" {
+		ips := strings.Split(forwarded, ",")
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(ips[i])
+			if net.ParseIP(ip) == nil {
+				break
+			}
+			if i == 0 || !p.trusted(ip) {
+				return ip
+			}
+		}
+	}
+	if ip := strings.TrimSpace(req.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
+	}
+	return remoteIP
+}
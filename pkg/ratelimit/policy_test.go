@@ -0,0 +1,128 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/servererrs"
+	"github.com/openimsdk/tools/errs"
+)
+
+// countLimiter allows burst takes of every key.
+type countLimiter map[string]int
+
+func (c countLimiter) Take(_ context.Context, key string, _ float64, burst int) (bool, time.Duration, error) {
+	if c[key] >= burst {
+		return false, time.Second, nil
+	}
+	c[key]++
+	return true, 0, nil
+}
+
+func TestPolicyCheck(t *testing.T) {
+	limiter := countLimiter{}
+	policy, err := NewPolicy([]config.RateLimitRule{
+		{Name: "send", Match: []string{"/msg/send_msg", "1003"}, Key: KeyUser, Rate: 1, Burst: 2},
+		{Name: "ip", Key: KeyIP, Rate: 1, Burst: 3},
+	}, limiter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	u1 := Subject{UserID: "u1", IP: "10.0.0.1"}
+	for i := 0; i < 2; i++ {
+		if err := policy.Check(ctx, "/msg/send_msg", u1); err != nil {
+			t.Fatalf("take %d: %v", i, err)
+		}
+	}
+	if err := policy.Check(ctx, "1003", u1); !isRateLimited(err) {
+		t.Errorf("the user bucket should be empty, got %v", err)
+	}
+	if err := policy.Check(ctx, "/msg/send_msg", Subject{UserID: "u2", IP: "10.0.0.2"}); err != nil {
+		t.Errorf("another user should not be limited: %v", err)
+	}
+	// The rejected check above stopped at the user rule, the ip bucket of u1 has one token left.
+	if err := policy.Check(ctx, "/user/get_users_info", Subject{IP: "10.0.0.1"}); err != nil {
+		t.Errorf("the ip bucket should have a token left: %v", err)
+	}
+	if err := policy.Check(ctx, "/user/get_users_info", Subject{IP: "10.0.0.1"}); !isRateLimited(err) {
+		t.Errorf("the ip bucket should be empty, got %v", err)
+	}
+	if err := policy.Check(ctx, "/user/get_users_info", Subject{UserID: "u3"}); err != nil {
+		t.Errorf("a subject without ip should not be limited by the ip rule: %v", err)
+	}
+}
+
+func TestNewPolicyInvalid(t *testing.T) {
+	rules := [][]config.RateLimitRule{
+		{{Key: KeyUser, Rate: 1, Burst: 1}},
+		{{Name: "a", Key: "device", Rate: 1, Burst: 1}},
+		{{Name: "a", Key: KeyUser, Rate: 0, Burst: 1}},
+		{{Name: "a", Key: KeyUser, Rate: 1, Burst: 1}, {Name: "a", Key: KeyIP, Rate: 1, Burst: 1}},
+	}
+	for i, rule := range rules {
+		if _, err := NewPolicy(rule, countLimiter{}); err == nil {
+			t.Errorf("rules %d should be invalid", i)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := NewProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		ip         string
+	}{
+		{remoteAddr: "192.168.1.2:5000", ip: "192.168.1.2"},
+		// the headers of an untrusted peer are ignored
+		{remoteAddr: "192.168.1.2:5000", forwarded: "1.2.3.4", realIP: "1.2.3.5", ip: "192.168.1.2"},
+		{remoteAddr: "10.0.0.2:5000", forwarded: "1.2.3.4, 10.0.0.1", ip: "1.2.3.4"},
+		// a spoofed first address is skipped at the first untrusted hop
+		{remoteAddr: "192.168.1.1:5000", forwarded: "6.6.6.6, 1.2.3.4, 10.0.0.1", ip: "1.2.3.4"},
+		{remoteAddr: "10.0.0.2:5000", forwarded: "10.0.0.3, 10.0.0.1", ip: "10.0.0.3"},
+		{remoteAddr: "10.0.0.2:5000", realIP: "1.2.3.5", ip: "1.2.3.5"},
+		{remoteAddr: "10.0.0.2:5000", forwarded: "bad", ip: "10.0.0.2"},
+	}
+	for _, test := range tests {
+		req := &http.Request{RemoteAddr: test.remoteAddr, Header: http.Header{}}
+		if test.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", test.forwarded)
+		}
+		if test.realIP != "" {
+			req.Header.Set("X-Real-IP", test.realIP)
+		}
+		if ip := proxies.ClientIP(req); ip != test.ip {
+			t.Errorf("%+v: got %s", test, ip)
+		}
+	}
+	if _, err := NewProxies([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("the proxy should be invalid")
+	}
+}
+
+func isRateLimited(err error) bool {
+	codeErr, ok := errs.Unwrap(err).(errs.CodeError)
+	return ok && codeErr.Code() == servererrs.RateLimitExceeded
+}
@@ -0,0 +1,131 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sensitive filters the sensitive words of the texts written by the users.
+package sensitive
+
+import "unicode"
+
+// Match is an occurrence of a word in a text, Start and End are rune offsets and End is exclusive.
+type Match struct {
+	Start int
+	End   int
+	Word  *Word
+}
+
+type acNode struct {
+	next map[rune]int32
+	fail int32
+	// outputs are the words ending at the node, including the ones reached by the fail links.
+	outputs []int32
+}
+
+// Automaton is an Aho-Corasick automaton matching all the words in a single pass over a text,
+// the words are matched case-insensitively.
+type Automaton struct {
+	nodes []acNode
+	words []Word
+}
+
+func NewAutomaton(words []Word) *Automaton {
+	a := &Automaton{nodes: []acNode{{}}}
+	for _, word := range words {
+		runes := foldRunes(word.Text)
+		if len(runes) == 0 {
+			continue
+		}
+		var cur int32
+		for _, r := range runes {
+			next, ok := a.nodes[cur].next[r]
+			if !ok {
+				next = int32(len(a.nodes))
+				a.nodes = append(a.nodes, acNode{})
+				if a.nodes[cur].next == nil {
+					a.nodes[cur].next = make(map[rune]int32)
+				}
+				a.nodes[cur].next[r] = next
+			}
+			cur = next
+		}
+		word.length = len(runes)
+		a.nodes[cur].outputs = append(a.nodes[cur].outputs, int32(len(a.words)))
+		a.words = append(a.words, word)
+	}
+	a.buildFail()
+	return a
+}
+
+// buildFail links every node to the node of its longest proper suffix in breadth first order,
+// so that the fail node of a node is complete when the node is visited.
+func (a *Automaton) buildFail() {
+	queue := make([]int32, 0, len(a.nodes))
+	for _, child := range a.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range a.nodes[cur].next {
+			fail := a.nodes[cur].fail
+			for {
+				if next, ok := a.nodes[fail].next[r]; ok {
+					a.nodes[child].fail = next
+					break
+				}
+				if fail == 0 {
+					break
+				}
+				fail = a.nodes[fail].fail
+			}
+			a.nodes[child].outputs = append(a.nodes[child].outputs, a.nodes[a.nodes[child].fail].outputs...)
+			queue = append(queue, child)
+		}
+	}
+}
+
+// Find returns all the occurrences of the words in text, overlapping ones included.
+func (a *Automaton) Find(text string) []Match {
+	if len(a.words) == 0 {
+		return nil
+	}
+	var (
+		matches []Match
+		cur     int32
+	)
+	for i, r := range foldRunes(text) {
+		for {
+			if next, ok := a.nodes[cur].next[r]; ok {
+				cur = next
+				break
+			}
+			if cur == 0 {
+				break
+			}
+			cur = a.nodes[cur].fail
+		}
+		for _, output := range a.nodes[cur].outputs {
+			word := &a.words[output]
+			matches = append(matches, Match{Start: i + 1 - word.length, End: i + 1, Word: word})
+		}
+	}
+	return matches
+}
+
+func foldRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
@@ -0,0 +1,209 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sensitive
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/cache/cachekey"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/redis/go-redis/v9"
+)
+
+// Action is what happens to a text containing a word of a list.
+type Action int32
+
+const (
+	// ActionFlag passes the text and reports it to the afterSensitiveWord webhook.
+	ActionFlag Action = 1
+	// ActionReplace replaces the word with the configured replacement.
+	ActionReplace Action = 2
+	// ActionReject rejects the text.
+	ActionReject Action = 3
+)
+
+func (a Action) Valid() bool {
+	return a >= ActionFlag && a <= ActionReject
+}
+
+// Word is a word of a list.
+type Word struct {
+	Text   string
+	ListID string
+	Action Action
+	length int
+}
+
+// Result is the outcome of checking a text.
+type Result struct {
+	Reject bool
+	Flag   bool
+	// Text is the checked text with the words of the replace lists replaced.
+	Text string
+	// Words are the distinct words found in the text.
+	Words    []string
+	replaced bool
+}
+
+// Replaced reports whether Text differs from the checked text.
+func (r *Result) Replaced() bool {
+	return r.replaced
+}
+
+// LoadFunc loads all the word lists.
+type LoadFunc func(ctx context.Context) ([]*model.SensitiveWordList, error)
+
+// Filter checks the texts with the word lists, the lists are swapped atomically when they are reloaded.
+type Filter struct {
+	replacement string
+	load        LoadFunc
+	automaton   atomic.Pointer[Automaton]
+}
+
+// Start creates the filter of conf, loads the lists and reloads them when they are changed. It returns nil when
+// the filter is disabled.
+func Start(ctx context.Context, conf *config.SensitiveWord, rdb redis.UniversalClient, load LoadFunc) (*Filter, error) {
+	if !conf.Enable {
+		return nil, nil
+	}
+	f := NewFilter(conf.Replacement, load)
+	if err := f.Reload(ctx); err != nil {
+		return nil, err
+	}
+	go f.watch(context.Background(), rdb, time.Duration(conf.ReloadInterval)*time.Second)
+	return f, nil
+}
+
+func NewFilter(replacement string, load LoadFunc) *Filter {
+	if replacement == "" {
+		replacement = "***"
+	}
+	f := &Filter{replacement: replacement, load: load}
+	f.automaton.Store(NewAutomaton(nil))
+	return f
+}
+
+// Reload loads the lists and replaces the automaton.
+func (f *Filter) Reload(ctx context.Context) error {
+	lists, err := f.load(ctx)
+	if err != nil {
+		return err
+	}
+	var words []Word
+	for _, list := range lists {
+		action := Action(list.Action)
+		if !action.Valid() {
+			log.ZWarn(ctx, "invalid sensitive word list action", nil, "listID", list.ListID, "action", list.Action)
+			continue
+		}
+		for _, text := range list.Words {
+			if text = strings.TrimSpace(text); text != "" {
+				words = append(words, Word{Text: text, ListID: list.ListID, Action: action})
+			}
+		}
+	}
+	f.automaton.Store(NewAutomaton(words))
+	log.ZInfo(ctx, "sensitive words reloaded", "lists", len(lists), "words", len(words))
+	return nil
+}
+
+// watch reloads the lists when a change is published, and every interval in case a change is missed.
+func (f *Filter) watch(ctx context.Context, rdb redis.UniversalClient, interval time.Duration) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.ZPanic(ctx, "sensitive word watch panic", errs.ErrPanic(r))
+		}
+	}()
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	changes := rdb.Subscribe(ctx, cachekey.SensitiveWordChannel).Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-changes:
+			if !ok {
+				return
+			}
+			log.ZDebug(ctx, "sensitive word lists changed", "payload", message.Payload)
+		case <-tick:
+		}
+		if err := f.Reload(ctx); err != nil {
+			log.ZError(ctx, "reload sensitive words failed", err)
+		}
+	}
+}
+
+// Check finds the words in text, the strongest action of the found words decides the result.
+func (f *Filter) Check(text string) *Result {
+	res := &Result{Text: text}
+	matches := f.automaton.Load().Find(text)
+	if len(matches) == 0 {
+		return res
+	}
+	var (
+		replace []Match
+		seen    = make(map[string]struct{})
+	)
+	for _, match := range matches {
+		switch match.Word.Action {
+		case ActionReject:
+			res.Reject = true
+		case ActionReplace:
+			replace = append(replace, match)
+		case ActionFlag:
+			res.Flag = true
+		}
+		if _, ok := seen[match.Word.Text]; !ok {
+			seen[match.Word.Text] = struct{}{}
+			res.Words = append(res.Words, match.Word.Text)
+		}
+	}
+	if len(replace) > 0 && !res.Reject {
+		res.Text = f.replace(text, replace)
+		res.replaced = true
+	}
+	return res
+}
+
+// replace replaces every run of runes covered by the matches with a single replacement.
+func (f *Filter) replace(text string, matches []Match) string {
+	runes := []rune(text)
+	covered := make([]bool, len(runes))
+	for _, match := range matches {
+		for i := match.Start; i < match.End; i++ {
+			covered[i] = true
+		}
+	}
+	var b strings.Builder
+	for i, r := range runes {
+		if !covered[i] {
+			b.WriteRune(r)
+		} else if i == 0 || !covered[i-1] {
+			b.WriteString(f.replacement)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,62 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sensitive
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/storage/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutomaton(t *testing.T) {
+	a := NewAutomaton([]Word{{Text: "he"}, {Text: "she"}, {Text: "his"}, {Text: "hers"}, {Text: "敏感"}})
+	var found []string
+	for _, match := range a.Find("uSHErs 很敏感") {
+		found = append(found, match.Word.Text)
+		assert.Equal(t, len([]rune(match.Word.Text)), match.End-match.Start)
+	}
+	assert.ElementsMatch(t, []string{"she", "he", "hers", "敏感"}, found)
+	assert.Empty(t, NewAutomaton(nil).Find("anything"))
+}
+
+func TestFilter(t *testing.T) {
+	lists := []*model.SensitiveWordList{
+		{ListID: "replace", Action: int32(ActionReplace), Words: []string{"bad", "badword", "坏"}},
+		{ListID: "flag", Action: int32(ActionFlag), Words: []string{"watch"}},
+		{ListID: "reject", Action: int32(ActionReject), Words: []string{"forbidden"}},
+	}
+	f := NewFilter("", func(ctx context.Context) ([]*model.SensitiveWordList, error) {
+		return lists, nil
+	})
+	assert.False(t, f.Check("bad").Replaced())
+	assert.NoError(t, f.Reload(context.Background()))
+
+	res := f.Check("a BadWord and 坏人")
+	assert.True(t, res.Replaced())
+	assert.Equal(t, "a *** and ***人", res.Text)
+	assert.False(t, res.Reject || res.Flag)
+	assert.ElementsMatch(t, []string{"bad", "badword", "坏"}, res.Words)
+
+	res = f.Check("watch the bad forbidden things")
+	assert.True(t, res.Reject)
+	assert.True(t, res.Flag)
+	assert.False(t, res.Replaced())
+
+	res = f.Check("nothing here")
+	assert.Empty(t, res.Words)
+	assert.Equal(t, "nothing here", res.Text)
+}
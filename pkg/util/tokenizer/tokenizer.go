@@ -0,0 +1,120 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tokenizer splits text into search tokens. Words of alphabetic scripts are lowercased and kept whole,
+// CJK text has no word boundaries and is split into unigrams and overlapping bigrams.
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxWordLen limits the length of a single token, longer words are truncated.
+const maxWordLen = 64
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// split calls fn for every run of word characters, cjk reports whether the run is CJK text.
+func split(text string, fn func(run []rune, cjk bool)) {
+	var (
+		run []rune
+		cjk bool
+	)
+	flush := func() {
+		if len(run) > 0 {
+			fn(run, cjk)
+			run = run[:0]
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			if !cjk {
+				flush()
+			}
+			cjk = true
+			run = append(run, r)
+		case isWord(r):
+			if cjk {
+				flush()
+			}
+			cjk = false
+			run = append(run, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+}
+
+type tokenSet struct {
+	tokens []string
+	seen   map[string]struct{}
+}
+
+func (s *tokenSet) add(token string) {
+	if _, ok := s.seen[token]; ok {
+		return
+	}
+	s.seen[token] = struct{}{}
+	s.tokens = append(s.tokens, token)
+}
+
+func word(run []rune) string {
+	if len(run) > maxWordLen {
+		run = run[:maxWordLen]
+	}
+	return string(run)
+}
+
+// Index returns the distinct tokens of a text to be indexed, CJK runs produce both unigrams and bigrams
+// so that a query of a single character matches too.
+func Index(text string) []string {
+	s := tokenSet{seen: make(map[string]struct{})}
+	split(text, func(run []rune, cjk bool) {
+		if !cjk {
+			s.add(word(run))
+			return
+		}
+		for i := range run {
+			s.add(string(run[i]))
+			if i+1 < len(run) {
+				s.add(string(run[i : i+2]))
+			}
+		}
+	})
+	return s.tokens
+}
+
+// Query returns the distinct tokens of a search query, CJK runs longer than one character produce only bigrams.
+func Query(text string) []string {
+	s := tokenSet{seen: make(map[string]struct{})}
+	split(text, func(run []rune, cjk bool) {
+		if !cjk || len(run) == 1 {
+			s.add(word(run))
+			return
+		}
+		for i := 0; i+1 < len(run); i++ {
+			s.add(string(run[i : i+2]))
+		}
+	})
+	return s.tokens
+}
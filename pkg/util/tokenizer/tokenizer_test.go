@@ -0,0 +1,50 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndex(t *testing.T) {
+	assert.Equal(t, []string{"hello", "world", "42"}, Index("Hello, WORLD! hello 42"))
+	assert.Equal(t, []string{"你", "你好", "好", "好世", "世", "世界", "界", "openim"}, Index("你好世界OpenIM"))
+	assert.Empty(t, Index(" ,.!"))
+}
+
+func TestQuery(t *testing.T) {
+	assert.Equal(t, []string{"你好", "好世", "世界", "im"}, Query("你好世界 IM"))
+	assert.Equal(t, []string{"你"}, Query("你"))
+	for _, token := range Query("你好世界") {
+		assert.Contains(t, Index("大家你好世界"), token)
+	}
+}
+
+func TestScripts(t *testing.T) {
+	// kana and hangul are split like han, the other letters form words
+	assert.Equal(t, []string{"こ", "こん", "ん", "んに", "に"}, Index("こんに"))
+	assert.Equal(t, []string{"안녕", "ab"}, Query("안녕 ab"))
+	assert.Equal(t, []string{"café", "naïve"}, Query("Café, naïve!"))
+	assert.Equal(t, []string{"chat", "中", "中文", "文", "v2"}, Index("chat中文v2"))
+}
+
+func TestWordLen(t *testing.T) {
+	long := strings.Repeat("a", maxWordLen+10)
+	assert.Equal(t, []string{long[:maxWordLen]}, Index(long))
+	assert.Equal(t, Index(long), Query(long))
+}
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/mq"
 	"github.com/openimsdk/tools/db/mongoutil"
 	"github.com/openimsdk/tools/db/redisutil"
 	"github.com/openimsdk/tools/discovery/etcd"
@@ -155,9 +156,11 @@ func performChecks(ctx context.Context, mongoConfig *config.Mongo, redisConfig *
 		"Redis": func(ctx context.Context) error {
 			return CheckRedis(ctx, redisConfig)
 		},
-		"Kafka": func(ctx context.Context) error {
+	}
+	if kafkaConfig.Backend == "" || kafkaConfig.Backend == mq.BackendKafka {
+		checks["Kafka"] = func(ctx context.Context) error {
 			return CheckKafka(ctx, kafkaConfig)
-		},
+		}
 	}
 	if minioConfig != nil {
 		checks["MinIO"] = func(ctx context.Context) error {
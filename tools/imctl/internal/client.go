@@ -0,0 +1,150 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/openimsdk/protocol/auth"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/tools/errs"
+)
+
+// ApiError is a response of the api whose errCode is not zero.
+type ApiError struct {
+	Path    string
+	ErrCode int
+	ErrMsg  string
+	ErrDlt  string
+}
+
+func (e *ApiError) Error() string {
+	return fmt.Sprintf("api %s errCode %d errMsg %s errDlt %s", e.Path, e.ErrCode, e.ErrMsg, e.ErrDlt)
+}
+
+func (e *ApiError) tokenInvalid() bool {
+	return e.ErrCode >= errs.TokenExpiredError && e.ErrCode <= errs.TokenNotExistError
+}
+
+// Client calls the REST api with an admin token, the token is fetched on the first call
+// and fetched again when the api rejects it.
+type Client struct {
+	profile Profile
+	client  *http.Client
+	token   string
+	seq     atomic.Uint64
+}
+
+func NewClient(profile Profile, timeout time.Duration) *Client {
+	return &Client{
+		profile: profile,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *Client) Profile() Profile {
+	return c.profile
+}
+
+func (c *Client) operationID() string {
+	return "imctl_" + strconv.FormatInt(time.Now().UnixMilli(), 10) + "_" + strconv.FormatUint(c.seq.Add(1), 10)
+}
+
+// Call posts req to the api path and decodes the data of the response into resp.
+func (c *Client) Call(ctx context.Context, path string, req any, resp any) error {
+	if c.token == "" {
+		if err := c.refreshToken(ctx); err != nil {
+			return err
+		}
+	}
+	err := c.post(ctx, path, c.token, req, resp)
+	var apiErr *ApiError
+	if errors.As(err, &apiErr) && apiErr.tokenInvalid() {
+		if err := c.refreshToken(ctx); err != nil {
+			return err
+		}
+		err = c.post(ctx, path, c.token, req, resp)
+	}
+	return err
+}
+
+func (c *Client) refreshToken(ctx context.Context) error {
+	req := auth.GetAdminTokenReq{
+		UserID: c.profile.UserID,
+		Secret: c.profile.Secret,
+	}
+	var resp auth.GetAdminTokenResp
+	if err := c.post(ctx, "/auth/get_admin_token", "", &req, &resp); err != nil {
+		return fmt.Errorf("get admin token: %w", err)
+	}
+	c.token = resp.Token
+	return nil
+}
+
+func (c *Client) post(ctx context.Context, path string, token string, req any, resp any) error {
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.profile.Api, "/")+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(constant.OperationID, c.operationID())
+	if token != "" {
+		request.Header.Set(constant.Token, token)
+	}
+	response, err := c.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("api %s status %s body %s", path, response.Status, body)
+	}
+	var baseResponse struct {
+		ErrCode int             `json:"errCode"`
+		ErrMsg  string          `json:"errMsg"`
+		ErrDlt  string          `json:"errDlt"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &baseResponse); err != nil {
+		return fmt.Errorf("api %s invalid response %s: %w", path, body, err)
+	}
+	if baseResponse.ErrCode != 0 {
+		return &ApiError{Path: path, ErrCode: baseResponse.ErrCode, ErrMsg: baseResponse.ErrMsg, ErrDlt: baseResponse.ErrDlt}
+	}
+	if resp != nil && len(baseResponse.Data) > 0 {
+		if err := json.Unmarshal(baseResponse.Data, resp); err != nil {
+			return fmt.Errorf("api %s decode data: %w", path, err)
+		}
+	}
+	return nil
+}
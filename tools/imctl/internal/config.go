@@ -0,0 +1,113 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultProfile = "default"
+	DefaultApi     = "http://127.0.0.1:10002"
+	DefaultUserID  = "imAdmin"
+	DefaultSecret  = "openIM123"
+)
+
+// Profile is the api address and the admin credential of an OpenIM deployment.
+type Profile struct {
+	Api    string `yaml:"api"`
+	UserID string `yaml:"userID"`
+	Secret string `yaml:"secret"`
+}
+
+// Config is the profile file of imctl, the current profile is used when no profile is given on the command line.
+type Config struct {
+	Current  string              `yaml:"current"`
+	Profiles map[string]*Profile `yaml:"profiles"`
+}
+
+// DefaultConfigPath returns $HOME/.imctl/config.yaml.
+func DefaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".imctl", "config.yaml")
+	}
+	return filepath.Join(home, ".imctl", "config.yaml")
+}
+
+// LoadConfig reads the profile file, a missing file is an empty config.
+func LoadConfig(path string) (*Config, error) {
+	conf := &Config{Profiles: make(map[string]*Profile)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return conf, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, conf); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if conf.Profiles == nil {
+		conf.Profiles = make(map[string]*Profile)
+	}
+	return conf, nil
+}
+
+// Save writes the config with owner only permission since it holds the secrets.
+func (c *Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Profile returns the named profile, or the current one when name is empty.
+func (c *Config) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = c.Current
+	}
+	if name == "" {
+		name = DefaultProfile
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		if name == DefaultProfile {
+			return &Profile{Api: DefaultApi, UserID: DefaultUserID, Secret: DefaultSecret}, nil
+		}
+		return nil, fmt.Errorf("profile %s not found", name)
+	}
+	return profile, nil
+}
+
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,49 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"strings"
+
+	"github.com/openimsdk/protocol/group"
+	"github.com/spf13/cobra"
+)
+
+func newGroupCommand(ctl *Imctl) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "group",
+		Short: "Manage groups",
+	}
+	cmd.AddCommand(newGroupKickCommand(ctl))
+	return cmd
+}
+
+func newGroupKickCommand(ctl *Imctl) *cobra.Command {
+	var reason string
+	cmd := &cobra.Command{
+		Use:   "kick GROUP_ID USER_ID...",
+		Short: "Kick the members out of a group",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := &group.KickGroupMemberReq{GroupID: args[0], KickedUserIDs: args[1:], Reason: reason}
+			if err := ctl.call(cmd, "/group/kick_group", req, nil); err != nil {
+				return err
+			}
+			return ctl.printer().Success("kicked %s out of group %s", strings.Join(args[1:], ","), args[0])
+		},
+	}
+	cmd.Flags().StringVar(&reason, "reason", "", "reason shown to the kicked members")
+	return cmd
+}
@@ -0,0 +1,147 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"sort"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/apistruct"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/msg"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/tools/utils/datautil"
+	"github.com/spf13/cobra"
+)
+
+func newMsgCommand(ctl *Imctl) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "msg",
+		Short: "Send notifications and inspect conversations",
+	}
+	cmd.AddCommand(
+		newMsgNotifyCommand(ctl),
+		newMsgSeqsCommand(ctl),
+	)
+	return cmd
+}
+
+func newMsgNotifyCommand(ctl *Imctl) *cobra.Command {
+	var (
+		sendID string
+		elem   apistruct.OANotificationElem
+	)
+	cmd := &cobra.Command{
+		Use:   "notify RECV_ID...",
+		Short: "Send a system notification to the users from a notification account",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			content := map[string]any{
+				"notificationName":    elem.NotificationName,
+				"notificationFaceURL": elem.NotificationFaceURL,
+				"notificationType":    elem.NotificationType,
+				"text":                elem.Text,
+				"url":                 elem.Url,
+				"ex":                  elem.Ex,
+			}
+			t := &Table{Header: []string{"RECV_ID", "SERVER_MSG_ID", "CLIENT_MSG_ID", "SEND_TIME"}}
+			results := make(map[string]*msg.SendMsgResp, len(args))
+			for _, recvID := range args {
+				req := &apistruct.SendMsgReq{
+					RecvID: recvID,
+					SendMsg: apistruct.SendMsg{
+						SendID:      sendID,
+						Content:     content,
+						ContentType: constant.OANotification,
+						SessionType: constant.NotificationChatType,
+					},
+				}
+				var resp msg.SendMsgResp
+				if err := ctl.call(cmd, "/msg/send_msg", req, &resp); err != nil {
+					return err
+				}
+				results[recvID] = &resp
+				t.Append(recvID, resp.ServerMsgID, resp.ClientMsgID, formatMilli(resp.SendTime))
+			}
+			return ctl.printer().Print(results, func() *Table { return t })
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&sendID, "send-id", "", "user id of the notification account")
+	flags.StringVar(&elem.NotificationName, "name", "", "name shown as the sender of the notification")
+	flags.StringVar(&elem.NotificationFaceURL, "face-url", "", "avatar shown as the sender of the notification")
+	flags.Int32Var(&elem.NotificationType, "type", 1, "notification type defined by the business")
+	flags.StringVar(&elem.Text, "text", "", "text of the notification")
+	flags.StringVar(&elem.Url, "url", "", "url attached to the notification")
+	flags.StringVar(&elem.Ex, "ex", "", "extra data of the notification")
+	_ = cmd.MarkFlagRequired("send-id")
+	_ = cmd.MarkFlagRequired("name")
+	_ = cmd.MarkFlagRequired("text")
+	return cmd
+}
+
+// conversationSeqs is the seq state of a conversation seen by a user.
+type conversationSeqs struct {
+	ConversationID string `json:"conversationID"`
+	MinSeq         int64  `json:"minSeq"`
+	MaxSeq         int64  `json:"maxSeq"`
+	HasReadSeq     int64  `json:"hasReadSeq"`
+	MaxSeqTime     int64  `json:"maxSeqTime"`
+}
+
+func newMsgSeqsCommand(ctl *Imctl) *cobra.Command {
+	var userID string
+	cmd := &cobra.Command{
+		Use:   "seqs [CONVERSATION_ID...]",
+		Short: "Show the seqs of the conversations of a user, all of the conversations when none is given",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var readResp msg.GetConversationsHasReadAndMaxSeqResp
+			readReq := &msg.GetConversationsHasReadAndMaxSeqReq{UserID: userID, ConversationIDs: args}
+			if err := ctl.call(cmd, "/msg/get_conversations_has_read_and_max_seq", readReq, &readResp); err != nil {
+				return err
+			}
+			var seqResp sdkws.GetMaxSeqResp
+			if err := ctl.call(cmd, "/msg/newest_seq", &sdkws.GetMaxSeqReq{UserID: userID}, &seqResp); err != nil {
+				return err
+			}
+			conversationIDs := args
+			if len(conversationIDs) == 0 {
+				conversationIDs = datautil.Keys(readResp.Seqs)
+				sort.Strings(conversationIDs)
+			}
+			seqs := make([]*conversationSeqs, 0, len(conversationIDs))
+			for _, conversationID := range conversationIDs {
+				s := &conversationSeqs{ConversationID: conversationID, MinSeq: seqResp.MinSeqs[conversationID]}
+				if read, ok := readResp.Seqs[conversationID]; ok {
+					s.MaxSeq = read.MaxSeq
+					s.HasReadSeq = read.HasReadSeq
+					s.MaxSeqTime = read.MaxSeqTime
+				} else {
+					s.MaxSeq = seqResp.MaxSeqs[conversationID]
+				}
+				seqs = append(seqs, s)
+			}
+			return ctl.printer().Print(seqs, func() *Table {
+				t := &Table{Header: []string{"CONVERSATION_ID", "MIN_SEQ", "MAX_SEQ", "HAS_READ_SEQ", "UNREAD", "MAX_SEQ_TIME"}}
+				for _, s := range seqs {
+					t.Append(s.ConversationID, s.MinSeq, s.MaxSeq, s.HasReadSeq, max(s.MaxSeq-s.HasReadSeq, 0), formatMilli(s.MaxSeqTime))
+				}
+				return t
+			})
+		},
+	}
+	cmd.Flags().StringVar(&userID, "user-id", "", "user id owning the conversations")
+	_ = cmd.MarkFlagRequired("user-id")
+	return cmd
+}
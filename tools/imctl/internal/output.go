@@ -0,0 +1,93 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+// Table is the table view of a result, the json view prints the raw result instead.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+func (t *Table) Append(row ...any) {
+	values := make([]string, len(row))
+	for i, v := range row {
+		values[i] = fmt.Sprint(v)
+	}
+	t.Rows = append(t.Rows, values)
+}
+
+// Printer writes the results in the chosen output format.
+type Printer struct {
+	Format string
+	Out    io.Writer
+}
+
+func (p *Printer) Check() error {
+	switch p.Format {
+	case OutputTable, OutputJSON:
+		return nil
+	default:
+		return fmt.Errorf("unsupported output %q, use %s or %s", p.Format, OutputTable, OutputJSON)
+	}
+}
+
+// Print writes result as indented json, or the table built by table.
+func (p *Printer) Print(result any, table func() *Table) error {
+	if p.Format == OutputJSON || table == nil {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.Out, string(data))
+		return err
+	}
+	t := table()
+	w := tabwriter.NewWriter(p.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(t.Header, "\t"))
+	for _, row := range t.Rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// Success prints the result of the commands that return no data.
+func (p *Printer) Success(format string, args ...any) error {
+	if p.Format == OutputJSON {
+		return p.Print(map[string]any{"success": true, "message": fmt.Sprintf(format, args...)}, nil)
+	}
+	_, err := fmt.Fprintf(p.Out, format+"\n", args...)
+	return err
+}
+
+func formatMilli(ms int64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return time.UnixMilli(ms).Format(time.DateTime)
+}
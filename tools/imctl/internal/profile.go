@@ -0,0 +1,161 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newConfigCommand(ctl *Imctl) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the profiles of the profile file",
+	}
+	cmd.AddCommand(
+		newConfigViewCommand(ctl),
+		newConfigSetCommand(ctl),
+		newConfigUseCommand(ctl),
+		newConfigDeleteCommand(ctl),
+	)
+	return cmd
+}
+
+func newConfigViewCommand(ctl *Imctl) *cobra.Command {
+	return &cobra.Command{
+		Use:   "view",
+		Short: "Show the profiles, the secrets are hidden",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := LoadConfig(ctl.ConfigPath)
+			if err != nil {
+				return err
+			}
+			type profileView struct {
+				Name    string `json:"name"`
+				Current bool   `json:"current"`
+				Api     string `json:"api"`
+				UserID  string `json:"userID"`
+			}
+			views := make([]profileView, 0, len(conf.Profiles))
+			for _, name := range conf.ProfileNames() {
+				profile := conf.Profiles[name]
+				views = append(views, profileView{Name: name, Current: name == conf.Current, Api: profile.Api, UserID: profile.UserID})
+			}
+			return ctl.printer().Print(views, func() *Table {
+				t := &Table{Header: []string{"CURRENT", "NAME", "API", "USER_ID"}}
+				for _, v := range views {
+					current := ""
+					if v.Current {
+						current = "*"
+					}
+					t.Append(current, v.Name, v.Api, v.UserID)
+				}
+				return t
+			})
+		},
+	}
+}
+
+func newConfigSetCommand(ctl *Imctl) *cobra.Command {
+	var profile Profile
+	cmd := &cobra.Command{
+		Use:   "set NAME",
+		Short: "Create or update a profile, the first profile becomes the current one",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := LoadConfig(ctl.ConfigPath)
+			if err != nil {
+				return err
+			}
+			name := args[0]
+			existing, ok := conf.Profiles[name]
+			if !ok {
+				existing = &Profile{Api: DefaultApi, UserID: DefaultUserID, Secret: DefaultSecret}
+				conf.Profiles[name] = existing
+			}
+			flags := cmd.Flags()
+			if flags.Changed("api") {
+				existing.Api = profile.Api
+			}
+			if flags.Changed("user-id") {
+				existing.UserID = profile.UserID
+			}
+			if flags.Changed("secret") {
+				existing.Secret = profile.Secret
+			}
+			if conf.Current == "" {
+				conf.Current = name
+			}
+			if err := conf.Save(ctl.ConfigPath); err != nil {
+				return err
+			}
+			return ctl.printer().Success("profile %s saved to %s", name, ctl.ConfigPath)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&profile.Api, "api", "", "api address of the deployment, "+DefaultApi+" for a new profile")
+	flags.StringVar(&profile.UserID, "user-id", "", "admin user id listed in imAdminUserID of share.yml")
+	flags.StringVar(&profile.Secret, "secret", "", "secret of share.yml")
+	return cmd
+}
+
+func newConfigUseCommand(ctl *Imctl) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use NAME",
+		Short: "Set the current profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := LoadConfig(ctl.ConfigPath)
+			if err != nil {
+				return err
+			}
+			if _, ok := conf.Profiles[args[0]]; !ok {
+				return fmt.Errorf("profile %s not found", args[0])
+			}
+			conf.Current = args[0]
+			if err := conf.Save(ctl.ConfigPath); err != nil {
+				return err
+			}
+			return ctl.printer().Success("switched to profile %s", args[0])
+		},
+	}
+}
+
+func newConfigDeleteCommand(ctl *Imctl) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete NAME",
+		Short: "Delete a profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := LoadConfig(ctl.ConfigPath)
+			if err != nil {
+				return err
+			}
+			if _, ok := conf.Profiles[args[0]]; !ok {
+				return fmt.Errorf("profile %s not found", args[0])
+			}
+			delete(conf.Profiles, args[0])
+			if conf.Current == args[0] {
+				conf.Current = ""
+			}
+			if err := conf.Save(ctl.ConfigPath); err != nil {
+				return err
+			}
+			return ctl.printer().Success("profile %s deleted", args[0])
+		},
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Imctl holds the global flags of a command line, the shell copies it for every line it runs
+// and shares the clients so that the admin tokens are reused.
+type Imctl struct {
+	ConfigPath string
+	Profile    string
+	Api        string
+	UserID     string
+	Secret     string
+	Output     string
+	Timeout    time.Duration
+
+	in      io.Reader
+	out     io.Writer
+	errOut  io.Writer
+	clients map[Profile]*Client
+}
+
+func NewCommand() *cobra.Command {
+	ctl := &Imctl{
+		ConfigPath: DefaultConfigPath(),
+		Output:     OutputTable,
+		Timeout:    time.Second * 10,
+		in:         os.Stdin,
+		out:        os.Stdout,
+		errOut:     os.Stderr,
+		clients:    make(map[Profile]*Client),
+	}
+	return newRootCommand(ctl)
+}
+
+func newRootCommand(ctl *Imctl) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "imctl",
+		Short: "imctl controls an OpenIM deployment through its REST api",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return ctl.printer().Check()
+		},
+		SilenceUsage: true,
+	}
+	cmd.SetIn(ctl.in)
+	cmd.SetOut(ctl.out)
+	cmd.SetErr(ctl.errOut)
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&ctl.ConfigPath, "config", ctl.ConfigPath, "path of the profile file")
+	flags.StringVarP(&ctl.Profile, "profile", "p", ctl.Profile, "profile to use, the current profile by default")
+	flags.StringVar(&ctl.Api, "api", ctl.Api, "api address, overrides the profile")
+	flags.StringVar(&ctl.UserID, "admin-user-id", ctl.UserID, "admin user id, overrides the profile")
+	flags.StringVar(&ctl.Secret, "secret", ctl.Secret, "secret of the deployment, overrides the profile")
+	flags.StringVarP(&ctl.Output, "output", "o", ctl.Output, "output format, table or json")
+	flags.DurationVar(&ctl.Timeout, "timeout", ctl.Timeout, "timeout of a command")
+	cmd.AddCommand(
+		newUserCommand(ctl),
+		newGroupCommand(ctl),
+		newMsgCommand(ctl),
+		newConfigCommand(ctl),
+		newShellCommand(ctl),
+	)
+	return cmd
+}
+
+func (c *Imctl) printer() *Printer {
+	return &Printer{Format: c.Output, Out: c.out}
+}
+
+// client returns the client of the selected profile with the flags applied.
+func (c *Imctl) client() (*Client, error) {
+	conf, err := LoadConfig(c.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := conf.Profile(c.Profile)
+	if err != nil {
+		return nil, err
+	}
+	selected := *profile
+	if c.Api != "" {
+		selected.Api = c.Api
+	}
+	if c.UserID != "" {
+		selected.UserID = c.UserID
+	}
+	if c.Secret != "" {
+		selected.Secret = c.Secret
+	}
+	if client, ok := c.clients[selected]; ok {
+		return client, nil
+	}
+	client := NewClient(selected, c.Timeout)
+	c.clients[selected] = client
+	return client, nil
+}
+
+// call runs an api call of a command within the timeout.
+func (c *Imctl) call(cmd *cobra.Command, path string, req any, resp any) error {
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), c.Timeout)
+	defer cancel()
+	return client.Call(ctx, path, req, resp)
+}
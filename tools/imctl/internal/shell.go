@@ -0,0 +1,115 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newShellCommand(ctl *Imctl) *cobra.Command {
+	return &cobra.Command{
+		Use:   "shell",
+		Short: "Run the commands interactively, the admin token is reused between the commands",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runShell(cmd, ctl)
+		},
+	}
+}
+
+func runShell(cmd *cobra.Command, ctl *Imctl) error {
+	fmt.Fprintln(ctl.out, `type "help" for the commands and "exit" to quit`)
+	scanner := bufio.NewScanner(ctl.in)
+	for {
+		fmt.Fprint(ctl.out, "imctl> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(ctl.out)
+			return scanner.Err()
+		}
+		args, err := splitArgs(scanner.Text())
+		if err != nil {
+			fmt.Fprintln(ctl.errOut, "Error:", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "shell":
+			fmt.Fprintln(ctl.errOut, "Error: already in the shell")
+			continue
+		}
+		// every line gets its own copy of the global flags, the flags given to the shell are the defaults
+		lineCtl := *ctl
+		root := newRootCommand(&lineCtl)
+		root.SetArgs(args)
+		// the error has been printed by cobra
+		_ = root.ExecuteContext(cmd.Context())
+	}
+}
+
+// splitArgs splits a line into arguments like a shell, the single and the double quotes group
+// the words and a backslash escapes the next character outside the single quotes.
+func splitArgs(line string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
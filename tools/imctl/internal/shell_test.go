@@ -0,0 +1,45 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		args []string
+	}{
+		{line: "", args: nil},
+		{line: "  user   get a b ", args: []string{"user", "get", "a", "b"}},
+		{line: `msg notify u1 --text "hello world"`, args: []string{"msg", "notify", "u1", "--text", "hello world"}},
+		{line: `--text 'a "b" \c'`, args: []string{"--text", `a "b" \c`}},
+		{line: `a\ b "" c`, args: []string{"a b", "", "c"}},
+	}
+	for _, test := range tests {
+		args, err := splitArgs(test.line)
+		if err != nil {
+			t.Fatalf("split %q: %v", test.line, err)
+		}
+		if !reflect.DeepEqual(args, test.args) {
+			t.Errorf("split %q got %q want %q", test.line, args, test.args)
+		}
+	}
+	if _, err := splitArgs(`a "b`); err == nil {
+		t.Error("unterminated quote should fail")
+	}
+}
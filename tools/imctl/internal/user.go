@@ -0,0 +1,142 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"strings"
+
+	"github.com/openimsdk/protocol/auth"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/openimsdk/protocol/msggateway"
+	"github.com/openimsdk/protocol/sdkws"
+	"github.com/openimsdk/protocol/user"
+	"github.com/spf13/cobra"
+)
+
+func newUserCommand(ctl *Imctl) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "Look up, register and log out users",
+	}
+	cmd.AddCommand(
+		newUserGetCommand(ctl),
+		newUserRegisterCommand(ctl),
+		newUserLogoutCommand(ctl),
+		newUserOnlineCommand(ctl),
+	)
+	return cmd
+}
+
+func newUserGetCommand(ctl *Imctl) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get USER_ID...",
+		Short: "Show the users",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var resp user.GetDesignateUsersResp
+			if err := ctl.call(cmd, "/user/get_users_info", &user.GetDesignateUsersReq{UserIDs: args}, &resp); err != nil {
+				return err
+			}
+			return ctl.printer().Print(resp.UsersInfo, func() *Table {
+				t := &Table{Header: []string{"USER_ID", "NICKNAME", "FACE_URL", "APP_MANAGER_LEVEL", "CREATE_TIME"}}
+				for _, u := range resp.UsersInfo {
+					t.Append(u.UserID, u.Nickname, u.FaceURL, u.AppMangerLevel, formatMilli(u.CreateTime))
+				}
+				return t
+			})
+		},
+	}
+}
+
+func newUserRegisterCommand(ctl *Imctl) *cobra.Command {
+	var (
+		nickname string
+		faceURL  string
+		ex       string
+	)
+	cmd := &cobra.Command{
+		Use:   "register USER_ID",
+		Short: "Register a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if nickname == "" {
+				nickname = args[0]
+			}
+			req := &user.UserRegisterReq{
+				Users: []*sdkws.UserInfo{{UserID: args[0], Nickname: nickname, FaceURL: faceURL, Ex: ex}},
+			}
+			if err := ctl.call(cmd, "/user/user_register", req, nil); err != nil {
+				return err
+			}
+			return ctl.printer().Success("user %s registered", args[0])
+		},
+	}
+	cmd.Flags().StringVar(&nickname, "nickname", "", "nickname of the user, the user id by default")
+	cmd.Flags().StringVar(&faceURL, "face-url", "", "avatar url of the user")
+	cmd.Flags().StringVar(&ex, "ex", "", "extra data of the user")
+	return cmd
+}
+
+func newUserLogoutCommand(ctl *Imctl) *cobra.Command {
+	var platformID int32
+	cmd := &cobra.Command{
+		Use:   "logout USER_ID",
+		Short: "Force a user to log out of a platform",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := &auth.ForceLogoutReq{UserID: args[0], PlatformID: platformID}
+			if err := ctl.call(cmd, "/auth/force_logout", req, nil); err != nil {
+				return err
+			}
+			return ctl.printer().Success("user %s logged out of %s", args[0], constant.PlatformIDToName(int(platformID)))
+		},
+	}
+	cmd.Flags().Int32Var(&platformID, "platform", 0, "platform id to log out, see the platform ids of the sdk")
+	_ = cmd.MarkFlagRequired("platform")
+	return cmd
+}
+
+func newUserOnlineCommand(ctl *Imctl) *cobra.Command {
+	return &cobra.Command{
+		Use:   "online USER_ID...",
+		Short: "Show the online status of the users on every platform",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var resp []*msggateway.GetUsersOnlineStatusResp_SuccessResult
+			if err := ctl.call(cmd, "/user/get_users_online_status", &msggateway.GetUsersOnlineStatusReq{UserIDs: args}, &resp); err != nil {
+				return err
+			}
+			return ctl.printer().Print(resp, func() *Table {
+				t := &Table{Header: []string{"USER_ID", "STATUS", "PLATFORMS"}}
+				for _, r := range resp {
+					status := "offline"
+					if r.Status == constant.Online {
+						status = "online"
+					}
+					platforms := make([]string, 0, len(r.DetailPlatformStatus))
+					for _, detail := range r.DetailPlatformStatus {
+						platform := constant.PlatformIDToName(int(detail.PlatformID))
+						if detail.IsBackground {
+							platform += "(background)"
+						}
+						platforms = append(platforms, platform)
+					}
+					t.Append(r.UserID, status, strings.Join(platforms, ","))
+				}
+				return t
+			})
+		},
+	}
+}
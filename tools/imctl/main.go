@@ -14,9 +14,14 @@
 
 package main
 
-import "fmt"
+import (
+	"os"
 
-func main() {
+	"github.com/openimsdk/open-im-server/v3/tools/imctl/internal"
+)
 
-	fmt.Println("imctl")
+func main() {
+	if err := internal.NewCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
 }